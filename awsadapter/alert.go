@@ -0,0 +1,34 @@
+package awsadapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gomapservice/geomap"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+// SNSAlerter is a geomap.Alerter publishing the alerts as json to an SNS topic
+type SNSAlerter struct {
+	Client   snsiface.SNSAPI
+	TopicARN string
+}
+
+func (a *SNSAlerter) Alert(ctx context.Context, alert geomap.Alert) error {
+
+	message, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.Client.PublishWithContext(ctx, &sns.PublishInput{
+		TopicArn: aws.String(a.TopicARN),
+		Subject:  aws.String(fmt.Sprintf("google maps %s on %s", alert.Status, alert.Endpoint)),
+		Message:  aws.String(string(message)),
+	})
+	return err
+}
@@ -0,0 +1,64 @@
+package awsadapter
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+/*
+	DynamoDBCache is a geomap.Cache shared by every lambda, kept in a DynamoDB table
+	with a string hash key "key". Enable the table TTL on "expires_at" so expired
+	entries are removed, they are ignored until then. Items are limited to 400KB
+	by DynamoDB, bigger responses fail to be cached
+*/
+type DynamoDBCache struct {
+	Client dynamodbiface.DynamoDBAPI
+	Table  string
+}
+
+func (d *DynamoDBCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+
+	out, err := d.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.Table),
+		Key:       map[string]*dynamodb.AttributeValue{"key": {S: aws.String(key)}},
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	value := out.Item["value"]
+	if value == nil {
+		return nil, false, nil
+	}
+
+	if expires := out.Item["expires_at"]; expires != nil {
+		expiresAt, err := strconv.ParseInt(aws.StringValue(expires.N), 10, 64)
+		if err == nil && time.Now().Unix() >= expiresAt {
+			return nil, false, nil
+		}
+	}
+
+	return value.B, true, nil
+}
+
+func (d *DynamoDBCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+
+	item := map[string]*dynamodb.AttributeValue{
+		"key":   {S: aws.String(key)},
+		"value": {B: value},
+	}
+	if ttl > 0 {
+		item["expires_at"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))}
+	}
+
+	_, err := d.Client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.Table),
+		Item:      item,
+	})
+	return err
+}
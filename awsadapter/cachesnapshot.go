@@ -0,0 +1,52 @@
+package awsadapter
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// S3CacheSnapshotStore is a geomap.CacheSnapshotStore keeping the latest cache snapshot as the S3 object Key
+type S3CacheSnapshotStore struct {
+	Client s3iface.S3API
+	Bucket string
+	Key    string
+}
+
+func (s *S3CacheSnapshotStore) Save(ctx context.Context, snapshot []byte) error {
+
+	_, err := s.Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(s.Key),
+		Body:        bytes.NewReader(snapshot),
+		ContentType: aws.String("application/gzip"),
+	})
+	return err
+}
+
+func (s *S3CacheSnapshotStore) Load(ctx context.Context) ([]byte, bool, error) {
+
+	out, err := s.Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Key),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer out.Body.Close()
+
+	snapshot, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return snapshot, true, nil
+}
@@ -0,0 +1,176 @@
+package awsadapter
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"time"
+
+	"gomapservice/geomap"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+)
+
+const (
+	// the longest delay of an SQS message and visibility timeout
+	sqsMaxDelay      = 15 * time.Minute
+	sqsMaxVisibility = 12 * time.Hour
+
+	// how long a replay failing for another reason waits before its next attempt
+	deferredRetryDelay = 15 * time.Minute
+
+	// time kept before the ctx deadline to stop receiving
+	replayMargin = 10 * time.Second
+)
+
+/*
+	SQSDeferredQueue keeps the requests deferred for quota (see geomap.WithDeferredQueue) as
+	messages of an SQS standard queue, the retention of the queue (up to 14 days) bounds how
+	long they wait. Replay them from a schedule running after the quota reset with Replay,
+	the messages not due yet are kept out of sight until their NotBefore
+*/
+type SQSDeferredQueue struct {
+	Client   sqsiface.SQSAPI
+	QueueURL string
+	Reset    geomap.QuotaReset
+}
+
+// ReplayReport counts what a Replay did with the messages it received
+type ReplayReport struct {
+	Replayed int `json:"replayed"`
+	Waiting  int `json:"waiting"`
+	Failed   int `json:"failed"`
+	Dropped  int `json:"dropped"`
+}
+
+func (q *SQSDeferredQueue) Defer(ctx context.Context, req geomap.DeferredRequest) error {
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Client.SendMessageWithContext(ctx, &sqs.SendMessageInput{
+		QueueUrl:     aws.String(q.QueueURL),
+		MessageBody:  aws.String(string(body)),
+		DelaySeconds: aws.Int64(delaySeconds(time.Until(req.NotBefore), sqsMaxDelay)),
+	})
+	return err
+}
+
+/*
+	Replay receives the deferred requests until the queue has none visible or ctx is about
+	to expire, replaying the due ones with client and params (e.g. the "key"). A request
+	still refused for quota waits for the next reset, one failing for a transient reason
+	(5xx, network) is retried later, the others are dropped
+*/
+func (q *SQSDeferredQueue) Replay(ctx context.Context, client geomap.API, params map[string]string) (ReplayReport, error) {
+
+	var report ReplayReport
+
+	for {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < replayMargin {
+			return report, nil
+		}
+
+		out, err := q.Client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(q.QueueURL),
+			MaxNumberOfMessages: aws.Int64(10),
+			WaitTimeSeconds:     aws.Int64(1),
+		})
+		if err != nil {
+			return report, err
+		}
+		if len(out.Messages) == 0 {
+			return report, nil
+		}
+
+		for _, message := range out.Messages {
+			if err := q.replay(ctx, client, params, message, &report); err != nil {
+				return report, err
+			}
+		}
+	}
+}
+
+// replay handles one message of Replay, the error is the queue failing
+func (q *SQSDeferredQueue) replay(ctx context.Context, client geomap.API, params map[string]string, message *sqs.Message, report *ReplayReport) error {
+
+	var req geomap.DeferredRequest
+	if err := json.Unmarshal([]byte(aws.StringValue(message.Body)), &req); err != nil {
+		log.Printf("awsadapter: dropping deferred message %s: %v", aws.StringValue(message.MessageId), err)
+		report.Dropped++
+		return q.delete(ctx, message)
+	}
+
+	if wait := time.Until(req.NotBefore); wait > 0 {
+		report.Waiting++
+		return q.hide(ctx, message, wait)
+	}
+
+	_, err := client.ReplayDeferred(ctx, req, params)
+	switch {
+	case err == nil:
+		report.Replayed++
+		return q.delete(ctx, message)
+	case geomap.QuotaExhausted(err):
+		report.Waiting++
+		return q.hide(ctx, message, time.Until(q.Reset.Next(time.Now())))
+	case transient(err):
+		report.Failed++
+		return q.hide(ctx, message, deferredRetryDelay)
+	}
+
+	log.Printf("awsadapter: dropping deferred %s request: %v", req.Endpoint, err)
+	report.Dropped++
+	return q.delete(ctx, message)
+}
+
+// hide keeps message out of sight for wait, the longest SQS allows at most, it is hidden again when received early
+func (q *SQSDeferredQueue) hide(ctx context.Context, message *sqs.Message, wait time.Duration) error {
+
+	_, err := q.Client.ChangeMessageVisibilityWithContext(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(q.QueueURL),
+		ReceiptHandle:     message.ReceiptHandle,
+		VisibilityTimeout: aws.Int64(delaySeconds(wait, sqsMaxVisibility)),
+	})
+	return err
+}
+
+func (q *SQSDeferredQueue) delete(ctx context.Context, message *sqs.Message) error {
+
+	_, err := q.Client.DeleteMessageWithContext(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(q.QueueURL),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+	return err
+}
+
+// delaySeconds rounds wait up to whole seconds, between 0 and max
+func delaySeconds(wait, max time.Duration) int64 {
+
+	if wait <= 0 {
+		return 0
+	}
+	if wait > max {
+		wait = max
+	}
+
+	return int64((wait + time.Second - 1) / time.Second)
+}
+
+// transient reports whether a replay failing with err may succeed later
+func transient(err error) bool {
+
+	switch e := err.(type) {
+	case *geomap.APIError:
+		return e.HTTPStatus >= 500 || e.HTTPStatus == 429 || e.Status == "OVER_QUERY_LIMIT" || e.Status == "UNKNOWN_ERROR"
+	case net.Error:
+		return true
+	}
+
+	return err == context.DeadlineExceeded || err == context.Canceled
+}
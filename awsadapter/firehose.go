@@ -0,0 +1,132 @@
+package awsadapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/firehose"
+	"github.com/aws/aws-sdk-go/service/firehose/firehoseiface"
+)
+
+// PutRecordBatch limits
+const (
+	firehoseMaxBatchRecords = 500
+	firehoseMaxBatchBytes   = 4 * 1024 * 1024
+	firehoseMaxRecordBytes  = 1000 * 1024
+)
+
+/*
+	FirehoseSink is a geomap.ResultSink pushing the results as json lines to a
+	Kinesis Firehose delivery stream. Results are buffered and sent by PutRecordBatch
+	once BatchSize records (at most 500) or 4 MiB are buffered, and on Flush.
+	Records rejected by throttling are retried with backoff up to MaxRetries times (3 by default)
+*/
+type FirehoseSink struct {
+	Client     firehoseiface.FirehoseAPI
+	Stream     string
+	BatchSize  int
+	MaxRetries int
+
+	mu      sync.Mutex
+	records []*firehose.Record
+	size    int
+}
+
+func (s *FirehoseSink) Put(ctx context.Context, result interface{}) error {
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if len(data) > firehoseMaxRecordBytes {
+		return fmt.Errorf("result of %d bytes is over the firehose record limit", len(data))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size+len(data) > firehoseMaxBatchBytes {
+		if err := s.flush(ctx); err != nil {
+			return err
+		}
+	}
+
+	s.records = append(s.records, &firehose.Record{Data: data})
+	s.size += len(data)
+
+	batchSize := s.BatchSize
+	if batchSize <= 0 || batchSize > firehoseMaxBatchRecords {
+		batchSize = firehoseMaxBatchRecords
+	}
+	if len(s.records) >= batchSize {
+		return s.flush(ctx)
+	}
+
+	return nil
+}
+
+func (s *FirehoseSink) Flush(ctx context.Context) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flush(ctx)
+}
+
+// flush sends the buffered records, retrying the failed ones, s.mu must be held
+func (s *FirehoseSink) flush(ctx context.Context) error {
+
+	maxRetries := s.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+
+	records := s.records
+	s.records, s.size = nil, 0
+
+	for attempt := 0; len(records) > 0; attempt++ {
+
+		out, err := s.Client.PutRecordBatchWithContext(ctx, &firehose.PutRecordBatchInput{
+			DeliveryStreamName: aws.String(s.Stream),
+			Records:            records,
+		})
+		if err != nil {
+			//the whole batch is throttled, retry it as is
+			if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != firehose.ErrCodeServiceUnavailableException {
+				return err
+			}
+		} else {
+			if aws.Int64Value(out.FailedPutCount) == 0 {
+				return nil
+			}
+
+			//responses are in the order of the records, keep the failed ones
+			var failed []*firehose.Record
+			for i, response := range out.RequestResponses {
+				if response.ErrorCode != nil {
+					failed = append(failed, records[i])
+				}
+			}
+			records = failed
+		}
+
+		if attempt >= maxRetries {
+			return fmt.Errorf("%d records not delivered to firehose", len(records))
+		}
+
+		select {
+		case <-time.After(100 * time.Millisecond << uint(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package awsadapter
+
+import (
+	"context"
+
+	"gomapservice/geomap"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+/*
+	SSMFlagSource is a geomap.FlagSource reading the disabled endpoints and features
+	from a Parameter Store parameter (comma separated, e.g. "photo,details-atmosphere"),
+	a missing parameter disables nothing
+*/
+type SSMFlagSource struct {
+	Client ssmiface.SSMAPI
+	Name   string
+}
+
+func (s *SSMFlagSource) Disabled(ctx context.Context) ([]string, error) {
+
+	out, err := s.Client.GetParameterWithContext(ctx, &ssm.GetParameterInput{
+		Name: aws.String(s.Name),
+	})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == ssm.ErrCodeParameterNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return geomap.ParseFlags(aws.StringValue(out.Parameter.Value)), nil
+}
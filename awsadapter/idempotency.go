@@ -0,0 +1,99 @@
+package awsadapter
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+/*
+	DynamoDBIdempotencyStore is a handler.IdempotencyStore keeping the responses in a
+	DynamoDB table with a string hash key "key", enable the table TTL on "expires_at"
+	so expired entries are removed. A claim expires after its lease, the response after
+	the ttl given to Complete
+*/
+type DynamoDBIdempotencyStore struct {
+	Client dynamodbiface.DynamoDBAPI
+	Table  string
+}
+
+const (
+	idempotencyPending = "pending"
+	idempotencyDone    = "done"
+)
+
+func (s *DynamoDBIdempotencyStore) Claim(ctx context.Context, key, fingerprint string, lease time.Duration) (string, []byte, bool, error) {
+
+	now := time.Now()
+
+	//expired entries, and the leases of requests which died, may linger until dynamodb
+	//removes them, they can be claimed again
+	_, err := s.Client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"key":         {S: aws.String(key)},
+			"state":       {S: aws.String(idempotencyPending)},
+			"fingerprint": {S: aws.String(fingerprint)},
+			"expires_at":  {N: aws.String(strconv.FormatInt(now.Add(lease).Unix(), 10))},
+		},
+		ConditionExpression:       aws.String("attribute_not_exists(#key) OR expires_at < :now"),
+		ExpressionAttributeNames:  map[string]*string{"#key": aws.String("key")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":now": {N: aws.String(strconv.FormatInt(now.Unix(), 10))}},
+	})
+	if err == nil {
+		return "", nil, true, nil
+	}
+	if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != dynamodb.ErrCodeConditionalCheckFailedException {
+		return "", nil, false, err
+	}
+
+	out, err := s.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.Table),
+		Key:            map[string]*dynamodb.AttributeValue{"key": {S: aws.String(key)}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	var storedFingerprint string
+	if attr := out.Item["fingerprint"]; attr != nil {
+		storedFingerprint = aws.StringValue(attr.S)
+	}
+
+	state, response := out.Item["state"], out.Item["response"]
+	if state == nil || aws.StringValue(state.S) != idempotencyDone || response == nil {
+		return storedFingerprint, nil, false, nil
+	}
+
+	return storedFingerprint, response.B, false, nil
+}
+
+func (s *DynamoDBIdempotencyStore) Complete(ctx context.Context, key, fingerprint string, response []byte, ttl time.Duration) error {
+
+	_, err := s.Client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"key":         {S: aws.String(key)},
+			"state":       {S: aws.String(idempotencyDone)},
+			"fingerprint": {S: aws.String(fingerprint)},
+			"response":    {B: response},
+			"expires_at":  {N: aws.String(strconv.FormatInt(time.Now().Add(ttl).Unix(), 10))},
+		},
+	})
+	return err
+}
+
+func (s *DynamoDBIdempotencyStore) Release(ctx context.Context, key string) error {
+
+	_, err := s.Client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.Table),
+		Key:       map[string]*dynamodb.AttributeValue{"key": {S: aws.String(key)}},
+	})
+	return err
+}
@@ -0,0 +1,353 @@
+package awsadapter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"gomapservice/geomap"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// the limits of a PutLogEvents batch, also the largest batch written to S3
+const (
+	maxJournalBatchEvents = 10000
+	maxJournalBatchBytes  = 1048576
+
+	//cloudwatch counts 26 bytes per event on top of its message
+	cloudWatchEventOverhead = 26
+
+	defaultJournalFlushInterval = 10 * time.Second
+	defaultJournalMaxBuffered   = 100000
+
+	//how long a background flush may take
+	journalFlushTimeout = 30 * time.Second
+)
+
+// journalBatchSize returns batchSize within the limits of a batch
+func journalBatchSize(batchSize int) int {
+
+	if batchSize <= 0 || batchSize > maxJournalBatchEvents {
+		return maxJournalBatchEvents
+	}
+
+	return batchSize
+}
+
+// journalMaxBuffered returns how many entries a sink keeps at most
+func journalMaxBuffered(maxBuffered int) int {
+
+	if maxBuffered <= 0 {
+		return defaultJournalMaxBuffered
+	}
+
+	return maxBuffered
+}
+
+/*
+	journalFlusher flushes a sink in the background, every interval and as soon as
+	a batch is full, so the writes on the request path never wait for AWS
+*/
+type journalFlusher struct {
+	once sync.Once
+	full chan struct{}
+}
+
+// start runs flush in the background, once
+func (f *journalFlusher) start(name string, interval time.Duration, flush func(ctx context.Context) error) {
+	f.once.Do(func() {
+
+		if interval <= 0 {
+			interval = defaultJournalFlushInterval
+		}
+		f.full = make(chan struct{}, 1)
+
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ticker.C:
+				case <-f.full:
+				}
+
+				ctx, cancel := context.WithTimeout(context.Background(), journalFlushTimeout)
+				if err := flush(ctx); err != nil {
+					log.Printf("awsadapter: %s journal flush failed: %v", name, err)
+				}
+				cancel()
+			}
+		}()
+	})
+}
+
+// batchFull wakes the background flush up, without waiting for it
+func (f *journalFlusher) batchFull() {
+	select {
+	case f.full <- struct{}{}:
+	default:
+	}
+}
+
+/*
+	S3JournalSink buffers journal entries and writes them to S3 as json lines objects,
+	one object per batch under Prefix/yyyy/mm/dd/, of BatchSize entries at most 10,000
+	entries or 1MB. Batches are written in the background every FlushInterval (10s by
+	default) and as soon as one is full, the Shutdown of the client flushes what is left.
+	The entries of a failed write are kept for the next flush, up to MaxBuffered entries
+	(100,000 by default) beyond which the oldest are dropped, see Dropped
+*/
+type S3JournalSink struct {
+	Client        s3iface.S3API
+	Bucket        string
+	Prefix        string
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxBuffered   int
+
+	flusher journalFlusher
+	flushMu sync.Mutex
+
+	mu      sync.Mutex
+	lines   [][]byte
+	size    int
+	dropped int
+}
+
+func (s *S3JournalSink) Write(ctx context.Context, entry geomap.JournalEntry) error {
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.flusher.start("s3", s.FlushInterval, s.Flush)
+
+	s.mu.Lock()
+	s.lines = append(s.lines, line)
+	s.size += len(line) + 1
+	s.dropOldest()
+	full := len(s.lines) >= journalBatchSize(s.BatchSize) || s.size >= maxJournalBatchBytes
+	s.mu.Unlock()
+
+	if full {
+		s.flusher.batchFull()
+	}
+
+	return nil
+}
+
+// Dropped returns how many entries were dropped since the sink was created, buffered past MaxBuffered
+func (s *S3JournalSink) Dropped() int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dropped
+}
+
+// dropOldest drops the oldest entries past MaxBuffered, s.mu held
+func (s *S3JournalSink) dropOldest() {
+
+	excess := len(s.lines) - journalMaxBuffered(s.MaxBuffered)
+	if excess <= 0 {
+		return
+	}
+
+	for _, line := range s.lines[:excess] {
+		s.size -= len(line) + 1
+	}
+	s.lines = append([][]byte(nil), s.lines[excess:]...)
+	s.dropped += excess
+}
+
+// Flush writes the buffered entries to S3, in batches
+func (s *S3JournalSink) Flush(ctx context.Context) error {
+
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	lines := s.lines
+	s.lines, s.size = nil, 0
+	s.mu.Unlock()
+
+	for len(lines) > 0 {
+		var body bytes.Buffer
+		n := 0
+		for n < len(lines) && n < journalBatchSize(s.BatchSize) {
+			if n > 0 && body.Len()+len(lines[n])+1 > maxJournalBatchBytes {
+				break
+			}
+			body.Write(lines[n])
+			body.WriteByte('\n')
+			n++
+		}
+
+		now := time.Now().UTC()
+		key := fmt.Sprintf("%s%s/%d.jsonl", s.Prefix, now.Format("2006/01/02"), now.UnixNano())
+
+		_, err := s.Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(s.Bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(body.Bytes()),
+			ContentType: aws.String("application/x-ndjson"),
+		})
+		if err != nil {
+			//back in front of the entries written meanwhile, the next flush sends them again
+			s.mu.Lock()
+			for _, line := range lines {
+				s.size += len(line) + 1
+			}
+			s.lines = append(lines, s.lines...)
+			s.dropOldest()
+			s.mu.Unlock()
+			return err
+		}
+
+		lines = lines[n:]
+	}
+
+	return nil
+}
+
+/*
+	CloudWatchJournalSink buffers journal entries and sends them to a CloudWatch Logs stream,
+	the group and stream must exist. Batches of BatchSize entries, within the limits of
+	PutLogEvents (10,000 events or 1MB), are sent in the background every FlushInterval
+	(10s by default) and as soon as one is full, the Shutdown of the client flushes what
+	is left. Up to MaxBuffered entries (100,000 by default) are kept while CloudWatch
+	fails, beyond which the oldest are dropped, see Dropped
+*/
+type CloudWatchJournalSink struct {
+	Client        cloudwatchlogsiface.CloudWatchLogsAPI
+	Group         string
+	Stream        string
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxBuffered   int
+
+	flusher journalFlusher
+
+	//held by Flush, the sequence token orders the batches
+	flushMu sync.Mutex
+	token   *string
+
+	mu      sync.Mutex
+	events  []*cloudwatchlogs.InputLogEvent
+	size    int
+	dropped int
+}
+
+func (s *CloudWatchJournalSink) Write(ctx context.Context, entry geomap.JournalEntry) error {
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.flusher.start("cloudwatch", s.FlushInterval, s.Flush)
+
+	s.mu.Lock()
+	s.events = append(s.events, &cloudwatchlogs.InputLogEvent{
+		Message:   aws.String(string(line)),
+		Timestamp: aws.Int64(entry.Time.UnixNano() / int64(time.Millisecond)),
+	})
+	s.size += len(line) + cloudWatchEventOverhead
+	s.dropOldest()
+	full := len(s.events) >= journalBatchSize(s.BatchSize) || s.size >= maxJournalBatchBytes
+	s.mu.Unlock()
+
+	if full {
+		s.flusher.batchFull()
+	}
+
+	return nil
+}
+
+// Dropped returns how many entries were dropped since the sink was created, buffered past MaxBuffered
+func (s *CloudWatchJournalSink) Dropped() int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.dropped
+}
+
+// dropOldest drops the oldest entries past MaxBuffered, s.mu held
+func (s *CloudWatchJournalSink) dropOldest() {
+
+	excess := len(s.events) - journalMaxBuffered(s.MaxBuffered)
+	if excess <= 0 {
+		return
+	}
+
+	for _, event := range s.events[:excess] {
+		s.size -= len(*event.Message) + cloudWatchEventOverhead
+	}
+	s.events = append([]*cloudwatchlogs.InputLogEvent(nil), s.events[excess:]...)
+	s.dropped += excess
+}
+
+// Flush sends the buffered entries to CloudWatch Logs
+func (s *CloudWatchJournalSink) Flush(ctx context.Context) error {
+
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	events := s.events
+	s.events, s.size = nil, 0
+	s.mu.Unlock()
+
+	//cloudwatch refuses a batch out of chronological order
+	sort.SliceStable(events, func(i, j int) bool {
+		return *events[i].Timestamp < *events[j].Timestamp
+	})
+
+	for len(events) > 0 {
+		n, size := 0, 0
+		for n < len(events) && n < journalBatchSize(s.BatchSize) {
+			eventSize := len(*events[n].Message) + cloudWatchEventOverhead
+			if n > 0 && size+eventSize > maxJournalBatchBytes {
+				break
+			}
+			size += eventSize
+			n++
+		}
+
+		out, err := s.Client.PutLogEventsWithContext(ctx, &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  aws.String(s.Group),
+			LogStreamName: aws.String(s.Stream),
+			LogEvents:     events[:n],
+			SequenceToken: s.token,
+		})
+		if err != nil {
+			//back in front of the entries written meanwhile, the next flush sends them again
+			s.mu.Lock()
+			for _, event := range events {
+				s.size += len(*event.Message) + cloudWatchEventOverhead
+			}
+			s.events = append(events, s.events...)
+			s.dropOldest()
+			s.mu.Unlock()
+			return err
+		}
+
+		s.token = out.NextSequenceToken
+		events = events[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package awsadapter
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"gomapservice/geomap"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+/*
+	S3PhotoStore is a geomap.PhotoStore keeping place photos in S3.
+	Returned URLs are CloudFront URLs when CloudFrontDomain is set,
+	otherwise pre-signed S3 URLs valid for URLExpiry (15 minutes by default)
+*/
+type S3PhotoStore struct {
+	Client           s3iface.S3API
+	Bucket           string
+	Prefix           string
+	CloudFrontDomain string
+	URLExpiry        time.Duration
+}
+
+func (s *S3PhotoStore) Get(ctx context.Context, key string) (string, bool, error) {
+
+	_, err := s.Client.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Prefix + key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.RequestFailure); ok && aerr.StatusCode() == 404 {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	photoURL, err := s.url(key)
+	return photoURL, err == nil, err
+}
+
+func (s *S3PhotoStore) Put(ctx context.Context, key string, photo geomap.PlacePhotoResponse) (string, error) {
+
+	_, err := s.Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(s.Prefix + key),
+		Body:        bytes.NewReader(photo.Data),
+		ContentType: aws.String(photo.ContentType),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return s.url(key)
+}
+
+func (s *S3PhotoStore) url(key string) (string, error) {
+
+	if s.CloudFrontDomain != "" {
+		return "https://" + s.CloudFrontDomain + "/" + s.Prefix + key, nil
+	}
+
+	expiry := s.URLExpiry
+	if expiry <= 0 {
+		expiry = 15 * time.Minute
+	}
+
+	req, _ := s.Client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Prefix + key),
+	})
+
+	return req.Presign(expiry)
+}
@@ -0,0 +1,133 @@
+package awsadapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"gomapservice/geomap"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+/*
+	DynamoDBSnapshotStore is a geomap.SnapshotStore keeping the snapshots as json
+	in a DynamoDB table with a string hash key "place_id"
+*/
+type DynamoDBSnapshotStore struct {
+	Client dynamodbiface.DynamoDBAPI
+	Table  string
+}
+
+func (s *DynamoDBSnapshotStore) Get(ctx context.Context, placeID string) (geomap.PlaceSnapshot, bool, error) {
+
+	var snapshot geomap.PlaceSnapshot
+
+	out, err := s.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.Table),
+		Key:            map[string]*dynamodb.AttributeValue{"place_id": {S: aws.String(placeID)}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return snapshot, false, err
+	}
+
+	value := out.Item["snapshot"]
+	if value == nil {
+		return snapshot, false, nil
+	}
+
+	if err := json.Unmarshal([]byte(aws.StringValue(value.S)), &snapshot); err != nil {
+		return snapshot, false, err
+	}
+
+	return snapshot, true, nil
+}
+
+func (s *DynamoDBSnapshotStore) Put(ctx context.Context, snapshot geomap.PlaceSnapshot) error {
+
+	value, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"place_id": {S: aws.String(snapshot.PlaceID)},
+			"snapshot": {S: aws.String(string(value))},
+		},
+	})
+	return err
+}
+
+// SNSChangeSink is a geomap.ChangeSink publishing the events as json to an SNS topic
+type SNSChangeSink struct {
+	Client   snsiface.SNSAPI
+	TopicARN string
+}
+
+func (s *SNSChangeSink) Publish(ctx context.Context, event geomap.PlaceChangeEvent) error {
+
+	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Client.PublishWithContext(ctx, &sns.PublishInput{
+		TopicArn: aws.String(s.TopicARN),
+		Subject:  aws.String(fmt.Sprintf("place %s changed", event.PlaceID)),
+		Message:  aws.String(string(message)),
+	})
+	return err
+}
+
+// the source and detail type of the events put by EventBridgeChangeSink, to match them in rules
+const (
+	PlaceChangeSource     = "gomapservice.placewatch"
+	PlaceChangeDetailType = "Place Changed"
+)
+
+/*
+	EventBridgeChangeSink is a geomap.ChangeSink putting the events on an EventBridge bus
+	(the default bus when EventBusName is empty) with PlaceChangeSource and PlaceChangeDetailType
+*/
+type EventBridgeChangeSink struct {
+	Client       eventbridgeiface.EventBridgeAPI
+	EventBusName string
+}
+
+func (s *EventBridgeChangeSink) Publish(ctx context.Context, event geomap.PlaceChangeEvent) error {
+
+	detail, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	entry := &eventbridge.PutEventsRequestEntry{
+		Source:     aws.String(PlaceChangeSource),
+		DetailType: aws.String(PlaceChangeDetailType),
+		Detail:     aws.String(string(detail)),
+	}
+	if s.EventBusName != "" {
+		entry.EventBusName = aws.String(s.EventBusName)
+	}
+
+	out, err := s.Client.PutEventsWithContext(ctx, &eventbridge.PutEventsInput{Entries: []*eventbridge.PutEventsRequestEntry{entry}})
+	if err != nil {
+		return err
+	}
+
+	//PutEvents reports the entries it failed without failing the call
+	if aws.Int64Value(out.FailedEntryCount) > 0 && len(out.Entries) > 0 {
+		return fmt.Errorf("put event: %s: %s", aws.StringValue(out.Entries[0].ErrorCode), aws.StringValue(out.Entries[0].ErrorMessage))
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package awsadapter
+
+import (
+	"context"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+const (
+	defaultRateLimitLease = 10
+	defaultRateLimitSync  = 100 * time.Millisecond
+
+	// conditional updates tried before a sync gives up until the next interval
+	rateLimitAttempts = 3
+)
+
+/*
+	DynamoDBRateLimiter is a geomap.Limiter shared by every container, a token bucket of
+	PerSecond tokens per second and Burst tokens at most kept as the item Key of a DynamoDB
+	table with a string hash key "key". Each container leases up to Lease tokens (10 by
+	default) at a time with a conditional update and spends them locally, syncing with the
+	table at most every SyncInterval (100ms) while it has none left, so the table sees
+	about PerSecond/Lease writes per second. Tokens leased by a container which stops are
+	lost, keep Lease small against PerSecond. With FailOpen, requests are let through while
+	the table can't be reached, they fail with its error otherwise
+*/
+type DynamoDBRateLimiter struct {
+	Client       dynamodbiface.DynamoDBAPI
+	Table        string
+	Key          string
+	PerSecond    float64
+	Burst        int
+	Lease        int
+	SyncInterval time.Duration
+	FailOpen     bool
+
+	mu       sync.Mutex
+	tokens   int
+	lastSync time.Time
+}
+
+// NewDynamoDBRateLimiter returns the limiter of the bucket key in table
+func NewDynamoDBRateLimiter(client dynamodbiface.DynamoDBAPI, table, key string, perSecond float64, burst int) *DynamoDBRateLimiter {
+	return &DynamoDBRateLimiter{Client: client, Table: table, Key: key, PerSecond: perSecond, Burst: burst}
+}
+
+func (l *DynamoDBRateLimiter) Wait(ctx context.Context) error {
+
+	for {
+		l.mu.Lock()
+		if l.tokens > 0 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+
+		wait := l.syncInterval() - time.Since(l.lastSync)
+		if wait <= 0 {
+			//the other waiters wait for the interval instead of syncing too
+			l.lastSync = time.Now()
+		}
+		l.mu.Unlock()
+
+		if wait <= 0 {
+			leased, next, err := l.lease(ctx)
+			if err != nil {
+				if !l.FailOpen {
+					return err
+				}
+				log.Printf("awsadapter: rate limit table unreachable, request allowed: %v", err)
+				return nil
+			}
+
+			if leased > 0 {
+				l.mu.Lock()
+				l.tokens += leased
+				l.mu.Unlock()
+				continue
+			}
+			wait = next
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (l *DynamoDBRateLimiter) syncInterval() time.Duration {
+
+	if l.SyncInterval > 0 {
+		return l.SyncInterval
+	}
+	return defaultRateLimitSync
+}
+
+/*
+	lease takes up to Lease tokens from the table, when there are none it returns
+	how long until the next one. A sync losing the race to other containers
+	retries a few times, then leases nothing
+*/
+func (l *DynamoDBRateLimiter) lease(ctx context.Context) (int, time.Duration, error) {
+
+	lease := l.Lease
+	if lease <= 0 {
+		lease = defaultRateLimitLease
+	}
+	burst := float64(l.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+
+	for attempt := 0; attempt < rateLimitAttempts; attempt++ {
+
+		out, err := l.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(l.Table),
+			Key:            map[string]*dynamodb.AttributeValue{"key": {S: aws.String(l.Key)}},
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+
+		now := time.Now().UnixNano() / int64(time.Millisecond)
+		tokens, updated := burst, ""
+		if item := out.Item; item["updated_at"] != nil {
+			updated = aws.StringValue(item["updated_at"].N)
+			last, _ := strconv.ParseInt(updated, 10, 64)
+			tokens = 0
+			if item["tokens"] != nil {
+				tokens, _ = strconv.ParseFloat(aws.StringValue(item["tokens"].N), 64)
+			}
+
+			//clocks of the containers differ, a bucket updated "in the future" just doesn't refill
+			if elapsed := now - last; elapsed > 0 {
+				tokens = math.Min(burst, tokens+float64(elapsed)/1000*l.PerSecond)
+			}
+
+			//updated_at must change for the condition of a concurrent sync to fail
+			if now <= last {
+				now = last + 1
+			}
+		}
+
+		taken := int(math.Min(float64(lease), math.Floor(tokens)))
+		if taken == 0 {
+			if l.PerSecond <= 0 {
+				return 0, l.syncInterval(), nil
+			}
+			return 0, time.Duration((1 - tokens) / l.PerSecond * float64(time.Second)), nil
+		}
+
+		input := &dynamodb.UpdateItemInput{
+			TableName:        aws.String(l.Table),
+			Key:              map[string]*dynamodb.AttributeValue{"key": {S: aws.String(l.Key)}},
+			UpdateExpression: aws.String("SET tokens = :tokens, updated_at = :now"),
+			ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+				":tokens": {N: aws.String(strconv.FormatFloat(tokens-float64(taken), 'f', -1, 64))},
+				":now":    {N: aws.String(strconv.FormatInt(now, 10))},
+			},
+		}
+		if updated == "" {
+			input.ConditionExpression = aws.String("attribute_not_exists(updated_at)")
+		} else {
+			input.ConditionExpression = aws.String("updated_at = :last")
+			input.ExpressionAttributeValues[":last"] = &dynamodb.AttributeValue{N: aws.String(updated)}
+		}
+
+		_, err = l.Client.UpdateItemWithContext(ctx, input)
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+			continue
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+
+		return taken, 0, nil
+	}
+
+	return 0, l.syncInterval(), nil
+}
@@ -0,0 +1,127 @@
+package awsadapter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// rateLimitTable is the bucket item of a DynamoDB table in memory, honoring the update conditions
+type rateLimitTable struct {
+	dynamodbiface.DynamoDBAPI
+
+	mu        sync.Mutex
+	item      map[string]*dynamodb.AttributeValue
+	err       error
+	conflicts int
+	updates   int
+}
+
+func (t *rateLimitTable) GetItemWithContext(ctx aws.Context, input *dynamodb.GetItemInput, opts ...request.Option) (*dynamodb.GetItemOutput, error) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	item := map[string]*dynamodb.AttributeValue{}
+	for name, value := range t.item {
+		item[name] = value
+	}
+	return &dynamodb.GetItemOutput{Item: item}, nil
+}
+
+func (t *rateLimitTable) UpdateItemWithContext(ctx aws.Context, input *dynamodb.UpdateItemInput, opts ...request.Option) (*dynamodb.UpdateItemOutput, error) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	conflict := awserr.New(dynamodb.ErrCodeConditionalCheckFailedException, "conditional request failed", nil)
+	if t.conflicts > 0 {
+		t.conflicts--
+		return nil, conflict
+	}
+
+	values := input.ExpressionAttributeValues
+	switch aws.StringValue(input.ConditionExpression) {
+	case "attribute_not_exists(updated_at)":
+		if t.item["updated_at"] != nil {
+			return nil, conflict
+		}
+	case "updated_at = :last":
+		if t.item["updated_at"] == nil || aws.StringValue(t.item["updated_at"].N) != aws.StringValue(values[":last"].N) {
+			return nil, conflict
+		}
+	}
+
+	t.item = map[string]*dynamodb.AttributeValue{"tokens": values[":tokens"], "updated_at": values[":now"]}
+	t.updates++
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func TestDynamoDBRateLimiter(t *testing.T) {
+
+	errUnreachable := errors.New("dynamodb unreachable")
+
+	tests := []struct {
+		name     string
+		table    *rateLimitTable
+		lease    int
+		failOpen bool
+
+		allowed int
+		err     error
+		updates int
+	}{
+		{name: "burst allowed then limited", table: &rateLimitTable{}, lease: 10, allowed: 5, err: context.DeadlineExceeded, updates: 1},
+		{name: "tokens leased a few at a time", table: &rateLimitTable{}, lease: 2, allowed: 5, err: context.DeadlineExceeded, updates: 3},
+		{name: "lost races retried", table: &rateLimitTable{conflicts: 2}, lease: 10, allowed: 5, err: context.DeadlineExceeded, updates: 1},
+		{name: "races lost until the deadline", table: &rateLimitTable{conflicts: 1000}, lease: 10, err: context.DeadlineExceeded},
+		{name: "unreachable table fails", table: &rateLimitTable{err: errUnreachable}, lease: 10, err: errUnreachable},
+		{name: "unreachable table fails open", table: &rateLimitTable{err: errUnreachable}, lease: 10, failOpen: true, allowed: 10},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			//no refill during the test, only the burst is available
+			limiter := NewDynamoDBRateLimiter(test.table, "ratelimit", "google", 0, 5)
+			limiter.Lease = test.lease
+			limiter.SyncInterval = time.Millisecond
+			limiter.FailOpen = test.failOpen
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			allowed := 0
+			var err error
+			for allowed < 10 {
+				if err = limiter.Wait(ctx); err != nil {
+					break
+				}
+				allowed++
+			}
+
+			if allowed != test.allowed || err != test.err {
+				t.Errorf("%d allowed then %v, expected %d then %v", allowed, err, test.allowed, test.err)
+			}
+			if test.table.updates != test.updates {
+				t.Errorf("%d table updates, expected %d", test.table.updates, test.updates)
+			}
+		})
+	}
+}
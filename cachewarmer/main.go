@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"gomapservice/geomap"
+	"gomapservice/handler"
+	"log"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Query is a hot query kept warm in the shared cache, without the key
+type Query struct {
+	Endpoint geomap.Endpoint   `json:"endpoint"`
+	Params   map[string]string `json:"params"`
+}
+
+// Input is the constant input of the scheduled EventBridge rule
+type Input struct {
+	Queries []Query `json:"queries"`
+}
+
+type Output struct {
+	Refreshed int     `json:"refreshed"`
+	Failed    []Query `json:"failed,omitempty"`
+}
+
+// queries refreshed at once, the warmer shouldn't eat the quota of the live traffic
+const concurrency = 5
+
+/*
+	Handler re-fetches the hot queries into the shared cache, scheduled more often
+	than the cache TTL so the busiest lookups never miss
+*/
+func Handler(ctx context.Context, input Input) (Output, error) {
+
+	var output Output
+	key := os.Getenv("GOOGLE_API_KEY")
+
+	group := geomap.NewGroup(ctx, 0)
+	sem := make(chan struct{}, concurrency)
+	results := make([]error, len(input.Queries))
+
+	for i, query := range input.Queries {
+		i, query := i, query
+		group.Go(func(ctx context.Context) error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			params := map[string]string{"key": key}
+			for param, value := range query.Params {
+				params[param] = value
+			}
+
+			//a failed query must not cancel the others
+			results[i] = geomap.RefreshCache(ctx, query.Endpoint, params)
+			return nil
+		})
+	}
+	group.Wait()
+
+	for i, err := range results {
+		if err != nil {
+			log.Printf("cachewarmer: %s %v failed: %v", input.Queries[i].Endpoint, input.Queries[i].Params, err)
+			output.Failed = append(output.Failed, input.Queries[i])
+			continue
+		}
+		output.Refreshed++
+	}
+
+	return output, nil
+}
+
+func main() {
+	if err := handler.Setup(); err != nil {
+		log.Fatal(err)
+	}
+
+	lambda.Start(Handler)
+}
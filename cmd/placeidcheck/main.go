@@ -0,0 +1,116 @@
+/*
+	placeidcheck validates a list of stored place ids with the free refresh call,
+	for database hygiene jobs.
+
+	The input has one place id per line. Every id is written to the output as a json line
+	with its status: valid, renamed (with the new_id to store instead), not_found (to be removed)
+	or failed (to be retried, the error is logged). A summary of the counts is logged at the end.
+*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"gomapservice/geomap"
+)
+
+func main() {
+
+	var (
+		in      = flag.String("in", "-", "file of place ids, one per line, - for stdin")
+		out     = flag.String("out", "-", "output jsonl file, - for stdout")
+		rate    = flag.Float64("rate", 10, "refresh requests per second")
+		workers = flag.Int("workers", 4, "concurrent refresh requests")
+	)
+	flag.Parse()
+
+	key := os.Getenv("GOOGLE_API_KEY")
+	if key == "" {
+		log.Fatal("placeidcheck: the GOOGLE_API_KEY environment variable is required")
+	}
+
+	if err := run(*in, *out, key, *rate, *workers); err != nil {
+		log.Fatalf("placeidcheck: %v", err)
+	}
+}
+
+func run(in, out, key string, rate float64, workers int) error {
+
+	ids, err := readIDs(in)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if out != "-" {
+		if w, err = os.Create(out); err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+
+	client, err := geomap.NewClient(geomap.WithRateLimiter(geomap.NewRateLimiter(rate, workers)))
+	if err != nil {
+		return err
+	}
+
+	validations, err := client.ValidatePlaceIDs(context.Background(), ids, geomap.ValidateOptions{
+		Params:      map[string]string{"key": key},
+		Concurrency: workers,
+	})
+	if err != nil {
+		return err
+	}
+
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	counts := map[string]int{}
+	for _, validation := range validations {
+		if validation.Err != nil {
+			log.Printf("placeidcheck: %s failed: %v", validation.ID, validation.Err)
+		}
+		counts[validation.Status]++
+
+		if err := encoder.Encode(validation); err != nil {
+			return err
+		}
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+
+	log.Printf("placeidcheck: %d place ids, %d valid, %d renamed, %d not found, %d failed", len(ids),
+		counts[geomap.PlaceIDValid], counts[geomap.PlaceIDRenamed], counts[geomap.PlaceIDNotFound], counts[geomap.PlaceIDFailed])
+	return nil
+}
+
+// readIDs reads one place id per line, blank lines and duplicates are skipped
+func readIDs(path string) ([]string, error) {
+
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, scanner.Err()
+}
@@ -0,0 +1,197 @@
+/*
+	placexport fetches the details of a list of place ids and writes them as jsonl, csv, parquet
+	or sql, for analytics teams building POI datasets. The sql format is a psql script upserting
+	the places by place_id into a PostGIS table (-table) with a geography(Point,4326) location.
+
+	The input has one place id per line. Exported place ids are appended to a progress file
+	(the output path with a .progress suffix) once written, a rerun with -resume skips them
+	so an interrupted export picks up where it stopped. Failed places are logged
+	and left out of the progress file so the next run retries them.
+*/
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"gomapservice/geomap"
+)
+
+func main() {
+
+	var (
+		in      = flag.String("in", "-", "file of place ids, one per line, - for stdin")
+		out     = flag.String("out", "", "output file")
+		format  = flag.String("format", "jsonl", "output format: jsonl, csv, parquet or sql")
+		table   = flag.String("table", "places", "PostGIS table the sql format upserts into")
+		fields  = flag.String("fields", "place_id,name,formatted_address,geometry,types", "comma separated details fields")
+		rate    = flag.Float64("rate", 10, "details requests per second")
+		workers = flag.Int("workers", 4, "concurrent details requests")
+		resume  = flag.Bool("resume", false, "skip the place ids listed in the progress file")
+	)
+	flag.Parse()
+
+	key := os.Getenv("GOOGLE_API_KEY")
+	if *out == "" || key == "" {
+		log.Fatal("placexport: -out and the GOOGLE_API_KEY environment variable are required")
+	}
+
+	postgisTable = *table
+
+	if err := run(*in, *out, *format, *fields, key, *rate, *workers, *resume); err != nil {
+		log.Fatalf("placexport: %v", err)
+	}
+}
+
+func run(in, out, format, fields, key string, rate float64, workers int, resume bool) error {
+
+	progressPath := out + ".progress"
+
+	done := map[string]bool{}
+	if resume {
+		exported, err := readIDs(progressPath)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for _, id := range exported {
+			done[id] = true
+		}
+	}
+
+	ids, err := readIDs(in)
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, id := range ids {
+		if !done[id] {
+			pending = append(pending, id)
+		}
+	}
+	log.Printf("placexport: %d place ids, %d already exported", len(ids), len(ids)-len(pending))
+
+	output, err := newOutput(format, out, resume)
+	if err != nil {
+		return err
+	}
+
+	progress, err := os.OpenFile(progressPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer progress.Close()
+
+	client, err := geomap.NewClient(geomap.WithRateLimiter(geomap.NewRateLimiter(rate, workers)))
+	if err != nil {
+		return err
+	}
+
+	places := make(chan geomap.Place)
+	queue := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				details, err := client.PlaceDetailV2(context.Background(), map[string]string{
+					"place_id": id,
+					"fields":   fields,
+					"key":      key,
+				})
+				if err == nil && details.Status != "OK" {
+					err = fmt.Errorf("status %s", details.Status)
+				}
+				if err != nil {
+					log.Printf("placexport: %s failed: %v", id, err)
+					continue
+				}
+				details.Result.PlaceID = id
+				places <- details.Result
+			}
+		}()
+	}
+
+	go func() {
+		for _, id := range pending {
+			queue <- id
+		}
+		close(queue)
+		wg.Wait()
+		close(places)
+	}()
+
+	//a place only counts as exported once the output holds it durably
+	var written []string
+	exported := 0
+	for place := range places {
+		if err := output.Write(place); err != nil {
+			return err
+		}
+		written = append(written, place.PlaceID)
+
+		if output.Durable() {
+			if err := appendIDs(progress, written); err != nil {
+				return err
+			}
+			written = written[:0]
+		}
+		exported++
+	}
+
+	if err := output.Close(); err != nil {
+		return err
+	}
+	if err := appendIDs(progress, written); err != nil {
+		return err
+	}
+
+	log.Printf("placexport: exported %d places, %d failed", exported, len(pending)-exported)
+	return nil
+}
+
+// readIDs reads one place id per line, blank lines and duplicates are skipped
+func readIDs(path string) ([]string, error) {
+
+	f := os.Stdin
+	if path != "-" {
+		var err error
+		if f, err = os.Open(path); err != nil {
+			return nil, err
+		}
+		defer f.Close()
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, scanner.Err()
+}
+
+func appendIDs(f *os.File, ids []string) error {
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := f.WriteString(strings.Join(ids, "\n") + "\n"); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
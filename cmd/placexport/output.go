@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gomapservice/export"
+	"gomapservice/geomap"
+)
+
+// the table the sql format upserts into, see -table
+var postgisTable = "places"
+
+// output writes the exported places in one format
+type output interface {
+	Write(place geomap.Place) error
+	//Durable reports whether the places written so far are safely stored
+	Durable() bool
+	Close() error
+}
+
+func newOutput(format, path string, resume bool) (output, error) {
+
+	switch format {
+	case "jsonl":
+		f, err := openOutput(path, resume)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonlOutput{f: f, w: bufio.NewWriter(f)}, nil
+
+	case "csv":
+		f, err := openOutput(path, resume)
+		if err != nil {
+			return nil, err
+		}
+		//appending to an exported file, the header is already there
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return &recordOutput{f: f, w: export.NewCSVWriter(f, info.Size() == 0), durable: true}, nil
+
+	case "sql":
+		f, err := openOutput(path, resume)
+		if err != nil {
+			return nil, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		//the table is created by the first statements of the file
+		pw, err := export.NewPostGISWriter(f, postgisTable, info.Size() == 0)
+		if err != nil {
+			return nil, err
+		}
+		return &recordOutput{f: f, w: pw, durable: true}, nil
+
+	case "parquet":
+		//parquet files can't be appended to, a resumed export writes the next part
+		if resume {
+			path = nextPart(path)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		pw, err := export.NewParquetWriter(f, new(export.PlaceRecord))
+		if err != nil {
+			return nil, err
+		}
+		return &recordOutput{f: f, w: pw}, nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+func openOutput(path string, resume bool) (*os.File, error) {
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	return os.OpenFile(path, flags, 0644)
+}
+
+// nextPart returns the first of path, path.1, path.2... not taken, keeping the extension last
+func nextPart(path string) string {
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	part := path
+	for i := 1; ; i++ {
+		if _, err := os.Stat(part); os.IsNotExist(err) {
+			return part
+		}
+		part = base + "." + strconv.Itoa(i) + ext
+	}
+}
+
+type jsonlOutput struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+func (o *jsonlOutput) Write(place geomap.Place) error {
+
+	line, err := json.Marshal(place)
+	if err != nil {
+		return err
+	}
+
+	if _, err := o.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return o.w.Flush()
+}
+
+func (o *jsonlOutput) Durable() bool { return true }
+
+func (o *jsonlOutput) Close() error {
+
+	if err := o.w.Flush(); err != nil {
+		return err
+	}
+
+	return o.f.Close()
+}
+
+// recordOutput writes the places as export records
+type recordOutput struct {
+	f       *os.File
+	w       export.Writer
+	durable bool
+}
+
+func (o *recordOutput) Write(place geomap.Place) error {
+	return o.w.Write(export.NewPlaceRecord(place))
+}
+
+// Durable is false for parquet until Close, its footer is only written then
+func (o *recordOutput) Durable() bool { return o.durable }
+
+func (o *recordOutput) Close() error {
+
+	if err := o.w.Close(); err != nil {
+		return err
+	}
+
+	return o.f.Close()
+}
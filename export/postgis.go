@@ -0,0 +1,134 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// column types of the records in postgres, the other columns are text
+var postgisTypes = map[string]string{
+	"lat":                "double precision",
+	"lng":                "double precision",
+	"rating":             "double precision",
+	"user_ratings_total": "bigint",
+	"price_level":        "integer",
+	"partial_match":      "boolean",
+}
+
+// table names written as is in the statements, optionally schema qualified
+var postgisTableName = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+
+/*
+	PostGISWriter writes records as SQL statements for psql, one upsert by place_id per
+	record, into a table whose "location" column is the geography(Point,4326) of the
+	lat and lng of the record, GiST indexed. Records must have a place_id, exported
+	again they replace the previous row. With create, the table and its index are
+	created if they don't exist before the first record
+*/
+type PostGISWriter struct {
+	w         io.Writer
+	table     string
+	create    bool
+	wroteHead bool
+}
+
+// NewPostGISWriter returns a PostGISWriter to w upserting into table, e.g. "warehouse.places"
+func NewPostGISWriter(w io.Writer, table string, create bool) (*PostGISWriter, error) {
+
+	if !postgisTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	return &PostGISWriter{w: w, table: table, create: create}, nil
+}
+
+func (p *PostGISWriter) Write(record Record) error {
+
+	columns, values := record.Columns(), record.Values()
+
+	placeID, lat, lng := "", "", ""
+	for i, column := range columns {
+		switch column {
+		case "place_id":
+			placeID = values[i]
+		case "lat":
+			lat = values[i]
+		case "lng":
+			lng = values[i]
+		}
+	}
+	if placeID == "" {
+		return errors.New("postgis records need a place_id to be upserted")
+	}
+
+	var statements strings.Builder
+
+	if p.create && !p.wroteHead {
+		statements.WriteString(p.createTable(columns))
+	}
+
+	literals := make([]string, len(values))
+	updates := make([]string, 0, len(columns))
+	for i, column := range columns {
+		literals[i] = postgisLiteral(column, values[i])
+		if column != "place_id" {
+			updates = append(updates, column+" = EXCLUDED."+column)
+		}
+	}
+
+	fmt.Fprintf(&statements, "INSERT INTO %s (%s, location, updated_at) VALUES (%s, ST_SetSRID(ST_MakePoint(%s, %s), 4326)::geography, now())\n",
+		p.table, strings.Join(columns, ", "), strings.Join(literals, ", "), postgisLiteral("lng", lng), postgisLiteral("lat", lat))
+	fmt.Fprintf(&statements, "ON CONFLICT (place_id) DO UPDATE SET %s, location = EXCLUDED.location, updated_at = EXCLUDED.updated_at;\n",
+		strings.Join(updates, ", "))
+
+	if _, err := io.WriteString(p.w, statements.String()); err != nil {
+		return err
+	}
+
+	p.wroteHead = true
+	return nil
+}
+
+// Close writes nothing, every statement stands alone. Closing the underlying writer is left to the caller
+func (p *PostGISWriter) Close() error {
+	return nil
+}
+
+// createTable returns the statements creating the table of records with columns and its spatial index
+func (p *PostGISWriter) createTable(columns []string) string {
+
+	definitions := make([]string, 0, len(columns)+2)
+	for _, column := range columns {
+		switch {
+		case column == "place_id":
+			definitions = append(definitions, "place_id text PRIMARY KEY")
+		case postgisTypes[column] != "":
+			definitions = append(definitions, column+" "+postgisTypes[column])
+		default:
+			definitions = append(definitions, column+" text")
+		}
+	}
+	definitions = append(definitions, "location geography(Point,4326) NOT NULL", "updated_at timestamptz NOT NULL DEFAULT now()")
+
+	//indexes live in the schema of their table
+	index := p.table[strings.LastIndex(p.table, ".")+1:] + "_location_idx"
+
+	return fmt.Sprintf("CREATE EXTENSION IF NOT EXISTS postgis;\nCREATE TABLE IF NOT EXISTS %s (\n\t%s\n);\nCREATE INDEX IF NOT EXISTS %s ON %s USING GIST (location);\n",
+		p.table, strings.Join(definitions, ",\n\t"), index, p.table)
+}
+
+/*
+	postgisLiteral returns value as a quoted SQL literal, postgres converts it to the type
+	of column. An empty number or boolean is NULL
+*/
+func postgisLiteral(column, value string) string {
+
+	if value == "" && postgisTypes[column] != "" {
+		return "NULL"
+	}
+
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
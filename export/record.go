@@ -0,0 +1,187 @@
+// Package export serializes place and address results for data warehouses, as csv, parquet or PostGIS sql
+package export
+
+import (
+	"strconv"
+	"strings"
+
+	"gomapservice/geomap"
+)
+
+/*
+	Record is a flat row of an export, Columns names the values in order.
+	The struct tags of the records are their parquet schema,
+	where the normalized address is the nested "address" group
+*/
+type Record interface {
+	Columns() []string
+	Values() []string
+}
+
+// Address is an address normalized from google address components
+type Address struct {
+	Formatted    string `parquet:"name=formatted_address, type=BYTE_ARRAY, convertedtype=UTF8"`
+	StreetNumber string `parquet:"name=street_number, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Route        string `parquet:"name=route, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Sublocality  string `parquet:"name=sublocality, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Locality     string `parquet:"name=locality, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AdminArea2   string `parquet:"name=admin_area_2, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AdminArea1   string `parquet:"name=admin_area_1, type=BYTE_ARRAY, convertedtype=UTF8"`
+	PostalCode   string `parquet:"name=postal_code, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Country      string `parquet:"name=country, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CountryCode  string `parquet:"name=country_code, type=BYTE_ARRAY, convertedtype=UTF8"`
+	CountryISO3  string `parquet:"name=country_iso3, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+var addressColumns = []string{
+	"formatted_address", "street_number", "route", "sublocality", "locality",
+	"admin_area_2", "admin_area_1", "postal_code", "country", "country_code", "country_iso3",
+}
+
+/*
+	NewAddress normalizes the address components of a result,
+	the country codes are the ISO 3166 ones of the country component
+*/
+func NewAddress(formatted string, components []geomap.AddressComponent) Address {
+
+	address := Address{Formatted: formatted}
+
+	for _, component := range components {
+		for _, componentType := range component.Types {
+			switch componentType {
+			case "street_number":
+				address.StreetNumber = component.LongName
+			case "route":
+				address.Route = component.LongName
+			case "sublocality":
+				address.Sublocality = component.LongName
+			case "locality":
+				address.Locality = component.LongName
+			case "administrative_area_level_2":
+				address.AdminArea2 = component.LongName
+			case "administrative_area_level_1":
+				address.AdminArea1 = component.LongName
+			case "postal_code":
+				address.PostalCode = component.LongName
+			case "country":
+				address.Country = component.LongName
+				address.CountryCode = component.ShortName
+			}
+		}
+	}
+
+	if country, ok := geomap.CountryOf(components); ok {
+		address.CountryCode, address.CountryISO3 = country.Alpha2, country.Alpha3
+	}
+
+	return address
+}
+
+func (a Address) values() []string {
+	return []string{
+		a.Formatted, a.StreetNumber, a.Route, a.Sublocality, a.Locality,
+		a.AdminArea2, a.AdminArea1, a.PostalCode, a.Country, a.CountryCode, a.CountryISO3,
+	}
+}
+
+// AddressRecord is a geocode result
+type AddressRecord struct {
+	PlaceID string  `parquet:"name=place_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Lat     float64 `parquet:"name=lat, type=DOUBLE"`
+	Lng     float64 `parquet:"name=lng, type=DOUBLE"`
+	Types   string  `parquet:"name=types, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Partial bool    `parquet:"name=partial_match, type=BOOLEAN"`
+	Address `parquet:"name=address"`
+}
+
+func NewAddressRecord(result geomap.GeocodeResult) *AddressRecord {
+	return &AddressRecord{
+		PlaceID: result.PlaceID,
+		Lat:     result.Geometry.Location.Lat,
+		Lng:     result.Geometry.Location.Lng,
+		Types:   strings.Join(result.Types, "|"),
+		Partial: result.PartialMatch,
+		Address: NewAddress(result.FormattedAddress, result.AddressComponents),
+	}
+}
+
+func (r *AddressRecord) Columns() []string {
+	return append([]string{"place_id", "lat", "lng", "types", "partial_match"}, addressColumns...)
+}
+
+func (r *AddressRecord) Values() []string {
+	return append([]string{
+		r.PlaceID,
+		formatFloat(r.Lat),
+		formatFloat(r.Lng),
+		r.Types,
+		strconv.FormatBool(r.Partial),
+	}, r.Address.values()...)
+}
+
+// PlaceRecord is a place with its normalized address
+type PlaceRecord struct {
+	PlaceID          string  `parquet:"name=place_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Name             string  `parquet:"name=name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Lat              float64 `parquet:"name=lat, type=DOUBLE"`
+	Lng              float64 `parquet:"name=lng, type=DOUBLE"`
+	Types            string  `parquet:"name=types, type=BYTE_ARRAY, convertedtype=UTF8"`
+	BusinessStatus   string  `parquet:"name=business_status, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Rating           float64 `parquet:"name=rating, type=DOUBLE"`
+	UserRatingsTotal int64   `parquet:"name=user_ratings_total, type=INT64"`
+	PriceLevel       int32   `parquet:"name=price_level, type=INT32"`
+	Website          string  `parquet:"name=website, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Phone            string  `parquet:"name=phone, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Address          `parquet:"name=address"`
+}
+
+func NewPlaceRecord(place geomap.Place) *PlaceRecord {
+
+	//places from searches have no components, only their vicinity
+	formatted := place.FormattedAddress
+	if formatted == "" {
+		formatted = place.Vicinity
+	}
+
+	return &PlaceRecord{
+		PlaceID:          place.PlaceID,
+		Name:             place.Name,
+		Lat:              place.Geometry.Location.Lat,
+		Lng:              place.Geometry.Location.Lng,
+		Types:            strings.Join(place.Types, "|"),
+		BusinessStatus:   place.BusinessStatus,
+		Rating:           place.Rating,
+		UserRatingsTotal: int64(place.UserRatingsTotal),
+		PriceLevel:       int32(place.PriceLevel),
+		Website:          place.Website,
+		Phone:            place.InternationalPhoneNumber,
+		Address:          NewAddress(formatted, place.AddressComponents),
+	}
+}
+
+func (r *PlaceRecord) Columns() []string {
+	return append([]string{
+		"place_id", "name", "lat", "lng", "types", "business_status",
+		"rating", "user_ratings_total", "price_level", "website", "phone",
+	}, addressColumns...)
+}
+
+func (r *PlaceRecord) Values() []string {
+	return append([]string{
+		r.PlaceID,
+		r.Name,
+		formatFloat(r.Lat),
+		formatFloat(r.Lng),
+		r.Types,
+		r.BusinessStatus,
+		formatFloat(r.Rating),
+		strconv.FormatInt(r.UserRatingsTotal, 10),
+		strconv.FormatInt(int64(r.PriceLevel), 10),
+		r.Website,
+		r.Phone,
+	}, r.Address.values()...)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
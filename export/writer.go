@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// Writer writes records of one type
+type Writer interface {
+	Write(record Record) error
+	Close() error
+}
+
+// CSVWriter writes records as csv, the header is written before the first record
+type CSVWriter struct {
+	w         *csv.Writer
+	header    bool
+	wroteHead bool
+}
+
+// NewCSVWriter returns a CSVWriter to w, set header to false when appending to an existing export
+func NewCSVWriter(w io.Writer, header bool) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w), header: header}
+}
+
+// Write writes record and flushes it, so written records survive an interrupted export
+func (c *CSVWriter) Write(record Record) error {
+
+	if c.header && !c.wroteHead {
+		if err := c.w.Write(record.Columns()); err != nil {
+			return err
+		}
+		c.wroteHead = true
+	}
+
+	if err := c.w.Write(record.Values()); err != nil {
+		return err
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// Close flushes the writer, closing the underlying writer is left to the caller
+func (c *CSVWriter) Close() error {
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+/*
+	ParquetWriter writes records as parquet with the schema of their struct tags,
+	the file is only readable once Close wrote its footer
+*/
+type ParquetWriter struct {
+	w *writer.ParquetWriter
+}
+
+// NewParquetWriter returns a ParquetWriter to w for records of the type of schema, e.g. new(PlaceRecord)
+func NewParquetWriter(w io.Writer, schema Record) (*ParquetWriter, error) {
+
+	if schema == nil {
+		return nil, errors.New("parquet schema record must not be nil")
+	}
+
+	pw, err := writer.NewParquetWriterFromWriter(w, schema, 4)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParquetWriter{w: pw}, nil
+}
+
+func (p *ParquetWriter) Write(record Record) error {
+	return p.w.Write(record)
+}
+
+// Close writes the footer, closing the underlying writer is left to the caller
+func (p *ParquetWriter) Close() error {
+	return p.w.WriteStop()
+}
@@ -0,0 +1,185 @@
+package geomap
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// requests observed before the limit is adjusted
+	defaultAdaptiveWindow = 20
+
+	// share of failed requests of a window backing the limit off
+	adaptiveErrorRate = 0.1
+)
+
+// statuses of google telling the client to slow down
+var throttleStatuses = map[string]bool{
+	"OVER_QUERY_LIMIT":   true,
+	"RESOURCE_EXHAUSTED": true,
+}
+
+/*
+	AdaptiveConcurrency bounds the requests in flight of a batch job with a limit adjusted
+	from what google answers (additive increase, multiplicative decrease): after each
+	window of requests the limit grows by one while the window was healthy and is halved
+	when it was throttled (OVER_QUERY_LIMIT, 429), failed more than 10% of the time or
+	its 90th percentile latency exceeded TargetLatency (unchecked when 0). A throttled
+	request halves the limit right away, once per window. Share one between jobs calling
+	the same api to split its capacity
+*/
+type AdaptiveConcurrency struct {
+	Min           int
+	Max           int
+	TargetLatency time.Duration
+	Window        int
+
+	mu        sync.Mutex
+	released  chan struct{}
+	limit     float64
+	inFlight  int
+	latencies []time.Duration
+	failed    int
+	backedOff bool
+}
+
+// NewAdaptiveConcurrency returns a controller starting at min requests in flight and growing up to max
+func NewAdaptiveConcurrency(min, max int, targetLatency time.Duration) *AdaptiveConcurrency {
+
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+
+	return &AdaptiveConcurrency{Min: min, Max: max, TargetLatency: targetLatency, limit: float64(min)}
+}
+
+// Limit returns the current number of requests allowed in flight
+func (a *AdaptiveConcurrency) Limit() int {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.currentLimit()
+}
+
+func (a *AdaptiveConcurrency) currentLimit() int {
+
+	if a.limit < float64(a.Min) {
+		return a.Min
+	}
+	return int(a.limit)
+}
+
+// Acquire waits for a slot under the limit, release it with Release
+func (a *AdaptiveConcurrency) Acquire(ctx context.Context) error {
+
+	for {
+		a.mu.Lock()
+		if a.inFlight < a.currentLimit() {
+			a.inFlight++
+			a.mu.Unlock()
+			return nil
+		}
+		if a.released == nil {
+			a.released = make(chan struct{})
+		}
+		released := a.released
+		a.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Release frees the slot of a request which took latency and failed with err (nil on success)
+func (a *AdaptiveConcurrency) Release(latency time.Duration, err error) {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.inFlight--
+	defer a.wakeWaiters()
+
+	//a canceled request says nothing about google
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return
+	}
+
+	if isThrottle(err) && !a.backedOff {
+		a.backOff()
+		a.backedOff = true
+	}
+
+	a.latencies = append(a.latencies, latency)
+	if err != nil {
+		a.failed++
+	}
+
+	window := a.Window
+	if window <= 0 {
+		window = defaultAdaptiveWindow
+	}
+	if len(a.latencies) < window {
+		return
+	}
+
+	switch {
+	case a.backedOff:
+		//already halved for this window
+	case float64(a.failed)/float64(len(a.latencies)) > adaptiveErrorRate:
+		a.backOff()
+	case a.TargetLatency > 0 && percentile(a.latencies, 0.9) > a.TargetLatency:
+		a.backOff()
+	default:
+		a.limit = math.Min(a.limit+1, float64(a.Max))
+	}
+
+	a.latencies = a.latencies[:0]
+	a.failed = 0
+	a.backedOff = false
+}
+
+func (a *AdaptiveConcurrency) backOff() {
+	a.limit = math.Max(a.limit/2, float64(a.Min))
+}
+
+// wakeWaiters lets the waiting Acquire calls check the limit again
+func (a *AdaptiveConcurrency) wakeWaiters() {
+
+	if a.released != nil {
+		close(a.released)
+		a.released = nil
+	}
+}
+
+// isThrottle tells whether err is google asking the client to slow down
+func isThrottle(err error) bool {
+
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *APIError:
+		return e.HTTPStatus == 429 || throttleStatuses[e.Status]
+	}
+
+	//the batch operations report the statuses as errors
+	return throttleStatuses[err.Error()]
+}
+
+// percentile returns the p (0 to 1) percentile of latencies
+func percentile(latencies []time.Duration, p float64) time.Duration {
+
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+}
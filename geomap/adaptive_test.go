@@ -0,0 +1,112 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// adaptiveRequest is the outcome of a request released to an AdaptiveConcurrency
+type adaptiveRequest struct {
+	latency time.Duration
+	err     error
+}
+
+// adaptiveWindow returns n requests of the same outcome
+func adaptiveWindow(n int, latency time.Duration, err error) []adaptiveRequest {
+
+	requests := make([]adaptiveRequest, n)
+	for i := range requests {
+		requests[i] = adaptiveRequest{latency: latency, err: err}
+	}
+
+	return requests
+}
+
+func TestAdaptiveConcurrency(t *testing.T) {
+
+	throttled := &APIError{HTTPStatus: 429}
+	healthy := func(windows int) []adaptiveRequest { return adaptiveWindow(4*windows, time.Millisecond, nil) }
+
+	tests := []struct {
+		name     string
+		min, max int
+		requests [][]adaptiveRequest
+		expected int
+	}{
+		{"healthy windows increase the limit by one", 2, 10, [][]adaptiveRequest{healthy(3)}, 5},
+		{"the limit stops at max", 2, 4, [][]adaptiveRequest{healthy(5)}, 4},
+		{"an unfinished window keeps the limit", 2, 10, [][]adaptiveRequest{adaptiveWindow(3, time.Millisecond, nil)}, 2},
+		{
+			"a throttled request halves the limit once per window", 2, 10,
+			[][]adaptiveRequest{healthy(4), adaptiveWindow(2, time.Millisecond, throttled)}, 3,
+		},
+		{
+			"the throttled window doesn't increase the limit", 2, 10,
+			[][]adaptiveRequest{healthy(4), adaptiveWindow(1, time.Millisecond, errors.New("OVER_QUERY_LIMIT")), healthy(1)[:3]}, 3,
+		},
+		{
+			"errors over 10% of a window halve the limit", 2, 10,
+			[][]adaptiveRequest{healthy(4), adaptiveWindow(1, time.Millisecond, errors.New("UNKNOWN_ERROR")), healthy(1)[:3]}, 3,
+		},
+		{
+			"slow windows halve the limit", 2, 10,
+			[][]adaptiveRequest{healthy(4), adaptiveWindow(4, time.Second, nil)}, 3,
+		},
+		{
+			"canceled requests are ignored", 2, 10,
+			[][]adaptiveRequest{healthy(4), adaptiveWindow(8, time.Second, context.Canceled)}, 6,
+		},
+		{"the limit never goes under min", 2, 10, [][]adaptiveRequest{adaptiveWindow(8, time.Millisecond, throttled)}, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			a := NewAdaptiveConcurrency(test.min, test.max, 100*time.Millisecond)
+			a.Window = 4
+
+			for _, requests := range test.requests {
+				for _, request := range requests {
+					if err := a.Acquire(context.Background()); err != nil {
+						t.Fatal(err)
+					}
+					a.Release(request.latency, request.err)
+				}
+			}
+
+			if limit := a.Limit(); limit != test.expected {
+				t.Errorf("limit %d, expected %d", limit, test.expected)
+			}
+		})
+	}
+}
+
+func TestAdaptiveConcurrencyAcquire(t *testing.T) {
+
+	a := NewAdaptiveConcurrency(1, 1, 0)
+	if err := a.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	//the limit is reached until the slot is released
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := a.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("acquired over the limit: %v", err)
+	}
+
+	acquired := make(chan error)
+	go func() { acquired <- a.Acquire(context.Background()) }()
+
+	a.Release(time.Millisecond, nil)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("released slot not acquired")
+	}
+}
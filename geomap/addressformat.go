@@ -0,0 +1,167 @@
+package geomap
+
+import (
+	"strings"
+	"sync"
+)
+
+/*
+	Address templates
+
+	A template is the lines of a display address separated by "\n", each line
+	made of {component_type} placeholders (the long name, {component_type:short}
+	for the short name) and literal separators. Lines and separators left empty
+	by missing components are dropped, so one template covers addresses with or
+	without a street number, a postal code...
+*/
+
+// DefaultAddressTemplate is the street first layout used for countries without a template
+const DefaultAddressTemplate = "{street_number} {route}\n{sublocality}\n{locality}, {administrative_area_level_1:short} {postal_code}\n{country}"
+
+var (
+	addressTemplatesMu sync.RWMutex
+	addressTemplates   = map[string]string{
+		//house number after the street, postal code before the city
+		"DE": "{route} {street_number}\n{postal_code} {locality}\n{country}",
+		"NL": "{route} {street_number}\n{postal_code} {locality}\n{country}",
+		"ES": "{route}, {street_number}\n{postal_code} {locality}\n{administrative_area_level_2}\n{country}",
+		"IT": "{route}, {street_number}\n{postal_code} {locality} {administrative_area_level_2:short}\n{country}",
+		"ID": "{route} No.{street_number}\n{sublocality}\n{locality} {postal_code}\n{administrative_area_level_1}\n{country}",
+
+		//postal code before the city
+		"FR": "{street_number} {route}\n{postal_code} {locality}\n{country}",
+		"BR": "{route}, {street_number} - {sublocality}\n{locality} - {administrative_area_level_1:short}\n{postal_code}\n{country}",
+
+		//street first with the postal code on its own line
+		"GB": "{street_number} {route}\n{postal_town}\n{postal_code}\n{country}",
+		"SG": "{street_number} {route}\n{country} {postal_code}",
+		"AU": "{street_number} {route}\n{locality} {administrative_area_level_1:short} {postal_code}\n{country}",
+
+		//city first, from the largest area down to the building
+		"JP": "〒{postal_code}\n{administrative_area_level_1}{locality}{sublocality_level_1}{sublocality_level_2}{premise}\n{country}",
+		"CN": "{country}\n{administrative_area_level_1}{locality}{sublocality}\n{route}{street_number}",
+		"KR": "{country}\n{administrative_area_level_1} {locality} {sublocality_level_1}\n{route} {premise}\n{postal_code}",
+	}
+)
+
+/*
+	RegisterAddressTemplate sets the template of FormatAddress for country, the ISO 3166-1
+	alpha-2 code google returns as the short name of the country component
+*/
+func RegisterAddressTemplate(country, template string) {
+	addressTemplatesMu.Lock()
+	addressTemplates[strings.ToUpper(country)] = template
+	addressTemplatesMu.Unlock()
+}
+
+/*
+	FormatAddress rebuilds a display address from components with the template of
+	their country, google formatted_address isn't always in the layout local users expect.
+	Lines are joined with ", " unless OmitCountry or Separator are set on an AddressFormatter
+*/
+func FormatAddress(components []AddressComponent) string {
+	return AddressFormatter{}.Format(components)
+}
+
+/*
+	AddressFormatter configures FormatAddress: Separator joins the lines (", " by default,
+	"\n" for labels), OmitCountry drops the country line for domestic audiences
+	and Template overrides the template of every country
+*/
+type AddressFormatter struct {
+	Separator   string
+	OmitCountry bool
+	Template    string
+}
+
+// Format is FormatAddress configured by f
+func (f AddressFormatter) Format(components []AddressComponent) string {
+
+	values := make(map[string]string, 2*len(components))
+	for _, component := range components {
+		for _, componentType := range component.Types {
+			//the first component of a type wins, google lists the most specific first
+			if _, ok := values[componentType]; ok || componentType == "political" {
+				continue
+			}
+			values[componentType] = component.LongName
+			values[componentType+":short"] = component.ShortName
+		}
+	}
+
+	template := f.Template
+	if template == "" {
+		template = addressTemplate(values["country:short"])
+	}
+	if f.OmitCountry {
+		delete(values, "country")
+		delete(values, "country:short")
+	}
+
+	separator := f.Separator
+	if separator == "" {
+		separator = ", "
+	}
+
+	var lines []string
+	for _, line := range strings.Split(template, "\n") {
+		if rendered := renderAddressLine(line, values); rendered != "" {
+			lines = append(lines, rendered)
+		}
+	}
+
+	return strings.Join(lines, separator)
+}
+
+func addressTemplate(country string) string {
+
+	addressTemplatesMu.RLock()
+	template, ok := addressTemplates[country]
+	addressTemplatesMu.RUnlock()
+
+	if !ok {
+		return DefaultAddressTemplate
+	}
+
+	return template
+}
+
+/*
+	renderAddressLine substitutes the placeholders of line, the literal before a
+	placeholder is dropped with it when the component is missing, so
+	"{route}, {street_number}" renders "Main Street" without a street number
+*/
+func renderAddressLine(line string, values map[string]string) string {
+
+	var (
+		rendered strings.Builder
+		literal  string
+	)
+
+	for {
+		start := strings.IndexByte(line, '{')
+		end := strings.IndexByte(line, '}')
+		if start < 0 || end < start {
+			break
+		}
+
+		literal = line[:start]
+		value := values[line[start+1:end]]
+		line = line[end+1:]
+
+		if value == "" {
+			literal = ""
+			continue
+		}
+
+		if rendered.Len() == 0 {
+			//separators lead nowhere on the first value, prefixes like "No." are kept
+			literal = strings.TrimLeft(literal, " ,-")
+		}
+		rendered.WriteString(literal)
+		rendered.WriteString(value)
+		literal = ""
+	}
+
+	return strings.TrimSpace(rendered.String())
+}
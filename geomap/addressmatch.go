@@ -0,0 +1,240 @@
+package geomap
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultMatchMaxDistance = 500
+	defaultMatchAccept      = 0.85
+	defaultMatchReview      = 0.6
+
+	// similarity under which two words don't match at all
+	minTokenSimilarity = 0.8
+)
+
+// MatchDecision is what an address verification pipeline should do with a match
+type MatchDecision string
+
+const (
+	MatchAccept MatchDecision = "accept"
+	MatchReview MatchDecision = "review"
+	MatchReject MatchDecision = "reject"
+)
+
+// the components checked on their own, an address naming the wrong one is likely another place
+var matchedComponents = []string{"street_number", "route", "postal_code", "locality"}
+
+/*
+	AddressMatchOptions configures MatchAddress. Location is where the address is expected
+	(e.g. a pin dropped by the user), nil leaves the distance out of the score, and results
+	MaxDistanceMeters (500 by default) or further away score 0 on distance. Scores of at least
+	AcceptScore (0.85) are accepted, of at least ReviewScore (0.6) sent to manual review
+*/
+type AddressMatchOptions struct {
+	Location          *LatLng
+	MaxDistanceMeters float64
+	AcceptScore       float64
+	ReviewScore       float64
+}
+
+/*
+	AddressMatch is how well an address matches a geocode result, scores go from 0 to 1.
+	Tokens is the share of the words of the address found in the result (typos tolerated,
+	numbers must be exact), Components the share of the street number, route, postal code
+	and locality of the result found in the address, those left out of an address whose
+	words are all in the result don't count. Unmatched lists the words of the
+	address missing from the result, DistanceMeters is -1 without a Location
+*/
+type AddressMatch struct {
+	Score          float64            `json:"score"`
+	Decision       MatchDecision      `json:"decision"`
+	Tokens         float64            `json:"tokens"`
+	Components     float64            `json:"components"`
+	ComponentScore map[string]float64 `json:"component_score"`
+	DistanceMeters float64            `json:"distance_meters"`
+	Unmatched      []string           `json:"unmatched,omitempty"`
+}
+
+/*
+	MatchAddress scores address, as typed by a user, against a geocode result: the
+	words of both are normalized for the country of the result (see
+	RegisterAddressNormalizer) and compared, then the distance to the expected
+	location when there is one. Partial matches are penalised
+*/
+func MatchAddress(address string, result GeocodeResult, opts AddressMatchOptions) AddressMatch {
+
+	if opts.MaxDistanceMeters <= 0 {
+		opts.MaxDistanceMeters = defaultMatchMaxDistance
+	}
+	if opts.AcceptScore <= 0 {
+		opts.AcceptScore = defaultMatchAccept
+	}
+	if opts.ReviewScore <= 0 {
+		opts.ReviewScore = defaultMatchReview
+	}
+
+	normalize := addressNormalizer(resultCountry(result))
+	words := strings.Fields(normalize(address))
+
+	var resultWords []string
+	byType := map[string][][]string{}
+	for _, component := range result.AddressComponents {
+		for _, name := range []string{component.LongName, component.ShortName} {
+			nameWords := strings.Fields(normalize(name))
+			resultWords = append(resultWords, nameWords...)
+			for _, componentType := range component.Types {
+				byType[componentType] = append(byType[componentType], nameWords)
+			}
+		}
+	}
+
+	match := AddressMatch{ComponentScore: map[string]float64{}, DistanceMeters: -1}
+
+	if len(words) > 0 {
+		found := 0.0
+		for _, word := range words {
+			similarity := bestSimilarity(word, resultWords)
+			if similarity == 0 {
+				match.Unmatched = append(match.Unmatched, word)
+			}
+			found += similarity
+		}
+		match.Tokens = found / float64(len(words))
+	}
+
+	//a component matches by its best name, e.g. "5th avenue" or "5th ave"
+	for _, componentType := range matchedComponents {
+		names, ok := byType[componentType]
+		if !ok {
+			continue
+		}
+
+		score := 0.0
+		for _, nameWords := range names {
+			if len(nameWords) == 0 {
+				continue
+			}
+			found := 0.0
+			for _, word := range nameWords {
+				found += bestSimilarity(word, words)
+			}
+			score = math.Max(score, found/float64(len(nameWords)))
+		}
+
+		//an address leaving out e.g. its postal code isn't naming another one
+		if score == 0 && len(match.Unmatched) == 0 {
+			continue
+		}
+
+		match.ComponentScore[componentType] = score
+		match.Components += match.ComponentScore[componentType]
+	}
+	if len(match.ComponentScore) > 0 {
+		match.Components /= float64(len(match.ComponentScore))
+	} else {
+		match.Components = match.Tokens
+	}
+
+	if opts.Location != nil {
+		match.DistanceMeters = DistanceMeters(*opts.Location, result.Geometry.Location)
+		proximity := math.Max(0, 1-match.DistanceMeters/opts.MaxDistanceMeters)
+		match.Score = 0.4*match.Tokens + 0.35*match.Components + 0.25*proximity
+	} else {
+		match.Score = 0.55*match.Tokens + 0.45*match.Components
+	}
+
+	if result.PartialMatch {
+		match.Score *= 0.9
+	}
+
+	switch {
+	case match.Score >= opts.AcceptScore:
+		match.Decision = MatchAccept
+	case match.Score >= opts.ReviewScore:
+		match.Decision = MatchReview
+	default:
+		match.Decision = MatchReject
+	}
+
+	return match
+}
+
+// resultCountry returns the country code of a geocode result, empty without one
+func resultCountry(result GeocodeResult) string {
+
+	for _, component := range result.AddressComponents {
+		for _, componentType := range component.Types {
+			if componentType == "country" {
+				return strings.ToUpper(component.ShortName)
+			}
+		}
+	}
+
+	return ""
+}
+
+// bestSimilarity returns the similarity of word to the closest of candidates, 0 under minTokenSimilarity
+func bestSimilarity(word string, candidates []string) float64 {
+
+	best := 0.0
+	for _, candidate := range candidates {
+		if similarity := tokenSimilarity(word, candidate); similarity > best {
+			best = similarity
+		}
+	}
+
+	if best < minTokenSimilarity {
+		return 0
+	}
+	return best
+}
+
+// tokenSimilarity is 1 minus the edit distance of a and b relative to the longest, numbers only match exactly
+func tokenSimilarity(a, b string) float64 {
+
+	if a == b {
+		return 1
+	}
+	if hasDigit(a) || hasDigit(b) {
+		return 0
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
+
+// editDistance is the levenshtein distance of a and b
+func editDistance(a, b []rune) int {
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,147 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// the states of an aerial view video
+const (
+	VideoStateProcessing = "PROCESSING"
+	VideoStateActive     = "ACTIVE"
+)
+
+// the media formats of an aerial view video, keys of AerialVideo.URIs
+const (
+	VideoFormatImage     = "IMAGE"
+	VideoFormatMP4High   = "MP4_HIGH"
+	VideoFormatMP4Medium = "MP4_MEDIUM"
+	VideoFormatMP4Low    = "MP4_LOW"
+	VideoFormatHLS       = "HLS"
+	VideoFormatDASH      = "DASH"
+)
+
+// ErrVideoNotFound is returned by LookupAerialVideo when no video was rendered for the address or id
+var ErrVideoNotFound = errors.New("aerial view video not found")
+
+// defaultVideoPollInterval is how often WaitAerialVideo looks the video up when not told
+const defaultVideoPollInterval = 30 * time.Second
+
+type VideoMetadata struct {
+	VideoID     string `json:"videoId"`
+	CaptureDate Date   `json:"captureDate"`
+	Duration    string `json:"duration"`
+	State       string `json:"state"`
+}
+
+// VideoURIs are the urls of one media format, they are signed and expire after a few hours
+type VideoURIs struct {
+	LandscapeURI string `json:"landscapeUri"`
+	PortraitURI  string `json:"portraitUri"`
+}
+
+/*
+	AerialVideo is an aerial view video of an address, URIs is only filled once
+	State is VideoStateActive and is keyed by media format (see VideoFormatMP4High...)
+*/
+type AerialVideo struct {
+	URIs     map[string]VideoURIs `json:"uris,omitempty"`
+	State    string               `json:"state"`
+	Metadata VideoMetadata        `json:"metadata"`
+}
+
+// AerialVideoLookup identifies a video by the Address it was rendered for or by its VideoID
+type AerialVideoLookup struct {
+	Address string
+	VideoID string
+}
+
+/*
+	RenderAerialVideo asks google to render the aerial view video of a US postal address,
+	rendering takes hours, poll the returned video id with WaitAerialVideo.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/aerial-view/reference/rest/v1/videos/renderVideo
+*/
+func RenderAerialVideo(ctx context.Context, address string) (AerialVideo, error) {
+	return DefaultClient().RenderAerialVideo(ctx, address)
+}
+
+// RenderAerialVideo is the package level RenderAerialVideo using c
+func (c *Client) RenderAerialVideo(ctx context.Context, address string) (AerialVideo, error) {
+
+	var video AerialVideo
+
+	if address == "" {
+		return video, errors.New("address is required")
+	}
+
+	err := c.postJSON(ctx, EndpointAerialRender, map[string]string{}, map[string]string{"address": address}, &video)
+	return video, err
+}
+
+/*
+	LookupAerialVideo returns the aerial view video of lookup, with the urls of its
+	media once rendered, or ErrVideoNotFound when none was rendered.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/aerial-view/reference/rest/v1/videos/lookupVideo
+*/
+func LookupAerialVideo(ctx context.Context, lookup AerialVideoLookup) (AerialVideo, error) {
+	return DefaultClient().LookupAerialVideo(ctx, lookup)
+}
+
+// LookupAerialVideo is the package level LookupAerialVideo using c
+func (c *Client) LookupAerialVideo(ctx context.Context, lookup AerialVideoLookup) (AerialVideo, error) {
+
+	var video AerialVideo
+
+	params := map[string]string{}
+	switch {
+	case lookup.VideoID != "":
+		params["videoId"] = lookup.VideoID
+	case lookup.Address != "":
+		params["address"] = lookup.Address
+	default:
+		return video, errors.New("video lookup needs an address or a video id")
+	}
+
+	err := c.getJSON(ctx, EndpointAerialLookup, params, &video)
+	if apiErr, ok := err.(*APIError); ok && apiErr.HTTPStatus == http.StatusNotFound {
+		return video, ErrVideoNotFound
+	}
+
+	return video, err
+}
+
+/*
+	WaitAerialVideo looks the video of lookup up every interval (30 seconds when 0)
+	until it is active and returns it, or until ctx is done
+*/
+func WaitAerialVideo(ctx context.Context, lookup AerialVideoLookup, interval time.Duration) (AerialVideo, error) {
+	return DefaultClient().WaitAerialVideo(ctx, lookup, interval)
+}
+
+// WaitAerialVideo is the package level WaitAerialVideo using c
+func (c *Client) WaitAerialVideo(ctx context.Context, lookup AerialVideoLookup, interval time.Duration) (AerialVideo, error) {
+
+	if interval <= 0 {
+		interval = defaultVideoPollInterval
+	}
+
+	for {
+		video, err := c.LookupAerialVideo(ctx, lookup)
+		if err != nil {
+			return video, err
+		}
+		if video.State == VideoStateActive {
+			return video, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return video, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package geomap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// statuses that mean the key is revoked, restricted or out of quota
+var alertStatuses = map[string]bool{
+	"OVER_QUERY_LIMIT": true,
+	"REQUEST_DENIED":   true,
+}
+
+/*
+	Alert reports Count responses with Status received since Since
+	without any successful response in between
+*/
+type Alert struct {
+	Status       string    `json:"status"`
+	Count        int       `json:"count"`
+	Since        time.Time `json:"since"`
+	Endpoint     Endpoint  `json:"endpoint"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+}
+
+/*
+	Alerter notifies operators, e.g. a webhook or SNS (see the awsadapter package),
+	it is called synchronously on the request path but at most once per cooldown
+*/
+type Alerter interface {
+	Alert(ctx context.Context, alert Alert) error
+}
+
+/*
+	AlertPolicy fires an alert once Threshold OVER_QUERY_LIMIT or REQUEST_DENIED
+	responses were received within Window with no OK in between,
+	then stays quiet for Cooldown before alerting on the same status again
+*/
+type AlertPolicy struct {
+	Threshold int
+	Window    time.Duration
+	Cooldown  time.Duration
+}
+
+// WithAlerter makes the client notify alerter of sustained quota or auth failures
+func WithAlerter(alerter Alerter, policy AlertPolicy) ClientOption {
+	return func(c *Client) error {
+
+		if policy.Threshold < 1 {
+			return errors.New("alert threshold must be at least 1")
+		}
+
+		c.alerter = alerter
+		c.alerts = &alertState{policy: policy}
+		return nil
+	}
+}
+
+type alertStreak struct {
+	count int
+	since time.Time
+}
+
+// alertState tracks the failure streaks of a client
+type alertState struct {
+	policy AlertPolicy
+
+	mu      sync.Mutex
+	streaks map[string]*alertStreak
+	fired   map[string]time.Time
+}
+
+// observe records a response status and returns the alert to fire, if any
+func (s *alertState) observe(endpoint Endpoint, summary responseSummary, now time.Time) (Alert, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if summary.Status == "OK" {
+		s.streaks = nil
+		return Alert{}, false
+	}
+	if !alertStatuses[summary.Status] {
+		return Alert{}, false
+	}
+
+	if s.streaks == nil {
+		s.streaks = map[string]*alertStreak{}
+	}
+	if s.fired == nil {
+		s.fired = map[string]time.Time{}
+	}
+
+	streak, ok := s.streaks[summary.Status]
+	if !ok || (s.policy.Window > 0 && now.Sub(streak.since) > s.policy.Window) {
+		streak = &alertStreak{since: now}
+		s.streaks[summary.Status] = streak
+	}
+	streak.count++
+
+	if streak.count < s.policy.Threshold {
+		return Alert{}, false
+	}
+	if last, ok := s.fired[summary.Status]; ok && now.Sub(last) < s.policy.Cooldown {
+		return Alert{}, false
+	}
+	s.fired[summary.Status] = now
+
+	return Alert{
+		Status:       summary.Status,
+		Count:        streak.count,
+		Since:        streak.since,
+		Endpoint:     endpoint,
+		ErrorMessage: summary.ErrorMessage,
+	}, true
+}
+
+// observeStatus feeds a response to the alert policy and fires the alerter when due
+func (c *Client) observeStatus(ctx context.Context, endpoint Endpoint, summary responseSummary) {
+
+	alert, fire := c.alerts.observe(endpoint, summary, time.Now())
+	if !fire {
+		return
+	}
+
+	if err := c.alerter.Alert(ctx, alert); err != nil {
+		log.Printf("geomap: alert failed: %v", err)
+	}
+}
+
+// webhookAlerter posts the alerts as json
+type webhookAlerter struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookAlerter returns an Alerter posting every alert as json to url
+func NewWebhookAlerter(url string, httpClient *http.Client) Alerter {
+
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &webhookAlerter{url: url, httpClient: httpClient}
+}
+
+func (w *webhookAlerter) Alert(ctx context.Context, alert Alert) error {
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
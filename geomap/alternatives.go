@@ -0,0 +1,161 @@
+package geomap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AvoidFeature is a route restriction accepted by the "avoid" param
+type AvoidFeature string
+
+const (
+	AvoidTolls    AvoidFeature = "tolls"
+	AvoidHighways AvoidFeature = "highways"
+	AvoidFerries  AvoidFeature = "ferries"
+	AvoidIndoor   AvoidFeature = "indoor"
+)
+
+/*
+	Alternatives sets "alternatives=true" so directions may return more than one route,
+	google ignores it for requests with waypoints
+*/
+func Alternatives() ParamOption {
+	return func(params map[string]string) error {
+		params["alternatives"] = "true"
+		return nil
+	}
+}
+
+/*
+	Avoid sets the "avoid" param for directions and distance matrix
+	more references https://developers.google.com/maps/documentation/directions/intro#Restrictions
+*/
+func Avoid(features ...AvoidFeature) ParamOption {
+	return func(params map[string]string) error {
+
+		if len(features) == 0 {
+			return errors.New("avoid features must not be empty")
+		}
+
+		values := make([]string, 0, len(features))
+		for _, feature := range features {
+			switch feature {
+			case AvoidTolls, AvoidHighways, AvoidFerries, AvoidIndoor:
+				values = append(values, string(feature))
+			default:
+				return fmt.Errorf("unknown avoid feature %q", feature)
+			}
+		}
+
+		params["avoid"] = strings.Join(values, "|")
+		return nil
+	}
+}
+
+// Distance returns the total distance of the route in meters
+func (r Route) Distance() int {
+
+	var meters int
+	for _, leg := range r.Legs {
+		meters += leg.Distance.Value
+	}
+
+	return meters
+}
+
+// Duration returns the total duration of the route, using duration_in_traffic when available
+func (r Route) Duration() time.Duration {
+
+	var duration time.Duration
+	for _, leg := range r.Legs {
+		if leg.DurationInTraffic != nil {
+			duration += leg.DurationInTraffic.Duration
+			continue
+		}
+		duration += leg.Duration.Duration
+	}
+
+	return duration
+}
+
+/*
+	TollSteps returns how many steps of the route go through a toll road.
+	google doesn't return toll information on legacy directions,
+	so this relies on the "Toll road" notice in the step instructions, which is only
+	written so in english: it is always 0 for the routes asked in another language,
+	see TollsKnown
+*/
+func (r Route) TollSteps() int {
+
+	var steps int
+	for _, leg := range r.Legs {
+		for _, step := range leg.Steps {
+			if strings.Contains(strings.ToLower(step.HTMLInstructions), "toll road") {
+				steps++
+			}
+		}
+	}
+
+	return steps
+}
+
+/*
+	TollsKnown tells whether TollSteps can be trusted for the routes asked in language
+	(the "language" param), google writes the instructions in english by default
+*/
+func TollsKnown(language string) bool {
+
+	language = strings.ToLower(language)
+	return language == "" || language == "en" || strings.HasPrefix(language, "en-")
+}
+
+/*
+	RouteComparison holds the index of the best route per criteria,
+	every index is -1 when there were no routes to compare,
+	LeastTolls is also -1 when the tolls are unknown, see TollsKnown
+*/
+type RouteComparison struct {
+	Fastest    int
+	Shortest   int
+	LeastTolls int
+}
+
+/*
+	CompareRoutes picks the fastest, shortest and least tolls route out of
+	the alternatives returned by a directions request asked in language,
+	ties on tolls are broken by the fastest route
+*/
+func CompareRoutes(routes []Route, language string) RouteComparison {
+
+	comparison := RouteComparison{Fastest: -1, Shortest: -1, LeastTolls: -1}
+	tollsKnown := TollsKnown(language)
+
+	for i, route := range routes {
+		if comparison.Fastest < 0 || route.Duration() < routes[comparison.Fastest].Duration() {
+			comparison.Fastest = i
+		}
+
+		if comparison.Shortest < 0 || route.Distance() < routes[comparison.Shortest].Distance() {
+			comparison.Shortest = i
+		}
+
+		if !tollsKnown {
+			continue
+		}
+
+		if comparison.LeastTolls < 0 {
+			comparison.LeastTolls = i
+			continue
+		}
+
+		best := routes[comparison.LeastTolls]
+		if route.TollSteps() < best.TollSteps() ||
+			(route.TollSteps() == best.TollSteps() && route.Duration() < best.Duration()) {
+			comparison.LeastTolls = i
+		}
+	}
+
+	return comparison
+}
@@ -0,0 +1,70 @@
+package geomap
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+/*
+	API is every operation of the Client, accept it instead of calling the package
+	level functions so tests can substitute a double, e.g. geomaptest.StubClient
+*/
+type API interface {
+	GetGeocode(ctx context.Context, params map[string]string) (GoogleGeocodeResponse, error)
+	GetGeocodeV2(ctx context.Context, params map[string]string) (GeocodeResponseV2, error)
+	BatchGeocode(ctx context.Context, addresses []string, opts BatchOptions) ([]BatchGeocodeResult, error)
+	StreamGeocode(ctx context.Context, addresses <-chan string, w io.Writer, opts BatchOptions) (BatchProgress, error)
+	GeocodeCandidates(ctx context.Context, address string, opts CandidateOptions) ([]GeocodeCandidate, error)
+
+	FindPlace(ctx context.Context, params map[string]string) (GooglePlaceSearchResponse, error)
+	FindPlaceV2(ctx context.Context, params map[string]string) (FindPlaceResponseV2, error)
+	PlaceNearby(ctx context.Context, params map[string]string) (GoogleNearbySearchResponse, error)
+	PlaceNearbyV2(ctx context.Context, params map[string]string) (NearbySearchResponseV2, error)
+	NearbyMulti(ctx context.Context, params map[string]string, variants []SearchVariant) (MultiSearchResult, error)
+	TextSearch(ctx context.Context, params map[string]string) (TextSearchResponseV2, error)
+	SearchNearby(ctx context.Context, params map[string]string) (*Places, error)
+	SearchText(ctx context.Context, params map[string]string) (*Places, error)
+	PlaceDetail(ctx context.Context, params map[string]string) (GooglePlaceDetailResponse, error)
+	PlaceDetailV2(ctx context.Context, params map[string]string) (PlaceDetailResponseV2, error)
+	PlaceAutocomplete(ctx context.Context, params map[string]string) (GoogleAutocompleteResponse, error)
+	PlacePhoto(ctx context.Context, params map[string]string) (PlacePhotoResponse, error)
+	HydrateDetails(ctx context.Context, nearbyResp NearbySearchResponseV2, fields []string, opts HydrateOptions) (HydrateResult, error)
+	PrefetchPhotos(ctx context.Context, places []Place, opts PrefetchOptions) (PrefetchResult, error)
+	RefreshPlaceID(ctx context.Context, oldID string, params map[string]string) (string, error)
+	RefreshStoredPlaceID(ctx context.Context, store PlaceIDStore, oldID string, params map[string]string) (string, error)
+	ValidatePlaceIDs(ctx context.Context, ids []string, opts ValidateOptions) ([]PlaceIDValidation, error)
+
+	GetDirections(ctx context.Context, params map[string]string) (GoogleDirectionsResponse, error)
+	GetDistanceMatrix(ctx context.Context, params map[string]string) (GoogleDistanceMatrixResponse, error)
+	RouteMatrix(ctx context.Context, req RouteMatrixRequest, fn func(RouteMatrixElement) error) error
+	ETAMatrix(ctx context.Context, origins, destinations []string, params map[string]string) (ETAMatrixResult, error)
+	NearestByTravelTime(ctx context.Context, origin, placeType string, n int, mode string, params map[string]string) ([]NearestPlace, error)
+	CommuteScore(ctx context.Context, home LatLng, pois []LatLng, modes []CommuteMode, params map[string]string) (CommuteResult, error)
+	SnapToRoads(ctx context.Context, params map[string]string) (SnapToRoadsResponse, error)
+	TripDistance(ctx context.Context, trace []TracePoint) (Trip, error)
+	SpeedLimits(ctx context.Context, req SpeedLimitsRequest) (SpeedLimitsResult, error)
+	Isochrone(ctx context.Context, origin GoogleLocation, budget time.Duration, params map[string]string, opts IsochroneOptions) (GeoJSONFeature, error)
+
+	GetTimezone(ctx context.Context, params map[string]string) (GoogleTimezoneResponse, error)
+
+	AirQuality(ctx context.Context, lat, lng float64) (AirQualityResponse, error)
+	Pollen(ctx context.Context, lat, lng float64, days int) (PollenResponse, error)
+	BuildingInsights(ctx context.Context, lat, lng float64, requiredQuality string) (BuildingInsightsResponse, error)
+	SolarDataLayers(ctx context.Context, lat, lng float64, opts DataLayersOptions) (DataLayersResponse, error)
+
+	CreateTileSession(ctx context.Context, req TileSessionRequest) (TileSession, error)
+	TileURL(session string, z, x, y int) (string, error)
+	Tile(ctx context.Context, session string, z, x, y int) (MapTile, error)
+	ViewportInfo(ctx context.Context, session string, zoom int, bounds TileBounds) (TileViewport, error)
+
+	RenderAerialVideo(ctx context.Context, address string) (AerialVideo, error)
+	LookupAerialVideo(ctx context.Context, lookup AerialVideoLookup) (AerialVideo, error)
+	WaitAerialVideo(ctx context.Context, lookup AerialVideoLookup, interval time.Duration) (AerialVideo, error)
+
+	RefreshCache(ctx context.Context, endpoint Endpoint, params map[string]string) error
+	ReplayDeferred(ctx context.Context, req DeferredRequest, params map[string]string) (json.RawMessage, error)
+}
+
+var _ API = (*Client)(nil)
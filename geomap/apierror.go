@@ -0,0 +1,60 @@
+package geomap
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+/*
+	APIError is returned when google answers with a non 200 http status,
+	it keeps the response so failures can be diagnosed from the logs
+*/
+type APIError struct {
+	HTTPStatus   int
+	Status       string
+	ErrorMessage string
+	Body         []byte
+}
+
+func (e *APIError) Error() string {
+
+	msg := fmt.Sprintf("Status not OK: http %d", e.HTTPStatus)
+	if e.Status != "" {
+		msg += " " + e.Status
+	}
+	if e.ErrorMessage != "" {
+		msg += ": " + e.ErrorMessage
+	}
+
+	return msg
+}
+
+// googleErrorBody is the json error of the maps apis and of the google front end
+type googleErrorBody struct {
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+	Error        struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// newAPIError builds the error of a non 200 response from its body
+func newAPIError(httpStatus int, body []byte) *APIError {
+
+	apiErr := &APIError{HTTPStatus: httpStatus, Body: body}
+
+	var parsed googleErrorBody
+	if json.Unmarshal(body, &parsed) == nil {
+		apiErr.Status = parsed.Status
+		apiErr.ErrorMessage = parsed.ErrorMessage
+		if apiErr.Status == "" {
+			apiErr.Status = parsed.Error.Status
+		}
+		if apiErr.ErrorMessage == "" {
+			apiErr.ErrorMessage = parsed.Error.Message
+		}
+	}
+
+	return apiErr
+}
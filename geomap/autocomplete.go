@@ -0,0 +1,56 @@
+package geomap
+
+import "context"
+
+type GoogleAutocompleteResponse struct {
+	Predictions  []Prediction `json:"predictions"`
+	Status       string       `json:"status"`
+	ErrorMessage string       `json:"error_message,omitempty"`
+}
+
+type Prediction struct {
+	Description          string               `json:"description"`
+	MatchedSubstrings    []MatchedSubstring   `json:"matched_substrings"`
+	PlaceID              string               `json:"place_id"`
+	Reference            string               `json:"reference"`
+	StructuredFormatting StructuredFormatting `json:"structured_formatting"`
+	Terms                []PredictionTerm     `json:"terms"`
+	Types                []string             `json:"types"`
+}
+
+type MatchedSubstring struct {
+	Length int `json:"length"`
+	Offset int `json:"offset"`
+}
+
+type StructuredFormatting struct {
+	MainText                  string             `json:"main_text"`
+	MainTextMatchedSubstrings []MatchedSubstring `json:"main_text_matched_substrings"`
+	SecondaryText             string             `json:"secondary_text"`
+}
+
+type PredictionTerm struct {
+	Offset int    `json:"offset"`
+	Value  string `json:"value"`
+}
+
+/*
+	PlaceAutocomplete will return GoogleAutocompleteResponse on success
+	params need "input" and "key", use a SessionManager to group the calls of one session for billing
+	more references https://developers.google.com/places/web-service/autocomplete
+*/
+func PlaceAutocomplete(ctx context.Context, params map[string]string) (GoogleAutocompleteResponse, error) {
+	return DefaultClient().PlaceAutocomplete(ctx, params)
+}
+
+// PlaceAutocomplete is the package level PlaceAutocomplete using c
+func (c *Client) PlaceAutocomplete(ctx context.Context, params map[string]string) (GoogleAutocompleteResponse, error) {
+
+	var googleAutocompleteResponse GoogleAutocompleteResponse
+
+	//a user is typing, see PriorityLimiter
+	ctx = withDefaultPriority(ctx, PriorityInteractive)
+
+	err := c.getJSON(ctx, EndpointAutocomplete, params, &googleAutocompleteResponse)
+	return googleAutocompleteResponse, err
+}
@@ -0,0 +1,136 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const defaultBatchConcurrency = 5
+
+/*
+	BatchOptions configures the batch operations, Params are sent with every request
+	and need at least the "key", Concurrency bounds the requests in flight (5 by default)
+	unless Adaptive adjusts them to what google sustains,
+	Sink receives every result as soon as it completes and Progress is called after it,
+	concurrently from the goroutines of the batch
+*/
+type BatchOptions struct {
+	Params      map[string]string
+	Concurrency int
+	Adaptive    *AdaptiveConcurrency
+	Sink        ResultSink
+	Progress    func(BatchProgress)
+}
+
+// BatchGeocodeResult is the geocode of one address of a batch, Error is set when it failed
+type BatchGeocodeResult struct {
+	Index    int               `json:"index"`
+	Address  string            `json:"address"`
+	Response GeocodeResponseV2 `json:"response"`
+	Err      error             `json:"-"`
+	Error    string            `json:"error,omitempty"`
+}
+
+/*
+	BatchGeocode geocodes every address concurrently, paced by the client rate limiter.
+	Results are returned in the order of addresses, a failed address doesn't stop the batch,
+	the error returned is the first error of the sink. The results are all held until
+	the end, see StreamGeocode for jobs too big for that
+*/
+func BatchGeocode(ctx context.Context, addresses []string, opts BatchOptions) ([]BatchGeocodeResult, error) {
+	return DefaultClient().BatchGeocode(ctx, addresses, opts)
+}
+
+// BatchGeocode is the package level BatchGeocode using c
+func (c *Client) BatchGeocode(ctx context.Context, addresses []string, opts BatchOptions) ([]BatchGeocodeResult, error) {
+
+	//interactive calls go first, see PriorityLimiter
+	ctx = withDefaultPriority(ctx, PriorityBulk)
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	results := make([]BatchGeocodeResult, len(addresses))
+
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, concurrency)
+		sinkMu   sync.Mutex
+		sinkErr  error
+		progress BatchProgress
+	)
+
+	for i, address := range addresses {
+
+		results[i].Index = i
+		results[i].Address = address
+
+		wg.Add(1)
+		go func(result *BatchGeocodeResult) {
+			defer wg.Done()
+
+			if opts.Adaptive != nil {
+				if err := opts.Adaptive.Acquire(ctx); err != nil {
+					result.Err = err
+					result.Error = err.Error()
+				} else {
+					start := time.Now()
+					c.geocodeBatchItem(ctx, result, opts.Params)
+					opts.Adaptive.Release(time.Since(start), result.Err)
+				}
+			} else {
+				select {
+				case sem <- struct{}{}:
+					defer func() { <-sem }()
+					c.geocodeBatchItem(ctx, result, opts.Params)
+				case <-ctx.Done():
+					result.Err = ctx.Err()
+					result.Error = result.Err.Error()
+				}
+			}
+
+			if opts.Sink != nil {
+				if err := opts.Sink.Put(ctx, result); err != nil {
+					sinkMu.Lock()
+					if sinkErr == nil {
+						sinkErr = err
+					}
+					sinkMu.Unlock()
+				}
+			}
+
+			progress.record(result.Err, opts.Progress)
+		}(&results[i])
+	}
+
+	wg.Wait()
+
+	if opts.Sink != nil {
+		if err := opts.Sink.Flush(ctx); err != nil && sinkErr == nil {
+			sinkErr = err
+		}
+	}
+
+	return results, sinkErr
+}
+
+func (c *Client) geocodeBatchItem(ctx context.Context, result *BatchGeocodeResult, params map[string]string) {
+
+	params = copyParams(params)
+	params["address"] = result.Address
+
+	resp, err := c.GetGeocodeV2(ctx, params)
+	if err == nil && resp.Status != "OK" && resp.Status != "ZERO_RESULTS" {
+		err = errors.New(resp.Status)
+	}
+
+	result.Response = resp
+	if err != nil {
+		result.Err = err
+		result.Error = err.Error()
+	}
+}
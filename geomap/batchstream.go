@@ -0,0 +1,149 @@
+package geomap
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BatchProgress counts the results of a batch so far
+type BatchProgress struct {
+	Done   int64 `json:"done"`
+	Failed int64 `json:"failed"`
+}
+
+// record counts a result which failed with err (nil on success) and reports the progress to fn
+func (p *BatchProgress) record(err error, fn func(BatchProgress)) {
+
+	current := BatchProgress{Done: atomic.AddInt64(&p.Done, 1), Failed: atomic.LoadInt64(&p.Failed)}
+	if err != nil {
+		current.Failed = atomic.AddInt64(&p.Failed, 1)
+	}
+
+	if fn != nil {
+		fn(current)
+	}
+}
+
+/*
+	StreamGeocode geocodes the addresses received until the channel is closed and writes
+	each result to w as a json line (NDJSON) as soon as it completes, so a job of any size
+	holds only the results in flight. Results come in the order they complete, their Index
+	is their position in addresses. opts.Sink also receives them and opts.Progress is called
+	after each one. The error is the first write or sink error, which stops the stream,
+	or the error of ctx when it was canceled
+*/
+func StreamGeocode(ctx context.Context, addresses <-chan string, w io.Writer, opts BatchOptions) (BatchProgress, error) {
+	return DefaultClient().StreamGeocode(ctx, addresses, w, opts)
+}
+
+// StreamGeocode is the package level StreamGeocode using c
+func (c *Client) StreamGeocode(ctx context.Context, addresses <-chan string, w io.Writer, opts BatchOptions) (BatchProgress, error) {
+
+	parent := withDefaultPriority(ctx, PriorityBulk)
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	workers := opts.Concurrency
+	if opts.Adaptive != nil {
+		workers = opts.Adaptive.Max
+	}
+	if workers <= 0 {
+		workers = defaultBatchConcurrency
+	}
+
+	sinks := []ResultSink{NewWriterSink(w)}
+	if opts.Sink != nil {
+		sinks = append(sinks, opts.Sink)
+	}
+
+	type item struct {
+		index   int
+		address string
+	}
+	items := make(chan item)
+
+	var (
+		progress BatchProgress
+		errMu    sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+
+	fail := func(err error) {
+		errMu.Lock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+		errMu.Unlock()
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for next := range items {
+				result := BatchGeocodeResult{Index: next.index, Address: next.address}
+
+				if opts.Adaptive != nil {
+					if err := opts.Adaptive.Acquire(ctx); err != nil {
+						result.Err = err
+						result.Error = err.Error()
+					} else {
+						start := time.Now()
+						c.geocodeBatchItem(ctx, &result, opts.Params)
+						opts.Adaptive.Release(time.Since(start), result.Err)
+					}
+				} else {
+					c.geocodeBatchItem(ctx, &result, opts.Params)
+				}
+
+				for _, sink := range sinks {
+					if err := sink.Put(ctx, &result); err != nil {
+						fail(err)
+					}
+				}
+
+				progress.record(result.Err, opts.Progress)
+			}
+		}()
+	}
+
+	index := 0
+feed:
+	for {
+		select {
+		case address, ok := <-addresses:
+			if !ok {
+				break feed
+			}
+			select {
+			case items <- item{index: index, address: address}:
+				index++
+			case <-ctx.Done():
+				break feed
+			}
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(items)
+	wg.Wait()
+
+	//the results written must reach the sinks, even when ctx stopped the stream
+	for _, sink := range sinks {
+		if err := sink.Flush(context.Background()); err != nil {
+			fail(err)
+		}
+	}
+
+	if firstErr == nil {
+		firstErr = parent.Err()
+	}
+
+	return BatchProgress{Done: atomic.LoadInt64(&progress.Done), Failed: atomic.LoadInt64(&progress.Failed)}, firstErr
+}
@@ -0,0 +1,180 @@
+package geomap
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// maxSearchRadius is the largest radius in meters google accepts for a nearby search
+const maxSearchRadius = 50000
+
+/*
+	CircleBounds returns the bounding box of the circle of radius meters around center,
+	its longitudes cross the antimeridian (SouthWest.Lng > Northeast.Lng) when the circle does
+	and span every longitude when it reaches a pole
+*/
+func CircleBounds(center LatLng, radiusMeters float64) GoogleViewport {
+
+	north := Destination(center, 0, radiusMeters).Lat
+	south := Destination(center, 180, radiusMeters).Lat
+
+	//the angular radius tells whether a pole is inside the circle
+	angular := toDegrees(radiusMeters / earthRadius)
+	if center.Lat+angular >= 90 {
+		north = 90
+	}
+	if center.Lat-angular <= -90 {
+		south = -90
+	}
+	if north == 90 || south == -90 {
+		return GoogleViewport{
+			Northeast: GoogleLocation{Lat: north, Lng: 180},
+			SouthWest: GoogleLocation{Lat: south, Lng: -180},
+		}
+	}
+
+	//the widest point of the circle isn't on the center latitude but where the meridians touch it
+	dLng := toDegrees(math.Asin(math.Min(1, math.Sin(radiusMeters/earthRadius)/math.Cos(toRadians(center.Lat)))))
+
+	return GoogleViewport{
+		Northeast: GoogleLocation{Lat: north, Lng: normalizeLng(center.Lng + dLng)},
+		SouthWest: GoogleLocation{Lat: south, Lng: normalizeLng(center.Lng - dLng)},
+	}
+}
+
+/*
+	BoundsCircle returns the smallest circle around the center of bounds
+	holding the whole box, the reverse of CircleBounds
+*/
+func BoundsCircle(bounds GoogleViewport) (center LatLng, radiusMeters float64) {
+
+	width := bounds.Northeast.Lng - bounds.SouthWest.Lng
+	if width < 0 {
+		width += 360
+	}
+
+	center = LatLng{
+		Lat: (bounds.Northeast.Lat + bounds.SouthWest.Lat) / 2,
+		Lng: normalizeLng(bounds.SouthWest.Lng + width/2),
+	}
+
+	corners := []LatLng{
+		bounds.Northeast,
+		bounds.SouthWest,
+		{Lat: bounds.Northeast.Lat, Lng: bounds.SouthWest.Lng},
+		{Lat: bounds.SouthWest.Lat, Lng: bounds.Northeast.Lng},
+	}
+	for _, corner := range corners {
+		radiusMeters = math.Max(radiusMeters, DistanceMeters(center, corner))
+	}
+
+	return center, radiusMeters
+}
+
+// ExpandBounds grows bounds by margin meters on every side, e.g. to keep the places just outside a viewport
+func ExpandBounds(bounds GoogleViewport, marginMeters float64) GoogleViewport {
+
+	north := Destination(bounds.Northeast, 0, marginMeters).Lat
+	south := Destination(bounds.SouthWest, 180, marginMeters).Lat
+	if bounds.Northeast.Lat+toDegrees(marginMeters/earthRadius) >= 90 {
+		north = 90
+	}
+	if bounds.SouthWest.Lat-toDegrees(marginMeters/earthRadius) <= -90 {
+		south = -90
+	}
+
+	//the edge closest to a pole is the one needing the widest margin in degrees
+	widest := math.Max(math.Abs(north), math.Abs(south))
+	if widest >= 90 {
+		return GoogleViewport{
+			Northeast: GoogleLocation{Lat: north, Lng: 180},
+			SouthWest: GoogleLocation{Lat: south, Lng: -180},
+		}
+	}
+	dLng := toDegrees(marginMeters / (earthRadius * math.Cos(toRadians(widest))))
+
+	width := bounds.Northeast.Lng - bounds.SouthWest.Lng
+	if width < 0 {
+		width += 360
+	}
+	if width+2*dLng >= 360 {
+		return GoogleViewport{
+			Northeast: GoogleLocation{Lat: north, Lng: 180},
+			SouthWest: GoogleLocation{Lat: south, Lng: -180},
+		}
+	}
+
+	return GoogleViewport{
+		Northeast: GoogleLocation{Lat: north, Lng: normalizeLng(bounds.Northeast.Lng + dLng)},
+		SouthWest: GoogleLocation{Lat: south, Lng: normalizeLng(bounds.SouthWest.Lng - dLng)},
+	}
+}
+
+// Contains tells whether location is inside the viewport, which may cross the antimeridian
+func (v GoogleViewport) Contains(location LatLng) bool {
+
+	if location.Lat < v.SouthWest.Lat || location.Lat > v.Northeast.Lat {
+		return false
+	}
+
+	if v.SouthWest.Lng <= v.Northeast.Lng {
+		return location.Lng >= v.SouthWest.Lng && location.Lng <= v.Northeast.Lng
+	}
+	return location.Lng >= v.SouthWest.Lng || location.Lng <= v.Northeast.Lng
+}
+
+// WithinRadius tells whether location is at most radius meters from center
+func WithinRadius(center LatLng, radiusMeters float64, location LatLng) bool {
+	return DistanceMeters(center, location) <= radiusMeters
+}
+
+// PlacesWithin returns the places located inside bounds, e.g. to drop the results google added around a viewport
+func PlacesWithin(places []Place, bounds GoogleViewport) []Place {
+
+	within := make([]Place, 0, len(places))
+	for _, place := range places {
+		if bounds.Contains(place.Geometry.Location) {
+			within = append(within, place)
+		}
+	}
+
+	return within
+}
+
+/*
+	SearchArea sets the location and radius of a nearby or text search to the circle
+	holding bounds, e.g. the viewport of a map, combine it with PlacesWithin to keep
+	only the places inside it. Bounds wider than the 50km google allows are refused
+*/
+func SearchArea(bounds GoogleViewport) ParamOption {
+	return func(params map[string]string) error {
+
+		center, radius := BoundsCircle(bounds)
+		if radius > maxSearchRadius {
+			return errors.New("search area must fit within a 50km radius")
+		}
+
+		params["location"] = center.String()
+		params["radius"] = strconv.FormatFloat(math.Ceil(radius), 'f', 0, 64)
+		return nil
+	}
+}
+
+// GeocodeBounds makes a geocoding request prefer the results inside bounds
+func GeocodeBounds(bounds GoogleViewport) ParamOption {
+	return func(params map[string]string) error {
+		params["bounds"] = bounds.SouthWest.String() + "|" + bounds.Northeast.String()
+		return nil
+	}
+}
+
+// normalizeLng brings a longitude back within -180..180
+func normalizeLng(lng float64) float64 {
+
+	if lng >= -180 && lng <= 180 {
+		return lng
+	}
+
+	return math.Mod(lng+540, 360) - 180
+}
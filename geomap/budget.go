@@ -0,0 +1,85 @@
+package geomap
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBudgetExceeded is returned instead of sending a request that would overspend the client budget
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+/*
+	Budget accounts for the estimated cost of the requests sent by a client (the list
+	prices of dry runs, cache hits are free) and refuses requests once LimitUSD is
+	reached, a zero LimitUSD only accounts. Reset it at the start of every billing period
+*/
+type Budget struct {
+	LimitUSD float64
+
+	mu       sync.Mutex
+	spentUSD float64
+	requests map[Endpoint]int64
+}
+
+// BudgetUsage is what a budget accounted since its last reset
+type BudgetUsage struct {
+	SpentUSD float64
+	Requests map[Endpoint]int64
+}
+
+// WithBudget makes the client account its requests in budget, a budget can be shared by clients
+func WithBudget(budget *Budget) ClientOption {
+	return func(c *Client) error {
+
+		if budget == nil {
+			return errors.New("budget must not be nil")
+		}
+
+		c.budget = budget
+		return nil
+	}
+}
+
+// Usage returns the spending and requests by endpoint since the last reset
+func (b *Budget) Usage() BudgetUsage {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	usage := BudgetUsage{SpentUSD: b.spentUSD, Requests: make(map[Endpoint]int64, len(b.requests))}
+	for endpoint, count := range b.requests {
+		usage.Requests[endpoint] = count
+	}
+
+	return usage
+}
+
+// Reset clears the spending, e.g. on the first day of the month
+func (b *Budget) Reset() {
+
+	b.mu.Lock()
+	b.spentUSD = 0
+	b.requests = nil
+	b.mu.Unlock()
+}
+
+// charge accounts a request to endpoint, failing when it would go over the limit
+func (b *Budget) charge(endpoint Endpoint) error {
+
+	cost := endpointSKUs[endpoint].cost
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.LimitUSD > 0 && b.spentUSD+cost > b.LimitUSD {
+		return ErrBudgetExceeded
+	}
+
+	b.spentUSD += cost
+	if b.requests == nil {
+		b.requests = map[Endpoint]int64{}
+	}
+	b.requests[endpoint]++
+
+	return nil
+}
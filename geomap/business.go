@@ -0,0 +1,73 @@
+package geomap
+
+import "context"
+
+// business_status values returned by google
+const (
+	BusinessOperational       = "OPERATIONAL"
+	BusinessClosedTemporarily = "CLOSED_TEMPORARILY"
+	BusinessClosedPermanently = "CLOSED_PERMANENTLY"
+)
+
+// TextSearchResponseV2 has the same shape as the nearby search response
+type TextSearchResponseV2 = NearbySearchResponseV2
+
+/*
+	TextSearch will return TextSearchResponseV2 on success
+	params need "query" and "key"
+	more references https://developers.google.com/places/web-service/search#TextSearchRequests
+*/
+func TextSearch(ctx context.Context, params map[string]string) (TextSearchResponseV2, error) {
+	return DefaultClient().TextSearch(ctx, params)
+}
+
+// TextSearch is the package level TextSearch using c
+func (c *Client) TextSearch(ctx context.Context, params map[string]string) (TextSearchResponseV2, error) {
+
+	var textSearchResponse TextSearchResponseV2
+
+	err := c.getJSON(ctx, EndpointTextSearch, params, &textSearchResponse)
+	if err == nil && c.excludeClosedBusinesses() {
+		textSearchResponse.Results = ExcludeClosed(textSearchResponse.Results)
+	}
+	if err == nil {
+		textSearchResponse.Results = c.rank(params, textSearchResponse.Results)
+	}
+
+	return textSearchResponse, err
+}
+
+/*
+	Closed reports whether the place is temporarily or permanently closed,
+	older responses only carry the permanently_closed flag
+*/
+func (p Place) Closed() bool {
+	return p.PermanentlyClosed ||
+		p.BusinessStatus == BusinessClosedTemporarily ||
+		p.BusinessStatus == BusinessClosedPermanently
+}
+
+// ExcludeClosed returns the places that are not closed, keeping their order
+func ExcludeClosed(places []Place) []Place {
+
+	open := make([]Place, 0, len(places))
+	for _, place := range places {
+		if !place.Closed() {
+			open = append(open, place)
+		}
+	}
+
+	return open
+}
+
+func openNearbyResults(results []NearbyResult) []NearbyResult {
+
+	open := make([]NearbyResult, 0, len(results))
+	for _, result := range results {
+		if !result.V2().Closed() {
+			open = append(open, result)
+		}
+	}
+
+	return open
+}
@@ -0,0 +1,189 @@
+package geomap
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+)
+
+/*
+	Cache stores raw google responses keyed by endpoint and params,
+	credentials are left out of the keys so rotating the api key keeps the cache warm
+	and geocoded addresses are normalized (see RegisterAddressNormalizer)
+*/
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+/*
+	WithCache makes the client serve repeated requests from cache,
+	responses with status OK are kept for ttl (see WithNegativeCache for the others)
+*/
+func WithCache(cache Cache, ttl time.Duration) ClientOption {
+	return func(c *Client) error {
+		c.cache = cache
+		c.cacheTTL = ttl
+		return nil
+	}
+}
+
+type cacheRefreshKey struct{}
+
+/*
+	RefreshCache fetches endpoint with params from google, ignoring the cached response,
+	and stores the new response in the client cache, e.g. to warm hot queries before they expire
+*/
+func RefreshCache(ctx context.Context, endpoint Endpoint, params map[string]string) error {
+	return DefaultClient().RefreshCache(ctx, endpoint, params)
+}
+
+// RefreshCache is the package level RefreshCache using c
+func (c *Client) RefreshCache(ctx context.Context, endpoint Endpoint, params map[string]string) error {
+
+	if c.cache == nil {
+		return errors.New("client has no cache")
+	}
+	if _, ok := endpointPaths[endpoint]; !ok || endpoint == EndpointPhoto || endpoint == EndpointTile {
+		return fmt.Errorf("endpoint %q can't be cached", endpoint)
+	}
+
+	var response struct {
+		Status       string `json:"status"`
+		ErrorMessage string `json:"error_message"`
+	}
+
+	err := c.getJSON(context.WithValue(ctx, cacheRefreshKey{}, true), endpoint, params, &response)
+	if err != nil {
+		return err
+	}
+	if response.Status != "OK" {
+		return fmt.Errorf("%s: %s", response.Status, response.ErrorMessage)
+	}
+
+	return nil
+}
+
+// cacheKey identifies a request, Encode sorts the params so the key is stable
+func cacheKey(endpoint Endpoint, query url.Values) string {
+
+	keyed := url.Values{}
+	for key, values := range query {
+		if !redactedParams[key] {
+			keyed[key] = values
+		}
+	}
+
+	return string(endpoint) + "?" + keyed.Encode()
+}
+
+// cached returns the cached response of key, cache failures are logged and treated as misses
+func (c *Client) cached(ctx context.Context, key string) ([]byte, bool) {
+
+	value, found, err := c.cache.Get(ctx, key)
+	if err != nil {
+		log.Printf("geomap: cache get failed: %v", err)
+		return nil, false
+	}
+
+	return value, found
+}
+
+// storeCached caches the response when its status is worth caching
+func (c *Client) storeCached(ctx context.Context, endpoint Endpoint, key string, result *fetchResult) {
+
+	status := result.summarize().Status
+	ttl, ok := c.cacheTTLFor(status)
+	if !ok {
+		return
+	}
+
+	//the body buffer goes back to the pool, the cache keeps its own copy
+	body := append([]byte(nil), result.body...)
+	if err := c.cache.Set(ctx, key, body, ttl); err != nil {
+		log.Printf("geomap: cache set failed: %v", err)
+	}
+
+	if status == "OK" {
+		c.storeStale(ctx, endpoint, key, body)
+	}
+}
+
+type memoryCacheEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// memoryCache is a Cache in process memory evicting the least recently used entries
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+/*
+	NewMemoryCache returns a Cache kept in process memory holding up to maxEntries
+	responses (unbounded when 0), the least recently used are evicted first
+*/
+func NewMemoryCache(maxEntries int) Cache {
+	return &memoryCache{
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryCacheEntry)
+	if !entry.expires.IsZero() && time.Now().After(entry.expires) {
+		m.order.Remove(element)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	if element, ok := m.entries[key]; ok {
+		element.Value = &memoryCacheEntry{key: key, value: value, expires: expires}
+		m.order.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{key: key, value: value, expires: expires})
+
+	if m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package geomap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+/*
+	Snapshotter is a Cache whose entries can be written out and read back,
+	e.g. NewMemoryCache, so a new process starts with the entries of a previous one
+*/
+type Snapshotter interface {
+	Snapshot(w io.Writer) (entries int, err error)
+	Restore(r io.Reader) (entries int, err error)
+}
+
+/*
+	CacheSnapshotStore keeps the latest snapshot of a cache, e.g. an S3 object
+	(see the awsadapter package). Load reports found false until a snapshot was saved
+*/
+type CacheSnapshotStore interface {
+	Save(ctx context.Context, snapshot []byte) error
+	Load(ctx context.Context) (snapshot []byte, found bool, err error)
+}
+
+// snapshotEntry is a line of a snapshot, a zero Expires never expires
+type snapshotEntry struct {
+	Key     string    `json:"key"`
+	Value   []byte    `json:"value"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+/*
+	Snapshot writes the live entries as gzipped json lines, the least recently
+	used first so Restore rebuilds the same eviction order
+*/
+func (m *memoryCache) Snapshot(w io.Writer) (int, error) {
+
+	now := time.Now()
+
+	m.mu.Lock()
+	entries := make([]snapshotEntry, 0, m.order.Len())
+	for element := m.order.Back(); element != nil; element = element.Prev() {
+		entry := element.Value.(*memoryCacheEntry)
+		if entry.expires.IsZero() || entry.expires.After(now) {
+			entries = append(entries, snapshotEntry{Key: entry.key, Value: entry.value, Expires: entry.expires})
+		}
+	}
+	m.mu.Unlock()
+
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(entries), gz.Close()
+}
+
+// Restore sets the entries of a snapshot which haven't expired since, over the current ones
+func (m *memoryCache) Restore(r io.Reader) (int, error) {
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	defer gz.Close()
+
+	restored := 0
+	decoder := json.NewDecoder(bufio.NewReader(gz))
+	for {
+		var entry snapshotEntry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			return restored, nil
+		} else if err != nil {
+			return restored, err
+		}
+
+		var ttl time.Duration
+		if !entry.Expires.IsZero() {
+			if ttl = time.Until(entry.Expires); ttl <= 0 {
+				continue
+			}
+		}
+
+		m.Set(context.Background(), entry.Key, entry.Value, ttl)
+		restored++
+	}
+}
+
+/*
+	CacheSnapshots saves the entries of Cache to Store every interval and loads the latest
+	snapshot when a process starts, so new lambda containers or restarted servers begin warm.
+	Servers call Run in the background, lambdas SaveIfDue after their requests since a
+	frozen container runs nothing between them
+*/
+type CacheSnapshots struct {
+	Cache Snapshotter
+	Store CacheSnapshotStore
+	Every time.Duration
+
+	mu    sync.Mutex
+	saved time.Time
+}
+
+// NewCacheSnapshots returns the snapshots of cache, which must be a Snapshotter (e.g. NewMemoryCache)
+func NewCacheSnapshots(cache Cache, store CacheSnapshotStore, every time.Duration) (*CacheSnapshots, error) {
+
+	snapshotter, ok := cache.(Snapshotter)
+	if !ok {
+		return nil, errors.New("cache can't be snapshotted")
+	}
+
+	return &CacheSnapshots{Cache: snapshotter, Store: store, Every: every, saved: time.Now()}, nil
+}
+
+// Load restores the latest snapshot, if any, returning how many entries it restored
+func (s *CacheSnapshots) Load(ctx context.Context) (int, error) {
+
+	snapshot, found, err := s.Store.Load(ctx)
+	if err != nil || !found {
+		return 0, err
+	}
+
+	return s.Cache.Restore(bytes.NewReader(snapshot))
+}
+
+// Save writes a snapshot now, returning how many entries it holds
+func (s *CacheSnapshots) Save(ctx context.Context) (int, error) {
+
+	var buf bytes.Buffer
+	entries, err := s.Cache.Snapshot(&buf)
+	if err != nil {
+		return 0, err
+	}
+
+	//an empty cache would replace a warmer snapshot of another process
+	if entries == 0 {
+		return 0, nil
+	}
+
+	return entries, s.Store.Save(ctx, buf.Bytes())
+}
+
+// Flush saves a snapshot now, so CacheSnapshots can be given to WithShutdownFlush
+func (s *CacheSnapshots) Flush(ctx context.Context) error {
+	_, err := s.Save(ctx)
+	return err
+}
+
+// SaveIfDue saves a snapshot when the last one is older than the interval, failures are logged
+func (s *CacheSnapshots) SaveIfDue(ctx context.Context) {
+
+	s.mu.Lock()
+	if time.Since(s.saved) < s.Every {
+		s.mu.Unlock()
+		return
+	}
+	s.saved = time.Now()
+	s.mu.Unlock()
+
+	if _, err := s.Save(ctx); err != nil {
+		log.Printf("geomap: cache snapshot failed: %v", err)
+	}
+}
+
+// Run saves a snapshot every interval until ctx is done, then a last one
+func (s *CacheSnapshots) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(s.Every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.SaveIfDue(ctx)
+		case <-ctx.Done():
+			//ctx is done, the last save gets its own deadline
+			final, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if _, err := s.Save(final); err != nil {
+				log.Printf("geomap: cache snapshot failed: %v", err)
+			}
+			cancel()
+			return
+		}
+	}
+}
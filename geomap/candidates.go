@@ -0,0 +1,127 @@
+package geomap
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+/*
+	CandidateOptions configures GeocodeCandidates, Params are sent with the geocode
+	and need at least the "key", candidates under MinConfidence (0 to 1) are dropped
+	and Limit caps the candidates returned (0 returns them all)
+*/
+type CandidateOptions struct {
+	Params        map[string]string
+	MinConfidence float64
+	Limit         int
+}
+
+// GeocodeCandidate is a geocode result with its confidence, from 0 to 1
+type GeocodeCandidate struct {
+	GeocodeResult
+	Confidence float64 `json:"confidence"`
+}
+
+// confidence of a result by the precision of its location
+var locationTypeConfidence = map[string]float64{
+	"ROOFTOP":            1,
+	"RANGE_INTERPOLATED": 0.8,
+	"GEOMETRIC_CENTER":   0.6,
+	"APPROXIMATE":        0.4,
+}
+
+/*
+	GeocodeCandidates geocodes address and returns every result google found, most
+	confident first, for address correction screens where a user picks the right one.
+	The confidence weighs the location type (rooftop over approximate), how much
+	of the address is found in the components of the result, and partial matches
+	are penalised. ZERO_RESULTS returns no candidate and no error
+*/
+func GeocodeCandidates(ctx context.Context, address string, opts CandidateOptions) ([]GeocodeCandidate, error) {
+	return DefaultClient().GeocodeCandidates(ctx, address, opts)
+}
+
+// GeocodeCandidates is the package level GeocodeCandidates using c
+func (c *Client) GeocodeCandidates(ctx context.Context, address string, opts CandidateOptions) ([]GeocodeCandidate, error) {
+
+	params := copyParams(opts.Params)
+	params["address"] = address
+
+	resp, err := c.GetGeocodeV2(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch err := resp.Check(false); err {
+	case nil:
+	case ErrZeroResults:
+		return []GeocodeCandidate{}, nil
+	default:
+		return nil, err
+	}
+
+	terms := addressTerms(address)
+
+	candidates := make([]GeocodeCandidate, 0, len(resp.Results))
+	for _, result := range resp.Results {
+		confidence := geocodeConfidence(result, terms)
+		if confidence < opts.MinConfidence {
+			continue
+		}
+		candidates = append(candidates, GeocodeCandidate{GeocodeResult: result, Confidence: confidence})
+	}
+
+	//ties keep the order of google
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	if opts.Limit > 0 && len(candidates) > opts.Limit {
+		candidates = candidates[:opts.Limit]
+	}
+
+	return candidates, nil
+}
+
+func geocodeConfidence(result GeocodeResult, terms []string) float64 {
+
+	precision, ok := locationTypeConfidence[result.Geometry.LocationType]
+	if !ok {
+		precision = locationTypeConfidence["APPROXIMATE"]
+	}
+
+	overlap := 1.0
+	if len(terms) > 0 {
+
+		names := make(map[string]bool)
+		for _, component := range result.AddressComponents {
+			for _, term := range addressTerms(component.LongName + " " + component.ShortName) {
+				names[term] = true
+			}
+		}
+
+		found := 0
+		for _, term := range terms {
+			if names[term] {
+				found++
+			}
+		}
+		overlap = float64(found) / float64(len(terms))
+	}
+
+	confidence := 0.4*precision + 0.6*overlap
+	if result.PartialMatch {
+		confidence *= 0.8
+	}
+
+	return confidence
+}
+
+// addressTerms splits an address into lower case words and numbers
+func addressTerms(address string) []string {
+	return strings.FieldsFunc(strings.ToLower(address), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
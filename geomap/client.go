@@ -0,0 +1,220 @@
+package geomap
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+/*
+	Client holds the configuration shared by the calls to google:
+	the http client, rate limiting, request deduplication, caching, journaling and result filtering.
+	The package level functions use a default client, build your own with NewClient
+	when different parts of a service need a different setup
+*/
+type Client struct {
+	httpClient *http.Client
+
+	baseURL      string
+	endpointURLs map[Endpoint]string
+
+	defaultParams         map[string]string
+	endpointDefaultParams map[Endpoint]map[string]string
+
+	mu            sync.RWMutex
+	limiter       Limiter
+	excludeClosed bool
+
+	codec           Codec
+	maxResponseSize int64
+	maxElements     int
+	maxPhotoSize    int64
+	guards          guardCounts
+
+	keyPool    *KeyPool
+	dryRun     bool
+	budget     *Budget
+	killSwitch *KillSwitch
+
+	fieldsCheck *fieldsCheck
+
+	experimental map[string]bool
+	speedLimits  bool
+
+	throttleRetries  int
+	throttleMaxWait  time.Duration
+	pageTokenRetries int
+	pageTokenDelay   time.Duration
+	hedging          *Hedging
+
+	etaMatrixConcurrency int
+
+	dedup  map[Endpoint]bool
+	flight flightGroup
+
+	geocodeDedup       bool
+	geocodeDedupRadius float64
+	geocodeStore       GeocodeStore
+
+	journal           JournalSink
+	journalSampleRate float64
+
+	deferred   DeferredQueue
+	quotaReset QuotaReset
+
+	scrubber *Scrubber
+	coarsen  Coarsener
+
+	countryPolicy *CountryPolicy
+	ranker        Ranker
+
+	alerter Alerter
+	alerts  *alertState
+
+	cache            Cache
+	cacheTTL         time.Duration
+	negativeCacheTTL time.Duration
+	staleIfError     map[Endpoint]time.Duration
+
+	photoStore      PhotoStore
+	photoProcessing *PhotoProcessing
+
+	drain drainState
+}
+
+// ClientOption configures a Client built by NewClient
+type ClientOption func(c *Client) error
+
+// NewClient returns a Client configured by opts
+func NewClient(opts ...ClientOption) (*Client, error) {
+
+	c := &Client{
+		httpClient:       &http.Client{},
+		dedup:            map[Endpoint]bool{},
+		throttleRetries:  defaultThrottleRetries,
+		throttleMaxWait:  defaultThrottleMaxWait,
+		pageTokenRetries: defaultPageTokenRetries,
+		pageTokenDelay:   defaultPageTokenDelay,
+	}
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// WithHTTPClient makes the client send its requests with httpClient
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) error {
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithRateLimiter makes every call wait on l before being sent
+func WithRateLimiter(l Limiter) ClientOption {
+	return func(c *Client) error {
+		c.limiter = l
+		return nil
+	}
+}
+
+/*
+	WithExcludeClosedBusinesses makes nearby and text search drop the places
+	that are temporarily or permanently closed from their results
+*/
+func WithExcludeClosedBusinesses() ClientOption {
+	return func(c *Client) error {
+		c.excludeClosed = true
+		return nil
+	}
+}
+
+/*
+	WithDeduplication shares one upstream request between concurrent identical
+	calls (same endpoint and params) to the given endpoints, the first caller's
+	context governs the shared request
+*/
+func WithDeduplication(endpoints ...Endpoint) ClientOption {
+	return func(c *Client) error {
+		for _, endpoint := range endpoints {
+			c.dedup[endpoint] = true
+		}
+		return nil
+	}
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultClient *Client
+)
+
+func init() {
+
+	defaultClient, _ = NewClient()
+}
+
+// DefaultClient returns the client used by the package level functions
+func DefaultClient() *Client {
+
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+
+	return defaultClient
+}
+
+// SetDefaultClient replaces the client used by the package level functions
+func SetDefaultClient(c *Client) {
+
+	defaultMu.Lock()
+	defaultClient = c
+	defaultMu.Unlock()
+}
+
+/*
+	SetRateLimiter makes every call of the default client wait on l before being sent,
+	pass nil to remove the limit
+*/
+func SetRateLimiter(l Limiter) {
+	DefaultClient().SetRateLimiter(l)
+}
+
+// SetRateLimiter replaces the limiter of c, pass nil to remove the limit
+func (c *Client) SetRateLimiter(l Limiter) {
+
+	c.mu.Lock()
+	c.limiter = l
+	c.mu.Unlock()
+}
+
+/*
+	SetExcludeClosedBusinesses makes nearby and text search of the default client
+	drop the places that are temporarily or permanently closed from their results
+*/
+func SetExcludeClosedBusinesses(exclude bool) {
+
+	c := DefaultClient()
+
+	c.mu.Lock()
+	c.excludeClosed = exclude
+	c.mu.Unlock()
+}
+
+func (c *Client) excludeClosedBusinesses() bool {
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.excludeClosed
+}
+
+func (c *Client) rateLimiter() Limiter {
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.limiter
+}
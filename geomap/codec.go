@@ -0,0 +1,53 @@
+package geomap
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+/*
+	Codec decodes the google responses. The default is encoding/json,
+	build with the jsoniter or gojson tag to default to json-iterator or go-json
+	for services decoding many responses per second
+*/
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// stdCodec is encoding/json
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// defaultCodec is replaced by the codec of the build tag, if any
+var defaultCodec Codec = stdCodec{}
+
+// WithCodec makes the client decode the responses with codec
+func WithCodec(codec Codec) ClientOption {
+	return func(c *Client) error {
+
+		if codec == nil {
+			return errors.New("codec must not be nil")
+		}
+
+		c.codec = codec
+		return nil
+	}
+}
+
+// jsonCodec returns the codec of the client, the default one unless set by WithCodec
+func (c *Client) jsonCodec() Codec {
+
+	if c.codec == nil {
+		return defaultCodec
+	}
+
+	return c.codec
+}
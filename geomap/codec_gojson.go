@@ -0,0 +1,21 @@
+//go:build gojson
+// +build gojson
+
+package geomap
+
+import gojson "github.com/goccy/go-json"
+
+// goJSONCodec is github.com/goccy/go-json
+type goJSONCodec struct{}
+
+func (goJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return gojson.Marshal(v)
+}
+
+func (goJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return gojson.Unmarshal(data, v)
+}
+
+func init() {
+	defaultCodec = goJSONCodec{}
+}
@@ -0,0 +1,10 @@
+//go:build jsoniter
+// +build jsoniter
+
+package geomap
+
+import jsoniter "github.com/json-iterator/go"
+
+func init() {
+	defaultCodec = jsoniter.ConfigCompatibleWithStandardLibrary
+}
@@ -0,0 +1,172 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	CommuteMode is how a travel mode (walking, bicycling, transit or driving) counts in
+	a commute score: a trip of at most Ideal scores 100, a trip of Max or more (or no
+	route) scores 0, linearly in between. Weight is the share of the mode in the score
+	relative to the other modes
+*/
+type CommuteMode struct {
+	Mode   string
+	Weight float64
+	Ideal  time.Duration
+	Max    time.Duration
+}
+
+/*
+	DefaultCommuteModes weigh walking and transit over driving, a place where the
+	everyday places are a short walk or ride away scores high without a car
+*/
+var DefaultCommuteModes = []CommuteMode{
+	{Mode: "walking", Weight: 0.4, Ideal: 5 * time.Minute, Max: 30 * time.Minute},
+	{Mode: "transit", Weight: 0.35, Ideal: 10 * time.Minute, Max: 60 * time.Minute},
+	{Mode: "driving", Weight: 0.25, Ideal: 10 * time.Minute, Max: 45 * time.Minute},
+}
+
+// CommuteTrip is the trip from home to a point of interest with a mode and its score
+type CommuteTrip struct {
+	Mode           string        `json:"mode"`
+	Reachable      bool          `json:"reachable"`
+	Duration       time.Duration `json:"duration"`
+	DistanceMeters int           `json:"distance_meters"`
+	Score          float64       `json:"score"`
+}
+
+// POICommute is the score of a point of interest, the weighted score of its trips
+type POICommute struct {
+	Location LatLng        `json:"location"`
+	Score    float64       `json:"score"`
+	Trips    []CommuteTrip `json:"trips"`
+}
+
+/*
+	CommuteResult is the commute score of home from 0 to 100, the mean of the scores
+	of the points of interest. ByMode has the mean score of each mode on its own
+*/
+type CommuteResult struct {
+	Score  float64            `json:"score"`
+	ByMode map[string]float64 `json:"by_mode"`
+	POIs   []POICommute       `json:"pois"`
+}
+
+/*
+	CommuteScore rates how accessible the points of interest (schools, offices,
+	stations...) are from home with a distance matrix request per mode, modes are
+	DefaultCommuteModes when nil. params are sent with every request and need at
+	least the "key", e.g. with a "departure_time" for transit at rush hour
+*/
+func CommuteScore(ctx context.Context, home LatLng, pois []LatLng, modes []CommuteMode, params map[string]string) (CommuteResult, error) {
+	return DefaultClient().CommuteScore(ctx, home, pois, modes, params)
+}
+
+// CommuteScore is the package level CommuteScore using c
+func (c *Client) CommuteScore(ctx context.Context, home LatLng, pois []LatLng, modes []CommuteMode, params map[string]string) (CommuteResult, error) {
+
+	if len(pois) == 0 {
+		return CommuteResult{}, errors.New("pois must not be empty")
+	}
+	if len(pois) > maxMatrixDestinations {
+		return CommuteResult{}, fmt.Errorf("at most %d pois are scored at once", maxMatrixDestinations)
+	}
+
+	if modes == nil {
+		modes = DefaultCommuteModes
+	}
+
+	totalWeight := 0.0
+	for _, mode := range modes {
+		if mode.Weight < 0 || mode.Max <= mode.Ideal {
+			return CommuteResult{}, fmt.Errorf("commute mode %s needs a positive weight and a max over its ideal", mode.Mode)
+		}
+		totalWeight += mode.Weight
+	}
+	if totalWeight == 0 {
+		return CommuteResult{}, errors.New("commute modes must have a weight")
+	}
+
+	destinations := make([]string, len(pois))
+	for i, poi := range pois {
+		destinations[i] = poi.String()
+	}
+
+	responses := make([]GoogleDistanceMatrixResponse, len(modes))
+	errs := make([]error, len(modes))
+
+	var wg sync.WaitGroup
+	for i, mode := range modes {
+
+		matrixParams := copyParams(params)
+		matrixParams["origins"] = home.String()
+		matrixParams["destinations"] = strings.Join(destinations, "|")
+		matrixParams["mode"] = mode.Mode
+
+		wg.Add(1)
+		go func(i int, matrixParams map[string]string) {
+			defer wg.Done()
+
+			resp, err := c.GetDistanceMatrix(ctx, matrixParams)
+			if err == nil && resp.Status != "OK" {
+				err = errors.New("distance matrix status " + resp.Status)
+			}
+			if err == nil && (len(resp.Rows) != 1 || len(resp.Rows[0].Elements) != len(pois)) {
+				err = errors.New("distance matrix returned an unexpected number of elements")
+			}
+			responses[i], errs[i] = resp, err
+		}(i, matrixParams)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return CommuteResult{}, err
+		}
+	}
+
+	result := CommuteResult{ByMode: map[string]float64{}, POIs: make([]POICommute, len(pois))}
+
+	for p, poi := range pois {
+
+		scored := POICommute{Location: poi}
+		for m, mode := range modes {
+
+			element := responses[m].Rows[0].Elements[p]
+			trip := CommuteTrip{Mode: mode.Mode, Reachable: element.Status == "OK"}
+			if trip.Reachable {
+				trip.Duration = time.Duration(element.Duration.Value) * time.Second
+				trip.DistanceMeters = element.Distance.Value
+				trip.Score = mode.score(trip.Duration)
+			}
+
+			scored.Trips = append(scored.Trips, trip)
+			scored.Score += trip.Score * mode.Weight / totalWeight
+			result.ByMode[mode.Mode] += trip.Score / float64(len(pois))
+		}
+
+		result.POIs[p] = scored
+		result.Score += scored.Score / float64(len(pois))
+	}
+
+	return result, nil
+}
+
+// score maps a trip duration to 0-100
+func (m CommuteMode) score(duration time.Duration) float64 {
+
+	switch {
+	case duration <= m.Ideal:
+		return 100
+	case duration >= m.Max:
+		return 0
+	}
+
+	return 100 * float64(m.Max-duration) / float64(m.Max-m.Ideal)
+}
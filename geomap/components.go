@@ -0,0 +1,66 @@
+package geomap
+
+import (
+	"errors"
+	"strings"
+)
+
+// maxComponentCountries is how many countries autocomplete accepts in its components filter
+const maxComponentCountries = 5
+
+/*
+	ComponentFilter restricts a geocoding (or autocomplete, countries only) request to
+	the results matching every set component. Countries are alpha-2 or alpha-3 codes
+	or names, they are sent as alpha-2 codes
+	more references https://developers.google.com/maps/documentation/geocoding/requests-geocoding#component-filtering
+*/
+type ComponentFilter struct {
+	Countries          []string
+	PostalCode         string
+	AdministrativeArea string
+	Locality           string
+	Route              string
+}
+
+/*
+	Components sets the "components" param of filter, an unknown country or more
+	than 5 of them are refused instead of being silently ignored by google
+*/
+func Components(filter ComponentFilter) ParamOption {
+	return func(params map[string]string) error {
+
+		if len(filter.Countries) > maxComponentCountries {
+			return errors.New("components accept at most 5 countries")
+		}
+
+		var components []string
+		for _, country := range filter.Countries {
+			code, err := countryCode(country)
+			if err != nil {
+				return err
+			}
+			components = append(components, "country:"+strings.ToLower(code))
+		}
+
+		for _, component := range []struct{ name, value string }{
+			{"postal_code", filter.PostalCode},
+			{"administrative_area", filter.AdministrativeArea},
+			{"locality", filter.Locality},
+			{"route", filter.Route},
+		} {
+			if value := strings.TrimSpace(component.value); value != "" {
+				if strings.ContainsAny(value, "|:") {
+					return errors.New(component.name + " must not contain '|' or ':'")
+				}
+				components = append(components, component.name+":"+value)
+			}
+		}
+
+		if len(components) == 0 {
+			return errors.New("component filter must not be empty")
+		}
+
+		params["components"] = strings.Join(components, "|")
+		return nil
+	}
+}
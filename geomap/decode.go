@@ -0,0 +1,125 @@
+package geomap
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// decoding guards, generous for any real google response
+const (
+	defaultMaxResponseSize = 32 << 20
+	defaultMaxElements     = 10000
+)
+
+var (
+	// ErrResponseTooLarge is returned when a response body is over the client limit
+	ErrResponseTooLarge = errors.New("response body too large")
+
+	// ErrTooManyElements is returned when an array of a response is over the client limit
+	ErrTooManyElements = errors.New("too many elements in response")
+)
+
+/*
+	WithDecodeLimits bounds what the client accepts from google: maxBytes per response body
+	(32MB by default) and maxElements per array of a decoded response (10000 by default),
+	so a malformed or adversarial response can't exhaust a lambda or feed a huge
+	result list to code calling google once per result, see GuardStats for the refusals
+*/
+func WithDecodeLimits(maxBytes int64, maxElements int) ClientOption {
+	return func(c *Client) error {
+
+		if maxBytes <= 0 || maxElements <= 0 {
+			return errors.New("decode limits must be positive")
+		}
+
+		c.maxResponseSize = maxBytes
+		c.maxElements = maxElements
+		return nil
+	}
+}
+
+/*
+	decode unmarshals body into v and checks its arrays against the client limit,
+	any panic while decoding is turned into an error instead of taking the handler down
+*/
+func (c *Client) decode(endpoint Endpoint, body []byte, v interface{}) (err error) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("decoding %s response: panic: %v", endpoint, r)
+		}
+	}()
+
+	codec := c.jsonCodec()
+
+	if err := codec.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decoding %s response: %v", endpoint, err)
+	}
+
+	maxElements := c.elementLimit()
+
+	err = checkElements(reflect.ValueOf(v), maxElements)
+	if err == ErrTooManyElements {
+		c.tripGuard(GuardElements, endpoint, int64(maxElements))
+	}
+
+	return err
+}
+
+// checkElements fails when a slice reachable from v is longer than max
+func checkElements(v reflect.Value, max int) error {
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return checkElements(v.Elem(), max)
+		}
+
+	case reflect.Slice, reflect.Array:
+		//bytes are raw data, e.g. json.RawMessage, not elements
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return nil
+		}
+		if v.Len() > max {
+			return ErrTooManyElements
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := checkElements(v.Index(i), max); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.Len() > max {
+			return ErrTooManyElements
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := checkElements(iter.Value(), max); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" {
+				if err := checkElements(v.Field(i), max); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// elementLimit returns the maximum elements of an array of a response
+func (c *Client) elementLimit() int {
+
+	if c.maxElements <= 0 {
+		return defaultMaxElements
+	}
+
+	return c.maxElements
+}
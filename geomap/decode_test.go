@@ -0,0 +1,521 @@
+package geomap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// element limit of the fuzzed client, small so the huge array seeds stay small too
+const fuzzMaxElements = 50
+
+// decodeTarget is a response type fuzzed through decode
+type decodeTarget struct {
+	endpoint Endpoint
+	new      func() interface{}
+
+	// a body of the response with a field of the wrong type
+	wrongType string
+
+	// the array (or object) field filled past the element limit, "" when the response has none
+	arrayField string
+	element    string
+}
+
+// truncatedBodies are cut in the middle of a token, a value or an object
+var truncatedBodies = []string{
+	``,
+	`{`,
+	`{"status":"OK","results":[{"geometry":{"location":{"lat":1`,
+	`{"status":"O`,
+	`{"results":[{},`,
+	`nul`,
+	`[`,
+}
+
+// seeds returns the malformed bodies of t, every one must fail to decode
+func (t decodeTarget) seeds() []string {
+
+	seeds := append([]string{t.wrongType}, truncatedBodies...)
+
+	if t.arrayField != "" {
+		open, end := "[", "]"
+		element := t.element
+		if strings.HasPrefix(element, `"%d":`) {
+			open, end = "{", "}"
+		}
+
+		elements := make([]string, fuzzMaxElements+1)
+		for i := range elements {
+			elements[i] = strings.Replace(element, "%d", string(rune('a'+i%26))+strings.Repeat("x", i/26), 1)
+		}
+		seeds = append(seeds, `{"`+t.arrayField+`":`+open+strings.Join(elements, ",")+end+`}`)
+	}
+
+	return seeds
+}
+
+/*
+	fuzzDecode checks that the malformed seeds of t fail to decode, then that no body
+	makes decode panic or succeed on something that isn't a json document within the limits
+*/
+func fuzzDecode(f *testing.F, t decodeTarget) {
+
+	c, err := NewClient(WithDecodeLimits(1<<20, fuzzMaxElements))
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	for _, seed := range t.seeds() {
+		if err := c.decode(t.endpoint, []byte(seed), t.new()); err == nil {
+			f.Errorf("decoding %.60q into %T: no error", seed, t.new())
+		}
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(ft *testing.T, body []byte) {
+
+		v := t.new()
+		err := c.decode(t.endpoint, body, v)
+
+		if err != nil && strings.Contains(err.Error(), "panic:") {
+			ft.Fatalf("decode panicked: %v", err)
+		}
+		if err == nil && !json.Valid(body) {
+			ft.Fatalf("decoded invalid json %q", body)
+		}
+		if err == nil && checkElements(reflect.ValueOf(v), fuzzMaxElements) != nil {
+			ft.Fatalf("decoded %q past the element limit", body)
+		}
+	})
+}
+
+func FuzzDecodeGeocode(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointGeocode,
+		new:        func() interface{} { return &GoogleGeocodeResponse{} },
+		wrongType:  `{"status":"OK","results":[{"geometry":{"location":{"lat":"north","lng":2}}}]}`,
+		arrayField: "results",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeGeocodeV2(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointGeocode,
+		new:        func() interface{} { return &GeocodeResponseV2{} },
+		wrongType:  `{"status":"OK","results":[{"partial_match":"yes"}]}`,
+		arrayField: "results",
+		element:    `{"types":["street_address"]}`,
+	})
+}
+
+func FuzzDecodeFindPlace(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointFindPlace,
+		new:        func() interface{} { return &GooglePlaceSearchResponse{} },
+		wrongType:  `{"status":"OK","candidates":{"name":"x"}}`,
+		arrayField: "candidates",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeFindPlaceV2(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointFindPlace,
+		new:        func() interface{} { return &FindPlaceResponseV2{} },
+		wrongType:  `{"status":"OK","candidates":[{"rating":"4.5"}]}`,
+		arrayField: "candidates",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeNearbySearch(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointNearbySearch,
+		new:        func() interface{} { return &GoogleNearbySearchResponse{} },
+		wrongType:  `{"status":"OK","results":[{"types":"cafe"}]}`,
+		arrayField: "results",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeNearbySearchV2(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointNearbySearch,
+		new:        func() interface{} { return &NearbySearchResponseV2{} },
+		wrongType:  `{"status":"OK","results":[{"user_ratings_total":"many"}]}`,
+		arrayField: "results",
+		element:    `{"photos":[]}`,
+	})
+}
+
+func FuzzDecodeTextSearch(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointTextSearch,
+		new:        func() interface{} { return &TextSearchResponseV2{} },
+		wrongType:  `{"status":"OK","html_attributions":[1]}`,
+		arrayField: "html_attributions",
+		element:    `"%d"`,
+	})
+}
+
+func FuzzDecodePlaceDetail(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointPlaceDetails,
+		new:        func() interface{} { return &GooglePlaceDetailResponse{} },
+		wrongType:  `{"status":"OK","result":{"opening_hours":{"periods":[{"open":{"day":"monday"}}]}}}`,
+		arrayField: "html_attributions",
+		element:    `"%d"`,
+	})
+}
+
+func FuzzDecodePlaceDetailV2(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointPlaceDetails,
+		new:        func() interface{} { return &PlaceDetailResponseV2{} },
+		wrongType:  `{"status":"OK","result":"ChIJ"}`,
+		arrayField: "html_attributions",
+		element:    `"%d"`,
+	})
+}
+
+func FuzzDecodeAutocomplete(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointAutocomplete,
+		new:        func() interface{} { return &GoogleAutocompleteResponse{} },
+		wrongType:  `{"status":"OK","predictions":[{"description":7}]}`,
+		arrayField: "predictions",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeDirections(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointDirections,
+		new:        func() interface{} { return &GoogleDirectionsResponse{} },
+		wrongType:  `{"status":"OK","routes":[{"legs":[{"distance":{"value":"far"}}]}]}`,
+		arrayField: "routes",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeDistanceMatrix(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointDistanceMatrix,
+		new:        func() interface{} { return &GoogleDistanceMatrixResponse{} },
+		wrongType:  `{"status":"OK","rows":[{"elements":{"status":"OK"}}]}`,
+		arrayField: "origin_addresses",
+		element:    `"%d"`,
+	})
+}
+
+func FuzzDecodeTimezone(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:  EndpointTimezone,
+		new:       func() interface{} { return &GoogleTimezoneResponse{} },
+		wrongType: `{"status":"OK","dstOffset":"one hour"}`,
+	})
+}
+
+func FuzzDecodeAirQuality(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointAirQuality,
+		new:        func() interface{} { return &AirQualityResponse{} },
+		wrongType:  `{"indexes":[{"aqi":"good"}]}`,
+		arrayField: "indexes",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodePollen(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointPollen,
+		new:        func() interface{} { return &PollenResponse{} },
+		wrongType:  `{"dailyInfo":[{"date":"2024-05-01"}]}`,
+		arrayField: "dailyInfo",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeBuildingInsights(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:  EndpointBuildingInsights,
+		new:       func() interface{} { return &BuildingInsightsResponse{} },
+		wrongType: `{"name":"buildings/1","center":"-6.2,106.8"}`,
+	})
+}
+
+func FuzzDecodeDataLayers(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointDataLayers,
+		new:        func() interface{} { return &DataLayersResponse{} },
+		wrongType:  `{"hourlyShadeUrls":{"jan":"https://example.com"}}`,
+		arrayField: "hourlyShadeUrls",
+		element:    `"%d"`,
+	})
+}
+
+func FuzzDecodeSnapToRoads(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointSnapToRoads,
+		new:        func() interface{} { return &SnapToRoadsResponse{} },
+		wrongType:  `{"snappedPoints":[{"originalIndex":"first"}]}`,
+		arrayField: "snappedPoints",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeSpeedLimits(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointSpeedLimits,
+		new:        func() interface{} { return &SpeedLimitsResponse{} },
+		wrongType:  `{"speedLimits":[{"speedLimit":"fast"}]}`,
+		arrayField: "speedLimits",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeTileSession(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:  EndpointTileSession,
+		new:       func() interface{} { return &TileSession{} },
+		wrongType: `{"session":"abc","tileWidth":"256px"}`,
+	})
+}
+
+func FuzzDecodeTileViewport(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointTileViewport,
+		new:        func() interface{} { return &TileViewport{} },
+		wrongType:  `{"copyright":["Google"]}`,
+		arrayField: "maxZoomRects",
+		element:    `{}`,
+	})
+}
+
+func FuzzDecodeAerialVideo(f *testing.F) {
+	fuzzDecode(f, decodeTarget{
+		endpoint:   EndpointAerialLookup,
+		new:        func() interface{} { return &AerialVideo{} },
+		wrongType:  `{"state":"ACTIVE","uris":["https://example.com"]}`,
+		arrayField: "uris",
+		element:    `"%d":{}`,
+	})
+}
+
+// fixtureTransport answers every request with body, so the benchmarks measure the client and not a server
+type fixtureTransport []byte
+
+func (t fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json; charset=UTF-8"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(t)),
+		Request:    req,
+	}, nil
+}
+
+/*
+	scriptedTransport answers the nth request with the nth body (the last one past the end)
+	after the nth delay, recording the requests, for the tests counting what reached google
+*/
+type scriptedTransport struct {
+	bodies []string
+	delays []time.Duration
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (t *scriptedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	t.mu.Lock()
+	n := len(t.requests)
+	t.requests = append(t.requests, req)
+	t.mu.Unlock()
+
+	if n < len(t.delays) && t.delays[n] > 0 {
+		select {
+		case <-time.After(t.delays[n]):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	body := t.bodies[len(t.bodies)-1]
+	if n < len(t.bodies) {
+		body = t.bodies[n]
+	}
+
+	return fixtureTransport(body).RoundTrip(req)
+}
+
+// sent returns the requests received so far
+func (t *scriptedTransport) sent() []*http.Request {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]*http.Request(nil), t.requests...)
+}
+
+func fixtureClient(tb testing.TB, body string, opts ...ClientOption) *Client {
+	return transportClient(tb, fixtureTransport(body), opts...)
+}
+
+func transportClient(tb testing.TB, transport http.RoundTripper, opts ...ClientOption) *Client {
+
+	opts = append([]ClientOption{WithHTTPClient(&http.Client{Transport: transport})}, opts...)
+	c, err := NewClient(opts...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return c
+}
+
+const fixtureComponent = `{"long_name":"Jalan Jenderal Sudirman","short_name":"Jl. Jend. Sudirman","types":["route"]}`
+
+const fixtureGeometry = `{"location":{"lat":-6.2087634,"lng":106.845599},"location_type":"ROOFTOP",` +
+	`"viewport":{"northeast":{"lat":-6.2074144,"lng":106.8469479},"southwest":{"lat":-6.2101124,"lng":106.8442499}}}`
+
+const fixturePhoto = `{"height":3024,"width":4032,"html_attributions":["<a href=https://maps.google.com/maps/contrib/1>A</a>"],` +
+	`"photo_reference":"AZose0mSxZ6cC5uLkzlKcLRcvHcYZDGgP6Po1rQKQX0Y3zs6hLl0v0kmq4Lsn9WJ8YhGqdbzj5v9fWzUKn"}`
+
+// fixtureGeocode is a typical geocode response of a street address
+func fixtureGeocode() string {
+
+	components := strings.TrimSuffix(strings.Repeat(fixtureComponent+",", 7), ",")
+	result := `{"address_components":[` + components + `],"formatted_address":"Jl. Jend. Sudirman No.1, Jakarta 10220, Indonesia",` +
+		`"geometry":` + fixtureGeometry + `,"place_id":"ChIJN1t_tDeuEmsRUsoyG83frY4",` +
+		`"plus_code":{"compound_code":"QRRW+G6 Jakarta","global_code":"6P58QRRW+G6"},"types":["street_address"]}`
+
+	return `{"results":[` + result + `],"status":"OK"}`
+}
+
+// fixturePlace is a place of a nearby search, with photos and opening hours
+func fixturePlace(i int) string {
+
+	return fmt.Sprintf(`{"business_status":"OPERATIONAL","geometry":%s,"icon":"https://maps.gstatic.com/icons/cafe-71.png",`+
+		`"name":"Kopi Kenangan %d","opening_hours":{"open_now":true},"photos":[%s],"place_id":"ChIJ%020d",`+
+		`"plus_code":{"compound_code":"QRRW+G6 Jakarta","global_code":"6P58QRRW+G6"},"price_level":2,"rating":4.4,`+
+		`"reference":"ChIJ%020d","scope":"GOOGLE","types":["cafe","food","point_of_interest","establishment"],`+
+		`"user_ratings_total":%d,"vicinity":"Jl. Jend. Sudirman No.%d, Jakarta"}`, fixtureGeometry, i, fixturePhoto, i, i, 100+i, i)
+}
+
+// fixturePlaceDetail is a typical details response with reviews, photos and opening hours
+func fixturePlaceDetail() string {
+
+	var periods, reviews, photos []string
+	for day := 0; day < 7; day++ {
+		periods = append(periods, fmt.Sprintf(`{"open":{"day":%d,"time":"0800"},"close":{"day":%d,"time":"2200"}}`, day, day))
+	}
+	for i := 0; i < 5; i++ {
+		reviews = append(reviews, fmt.Sprintf(`{"author_name":"Reviewer %d","rating":%d,"relative_time_description":"a month ago",`+
+			`"text":"%s","time":1700000000}`, i, 1+i%5, strings.Repeat("Great coffee and friendly staff. ", 8)))
+	}
+	for i := 0; i < 10; i++ {
+		photos = append(photos, fixturePhoto)
+	}
+
+	result := strings.TrimSuffix(fixturePlace(1), "}") + `,"address_components":[` + fixtureComponent + `,` + fixtureComponent + `],` +
+		`"formatted_address":"Jl. Jend. Sudirman No.1, Jakarta 10220, Indonesia","formatted_phone_number":"(021) 1234567",` +
+		`"international_phone_number":"+62 21 1234567","website":"https://example.com","url":"https://maps.google.com/?cid=1",` +
+		`"utc_offset":420,"reviews":[` + strings.Join(reviews, ",") + `],` +
+		`"opening_hours":{"open_now":true,"periods":[` + strings.Join(periods, ",") + `],"weekday_text":["Monday: 8AM-10PM"]},` +
+		`"photos":[` + strings.Join(photos, ",") + `]}`
+
+	return `{"html_attributions":[],"result":` + result + `,"status":"OK"}`
+}
+
+// fixtureNearby is the worst case nearby search, a full page of 20 places
+func fixtureNearby() string {
+
+	places := make([]string, 20)
+	for i := range places {
+		places[i] = fixturePlace(i)
+	}
+
+	return `{"html_attributions":[],"next_page_token":"Aap_uEA7vb0DDYVJWEaX3O-AtYp77AaswQKSGtDaimt3gt7QCNpdjp1BkdM6acJ96xTec3tsV_ZJNL_JP-lqsVxydG3nh739RE_",` +
+		`"results":[` + strings.Join(places, ",") + `],"status":"OK"}`
+}
+
+// fixtureDistanceMatrix is the worst case distance matrix, 25 origins by 25 destinations
+func fixtureDistanceMatrix() string {
+
+	addresses := make([]string, 25)
+	for i := range addresses {
+		addresses[i] = fmt.Sprintf(`"Jl. Jend. Sudirman No.%d, Jakarta, Indonesia"`, i)
+	}
+
+	elements := make([]string, 25)
+	for i := range elements {
+		elements[i] = fmt.Sprintf(`{"distance":{"text":"%d km","value":%d},"duration":{"text":"%d mins","value":%d},`+
+			`"duration_in_traffic":{"text":"%d mins","value":%d},"status":"OK"}`, i, i*1000, i, i*60, i+5, i*60+300)
+	}
+	row := `{"elements":[` + strings.Join(elements, ",") + `]}`
+
+	return `{"destination_addresses":[` + strings.Join(addresses, ",") + `],"origin_addresses":[` + strings.Join(addresses, ",") + `],` +
+		`"rows":[` + strings.TrimSuffix(strings.Repeat(row+",", 25), ",") + `],"status":"OK"}`
+}
+
+func BenchmarkDecodeGeocodeTypical(b *testing.B) {
+
+	c := fixtureClient(b, fixtureGeocode())
+	params := map[string]string{"key": "k", "address": "Jl. Jend. Sudirman No.1, Jakarta"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.GetGeocode(context.Background(), params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodePlaceDetailTypical(b *testing.B) {
+
+	c := fixtureClient(b, fixturePlaceDetail())
+	params := map[string]string{"key": "k", "placeid": "ChIJN1t_tDeuEmsRUsoyG83frY4"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.PlaceDetail(context.Background(), params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeNearbyWorstCase(b *testing.B) {
+
+	c := fixtureClient(b, fixtureNearby())
+	params := map[string]string{"key": "k", "location": "-6.2087634,106.845599", "radius": "1500", "type": "cafe"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.PlaceNearby(context.Background(), params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeDistanceMatrixWorstCase(b *testing.B) {
+
+	c := fixtureClient(b, fixtureDistanceMatrix())
+	params := map[string]string{"key": "k", "origins": "-6.2,106.8", "destinations": "-6.3,106.9"}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.GetDistanceMatrix(context.Background(), params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
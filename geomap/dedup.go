@@ -0,0 +1,47 @@
+package geomap
+
+import "sync"
+
+// flightCall is one in-flight request shared by identical calls
+type flightCall struct {
+	wg     sync.WaitGroup
+	result *fetchResult
+	err    error
+}
+
+/*
+	flightGroup deduplicates concurrent identical requests (singleflight),
+	the callers arriving while a request is in flight wait for its result
+*/
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+func (g *flightGroup) do(key string, fn func() (*fetchResult, error)) (*fetchResult, error) {
+
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = map[string]*flightCall{}
+	}
+
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.result, call.err
+	}
+
+	call := &flightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.result, call.err = fn()
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return call.result, call.err
+}
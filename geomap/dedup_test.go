@@ -0,0 +1,69 @@
+package geomap
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeduplication(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		opts     []ClientOption
+		params   func(i int) map[string]string
+		expected int
+	}{
+		{
+			name:     "identical calls share a request",
+			opts:     []ClientOption{WithDeduplication(EndpointGeocode)},
+			params:   func(i int) map[string]string { return map[string]string{"address": "Jl. Sudirman 1", "key": "k"} },
+			expected: 1,
+		},
+		{
+			name:     "identical calls share the request of the key picked from the pool",
+			opts:     []ClientOption{WithDeduplication(EndpointGeocode), WithKeyPool(NewKeyPool("a", "b"))},
+			params:   func(i int) map[string]string { return map[string]string{"address": "Jl. Sudirman 1"} },
+			expected: 1,
+		},
+		{
+			name:     "other params are other requests",
+			opts:     []ClientOption{WithDeduplication(EndpointGeocode)},
+			params:   func(i int) map[string]string { return map[string]string{"address": string(rune('a' + i)), "key": "k"} },
+			expected: 10,
+		},
+		{
+			name:     "endpoints without deduplication send every call",
+			opts:     []ClientOption{WithDeduplication(EndpointNearbySearch)},
+			params:   func(i int) map[string]string { return map[string]string{"address": "Jl. Sudirman 1", "key": "k"} },
+			expected: 10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			transport := &scriptedTransport{bodies: []string{fixtureGeocode()}, delays: []time.Duration{100 * time.Millisecond}}
+			c := transportClient(t, transport, test.opts...)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					resp, err := c.GetGeocode(context.Background(), test.params(i))
+					if err != nil || resp.Status != "OK" {
+						t.Errorf("call %d: status %q, error %v", i, resp.Status, err)
+					}
+				}(i)
+			}
+			wg.Wait()
+
+			if sent := len(transport.sent()); sent != test.expected {
+				t.Errorf("%d requests sent, expected %d", sent, test.expected)
+			}
+		})
+	}
+}
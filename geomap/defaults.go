@@ -0,0 +1,72 @@
+package geomap
+
+import "fmt"
+
+/*
+	WithDefaultParams sends params (e.g. "language", "region", "components") with every
+	request to the maps.googleapis.com endpoints, the params of a call override them
+	and a param set to "" in a call removes its default.
+	The newer apis served from their own host (air quality, solar...) name their params
+	differently, set their defaults with WithEndpointDefaultParams
+*/
+func WithDefaultParams(params map[string]string) ClientOption {
+	return func(c *Client) error {
+
+		c.defaultParams = mergeParams(c.defaultParams, params)
+		return nil
+	}
+}
+
+// WithEndpointDefaultParams is WithDefaultParams for the requests to endpoint only, ahead of the client defaults
+func WithEndpointDefaultParams(endpoint Endpoint, params map[string]string) ClientOption {
+	return func(c *Client) error {
+
+		if _, ok := endpointPaths[endpoint]; !ok {
+			return fmt.Errorf("unknown endpoint %q", endpoint)
+		}
+
+		if c.endpointDefaultParams == nil {
+			c.endpointDefaultParams = map[Endpoint]map[string]string{}
+		}
+		c.endpointDefaultParams[endpoint] = mergeParams(c.endpointDefaultParams[endpoint], params)
+		return nil
+	}
+}
+
+// withDefaults returns params completed with the defaults of endpoint
+func (c *Client) withDefaults(endpoint Endpoint, params map[string]string) map[string]string {
+
+	endpointDefaults := c.endpointDefaultParams[endpoint]
+
+	var defaults map[string]string
+	if _, ownHost := endpointHosts[endpoint]; !ownHost {
+		defaults = c.defaultParams
+	}
+
+	if len(defaults) == 0 && len(endpointDefaults) == 0 {
+		return params
+	}
+
+	merged := mergeParams(mergeParams(defaults, endpointDefaults), params)
+	for key, val := range merged {
+		if val == "" {
+			delete(merged, key)
+		}
+	}
+
+	return merged
+}
+
+// mergeParams returns a copy of base with the params of override added or replaced
+func mergeParams(base, override map[string]string) map[string]string {
+
+	merged := make(map[string]string, len(base)+len(override))
+	for key, val := range base {
+		merged[key] = val
+	}
+	for key, val := range override {
+		merged[key] = val
+	}
+
+	return merged
+}
@@ -0,0 +1,202 @@
+package geomap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/*
+	DeferredRequest is a request google refused because the daily quota was exhausted,
+	kept to be replayed after the quota reset (NotBefore). Params are redacted of the
+	credentials, the replaying client signs it with its own key
+*/
+type DeferredRequest struct {
+	Endpoint     Endpoint          `json:"endpoint"`
+	Params       map[string]string `json:"params"`
+	Repeated     url.Values        `json:"repeated,omitempty"`
+	Payload      json.RawMessage   `json:"payload,omitempty"`
+	Status       string            `json:"status"`
+	ErrorMessage string            `json:"error_message,omitempty"`
+	DeferredAt   time.Time         `json:"deferred_at"`
+	NotBefore    time.Time         `json:"not_before"`
+}
+
+/*
+	DeferredQueue keeps the requests refused for quota until they are replayed, e.g. an SQS
+	queue (see the awsadapter package), so the work survives the container
+*/
+type DeferredQueue interface {
+	Defer(ctx context.Context, req DeferredRequest) error
+}
+
+/*
+	QuotaReset is when the daily quotas of google reset, Hour o'clock in Location,
+	midnight Pacific Time unless the quotas of the project say otherwise
+*/
+type QuotaReset struct {
+	Location *time.Location
+	Hour     int
+}
+
+// DefaultQuotaReset returns the reset of the google quotas, midnight in America/Los_Angeles
+func DefaultQuotaReset() QuotaReset {
+
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		//no zoneinfo in the container, off by an hour half of the year
+		location = time.FixedZone("PST", -8*60*60)
+	}
+
+	return QuotaReset{Location: location}
+}
+
+// Next returns the first reset after t
+func (r QuotaReset) Next(t time.Time) time.Time {
+
+	location := r.Location
+	if location == nil {
+		location = time.UTC
+	}
+
+	local := t.In(location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), r.Hour, 0, 0, 0, location)
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, r.Hour, 0, 0, 0, location)
+	}
+
+	return next
+}
+
+/*
+	WithDeferredQueue captures the requests refused because the daily quota is exhausted
+	(OVER_QUERY_LIMIT or RESOURCE_EXHAUSTED, not the per second rate limits) into queue,
+	to be replayed with ReplayDeferred once reset has passed. The calls still fail
+	with the refusal, the replays fill the cache for the next ones
+*/
+func WithDeferredQueue(queue DeferredQueue, reset QuotaReset) ClientOption {
+	return func(c *Client) error {
+
+		if queue == nil {
+			return errors.New("deferred queue must not be nil")
+		}
+
+		c.deferred = queue
+		c.quotaReset = reset
+		return nil
+	}
+}
+
+type deferredReplayKey struct{}
+
+/*
+	QuotaExhausted reports whether err is a refusal of google for an exhausted daily quota,
+	which only the quota reset lifts
+*/
+func QuotaExhausted(err error) bool {
+
+	apiErr, ok := err.(*APIError)
+	return ok && quotaExhausted(apiErr.Status, apiErr.ErrorMessage)
+}
+
+// quotaExhausted tells the daily quotas from the rate limits, which google refuses with the same statuses
+func quotaExhausted(status, message string) bool {
+
+	if status != "OVER_QUERY_LIMIT" && status != "RESOURCE_EXHAUSTED" {
+		return false
+	}
+
+	message = strings.ToLower(message)
+	for _, rate := range []string{"rate-limit", "rate limit", "per second", "per minute"} {
+		if strings.Contains(message, rate) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+	deferExhausted queues the request of a fetch which ended with err or result when google
+	refused it for quota, once per request sent however many calls shared it
+*/
+func (c *Client) deferExhausted(ctx context.Context, endpoint Endpoint, params map[string]string, repeated url.Values, payload []byte, result *fetchResult, err error) {
+
+	if c.deferred == nil || ctx.Value(deferredReplayKey{}) != nil {
+		return
+	}
+
+	var status, message string
+	if apiErr, ok := err.(*APIError); ok {
+		status, message = apiErr.Status, apiErr.ErrorMessage
+	} else if err == nil && bytes.Contains(result.body, []byte("OVER_QUERY_LIMIT")) {
+		summary := result.summarize()
+		status, message = summary.Status, summary.ErrorMessage
+	}
+	if !quotaExhausted(status, message) {
+		return
+	}
+
+	now := time.Now()
+	req := DeferredRequest{
+		Endpoint:     endpoint,
+		Params:       WithoutCredentials(params),
+		Repeated:     repeated,
+		Payload:      payload,
+		Status:       status,
+		ErrorMessage: message,
+		DeferredAt:   now,
+		NotBefore:    c.quotaReset.Next(now),
+	}
+
+	//the caller is already failing, the queue must not add to it
+	if err := c.deferred.Defer(ctx, req); err != nil {
+		log.Printf("geomap: deferring %s failed: %v", endpoint, err)
+	}
+}
+
+/*
+	ReplayDeferred sends a deferred request again, with params (e.g. the "key") added to its
+	own, and returns the response body. It fails with an APIError matching QuotaExhausted
+	while the quota is still exhausted, and is never deferred again
+*/
+func ReplayDeferred(ctx context.Context, req DeferredRequest, params map[string]string) (json.RawMessage, error) {
+	return DefaultClient().ReplayDeferred(ctx, req, params)
+}
+
+// ReplayDeferred is the package level ReplayDeferred using c
+func (c *Client) ReplayDeferred(ctx context.Context, req DeferredRequest, params map[string]string) (json.RawMessage, error) {
+
+	if _, ok := endpointPaths[req.Endpoint]; !ok {
+		return nil, errors.New("unknown endpoint " + string(req.Endpoint))
+	}
+
+	merged := copyParams(req.Params)
+	for key, val := range params {
+		merged[key] = val
+	}
+
+	var payload []byte
+	if len(req.Payload) > 0 {
+		payload = req.Payload
+	}
+
+	var body json.RawMessage
+	if err := c.call(context.WithValue(ctx, deferredReplayKey{}, true), req.Endpoint, merged, req.Repeated, payload, &body); err != nil {
+		return nil, err
+	}
+
+	if bytes.Contains(body, []byte("OVER_QUERY_LIMIT")) {
+		if summary := summarize(body); summary.Status == "OVER_QUERY_LIMIT" {
+			return nil, &APIError{HTTPStatus: http.StatusOK, Status: summary.Status, ErrorMessage: summary.ErrorMessage, Body: body}
+		}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,235 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// ErrAtmosphereFields is returned by a strict client for a details request billed as atmosphere data it didn't ask for
+var ErrAtmosphereFields = errors.New("details request billed as atmosphere data")
+
+// FieldSKU is the data SKU a place details field is billed under, on top of the details request
+type FieldSKU string
+
+const (
+	SKUBasic      FieldSKU = "basic"
+	SKUContact    FieldSKU = "contact"
+	SKUAtmosphere FieldSKU = "atmosphere"
+)
+
+/*
+	detailFieldSKUs are the place details fields and the SKU they are billed under,
+	more references https://developers.google.com/maps/documentation/places/web-service/details#fields
+*/
+var detailFieldSKUs = map[string]FieldSKU{
+	"address_component":              SKUBasic,
+	"address_components":             SKUBasic,
+	"adr_address":                    SKUBasic,
+	"business_status":                SKUBasic,
+	"formatted_address":              SKUBasic,
+	"geometry":                       SKUBasic,
+	"icon":                           SKUBasic,
+	"icon_background_color":          SKUBasic,
+	"icon_mask_base_uri":             SKUBasic,
+	"name":                           SKUBasic,
+	"permanently_closed":             SKUBasic,
+	"photo":                          SKUBasic,
+	"photos":                         SKUBasic,
+	"place_id":                       SKUBasic,
+	"plus_code":                      SKUBasic,
+	"type":                           SKUBasic,
+	"types":                          SKUBasic,
+	"url":                            SKUBasic,
+	"utc_offset":                     SKUBasic,
+	"vicinity":                       SKUBasic,
+	"wheelchair_accessible_entrance": SKUBasic,
+
+	"current_opening_hours":      SKUContact,
+	"formatted_phone_number":     SKUContact,
+	"international_phone_number": SKUContact,
+	"opening_hours":              SKUContact,
+	"secondary_opening_hours":    SKUContact,
+	"website":                    SKUContact,
+
+	"curbside_pickup":        SKUAtmosphere,
+	"delivery":               SKUAtmosphere,
+	"dine_in":                SKUAtmosphere,
+	"editorial_summary":      SKUAtmosphere,
+	"price_level":            SKUAtmosphere,
+	"rating":                 SKUAtmosphere,
+	"reservable":             SKUAtmosphere,
+	"reviews":                SKUAtmosphere,
+	"serves_beer":            SKUAtmosphere,
+	"serves_breakfast":       SKUAtmosphere,
+	"serves_brunch":          SKUAtmosphere,
+	"serves_dinner":          SKUAtmosphere,
+	"serves_lunch":           SKUAtmosphere,
+	"serves_vegetarian_food": SKUAtmosphere,
+	"serves_wine":            SKUAtmosphere,
+	"takeout":                SKUAtmosphere,
+	"user_ratings_total":     SKUAtmosphere,
+}
+
+/*
+	FieldsUsage groups requested details fields by SKU, Unknown has the names google
+	doesn't know. A request without fields returns, and is billed for, every SKU
+*/
+type FieldsUsage struct {
+	Basic      []string
+	Contact    []string
+	Atmosphere []string
+	Unknown    []string
+}
+
+// SKUs returns the data SKUs the fields are billed under
+func (u FieldsUsage) SKUs() []FieldSKU {
+
+	var skus []FieldSKU
+	if len(u.Basic) > 0 {
+		skus = append(skus, SKUBasic)
+	}
+	if len(u.Contact) > 0 {
+		skus = append(skus, SKUContact)
+	}
+	if len(u.Atmosphere) > 0 {
+		skus = append(skus, SKUAtmosphere)
+	}
+
+	return skus
+}
+
+/*
+	ClassifyFields groups details fields (as given to the "fields" param, e.g.
+	"geometry/location") by the SKU they are billed under
+*/
+func ClassifyFields(fields []string) FieldsUsage {
+
+	var usage FieldsUsage
+	for _, field := range fields {
+
+		//subfields are billed as their field, e.g. "geometry/location"
+		name := strings.SplitN(strings.TrimSpace(field), "/", 2)[0]
+
+		switch detailFieldSKUs[name] {
+		case SKUBasic:
+			usage.Basic = append(usage.Basic, field)
+		case SKUContact:
+			usage.Contact = append(usage.Contact, field)
+		case SKUAtmosphere:
+			usage.Atmosphere = append(usage.Atmosphere, field)
+		default:
+			usage.Unknown = append(usage.Unknown, field)
+		}
+	}
+
+	return usage
+}
+
+// UseCase is a common reason to request place details, see MinimalFieldsFor
+type UseCase string
+
+const (
+	// an address picked from autocomplete, e.g. a delivery address
+	UseCaseAddress UseCase = "address"
+
+	// a pin on a map with its name
+	UseCaseMapPin UseCase = "map_pin"
+
+	// how to reach the place: address, phone, website and opening hours
+	UseCaseContact UseCase = "contact"
+
+	// a result card with the rating and price level
+	UseCaseListing UseCase = "listing"
+
+	// the reviews of the place
+	UseCaseReviews UseCase = "reviews"
+)
+
+var useCaseFields = map[UseCase][]string{
+	UseCaseAddress: {"address_components", "formatted_address", "geometry/location", "place_id"},
+	UseCaseMapPin:  {"geometry/location", "name", "place_id"},
+	UseCaseContact: {"formatted_address", "formatted_phone_number", "name", "opening_hours", "place_id", "website"},
+	UseCaseListing: {"business_status", "geometry/location", "name", "photos", "place_id", "price_level", "rating", "user_ratings_total", "vicinity"},
+	UseCaseReviews: {"name", "place_id", "rating", "reviews", "user_ratings_total"},
+}
+
+// MinimalFieldsFor returns the fewest details fields serving useCase, nil for an unknown use case
+func MinimalFieldsFor(useCase UseCase) []string {
+
+	fields, ok := useCaseFields[useCase]
+	if !ok {
+		return nil
+	}
+
+	return append([]string(nil), fields...)
+}
+
+/*
+	WithFieldsCheck makes the client check the fields of its details requests:
+	a request without fields, with unknown fields or with atmosphere fields is logged,
+	or refused when strict (ErrAtmosphereFields for the atmosphere ones). Contexts
+	from WithAtmosphereFields may request atmosphere fields
+*/
+func WithFieldsCheck(strict bool) ClientOption {
+	return func(c *Client) error {
+		c.fieldsCheck = &fieldsCheck{strict: strict}
+		return nil
+	}
+}
+
+type atmosphereFieldsKey struct{}
+
+// WithAtmosphereFields returns a context whose details requests knowingly pay for atmosphere fields
+func WithAtmosphereFields(ctx context.Context) context.Context {
+	return context.WithValue(ctx, atmosphereFieldsKey{}, true)
+}
+
+// fieldsCheck logs each problem once so a hot code path doesn't flood the logs
+type fieldsCheck struct {
+	strict bool
+	warned sync.Map
+}
+
+// check inspects the fields of a details request
+func (f *fieldsCheck) check(ctx context.Context, params map[string]string) error {
+
+	allowAtmosphere, _ := ctx.Value(atmosphereFieldsKey{}).(bool)
+
+	var problem string
+	var err error
+
+	fields := params["fields"]
+	usage := ClassifyFields(strings.Split(fields, ","))
+
+	switch {
+	case fields == "":
+		if !allowAtmosphere {
+			problem = "details request without fields is billed for every SKU"
+			err = ErrAtmosphereFields
+		}
+	case len(usage.Unknown) > 0:
+		problem = fmt.Sprintf("unknown details fields %s", strings.Join(usage.Unknown, ","))
+		err = fmt.Errorf("unknown details fields %s", strings.Join(usage.Unknown, ","))
+	case len(usage.Atmosphere) > 0 && !allowAtmosphere:
+		problem = fmt.Sprintf("details fields %s are billed as atmosphere data", strings.Join(usage.Atmosphere, ","))
+		err = ErrAtmosphereFields
+	}
+
+	if problem == "" {
+		return nil
+	}
+
+	if f.strict {
+		return err
+	}
+
+	if _, warned := f.warned.LoadOrStore(fields, true); !warned {
+		log.Printf("geomap: %s", problem)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type GoogleDirectionsResponse struct {
+	GeocodedWaypoints []GeocodedWaypoint `json:"geocoded_waypoints"`
+	Routes            []Route            `json:"routes"`
+	Status            string             `json:"status"`
+	ErrorMessage      string             `json:"error_message,omitempty"`
+}
+
+type GeocodedWaypoint struct {
+	GeocoderStatus string   `json:"geocoder_status"`
+	PartialMatch   bool     `json:"partial_match,omitempty"`
+	PlaceID        string   `json:"place_id"`
+	Types          []string `json:"types"`
+}
+
+type Route struct {
+	Bounds           GoogleViewport `json:"bounds"`
+	Copyrights       string         `json:"copyrights"`
+	Fare             *TransitFare   `json:"fare,omitempty"`
+	Legs             []RouteLeg     `json:"legs"`
+	OverviewPolyline Polyline       `json:"overview_polyline"`
+	Summary          string         `json:"summary"`
+	Warnings         []string       `json:"warnings"`
+	WaypointOrder    []int          `json:"waypoint_order"`
+}
+
+type RouteLeg struct {
+	ArrivalTime       *GoogleTime     `json:"arrival_time,omitempty"`
+	DepartureTime     *GoogleTime     `json:"departure_time,omitempty"`
+	Distance          TextValue       `json:"distance"`
+	Duration          GoogleDuration  `json:"duration"`
+	DurationInTraffic *GoogleDuration `json:"duration_in_traffic,omitempty"`
+	EndAddress        string          `json:"end_address"`
+	EndLocation       GoogleLocation  `json:"end_location"`
+	StartAddress      string          `json:"start_address"`
+	StartLocation     GoogleLocation  `json:"start_location"`
+	Steps             []RouteStep     `json:"steps"`
+}
+
+type RouteStep struct {
+	Distance         TextValue       `json:"distance"`
+	Duration         GoogleDuration  `json:"duration"`
+	EndLocation      GoogleLocation  `json:"end_location"`
+	HTMLInstructions string          `json:"html_instructions"`
+	Maneuver         string          `json:"maneuver,omitempty"`
+	Polyline         Polyline        `json:"polyline"`
+	StartLocation    GoogleLocation  `json:"start_location"`
+	Steps            []RouteStep     `json:"steps,omitempty"`
+	TransitDetails   *TransitDetails `json:"transit_details,omitempty"`
+	TravelMode       string          `json:"travel_mode"`
+}
+
+type TextValue struct {
+	Text  string `json:"text"`
+	Value int    `json:"value"`
+}
+
+type Polyline struct {
+	Points string `json:"points"`
+}
+
+/*
+	Waypoint is a stop between origin and destination of a directions request.
+	Location can be an address, a "lat,lng" pair or "place_id:<id>",
+	a Via waypoint is passed through without stopping and won't split the route into legs
+*/
+type Waypoint struct {
+	Location string
+	Via      bool
+}
+
+// google accepts at most 25 waypoints on a directions request
+const maxWaypoints = 25
+
+/*
+	Waypoints sets the "waypoints" param, when optimize is true google is allowed
+	to reorder the stops and the chosen order is returned on Route.WaypointOrder
+	more references https://developers.google.com/maps/documentation/directions/intro#Waypoints
+*/
+func Waypoints(optimize bool, waypoints ...Waypoint) ParamOption {
+	return func(params map[string]string) error {
+
+		if len(waypoints) == 0 {
+			return errors.New("waypoints must not be empty")
+		}
+		if len(waypoints) > maxWaypoints {
+			return fmt.Errorf("too many waypoints: %d, maximum is %d", len(waypoints), maxWaypoints)
+		}
+
+		parts := make([]string, 0, len(waypoints)+1)
+		if optimize {
+			parts = append(parts, "optimize:true")
+		}
+
+		for i, waypoint := range waypoints {
+			location := strings.TrimSpace(waypoint.Location)
+			if location == "" {
+				return fmt.Errorf("waypoint %d has an empty location", i)
+			}
+			if strings.Contains(location, "|") {
+				return fmt.Errorf("waypoint %d location must not contain '|'", i)
+			}
+
+			if waypoint.Via {
+				location = "via:" + location
+			}
+			parts = append(parts, location)
+		}
+
+		params["waypoints"] = strings.Join(parts, "|")
+		return nil
+	}
+}
+
+/*
+	ApplyWaypointOrder reorders waypoints in place following order,
+	which is expected to be the Route.WaypointOrder returned for an optimized request.
+	waypoints can be a slice of any type as long as it is the same slice (same order)
+	used to build the request, so callers can reorder their own stop structs directly
+*/
+func ApplyWaypointOrder(order []int, waypoints interface{}) error {
+
+	slice := reflect.ValueOf(waypoints)
+	if slice.Kind() != reflect.Slice {
+		return fmt.Errorf("waypoints must be a slice, got %T", waypoints)
+	}
+
+	if slice.Len() != len(order) {
+		return fmt.Errorf("waypoint order has %d entries but there are %d waypoints", len(order), slice.Len())
+	}
+
+	//make sure the order is a permutation before touching the slice
+	seen := make([]bool, len(order))
+	for _, idx := range order {
+		if idx < 0 || idx >= len(order) || seen[idx] {
+			return fmt.Errorf("invalid waypoint order %v", order)
+		}
+		seen[idx] = true
+	}
+
+	reordered := reflect.MakeSlice(slice.Type(), slice.Len(), slice.Len())
+	for i, idx := range order {
+		reordered.Index(i).Set(slice.Index(idx))
+	}
+	reflect.Copy(slice, reordered)
+
+	return nil
+}
+
+/*
+	GetDirections will return GoogleDirectionsResponse on success
+	params need "origin", "destination" and "key", use ApplyParams with Waypoints to add stops
+	more references https://developers.google.com/maps/documentation/directions/intro
+*/
+func GetDirections(ctx context.Context, params map[string]string) (GoogleDirectionsResponse, error) {
+	return DefaultClient().GetDirections(ctx, params)
+}
+
+// GetDirections is the package level GetDirections using c
+func (c *Client) GetDirections(ctx context.Context, params map[string]string) (GoogleDirectionsResponse, error) {
+
+	var googleDirectionsResponse GoogleDirectionsResponse
+
+	err := c.getJSON(ctx, EndpointDirections, params, &googleDirectionsResponse)
+	return googleDirectionsResponse, err
+}
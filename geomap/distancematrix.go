@@ -0,0 +1,40 @@
+package geomap
+
+import "context"
+
+type GoogleDistanceMatrixResponse struct {
+	DestinationAddresses []string            `json:"destination_addresses"`
+	OriginAddresses      []string            `json:"origin_addresses"`
+	Rows                 []DistanceMatrixRow `json:"rows"`
+	Status               string              `json:"status"`
+	ErrorMessage         string              `json:"error_message,omitempty"`
+}
+
+type DistanceMatrixRow struct {
+	Elements []DistanceMatrixElement `json:"elements"`
+}
+
+type DistanceMatrixElement struct {
+	Distance          TextValue       `json:"distance"`
+	Duration          GoogleDuration  `json:"duration"`
+	DurationInTraffic *GoogleDuration `json:"duration_in_traffic,omitempty"`
+	Status            string          `json:"status"`
+}
+
+/*
+	GetDistanceMatrix will return GoogleDistanceMatrixResponse on success
+	params need "origins", "destinations" (both pipe separated) and "key"
+	more references https://developers.google.com/maps/documentation/distance-matrix/intro
+*/
+func GetDistanceMatrix(ctx context.Context, params map[string]string) (GoogleDistanceMatrixResponse, error) {
+	return DefaultClient().GetDistanceMatrix(ctx, params)
+}
+
+// GetDistanceMatrix is the package level GetDistanceMatrix using c
+func (c *Client) GetDistanceMatrix(ctx context.Context, params map[string]string) (GoogleDistanceMatrixResponse, error) {
+
+	var googleDistanceMatrixResponse GoogleDistanceMatrixResponse
+
+	err := c.getJSON(ctx, EndpointDistanceMatrix, params, &googleDistanceMatrixResponse)
+	return googleDistanceMatrixResponse, err
+}
@@ -0,0 +1,138 @@
+package geomap
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+	DryRunResult is returned as the error of every call of a dry run client,
+	with the request that would have been sent and its estimated cost
+*/
+type DryRunResult struct {
+	Endpoint Endpoint
+	URL      string
+	SKU      string
+	CostUSD  float64
+	Request  RenderedRequest
+}
+
+func (r *DryRunResult) Error() string {
+	return fmt.Sprintf("dry run: %s %s (%s, ~$%.4f)", r.Endpoint, r.URL, r.SKU, r.CostUSD)
+}
+
+/*
+	WithDryRun makes the client build and validate its requests without sending them,
+	every call fails with a *DryRunResult holding the request (credentials redacted,
+	see RenderedRequest.Curl) and the estimated cost, to review new code paths before deploying them.
+	The cache is bypassed so the cost is the one of an actual request
+*/
+func WithDryRun() ClientOption {
+	return func(c *Client) error {
+		c.dryRun = true
+		return nil
+	}
+}
+
+// sku is a billed google maps product and its price per call in USD
+type sku struct {
+	name string
+	cost float64
+}
+
+/*
+	endpointSKUs are the list prices per call, for estimates only:
+	volume discounts and the monthly credit aren't accounted for
+	more references https://developers.google.com/maps/billing/gmp-billing
+*/
+var endpointSKUs = map[Endpoint]sku{
+	EndpointGeocode:          {"Geocoding", 0.005},
+	EndpointFindPlace:        {"Find Place", 0.017},
+	EndpointNearbySearch:     {"Nearby Search", 0.032},
+	EndpointTextSearch:       {"Text Search", 0.032},
+	EndpointPlaceDetails:     {"Place Details", 0.017},
+	EndpointAutocomplete:     {"Autocomplete - Per Request", 0.00283},
+	EndpointPhoto:            {"Places - Photo", 0.007},
+	EndpointDirections:       {"Directions", 0.005},
+	EndpointDistanceMatrix:   {"Distance Matrix", 0.005},
+	EndpointTimezone:         {"Time Zone", 0.005},
+	EndpointAirQuality:       {"Air Quality", 0.005},
+	EndpointPollen:           {"Pollen", 0.01},
+	EndpointBuildingInsights: {"Solar API Building Insights", 0.01},
+	EndpointDataLayers:       {"Solar API Data Layers", 0.075},
+	EndpointRouteMatrix:      {"Compute Route Matrix Essentials - Per Element", 0.005},
+	EndpointSnapToRoads:      {"Roads - Route Traveled", 0.01},
+	EndpointSpeedLimits:      {"Roads - Speed Limits", 0.02},
+	EndpointTileSession:      {"Map Tiles - Session", 0},
+	EndpointTile:             {"Map Tiles - 2D Tiles", 0.0006},
+	EndpointTileViewport:     {"Map Tiles - Viewport Information", 0},
+	EndpointAerialRender:     {"Aerial View - Render Video", 0},
+	EndpointAerialLookup:     {"Aerial View - Lookup Video", 0.016},
+}
+
+// params every request to the endpoint needs besides the key
+var requiredParams = map[Endpoint][][]string{
+	EndpointGeocode:          {{"address", "components", "latlng", "place_id"}},
+	EndpointFindPlace:        {{"input"}, {"inputtype"}},
+	EndpointNearbySearch:     {{"location", "pagetoken"}},
+	EndpointTextSearch:       {{"query", "pagetoken"}},
+	EndpointPlaceDetails:     {{"place_id", "placeid"}},
+	EndpointAutocomplete:     {{"input"}},
+	EndpointPhoto:            {{"photoreference"}, {"maxwidth", "maxheight"}},
+	EndpointDirections:       {{"origin"}, {"destination"}},
+	EndpointDistanceMatrix:   {{"origins"}, {"destinations"}},
+	EndpointTimezone:         {{"location"}, {"timestamp"}},
+	EndpointPollen:           {{"location.latitude"}, {"location.longitude"}, {"days"}},
+	EndpointBuildingInsights: {{"location.latitude"}, {"location.longitude"}},
+	EndpointDataLayers:       {{"location.latitude"}, {"location.longitude"}, {"radiusMeters"}},
+	EndpointSnapToRoads:      {{"path"}},
+	EndpointTile:             {{"session"}},
+	EndpointTileViewport:     {{"session"}, {"zoom"}, {"north"}, {"south"}, {"east"}, {"west"}},
+	EndpointAerialLookup:     {{"address", "videoId"}},
+}
+
+// dryRun validates the request and returns its dry run result
+func dryRun(endpoint Endpoint, request RenderedRequest, params map[string]string) error {
+
+	for _, anyOf := range requiredParams[endpoint] {
+		found := false
+		for _, param := range anyOf {
+			if params[param] != "" {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s request needs %s", endpoint, strings.Join(anyOf, " or "))
+		}
+	}
+
+	s := endpointSKUs[endpoint]
+	result := &DryRunResult{
+		Endpoint: endpoint,
+		URL:      request.URL,
+		SKU:      s.name,
+		CostUSD:  s.cost,
+		Request:  request,
+	}
+
+	switch endpoint {
+	case EndpointDistanceMatrix:
+		//billed per element
+		elements := len(strings.Split(params["origins"], "|")) * len(strings.Split(params["destinations"], "|"))
+		if params["departure_time"] != "" || params["traffic_model"] != "" {
+			result.SKU, result.CostUSD = "Distance Matrix Advanced", 0.01
+		}
+		result.CostUSD *= float64(elements)
+
+	case EndpointDirections:
+		waypoints := 0
+		if params["waypoints"] != "" {
+			waypoints = len(strings.Split(strings.TrimPrefix(params["waypoints"], "optimize:true|"), "|"))
+		}
+		if waypoints > 10 || strings.HasPrefix(params["waypoints"], "optimize:true") || params["departure_time"] != "" || params["traffic_model"] != "" {
+			result.SKU, result.CostUSD = "Directions Advanced", 0.01
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,185 @@
+package geomap
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Endpoint identifies a google api called by the client
+type Endpoint string
+
+const (
+	EndpointGeocode          Endpoint = "geocode"
+	EndpointFindPlace        Endpoint = "findplace"
+	EndpointNearbySearch     Endpoint = "nearbysearch"
+	EndpointTextSearch       Endpoint = "textsearch"
+	EndpointPlaceDetails     Endpoint = "details"
+	EndpointAutocomplete     Endpoint = "autocomplete"
+	EndpointPhoto            Endpoint = "photo"
+	EndpointDirections       Endpoint = "directions"
+	EndpointDistanceMatrix   Endpoint = "distancematrix"
+	EndpointTimezone         Endpoint = "timezone"
+	EndpointAirQuality       Endpoint = "airquality"
+	EndpointPollen           Endpoint = "pollen"
+	EndpointBuildingInsights Endpoint = "buildinginsights"
+	EndpointDataLayers       Endpoint = "datalayers"
+	EndpointRouteMatrix      Endpoint = "routematrix"
+	EndpointSnapToRoads      Endpoint = "snaptoroads"
+	EndpointSpeedLimits      Endpoint = "speedlimits"
+	EndpointTileSession      Endpoint = "tilesession"
+	EndpointTile             Endpoint = "tile"
+	EndpointTileViewport     Endpoint = "tileviewport"
+	EndpointAerialRender     Endpoint = "aerialrender"
+	EndpointAerialLookup     Endpoint = "aeriallookup"
+)
+
+// DefaultBaseURL is where the endpoints are served unless the client is given another base
+const DefaultBaseURL = "https://maps.googleapis.com"
+
+var endpointPaths = map[Endpoint]string{
+	EndpointGeocode:          "/maps/api/geocode/json",
+	EndpointFindPlace:        "/maps/api/place/findplacefromtext/json",
+	EndpointNearbySearch:     "/maps/api/place/nearbysearch/json",
+	EndpointTextSearch:       "/maps/api/place/textsearch/json",
+	EndpointPlaceDetails:     "/maps/api/place/details/json",
+	EndpointAutocomplete:     "/maps/api/place/autocomplete/json",
+	EndpointPhoto:            "/maps/api/place/photo",
+	EndpointDirections:       "/maps/api/directions/json",
+	EndpointDistanceMatrix:   "/maps/api/distancematrix/json",
+	EndpointTimezone:         "/maps/api/timezone/json",
+	EndpointAirQuality:       "/v1/currentConditions:lookup",
+	EndpointPollen:           "/v1/forecast:lookup",
+	EndpointBuildingInsights: "/v1/buildingInsights:findClosest",
+	EndpointDataLayers:       "/v1/dataLayers:get",
+	EndpointRouteMatrix:      "/distanceMatrix/v2:computeRouteMatrix",
+	EndpointSnapToRoads:      "/v1/snapToRoads",
+	EndpointSpeedLimits:      "/v1/speedLimits",
+	EndpointTileSession:      "/v1/createSession",
+	EndpointTile:             "/v1/2dtiles",
+	EndpointTileViewport:     "/tile/v1/viewport",
+	EndpointAerialRender:     "/v1/videos:renderVideo",
+	EndpointAerialLookup:     "/v1/videos:lookupVideo",
+}
+
+// endpointHosts serve the endpoints of the newer apis, each from its own host instead of DefaultBaseURL
+var endpointHosts = map[Endpoint]string{
+	EndpointAirQuality:       "https://airquality.googleapis.com",
+	EndpointPollen:           "https://pollen.googleapis.com",
+	EndpointBuildingInsights: "https://solar.googleapis.com",
+	EndpointDataLayers:       "https://solar.googleapis.com",
+	EndpointRouteMatrix:      "https://routes.googleapis.com",
+	EndpointSnapToRoads:      "https://roads.googleapis.com",
+	EndpointSpeedLimits:      "https://roads.googleapis.com",
+	EndpointTileSession:      "https://tile.googleapis.com",
+	EndpointTile:             "https://tile.googleapis.com",
+	EndpointTileViewport:     "https://tile.googleapis.com",
+	EndpointAerialRender:     "https://aerialview.googleapis.com",
+	EndpointAerialLookup:     "https://aerialview.googleapis.com",
+}
+
+// Endpoints returns every endpoint the client knows
+func Endpoints() []Endpoint {
+
+	endpoints := make([]Endpoint, 0, len(endpointPaths))
+	for endpoint := range endpointPaths {
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints
+}
+
+/*
+	WithBaseURL serves every endpoint from base instead of DefaultBaseURL,
+	e.g. "https://maps.google.cn", a corporate egress proxy or a local mock,
+	the endpoint paths are kept. The newer apis served from their own host
+	(air quality, pollen, solar, routes...) are moved to base too, use WithEndpointURL to only move some
+*/
+func WithBaseURL(base string) ClientOption {
+	return func(c *Client) error {
+
+		normalized, err := normalizeBaseURL(base)
+		if err != nil {
+			return err
+		}
+
+		c.baseURL = normalized
+		return nil
+	}
+}
+
+// WithEndpointURL sends the requests to endpoint to the full rawURL, ahead of any base url
+func WithEndpointURL(endpoint Endpoint, rawURL string) ClientOption {
+	return func(c *Client) error {
+
+		if _, ok := endpointPaths[endpoint]; !ok {
+			return fmt.Errorf("unknown endpoint %q", endpoint)
+		}
+
+		normalized, err := normalizeBaseURL(rawURL)
+		if err != nil {
+			return err
+		}
+
+		if c.endpointURLs == nil {
+			c.endpointURLs = map[Endpoint]string{}
+		}
+		c.endpointURLs[endpoint] = normalized
+		return nil
+	}
+}
+
+// endpointURL returns where the requests to endpoint are sent
+func (c *Client) endpointURL(endpoint Endpoint) string {
+
+	if override, ok := c.endpointURLs[endpoint]; ok {
+		return override
+	}
+
+	base := c.baseURL
+	if base == "" {
+		base = endpointHosts[endpoint]
+	}
+	if base == "" {
+		base = DefaultBaseURL
+	}
+
+	return base + endpointPaths[endpoint]
+}
+
+type pathSuffixKey struct{}
+
+// withPathSuffix makes the request of ctx go to a sub path of the endpoint, e.g. the z/x/y of a tile
+func withPathSuffix(ctx context.Context, suffix string) context.Context {
+	return context.WithValue(ctx, pathSuffixKey{}, suffix)
+}
+
+// requestURL is the endpointURL of endpoint followed by the path suffix of ctx
+func (c *Client) requestURL(ctx context.Context, endpoint Endpoint) string {
+
+	suffix, _ := ctx.Value(pathSuffixKey{}).(string)
+	return c.endpointURL(endpoint) + suffix
+}
+
+// normalizeBaseURL checks rawURL is an absolute http(s) url without query and trims its trailing slashes
+func normalizeBaseURL(rawURL string) (string, error) {
+
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("url %q must be http or https", rawURL)
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("url %q has no host", rawURL)
+	}
+	if parsed.RawQuery != "" || parsed.Fragment != "" {
+		return "", fmt.Errorf("url %q must not have a query or fragment", rawURL)
+	}
+
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	return parsed.String(), nil
+}
@@ -0,0 +1,181 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// LatLngLiteral is the location of the json bodies and responses of the newer apis
+type LatLngLiteral struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Color is an RGB color, components from 0 to 1, used to display an index
+type Color struct {
+	Red   float64 `json:"red,omitempty"`
+	Green float64 `json:"green,omitempty"`
+	Blue  float64 `json:"blue,omitempty"`
+}
+
+// Date is a calendar date without time zone
+type Date struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+type AirQualityResponse struct {
+	DateTime              string                `json:"dateTime"`
+	RegionCode            string                `json:"regionCode"`
+	Indexes               []AirQualityIndex     `json:"indexes"`
+	Pollutants            []Pollutant           `json:"pollutants,omitempty"`
+	HealthRecommendations HealthRecommendations `json:"healthRecommendations,omitempty"`
+}
+
+// AirQualityIndex is the universal AQI or a local index of the region
+type AirQualityIndex struct {
+	Code              string `json:"code"`
+	DisplayName       string `json:"displayName"`
+	AQI               int    `json:"aqi"`
+	AQIDisplay        string `json:"aqiDisplay"`
+	Color             Color  `json:"color"`
+	Category          string `json:"category"`
+	DominantPollutant string `json:"dominantPollutant"`
+}
+
+type Pollutant struct {
+	Code           string                  `json:"code"`
+	DisplayName    string                  `json:"displayName"`
+	FullName       string                  `json:"fullName"`
+	Concentration  PollutantConcentration  `json:"concentration"`
+	AdditionalInfo PollutantAdditionalInfo `json:"additionalInfo"`
+}
+
+type PollutantConcentration struct {
+	Value float64 `json:"value"`
+	Units string  `json:"units"`
+}
+
+type PollutantAdditionalInfo struct {
+	Sources string `json:"sources"`
+	Effects string `json:"effects"`
+}
+
+type HealthRecommendations struct {
+	GeneralPopulation      string `json:"generalPopulation,omitempty"`
+	Elderly                string `json:"elderly,omitempty"`
+	LungDiseasePopulation  string `json:"lungDiseasePopulation,omitempty"`
+	HeartDiseasePopulation string `json:"heartDiseasePopulation,omitempty"`
+	Athletes               string `json:"athletes,omitempty"`
+	PregnantWomen          string `json:"pregnantWomen,omitempty"`
+	Children               string `json:"children,omitempty"`
+}
+
+type PollenResponse struct {
+	RegionCode    string      `json:"regionCode"`
+	DailyInfo     []PollenDay `json:"dailyInfo"`
+	NextPageToken string      `json:"nextPageToken,omitempty"`
+}
+
+// PollenDay is the pollen forecast of one day, by pollen type (grass, tree, weed) and by plant
+type PollenDay struct {
+	Date           Date             `json:"date"`
+	PollenTypeInfo []PollenTypeInfo `json:"pollenTypeInfo"`
+	PlantInfo      []PlantInfo      `json:"plantInfo"`
+}
+
+type PollenTypeInfo struct {
+	Code                  string           `json:"code"`
+	DisplayName           string           `json:"displayName"`
+	InSeason              bool             `json:"inSeason"`
+	IndexInfo             *PollenIndexInfo `json:"indexInfo,omitempty"`
+	HealthRecommendations []string         `json:"healthRecommendations,omitempty"`
+}
+
+type PlantInfo struct {
+	Code             string            `json:"code"`
+	DisplayName      string            `json:"displayName"`
+	InSeason         bool              `json:"inSeason"`
+	IndexInfo        *PollenIndexInfo  `json:"indexInfo,omitempty"`
+	PlantDescription *PlantDescription `json:"plantDescription,omitempty"`
+}
+
+// PollenIndexInfo is the universal pollen index (0 to 5) of a pollen type or plant
+type PollenIndexInfo struct {
+	Code             string `json:"code"`
+	DisplayName      string `json:"displayName"`
+	Value            int    `json:"value"`
+	Category         string `json:"category"`
+	IndexDescription string `json:"indexDescription"`
+	Color            Color  `json:"color"`
+}
+
+type PlantDescription struct {
+	Type           string `json:"type"`
+	Family         string `json:"family"`
+	Season         string `json:"season"`
+	SpecialColors  string `json:"specialColors,omitempty"`
+	SpecialShapes  string `json:"specialShapes,omitempty"`
+	CrossReaction  string `json:"crossReaction,omitempty"`
+	Picture        string `json:"picture,omitempty"`
+	PictureCloseup string `json:"pictureCloseup,omitempty"`
+}
+
+// the extra computations requested with the current air quality
+var airQualityComputations = []string{"LOCAL_AQI", "DOMINANT_POLLUTANT_CONCENTRATION", "POLLUTANT_ADDITIONAL_INFO", "HEALTH_RECOMMENDATIONS"}
+
+/*
+	AirQuality returns the current air quality at lat, lng: the universal and local
+	indexes, the pollutant concentrations and the health recommendations.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/air-quality/current-conditions
+*/
+func AirQuality(ctx context.Context, lat, lng float64) (AirQualityResponse, error) {
+	return DefaultClient().AirQuality(ctx, lat, lng)
+}
+
+// AirQuality is the package level AirQuality using c
+func (c *Client) AirQuality(ctx context.Context, lat, lng float64) (AirQualityResponse, error) {
+
+	var airQualityResponse AirQualityResponse
+
+	payload := map[string]interface{}{
+		"location":          LatLngLiteral{Latitude: lat, Longitude: lng},
+		"universalAqi":      true,
+		"extraComputations": airQualityComputations,
+	}
+
+	err := c.postJSON(ctx, EndpointAirQuality, map[string]string{}, payload, &airQualityResponse)
+	return airQualityResponse, err
+}
+
+/*
+	Pollen returns the pollen forecast at lat, lng for the next days (1 to 5),
+	with the index of every pollen type and plant.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/pollen/forecast
+*/
+func Pollen(ctx context.Context, lat, lng float64, days int) (PollenResponse, error) {
+	return DefaultClient().Pollen(ctx, lat, lng, days)
+}
+
+// Pollen is the package level Pollen using c
+func (c *Client) Pollen(ctx context.Context, lat, lng float64, days int) (PollenResponse, error) {
+
+	var pollenResponse PollenResponse
+
+	if days < 1 || days > 5 {
+		return pollenResponse, errors.New("pollen forecast days must be between 1 and 5")
+	}
+
+	params := map[string]string{
+		"location.latitude":  strconv.FormatFloat(lat, 'f', -1, 64),
+		"location.longitude": strconv.FormatFloat(lng, 'f', -1, 64),
+		"days":               strconv.Itoa(days),
+	}
+
+	err := c.getJSON(ctx, EndpointPollen, params, &pollenResponse)
+	return pollenResponse, err
+}
@@ -0,0 +1,177 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// distance matrix limits per request
+const (
+	maxMatrixOrigins      = 25
+	maxMatrixDestinations = 25
+	maxMatrixElements     = 100
+)
+
+const defaultETAMatrixConcurrency = 5
+
+/*
+	ETACell is one origin/destination pair of an ETA matrix,
+	Status is the element status or the status of the chunk request when it failed as a whole
+*/
+type ETACell struct {
+	DistanceMatrixElement
+	Err error `json:"-"`
+}
+
+type ETAMatrixResult struct {
+	Origins      []string    `json:"origins"`
+	Destinations []string    `json:"destinations"`
+	Cells        [][]ETACell `json:"cells"`
+}
+
+/*
+	ETAMatrix computes the distance matrix between every origin and destination.
+	The request is transparently split into chunks respecting the distance matrix limits
+	(25 origins, 25 destinations, 100 elements), the chunks are sent in parallel
+	(5 in flight by default, see WithETAMatrixConcurrency) and are paced by the rate
+	limiter, then reassembled into one matrix.
+	params are sent with every chunk and need at least the "key",
+	the first chunk error is returned alongside the matrix, failed cells carry it on Err
+*/
+func ETAMatrix(ctx context.Context, origins, destinations []string, params map[string]string) (ETAMatrixResult, error) {
+	return DefaultClient().ETAMatrix(ctx, origins, destinations, params)
+}
+
+// WithETAMatrixConcurrency bounds the chunk requests in flight of an ETAMatrix call
+func WithETAMatrixConcurrency(concurrency int) ClientOption {
+	return func(c *Client) error {
+
+		if concurrency <= 0 {
+			return errors.New("eta matrix concurrency must be positive")
+		}
+
+		c.etaMatrixConcurrency = concurrency
+		return nil
+	}
+}
+
+// ETAMatrix is the package level ETAMatrix using c
+func (c *Client) ETAMatrix(ctx context.Context, origins, destinations []string, params map[string]string) (ETAMatrixResult, error) {
+
+	result := ETAMatrixResult{Origins: origins, Destinations: destinations}
+
+	if len(origins) == 0 || len(destinations) == 0 {
+		return result, errors.New("origins and destinations must not be empty")
+	}
+
+	result.Cells = make([][]ETACell, len(origins))
+	for i := range result.Cells {
+		result.Cells[i] = make([]ETACell, len(destinations))
+	}
+
+	//destinations per chunk first, then fit as many origins as the element limit allows
+	destStep := len(destinations)
+	if destStep > maxMatrixDestinations {
+		destStep = maxMatrixDestinations
+	}
+	originStep := maxMatrixElements / destStep
+	if originStep > maxMatrixOrigins {
+		originStep = maxMatrixOrigins
+	}
+
+	concurrency := c.etaMatrixConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultETAMatrixConcurrency
+	}
+
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, concurrency)
+		errMu    sync.Mutex
+		firstErr error
+	)
+
+	for o := 0; o < len(origins); o += originStep {
+		for d := 0; d < len(destinations); d += destStep {
+
+			oEnd := minInt(o+originStep, len(origins))
+			dEnd := minInt(d+destStep, len(destinations))
+
+			wg.Add(1)
+			go func(o, oEnd, d, dEnd int) {
+				defer wg.Done()
+
+				var err error
+				select {
+				case sem <- struct{}{}:
+					err = c.fillETAChunk(ctx, result.Cells, origins, destinations, o, oEnd, d, dEnd, params)
+					<-sem
+				case <-ctx.Done():
+					err = ctx.Err()
+					for i := o; i < oEnd; i++ {
+						for j := d; j < dEnd; j++ {
+							result.Cells[i][j] = ETACell{DistanceMatrixElement: DistanceMatrixElement{Status: "REQUEST_FAILED"}, Err: err}
+						}
+					}
+				}
+				if err != nil {
+					errMu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					errMu.Unlock()
+				}
+			}(o, oEnd, d, dEnd)
+		}
+	}
+
+	wg.Wait()
+
+	return result, firstErr
+}
+
+// fillETAChunk requests one chunk of the matrix and copies the elements into cells
+func (c *Client) fillETAChunk(ctx context.Context, cells [][]ETACell, origins, destinations []string, o, oEnd, d, dEnd int, params map[string]string) error {
+
+	chunkParams := copyParams(params)
+	chunkParams["origins"] = strings.Join(origins[o:oEnd], "|")
+	chunkParams["destinations"] = strings.Join(destinations[d:dEnd], "|")
+
+	resp, err := c.GetDistanceMatrix(ctx, chunkParams)
+	if err == nil && resp.Status != "OK" {
+		err = errors.New("distance matrix status " + resp.Status)
+	}
+
+	for i := o; i < oEnd; i++ {
+		for j := d; j < dEnd; j++ {
+
+			if err != nil {
+				status := resp.Status
+				if status == "" {
+					status = "REQUEST_FAILED"
+				}
+				cells[i][j] = ETACell{DistanceMatrixElement: DistanceMatrixElement{Status: status}, Err: err}
+				continue
+			}
+
+			row, col := i-o, j-d
+			if row >= len(resp.Rows) || col >= len(resp.Rows[row].Elements) {
+				cells[i][j] = ETACell{DistanceMatrixElement: DistanceMatrixElement{Status: "MISSING_ELEMENT"}}
+				continue
+			}
+
+			cells[i][j] = ETACell{DistanceMatrixElement: resp.Rows[row].Elements[col]}
+		}
+	}
+
+	return err
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,123 @@
+package geomap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+/*
+	Experimental features
+
+	Google adds fields to its responses in some regions before they are generally
+	available and their shape may still change. They are only requested and parsed
+	when enabled with WithExperimental, a malformed experimental field is dropped
+	rather than failing the whole response
+*/
+
+// ExperimentalAddressDescriptors adds the landmarks and areas describing the location of a geocode
+const ExperimentalAddressDescriptors = "ADDRESS_DESCRIPTORS"
+
+/*
+	AddressDescriptor describes a location relative to nearby landmarks and containing areas,
+	e.g. "opposite the central station, within the old town"
+*/
+type AddressDescriptor struct {
+	Landmarks []Landmark `json:"landmarks,omitempty"`
+	Areas     []Area     `json:"areas,omitempty"`
+}
+
+type Landmark struct {
+	PlaceID                    string        `json:"place_id"`
+	DisplayName                LocalizedText `json:"display_name"`
+	Types                      []string      `json:"types,omitempty"`
+	SpatialRelationship        string        `json:"spatial_relationship,omitempty"`
+	StraightLineDistanceMeters float64       `json:"straight_line_distance_meters,omitempty"`
+	TravelDistanceMeters       float64       `json:"travel_distance_meters,omitempty"`
+}
+
+type Area struct {
+	PlaceID     string        `json:"place_id"`
+	DisplayName LocalizedText `json:"display_name"`
+	Containment string        `json:"containment,omitempty"`
+}
+
+type LocalizedText struct {
+	Text         string `json:"text"`
+	LanguageCode string `json:"language_code,omitempty"`
+}
+
+// UnmarshalJSON drops a descriptor google sends in an unexpected shape instead of failing the geocode
+func (d *AddressDescriptor) UnmarshalJSON(data []byte) error {
+
+	type plain AddressDescriptor
+
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		*d = AddressDescriptor{}
+		return nil
+	}
+
+	*d = AddressDescriptor(decoded)
+	return nil
+}
+
+// the experimental features and the extra_computations value requesting them
+var experimentalComputations = map[string]bool{
+	ExperimentalAddressDescriptors: true,
+}
+
+/*
+	WithExperimental enables experimental response fields, e.g. ExperimentalAddressDescriptors.
+	They are requested with the extra_computations param, only opt in once you handle them
+	changing or disappearing
+*/
+func WithExperimental(features ...string) ClientOption {
+	return func(c *Client) error {
+
+		for _, feature := range features {
+			if !experimentalComputations[feature] {
+				return fmt.Errorf("unknown experimental feature %q", feature)
+			}
+			if c.experimental == nil {
+				c.experimental = map[string]bool{}
+			}
+			c.experimental[feature] = true
+		}
+		return nil
+	}
+}
+
+// withExperimental adds the extra computations enabled on the client to params
+func (c *Client) withExperimental(params map[string]string) map[string]string {
+
+	if len(c.experimental) == 0 {
+		return params
+	}
+
+	computations := map[string]bool{}
+	for _, computation := range strings.Split(params["extra_computations"], ",") {
+		if computation != "" {
+			computations[computation] = true
+		}
+	}
+
+	extended := params["extra_computations"]
+	for feature := range c.experimental {
+		if computations[feature] {
+			continue
+		}
+		if extended != "" {
+			extended += ","
+		}
+		extended += feature
+	}
+
+	if extended == params["extra_computations"] {
+		return params
+	}
+
+	params = copyParams(params)
+	params["extra_computations"] = extended
+	return params
+}
@@ -0,0 +1,65 @@
+package geomap
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// mean earth radius in meters
+const earthRadius = 6371008.8
+
+// DistanceMeters returns the great circle (haversine) distance between a and b in meters
+func DistanceMeters(a, b GoogleLocation) float64 {
+
+	lat1, lat2 := toRadians(a.Lat), toRadians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := toRadians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Destination returns the location reached from origin after meters on the given bearing (degrees from north)
+func Destination(origin GoogleLocation, bearing, meters float64) GoogleLocation {
+
+	lat1, lng1 := toRadians(origin.Lat), toRadians(origin.Lng)
+	theta := toRadians(bearing)
+	delta := meters / earthRadius
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lng2 := lng1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+
+	return GoogleLocation{Lat: toDegrees(lat2), Lng: normalizeLng(toDegrees(lng2))}
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func toDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// formatLocation renders a location as the "lat,lng" string google expects
+func formatLocation(location GoogleLocation) string {
+	return location.String()
+}
+
+// parseLocation reads a "lat,lng" string, ok is false for anything else (an address, a place id)
+func parseLocation(s string) (GoogleLocation, bool) {
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return GoogleLocation{}, false
+	}
+
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lng, errLng := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if errLat != nil || errLng != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return GoogleLocation{}, false
+	}
+
+	return GoogleLocation{Lat: lat, Lng: lng}, true
+}
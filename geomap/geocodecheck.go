@@ -0,0 +1,51 @@
+package geomap
+
+import "errors"
+
+var (
+	// ErrZeroResults is returned when google found nothing for the request
+	ErrZeroResults = errors.New("zero results")
+
+	// ErrPartialMatch is returned by strict checks when the best result only partially matched the query
+	ErrPartialMatch = errors.New("partial match")
+)
+
+/*
+	Check returns nil when the geocode found at least one result.
+	ZERO_RESULTS is reported as ErrZeroResults so it can be told apart from real failures,
+	when rejectPartial is true a best result with partial_match also fails with ErrPartialMatch,
+	useful for strict address validation
+*/
+func (r GoogleGeocodeResponse) Check(rejectPartial bool) error {
+
+	partial := len(r.Results) > 0 && r.Results[0].PartialMatch
+	return checkGeocode(r.Status, len(r.Results), partial, rejectPartial)
+}
+
+// Check is GoogleGeocodeResponse.Check for the v2 model
+func (r GeocodeResponseV2) Check(rejectPartial bool) error {
+
+	partial := len(r.Results) > 0 && r.Results[0].PartialMatch
+	return checkGeocode(r.Status, len(r.Results), partial, rejectPartial)
+}
+
+func checkGeocode(status string, results int, partial, rejectPartial bool) error {
+
+	switch status {
+	case "OK":
+	case "ZERO_RESULTS":
+		return ErrZeroResults
+	default:
+		return errors.New("geocode status " + status)
+	}
+
+	if results == 0 {
+		return ErrZeroResults
+	}
+
+	if rejectPartial && partial {
+		return ErrPartialMatch
+	}
+
+	return nil
+}
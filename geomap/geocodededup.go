@@ -0,0 +1,99 @@
+package geomap
+
+import (
+	"errors"
+	"math"
+)
+
+/*
+	WithGeocodeDedup makes GetGeocode and GetGeocodeV2 merge the near duplicate results
+	of an address, see DedupGeocodeResults. Reverse geocodes are left alone, their results
+	are the nested areas around one point (street address, route, locality...) on purpose
+*/
+func WithGeocodeDedup(radiusMeters float64) ClientOption {
+	return func(c *Client) error {
+
+		if radiusMeters < 0 {
+			return errors.New("dedup radius must not be negative")
+		}
+
+		c.geocodeDedup = true
+		c.geocodeDedupRadius = radiusMeters
+		return nil
+	}
+}
+
+/*
+	DedupGeocodeResults merges the results with the same place_id or whose locations are
+	within radiusMeters of each other (0 only merges on place_id) into the first of them,
+	keeping google's order. The merged result has the types of all of them, the viewport
+	covering all of them, and is a partial match only when all of them are
+*/
+func DedupGeocodeResults(results []GeocodeResult, radiusMeters float64) []GeocodeResult {
+
+	merged := make([]GeocodeResult, 0, len(results))
+
+next:
+	for _, result := range results {
+
+		for i := range merged {
+			kept := &merged[i]
+
+			samePlace := result.PlaceID != "" && result.PlaceID == kept.PlaceID
+			if samePlace || (radiusMeters > 0 && DistanceMeters(kept.Geometry.Location, result.Geometry.Location) <= radiusMeters) {
+				mergeGeocodeResult(kept, result)
+				continue next
+			}
+		}
+
+		//the merged results must not share the types of the response
+		result.Types = append([]string(nil), result.Types...)
+		merged = append(merged, result)
+	}
+
+	return merged
+}
+
+// mergeGeocodeResult folds duplicate into kept
+func mergeGeocodeResult(kept *GeocodeResult, duplicate GeocodeResult) {
+
+	types := make(map[string]bool, len(kept.Types))
+	for _, keptType := range kept.Types {
+		types[keptType] = true
+	}
+	for _, duplicateType := range duplicate.Types {
+		if !types[duplicateType] {
+			types[duplicateType] = true
+			kept.Types = append(kept.Types, duplicateType)
+		}
+	}
+
+	kept.PartialMatch = kept.PartialMatch && duplicate.PartialMatch
+	kept.Geometry.Viewport = unionViewport(kept.Geometry.Viewport, duplicate.Geometry.Viewport)
+}
+
+// unionViewport returns the viewport covering a and b, a zero viewport covers nothing
+func unionViewport(a, b GoogleViewport) GoogleViewport {
+
+	if a == (GoogleViewport{}) {
+		return b
+	}
+	if b == (GoogleViewport{}) {
+		return a
+	}
+
+	return GoogleViewport{
+		Northeast: GoogleLocation{Lat: math.Max(a.Northeast.Lat, b.Northeast.Lat), Lng: math.Max(a.Northeast.Lng, b.Northeast.Lng)},
+		SouthWest: GoogleLocation{Lat: math.Min(a.SouthWest.Lat, b.SouthWest.Lat), Lng: math.Min(a.SouthWest.Lng, b.SouthWest.Lng)},
+	}
+}
+
+// dedupGeocode merges the results of a forward geocode when the client dedups them
+func (c *Client) dedupGeocode(params map[string]string, results []GeocodeResult) []GeocodeResult {
+
+	if !c.geocodeDedup || params["latlng"] != "" {
+		return results
+	}
+
+	return DedupGeocodeResults(results, c.geocodeDedupRadius)
+}
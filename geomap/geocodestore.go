@@ -0,0 +1,127 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+)
+
+/*
+	GeocodeStore persists geocode results, unlike a Cache which holds raw responses,
+	building a geocode database of the addresses seen (e.g. the DynamoDB store of the
+	store package). Keys are the normalized address with the params changing the results,
+	see WithGeocodeStore. Stores must expire the results, google allows keeping them for
+	30 days at most
+*/
+type GeocodeStore interface {
+	LoadGeocode(ctx context.Context, key GeocodeKey) (results []GeocodeResult, found bool, err error)
+	SaveGeocode(ctx context.Context, key GeocodeKey, results []GeocodeResult) error
+}
+
+/*
+	GeocodeKey identifies the results of a geocode by its normalized address and the params
+	changing them. Tenant is set by the clients of a ClientPool, the store keeps the results
+	of each tenant apart
+*/
+type GeocodeKey struct {
+	Tenant     string
+	Address    string
+	Components string
+	Region     string
+	Language   string
+	Bounds     string
+}
+
+// String returns the key as a single line, e.g. to hash it, without the tenant
+func (k GeocodeKey) String() string {
+	return strings.Join([]string{k.Address, k.Components, k.Region, k.Language, k.Bounds}, "|")
+}
+
+/*
+	WithGeocodeStore makes GetGeocode and GetGeocodeV2 read the results of an address
+	from store before asking google, and save what google found, so an address is geocoded
+	once until the store expires it. Only successful geocodes of an address are stored, reverse and
+	place_id geocodes are sent as usual. A failing store is logged and google is asked
+*/
+func WithGeocodeStore(store GeocodeStore) ClientOption {
+	return func(c *Client) error {
+
+		if store == nil {
+			return errors.New("geocode store must not be nil")
+		}
+
+		c.geocodeStore = store
+		return nil
+	}
+}
+
+// geocodeKey returns the store key of a geocode, false when it isn't the geocode of an address
+func (c *Client) geocodeKey(params map[string]string) (GeocodeKey, bool) {
+
+	params = c.withDefaults(EndpointGeocode, params)
+
+	address := params["address"]
+	if address == "" || params["latlng"] != "" || params["place_id"] != "" {
+		return GeocodeKey{}, false
+	}
+
+	query := url.Values{"region": {params["region"]}, "components": {params["components"]}}
+
+	return GeocodeKey{
+		Address:    addressNormalizer(addressRegion(query))(address),
+		Components: params["components"],
+		Region:     strings.ToLower(params["region"]),
+		Language:   params["language"],
+		Bounds:     params["bounds"],
+	}, true
+}
+
+/*
+	storedGeocode returns the stored results of the geocode of params, if any. The request
+	goes through the country policy as if it was sent to google, the caller transforms
+	the results as it would a cached response
+*/
+func (c *Client) storedGeocode(ctx context.Context, params map[string]string) ([]GeocodeResult, bool, error) {
+
+	if c.geocodeStore == nil {
+		return nil, false, nil
+	}
+
+	if c.countryPolicy != nil {
+		if err := c.countryPolicy.checkRequest(c.withDefaults(EndpointGeocode, params)); err != nil {
+			return nil, false, err
+		}
+	}
+
+	key, ok := c.geocodeKey(params)
+	if !ok {
+		return nil, false, nil
+	}
+
+	results, found, err := c.geocodeStore.LoadGeocode(ctx, key)
+	if err != nil {
+		log.Printf("geomap: geocode store load failed: %v", err)
+		return nil, false, nil
+	}
+
+	return results, found && len(results) > 0, nil
+}
+
+// storeGeocode saves the results google found for the geocode of params
+func (c *Client) storeGeocode(ctx context.Context, params map[string]string, status string, results []GeocodeResult) {
+
+	if c.geocodeStore == nil || status != "OK" || len(results) == 0 {
+		return
+	}
+
+	key, ok := c.geocodeKey(params)
+	if !ok {
+		return
+	}
+
+	if err := c.geocodeStore.SaveGeocode(ctx, key, results); err != nil {
+		log.Printf("geomap: geocode store save failed: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package geomap
+
+import (
+	"errors"
+	"strings"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// EncodeGeohash returns the geohash of location with precision characters (1 to 12)
+func EncodeGeohash(location GoogleLocation, precision int) string {
+
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > 12 {
+		precision = 12
+	}
+
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+
+	var (
+		hash  strings.Builder
+		bits  uint
+		index int
+		even  = true
+	)
+
+	for hash.Len() < precision {
+		if even {
+			mid := (lngRange[0] + lngRange[1]) / 2
+			if location.Lng >= mid {
+				index = index<<1 | 1
+				lngRange[0] = mid
+			} else {
+				index <<= 1
+				lngRange[1] = mid
+			}
+		} else {
+			mid := (latRange[0] + latRange[1]) / 2
+			if location.Lat >= mid {
+				index = index<<1 | 1
+				latRange[0] = mid
+			} else {
+				index <<= 1
+				latRange[1] = mid
+			}
+		}
+		even = !even
+
+		bits++
+		if bits == 5 {
+			hash.WriteByte(geohashAlphabet[index])
+			bits, index = 0, 0
+		}
+	}
+
+	return hash.String()
+}
+
+// DecodeGeohash returns the bounds of the geohash cell
+func DecodeGeohash(hash string) (GoogleViewport, error) {
+
+	if hash == "" {
+		return GoogleViewport{}, errors.New("empty geohash")
+	}
+
+	latRange := [2]float64{-90, 90}
+	lngRange := [2]float64{-180, 180}
+	even := true
+
+	for _, char := range strings.ToLower(hash) {
+		index := strings.IndexRune(geohashAlphabet, char)
+		if index < 0 {
+			return GoogleViewport{}, errors.New("invalid geohash " + hash)
+		}
+
+		for bit := 4; bit >= 0; bit-- {
+			set := index>>uint(bit)&1 == 1
+			if even {
+				mid := (lngRange[0] + lngRange[1]) / 2
+				if set {
+					lngRange[0] = mid
+				} else {
+					lngRange[1] = mid
+				}
+			} else {
+				mid := (latRange[0] + latRange[1]) / 2
+				if set {
+					latRange[0] = mid
+				} else {
+					latRange[1] = mid
+				}
+			}
+			even = !even
+		}
+	}
+
+	return GoogleViewport{
+		SouthWest: GoogleLocation{Lat: latRange[0], Lng: lngRange[0]},
+		Northeast: GoogleLocation{Lat: latRange[1], Lng: lngRange[1]},
+	}, nil
+}
+
+// geohashCenter returns the center of the geohash cell containing location
+func geohashCenter(location GoogleLocation, precision int) GoogleLocation {
+
+	cell, _ := DecodeGeohash(EncodeGeohash(location, precision))
+
+	return GoogleLocation{
+		Lat: (cell.SouthWest.Lat + cell.Northeast.Lat) / 2,
+		Lng: (cell.SouthWest.Lng + cell.Northeast.Lng) / 2,
+	}
+}
@@ -0,0 +1,41 @@
+package geomap
+
+// GeoJSON types used by the geometry helpers, coordinates are [lng, lat] as per RFC 7946
+
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type GeoJSONGeometry struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+// NewGeoJSONPolygon builds a polygon feature out of ring, closing the ring when needed
+func NewGeoJSONPolygon(ring []GoogleLocation, properties map[string]interface{}) GeoJSONFeature {
+
+	coordinates := make([][2]float64, 0, len(ring)+1)
+	for _, location := range ring {
+		coordinates = append(coordinates, [2]float64{location.Lng, location.Lat})
+	}
+	if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+		coordinates = append(coordinates, coordinates[0])
+	}
+
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+
+	return GeoJSONFeature{
+		Type:       "Feature",
+		Geometry:   GeoJSONGeometry{Type: "Polygon", Coordinates: [][][2]float64{coordinates}},
+		Properties: properties,
+	}
+}
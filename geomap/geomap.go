@@ -1,12 +1,6 @@
 package geomap
 
-import (
-	"context"
-	"encoding/json"
-	"errors"
-	"io/ioutil"
-	"net/http"
-)
+import "context"
 
 /*
 	Google Map API package
@@ -19,85 +13,97 @@ import (
 	Each of the api call will need the google map API key
 */
 
+// Deprecated: use PlaceDetailResponseV2, this model drops fields returned by google.
 type GooglePlaceDetailResponse struct {
-	HTMLAttributions []interface{} `json:"html_attributions"`
-	Result           struct {
-		AddressComponents        []AddressComponent  `json:"address_components"`
-		AdrAddress               string              `json:"adr_address"`
-		FormattedAddress         string              `json:"formatted_address"`
-		FormattedPhoneNumber     string              `json:"formatted_phone_number"`
-		Geometry                 GoogleGeometry      `json:"geometry"`
-		Icon                     string              `json:"icon"`
-		ID                       string              `json:"id"`
-		InternationalPhoneNumber string              `json:"international_phone_number"`
-		Name                     string              `json:"name"`
-		OpeningHours             OpeningHour         `json:"opening_hours"`
-		Photos                   []Photo             `json:"photos"`
-		PlaceID                  string              `json:"place_id"`
-		PlusCode                 GooglePlusCode      `json:"plus_code"`
-		PriceLevel               int                 `json:"price_level"`
-		Rating                   float64             `json:"rating"`
-		Reference                string              `json:"reference"`
-		Reviews                  []GooglePlaceReview `json:"reviews"`
-		Scope                    string              `json:"scope"`
-		Types                    []string            `json:"types"`
-		URL                      string              `json:"url"`
-		UserRatingsTotal         int                 `json:"user_ratings_total"`
-		UtcOffset                int                 `json:"utc_offset"`
-		Vicinity                 string              `json:"vicinity"`
-		Website                  string              `json:"website"`
-	} `json:"result"`
-	Status string `json:"status"`
-}
-
+	HTMLAttributions []interface{}     `json:"html_attributions"`
+	Result           PlaceDetailResult `json:"result"`
+	Status           string            `json:"status"`
+}
+
+// PlaceDetailResult is the place of a GooglePlaceDetailResponse
+type PlaceDetailResult struct {
+	AddressComponents        []AddressComponent  `json:"address_components"`
+	AdrAddress               string              `json:"adr_address"`
+	BusinessStatus           string              `json:"business_status,omitempty"`
+	FormattedAddress         string              `json:"formatted_address"`
+	FormattedPhoneNumber     string              `json:"formatted_phone_number"`
+	Geometry                 GoogleGeometry      `json:"geometry"`
+	Icon                     string              `json:"icon"`
+	ID                       string              `json:"id"`
+	InternationalPhoneNumber string              `json:"international_phone_number"`
+	Name                     string              `json:"name"`
+	OpeningHours             OpeningHour         `json:"opening_hours"`
+	PermanentlyClosed        bool                `json:"permanently_closed,omitempty"`
+	Photos                   []Photo             `json:"photos"`
+	PlaceID                  string              `json:"place_id"`
+	PlusCode                 GooglePlusCode      `json:"plus_code"`
+	PriceLevel               int                 `json:"price_level"`
+	Rating                   float64             `json:"rating"`
+	Reference                string              `json:"reference"`
+	Reviews                  []GooglePlaceReview `json:"reviews"`
+	Scope                    string              `json:"scope"`
+	Types                    []string            `json:"types"`
+	URL                      string              `json:"url"`
+	UserRatingsTotal         int                 `json:"user_ratings_total"`
+	UtcOffset                int                 `json:"utc_offset"`
+	Vicinity                 string              `json:"vicinity"`
+	Website                  string              `json:"website"`
+}
+
+// Deprecated: use GeocodeResponseV2, this model drops fields returned by google.
 type GoogleGeocodeResponse struct {
-	Results []struct {
-		AddressComponents []AddressComponent `json:"address_components"`
-		FormattedAddress  string             `json:"formatted_address"`
-		Geometry          GoogleGeometry     `json:"geometry"`
-		PlaceID           string             `json:"place_id"`
-		PlusCode          GooglePlusCode     `json:"plus_code"`
-		Types             []string           `json:"types"`
-	} `json:"results"`
-	Status string `json:"status"`
+	Results []GeocodeResult `json:"results"`
+	Status  string          `json:"status"`
 }
 
+// Deprecated: use FindPlaceResponseV2, this model drops fields returned by google.
 type GooglePlaceSearchResponse struct {
 	Candidates []Candidate `json:"candidates"`
 	Status     string      `json:"status"`
 }
 
+// Deprecated: use NearbySearchResponseV2, this model drops fields returned by google.
 type GoogleNearbySearchResponse struct {
-	HTMLAttributions []interface{} `json:"html_attributions"`
-	Results          []struct {
-		Geometry         GoogleGeometry `json:"geometry"`
-		Icon             string         `json:"icon"`
-		ID               string         `json:"id"`
-		Name             string         `json:"name"`
-		OpeningHours     OpeningHour    `json:"opening_hours"`
-		Photos           []Photo        `json:"photos"`
-		PlaceID          string         `json:"place_id"`
-		PlusCode         GooglePlusCode `json:"plus_code"`
-		PriceLevel       int            `json:"price_level,omitempty"`
-		Rating           float64        `json:"rating"`
-		Reference        string         `json:"reference"`
-		Scope            string         `json:"scope"`
-		Types            []string       `json:"types"`
-		UserRatingsTotal int            `json:"user_ratings_total"`
-		Vicinity         string         `json:"vicinity"`
-	} `json:"results"`
-	Status string `json:"status"`
+	HTMLAttributions []interface{}  `json:"html_attributions"`
+	NextPageToken    string         `json:"next_page_token,omitempty"`
+	Results          []NearbyResult `json:"results"`
+	Status           string         `json:"status"`
+}
+
+type NearbyResult struct {
+	BusinessStatus    string         `json:"business_status,omitempty"`
+	Geometry          GoogleGeometry `json:"geometry"`
+	Icon              string         `json:"icon"`
+	ID                string         `json:"id"`
+	Name              string         `json:"name"`
+	OpeningHours      OpeningHour    `json:"opening_hours"`
+	PermanentlyClosed bool           `json:"permanently_closed,omitempty"`
+	Photos            []Photo        `json:"photos"`
+	PlaceID           string         `json:"place_id"`
+	PlusCode          GooglePlusCode `json:"plus_code"`
+	PriceLevel        int            `json:"price_level,omitempty"`
+	Rating            float64        `json:"rating"`
+	Reference         string         `json:"reference"`
+	Scope             string         `json:"scope"`
+	Types             []string       `json:"types"`
+	UserRatingsTotal  int            `json:"user_ratings_total"`
+	Vicinity          string         `json:"vicinity"`
 }
 
 type OpeningHour struct {
-	OpenNow bool `json:"open_now"`
-	Periods []struct {
-		Open struct {
-			Day  int    `json:"day"`
-			Time string `json:"time"`
-		} `json:"open"`
-	} `json:"periods,omitempty"`
-	WeekdayText []string `json:"weekday_text,omitempty"`
+	OpenNow     bool            `json:"open_now"`
+	Periods     []OpeningPeriod `json:"periods,omitempty"`
+	WeekdayText []string        `json:"weekday_text,omitempty"`
+}
+
+type OpeningPeriod struct {
+	Open OpeningTime `json:"open"`
+}
+
+// OpeningTime is a day of the week (0 is sunday) and a "hhmm" time
+type OpeningTime struct {
+	Day  int    `json:"day"`
+	Time string `json:"time"`
 }
 
 type GooglePlaceReview struct {
@@ -111,6 +117,7 @@ type GooglePlaceReview struct {
 	Time                    int    `json:"time"`
 }
 
+// Deprecated: use Place, Rating is an int here while google returns decimals.
 type Candidate struct {
 	FormattedAddress string  `json:"formatted_address"`
 	Name             string  `json:"name"`
@@ -152,61 +159,40 @@ type GoogleViewport struct {
 	SouthWest GoogleLocation `json:"southwest"`
 }
 
-var (
-	client *http.Client
-)
-
-func init() {
-
-	client = &http.Client{}
-}
-
 /*
 	GetReverseGeoCode will return GoogleReverseGeocodeResponse on success
 	the example of usage is sending params that contains "address" and "key" (both of them are required)
 	more references https://developers.google.com/maps/documentation/geocoding/intro#Geocoding
 */
 func GetGeocode(ctx context.Context, params map[string]string) (GoogleGeocodeResponse, error) {
+	return DefaultClient().GetGeocode(ctx, params)
+}
 
-	var googleGeocodeResponse GoogleGeocodeResponse
-
-	//Generating url for geocode
-	reqURL := "https://maps.googleapis.com/maps/api/geocode/json"
-
-	req, err := http.NewRequest("GET", reqURL, nil)
-	if err != nil {
-		return googleGeocodeResponse, err
-	}
-
-	//Insert the query mapping into the request
-	q := req.URL.Query()
-	for key, val := range params {
-		q.Add(key, val)
-	}
-	req.URL.RawQuery = q.Encode()
+// GetGeocode is the package level GetGeocode using c
+func (c *Client) GetGeocode(ctx context.Context, params map[string]string) (GoogleGeocodeResponse, error) {
 
-	resp, err := client.Do(req)
+	results, found, err := c.storedGeocode(ctx, params)
 	if err != nil {
-		return googleGeocodeResponse, err
+		return GoogleGeocodeResponse{}, err
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return googleGeocodeResponse, errors.New("Status not OK")
+	if found {
+		stored := GoogleGeocodeResponse{Status: "OK", Results: results}
+		if err := c.transform(&stored); err != nil {
+			return GoogleGeocodeResponse{}, err
+		}
+		stored.Results = c.dedupGeocode(params, stored.Results)
+		return stored, nil
 	}
 
-	defer resp.Body.Close()
-	contents, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return googleGeocodeResponse, err
-	}
+	var googleGeocodeResponse GoogleGeocodeResponse
 
-	//Unmarshal the contents
-	err = json.Unmarshal(contents, &googleGeocodeResponse)
-	if err != nil {
-		return googleGeocodeResponse, err
+	err = c.getJSON(ctx, EndpointGeocode, params, &googleGeocodeResponse)
+	if err == nil {
+		c.storeGeocode(ctx, params, googleGeocodeResponse.Status, googleGeocodeResponse.Results)
+		googleGeocodeResponse.Results = c.dedupGeocode(params, googleGeocodeResponse.Results)
 	}
 
-	return googleGeocodeResponse, nil
+	return googleGeocodeResponse, err
 }
 
 /*
@@ -214,46 +200,16 @@ func GetGeocode(ctx context.Context, params map[string]string) (GoogleGeocodeRes
 	more references https://developers.google.com/places/web-service/search
 */
 func FindPlace(ctx context.Context, params map[string]string) (GooglePlaceSearchResponse, error) {
+	return DefaultClient().FindPlace(ctx, params)
+}
 
-	var googleFindPlaceResponse GooglePlaceSearchResponse
-
-	//Generating url for geocode
-	reqURL := "https://maps.googleapis.com/maps/api/place/findplacefromtext/json"
-
-	req, err := http.NewRequest("GET", reqURL, nil)
-	if err != nil {
-		return googleFindPlaceResponse, err
-	}
-
-	//Insert the query mapping into the request
-	q := req.URL.Query()
-	for key, val := range params {
-		q.Add(key, val)
-	}
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return googleFindPlaceResponse, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return googleFindPlaceResponse, errors.New("Status not OK")
-	}
-
-	defer resp.Body.Close()
-	contents, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return googleFindPlaceResponse, err
-	}
+// FindPlace is the package level FindPlace using c
+func (c *Client) FindPlace(ctx context.Context, params map[string]string) (GooglePlaceSearchResponse, error) {
 
-	//Unmarshal the contents
-	err = json.Unmarshal(contents, &googleFindPlaceResponse)
-	if err != nil {
-		return googleFindPlaceResponse, err
-	}
+	var googleFindPlaceResponse GooglePlaceSearchResponse
 
-	return googleFindPlaceResponse, nil
+	err := c.getJSON(ctx, EndpointFindPlace, params, &googleFindPlaceResponse)
+	return googleFindPlaceResponse, err
 }
 
 /*
@@ -261,46 +217,20 @@ func FindPlace(ctx context.Context, params map[string]string) (GooglePlaceSearch
 	more references https://developers.google.com/places/web-service/search
 */
 func PlaceNearby(ctx context.Context, params map[string]string) (GoogleNearbySearchResponse, error) {
+	return DefaultClient().PlaceNearby(ctx, params)
+}
 
-	var googleNearbySearchResponse GoogleNearbySearchResponse
-
-	//Generating url for geocode
-	reqURL := "https://maps.googleapis.com/maps/api/place/nearbysearch/json"
-
-	req, err := http.NewRequest("GET", reqURL, nil)
-	if err != nil {
-		return googleNearbySearchResponse, err
-	}
-
-	//Insert the query mapping into the request
-	q := req.URL.Query()
-	for key, val := range params {
-		q.Add(key, val)
-	}
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return googleNearbySearchResponse, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return googleNearbySearchResponse, errors.New("Status not OK")
-	}
+// PlaceNearby is the package level PlaceNearby using c
+func (c *Client) PlaceNearby(ctx context.Context, params map[string]string) (GoogleNearbySearchResponse, error) {
 
-	defer resp.Body.Close()
-	contents, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return googleNearbySearchResponse, err
-	}
+	var googleNearbySearchResponse GoogleNearbySearchResponse
 
-	//Unmarshal the contents
-	err = json.Unmarshal(contents, &googleNearbySearchResponse)
-	if err != nil {
-		return googleNearbySearchResponse, err
+	err := c.getJSON(ctx, EndpointNearbySearch, params, &googleNearbySearchResponse)
+	if err == nil && c.excludeClosedBusinesses() {
+		googleNearbySearchResponse.Results = openNearbyResults(googleNearbySearchResponse.Results)
 	}
 
-	return googleNearbySearchResponse, nil
+	return googleNearbySearchResponse, err
 }
 
 /*
@@ -308,44 +238,14 @@ func PlaceNearby(ctx context.Context, params map[string]string) (GoogleNearbySea
 	more references https://developers.google.com/places/web-service/details
 */
 func PlaceDetail(ctx context.Context, params map[string]string) (GooglePlaceDetailResponse, error) {
+	return DefaultClient().PlaceDetail(ctx, params)
+}
 
-	var googlePlaceDetailResponse GooglePlaceDetailResponse
-
-	//Generating url for geocode
-	reqURL := "https://maps.googleapis.com/maps/api/place/details/json"
-
-	req, err := http.NewRequest("GET", reqURL, nil)
-	if err != nil {
-		return googlePlaceDetailResponse, err
-	}
-
-	//Insert the query mapping into the request
-	q := req.URL.Query()
-	for key, val := range params {
-		q.Add(key, val)
-	}
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return googlePlaceDetailResponse, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return googlePlaceDetailResponse, errors.New("Status not OK")
-	}
+// PlaceDetail is the package level PlaceDetail using c
+func (c *Client) PlaceDetail(ctx context.Context, params map[string]string) (GooglePlaceDetailResponse, error) {
 
-	defer resp.Body.Close()
-	contents, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return googlePlaceDetailResponse, err
-	}
-
-	//Unmarshal the contents
-	err = json.Unmarshal(contents, &googlePlaceDetailResponse)
-	if err != nil {
-		return googlePlaceDetailResponse, err
-	}
+	var googlePlaceDetailResponse GooglePlaceDetailResponse
 
-	return googlePlaceDetailResponse, nil
+	err := c.getJSON(ctx, EndpointPlaceDetails, params, &googlePlaceDetailResponse)
+	return googlePlaceDetailResponse, err
 }
@@ -0,0 +1,188 @@
+/*
+	Package geomaptest provides a StubClient for unit tests of code calling google through geomap
+*/
+package geomaptest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gomapservice/geomap"
+)
+
+// stubHost is the host the stubbed endpoints are served from, nothing listens there
+const stubHost = "geomap.stub"
+
+// Call is a request received by a StubClient
+type Call struct {
+	Endpoint geomap.Endpoint
+	Params   map[string]string
+}
+
+// ResponseFunc computes the response to a request, see StubClient.OnFunc
+type ResponseFunc func(params map[string]string) (response interface{}, err error)
+
+type stub struct {
+	endpoint   geomap.Endpoint
+	match      map[string]string
+	httpStatus int
+	fn         ResponseFunc
+}
+
+/*
+	StubClient is a geomap.API answering from canned responses instead of google,
+	so every operation (and the client options, e.g. the cache or the country policy)
+	can be used in unit tests without any network. Responses are the google json,
+	given as []byte, string or any value marshaled to json, e.g. a typed response
+	or a fixture file content. Requests without a matching stub fail
+*/
+type StubClient struct {
+	*geomap.Client
+
+	mu    sync.Mutex
+	stubs []stub
+	calls []Call
+}
+
+var _ geomap.API = (*StubClient)(nil)
+
+// NewStubClient returns a StubClient, opts configure its client like NewClient
+func NewStubClient(opts ...geomap.ClientOption) (*StubClient, error) {
+
+	s := &StubClient{}
+
+	stubOpts := []geomap.ClientOption{geomap.WithHTTPClient(&http.Client{Transport: s})}
+	for _, endpoint := range geomap.Endpoints() {
+		stubOpts = append(stubOpts, geomap.WithEndpointURL(endpoint, "http://"+stubHost+"/"+string(endpoint)))
+	}
+
+	client, err := geomap.NewClient(append(stubOpts, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Client = client
+	return s, nil
+}
+
+/*
+	On answers the requests to endpoint whose params contain every param of match
+	(nil matches every request) with response. Stubs are tried in the order they were added
+*/
+func (s *StubClient) On(endpoint geomap.Endpoint, match map[string]string, response interface{}) *StubClient {
+	return s.add(stub{endpoint: endpoint, match: match, httpStatus: http.StatusOK, fn: func(map[string]string) (interface{}, error) {
+		return response, nil
+	}})
+}
+
+// OnStatus answers the matching requests with an http error status and body, e.g. to test *geomap.APIError handling
+func (s *StubClient) OnStatus(endpoint geomap.Endpoint, match map[string]string, httpStatus int, body interface{}) *StubClient {
+	return s.add(stub{endpoint: endpoint, match: match, httpStatus: httpStatus, fn: func(map[string]string) (interface{}, error) {
+		return body, nil
+	}})
+}
+
+// OnFunc answers every request to endpoint with fn, an error returned by fn fails the request like a network error
+func (s *StubClient) OnFunc(endpoint geomap.Endpoint, fn ResponseFunc) *StubClient {
+	return s.add(stub{endpoint: endpoint, httpStatus: http.StatusOK, fn: fn})
+}
+
+func (s *StubClient) add(st stub) *StubClient {
+
+	s.mu.Lock()
+	s.stubs = append(s.stubs, st)
+	s.mu.Unlock()
+
+	return s
+}
+
+// Calls returns the requests received so far, in order
+func (s *StubClient) Calls() []Call {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Call(nil), s.calls...)
+}
+
+// RoundTrip serves the requests of the client from the stubs
+func (s *StubClient) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	endpoint := geomap.Endpoint(strings.TrimPrefix(req.URL.Path, "/"))
+
+	params := map[string]string{}
+	for key, values := range req.URL.Query() {
+		params[key] = values[0]
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Endpoint: endpoint, Params: params})
+	var found *stub
+	for i := range s.stubs {
+		if s.stubs[i].matches(endpoint, params) {
+			found = &s.stubs[i]
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if found == nil {
+		return nil, fmt.Errorf("geomaptest: no stub for %s %v", endpoint, params)
+	}
+
+	response, err := found.fn(params)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := encode(response)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "application/json; charset=UTF-8"
+	if endpoint == geomap.EndpointPhoto && found.httpStatus == http.StatusOK {
+		contentType = http.DetectContentType(body)
+	}
+
+	return &http.Response{
+		StatusCode: found.httpStatus,
+		Status:     http.StatusText(found.httpStatus),
+		Header:     http.Header{"Content-Type": []string{contentType}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func (st stub) matches(endpoint geomap.Endpoint, params map[string]string) bool {
+
+	if st.endpoint != endpoint {
+		return false
+	}
+
+	for key, val := range st.match {
+		if params[key] != val {
+			return false
+		}
+	}
+
+	return true
+}
+
+// encode returns the body of a response, raw bytes and strings are sent as is
+func encode(response interface{}) ([]byte, error) {
+
+	switch r := response.(type) {
+	case []byte:
+		return r, nil
+	case string:
+		return []byte(r), nil
+	}
+
+	return json.Marshal(response)
+}
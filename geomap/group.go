@@ -0,0 +1,63 @@
+package geomap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+	Group runs several calls concurrently under one shared deadline,
+	the first failing call cancels the others and its error is returned by Wait.
+	Results are collected by the closures passed to Go, e.g. a handler can
+	geocode, search nearby and look up the timezone at once then Wait for all of them
+*/
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+/*
+	NewGroup returns a group whose calls share ctx,
+	a positive timeout adds a deadline shared by every call
+*/
+func NewGroup(ctx context.Context, timeout time.Duration) *Group {
+
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+// Go runs fn in its own goroutine with the group context
+func (g *Group) Go(fn func(ctx context.Context) error) {
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		if err := fn(g.ctx); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// Wait blocks until every call returned and returns the first error
+func (g *Group) Wait() error {
+
+	g.wg.Wait()
+	g.cancel()
+
+	return g.err
+}
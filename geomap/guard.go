@@ -0,0 +1,118 @@
+package geomap
+
+import (
+	"errors"
+	"io"
+	"log"
+	"sync"
+)
+
+// defaultMaxPhotoSize bounds the photos, well below the other responses as lambdas hold them whole
+const defaultMaxPhotoSize = 10 << 20
+
+// the guards protecting the client from oversized responses, keys of GuardStats
+const (
+	GuardResponseSize = "response_size"
+	GuardElements     = "elements"
+	GuardPhotoSize    = "photo_size"
+)
+
+/*
+	WithMaxPhotoSize bounds the photos fetched by PlacePhoto to maxBytes (10MB by default),
+	a bigger photo fails with ErrResponseTooLarge instead of exhausting the lambda memory
+*/
+func WithMaxPhotoSize(maxBytes int64) ClientOption {
+	return func(c *Client) error {
+
+		if maxBytes <= 0 {
+			return errors.New("max photo size must be positive")
+		}
+
+		c.maxPhotoSize = maxBytes
+		return nil
+	}
+}
+
+// guardCounts counts the times each guard refused a response
+type guardCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+/*
+	GuardStats returns how many responses each guard of the default client refused
+	(GuardResponseSize, GuardElements, GuardPhotoSize), a growing count means the
+	limits are too tight for the traffic or google is answering unexpectedly
+*/
+func GuardStats() map[string]int64 {
+	return DefaultClient().GuardStats()
+}
+
+// GuardStats is the package level GuardStats using c
+func (c *Client) GuardStats() map[string]int64 {
+
+	c.guards.mu.Lock()
+	defer c.guards.mu.Unlock()
+
+	stats := make(map[string]int64, len(c.guards.counts))
+	for guard, count := range c.guards.counts {
+		stats[guard] = count
+	}
+
+	return stats
+}
+
+// tripGuard counts and logs a response refused by guard
+func (c *Client) tripGuard(guard string, endpoint Endpoint, limit int64) {
+
+	c.guards.mu.Lock()
+	if c.guards.counts == nil {
+		c.guards.counts = map[string]int64{}
+	}
+	c.guards.counts[guard]++
+	c.guards.mu.Unlock()
+
+	log.Printf("geomap: %s response refused by the %s guard (limit %d)", endpoint, guard, limit)
+}
+
+// responseLimit returns the maximum body size of a response of endpoint
+func (c *Client) responseLimit(endpoint Endpoint) (int64, string) {
+
+	if endpoint == EndpointPhoto {
+		if c.maxPhotoSize > 0 {
+			return c.maxPhotoSize, GuardPhotoSize
+		}
+		return defaultMaxPhotoSize, GuardPhotoSize
+	}
+
+	if c.maxResponseSize > 0 {
+		return c.maxResponseSize, GuardResponseSize
+	}
+	return defaultMaxResponseSize, GuardResponseSize
+}
+
+// limitedReader fails with ErrResponseTooLarge once more than remaining bytes were read
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+
+	if l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+
+	//one byte over the limit tells a body of exactly the limit from a bigger one
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrResponseTooLarge
+	}
+
+	return n, err
+}
@@ -0,0 +1,115 @@
+package geomap
+
+import (
+	"fmt"
+	"sort"
+)
+
+// cells of about 1.2km by 0.6km, a neighborhood
+const defaultHeatmapPrecision = 6
+
+/*
+	HeatmapOptions configures DensityGrid, Precision is the geohash length of the cells
+	(6 by default, each character more divides a cell by 32) and Weight what a place
+	adds to its cell (1 by default), e.g. its number of ratings
+*/
+type HeatmapOptions struct {
+	Precision int
+	Weight    func(place Place) float64
+}
+
+/*
+	HeatmapCell is a geohash cell holding places, Intensity is its weight relative
+	to the heaviest cell of the grid, from 0 to 1
+*/
+type HeatmapCell struct {
+	Geohash   string         `json:"geohash"`
+	Bounds    GoogleViewport `json:"bounds"`
+	Count     int            `json:"count"`
+	Weight    float64        `json:"weight"`
+	Intensity float64        `json:"intensity"`
+}
+
+/*
+	DensityGrid buckets places into geohash cells and returns the cells holding any,
+	ordered by geohash. Places without a location are left out
+*/
+func DensityGrid(places []Place, opts HeatmapOptions) ([]HeatmapCell, error) {
+
+	precision := opts.Precision
+	if precision == 0 {
+		precision = defaultHeatmapPrecision
+	}
+	if precision < 1 || precision > 12 {
+		return nil, fmt.Errorf("heatmap precision %d must be between 1 and 12", precision)
+	}
+
+	cells := map[string]*HeatmapCell{}
+	for _, place := range places {
+
+		location := place.Geometry.Location
+		if location.Lat == 0 && location.Lng == 0 {
+			continue
+		}
+
+		weight := 1.0
+		if opts.Weight != nil {
+			weight = opts.Weight(place)
+		}
+
+		hash := EncodeGeohash(location, precision)
+		cell, ok := cells[hash]
+		if !ok {
+			bounds, err := DecodeGeohash(hash)
+			if err != nil {
+				return nil, err
+			}
+			cell = &HeatmapCell{Geohash: hash, Bounds: bounds}
+			cells[hash] = cell
+		}
+		cell.Count++
+		cell.Weight += weight
+	}
+
+	grid := make([]HeatmapCell, 0, len(cells))
+	maxWeight := 0.0
+	for _, cell := range cells {
+		grid = append(grid, *cell)
+		if cell.Weight > maxWeight {
+			maxWeight = cell.Weight
+		}
+	}
+
+	if maxWeight > 0 {
+		for i := range grid {
+			grid[i].Intensity = grid[i].Weight / maxWeight
+		}
+	}
+
+	sort.Slice(grid, func(i, j int) bool { return grid[i].Geohash < grid[j].Geohash })
+
+	return grid, nil
+}
+
+/*
+	HeatmapGeoJSON returns a polygon feature per cell with its geohash, count, weight
+	and intensity as properties, for the heatmap layers of dashboards
+*/
+func HeatmapGeoJSON(cells []HeatmapCell) GeoJSONFeatureCollection {
+
+	collection := GeoJSONFeatureCollection{Type: "FeatureCollection", Features: make([]GeoJSONFeature, 0, len(cells))}
+	for _, cell := range cells {
+
+		ne, sw := cell.Bounds.Northeast, cell.Bounds.SouthWest
+		ring := []GoogleLocation{sw, {Lat: sw.Lat, Lng: ne.Lng}, ne, {Lat: ne.Lat, Lng: sw.Lng}}
+
+		collection.Features = append(collection.Features, NewGeoJSONPolygon(ring, map[string]interface{}{
+			"geohash":   cell.Geohash,
+			"count":     cell.Count,
+			"weight":    cell.Weight,
+			"intensity": cell.Intensity,
+		}))
+	}
+
+	return collection
+}
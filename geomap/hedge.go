@@ -0,0 +1,262 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// latencies kept by endpoint to compute the hedging delay
+	defaultHedgeWindow = 100
+
+	// latencies needed before an endpoint is hedged
+	minHedgeSamples = 20
+)
+
+/*
+	Hedging sends a second identical request when a call hasn't answered after the
+	Percentile latency of its endpoint (e.g. 0.95) and takes whichever answers first,
+	canceling the other. The delay stays between MinDelay and MaxDelay (unbounded when 0)
+	and an endpoint is hedged once it answered 20 requests. At most MaxRatio of the
+	requests are hedged (e.g. 0.05 for 5% more requests), and a hedge the client budget
+	refuses isn't sent. POST endpoints aren't idempotent and are never hedged
+*/
+type Hedging struct {
+	Percentile float64
+	MinDelay   time.Duration
+	MaxDelay   time.Duration
+	MaxRatio   float64
+	Window     int
+
+	mu        sync.Mutex
+	latencies map[Endpoint][]time.Duration
+	next      map[Endpoint]int
+	stats     HedgeStats
+}
+
+// HedgeStats counts the requests of a Hedging, Won is how many hedges answered first
+type HedgeStats struct {
+	Requests int64 `json:"requests"`
+	Hedged   int64 `json:"hedged"`
+	Won      int64 `json:"won"`
+}
+
+// NewHedging returns a Hedging at percentile (0 to 1) of the latencies hedging up to maxRatio of the requests
+func NewHedging(percentile, maxRatio float64) (*Hedging, error) {
+
+	if percentile <= 0 || percentile >= 1 {
+		return nil, errors.New("hedging percentile must be between 0 and 1")
+	}
+	if maxRatio <= 0 || maxRatio > 1 {
+		return nil, errors.New("hedging ratio must be between 0 and 1")
+	}
+
+	return &Hedging{Percentile: percentile, MaxRatio: maxRatio}, nil
+}
+
+// WithHedging makes the client hedge its slow GET requests with h, a Hedging can be shared by clients
+func WithHedging(h *Hedging) ClientOption {
+	return func(c *Client) error {
+
+		if h == nil {
+			return errors.New("hedging must not be nil")
+		}
+
+		c.hedging = h
+		return nil
+	}
+}
+
+// Stats returns the requests and hedges sent so far
+func (h *Hedging) Stats() HedgeStats {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.stats
+}
+
+// delay returns how long a request to endpoint waits before it is hedged, false while it can't be
+func (h *Hedging) delay(endpoint Endpoint) (time.Duration, bool) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.stats.Requests++
+
+	latencies := h.latencies[endpoint]
+	if len(latencies) < minHedgeSamples {
+		return 0, false
+	}
+
+	delay := percentile(latencies, h.Percentile)
+	if delay < h.MinDelay {
+		delay = h.MinDelay
+	}
+	if h.MaxDelay > 0 && delay > h.MaxDelay {
+		delay = h.MaxDelay
+	}
+
+	return delay, true
+}
+
+// allow counts a hedge unless it would go over MaxRatio of the requests
+func (h *Hedging) allow() bool {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if float64(h.stats.Hedged+1) > h.MaxRatio*float64(h.stats.Requests) {
+		return false
+	}
+
+	h.stats.Hedged++
+	return true
+}
+
+// observe records the latency of the response taken for a request to endpoint
+func (h *Hedging) observe(endpoint Endpoint, latency time.Duration, hedgeWon bool) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if hedgeWon {
+		h.stats.Won++
+	}
+
+	if h.latencies == nil {
+		h.latencies = map[Endpoint][]time.Duration{}
+		h.next = map[Endpoint]int{}
+	}
+
+	window := h.Window
+	if window <= 0 {
+		window = defaultHedgeWindow
+	}
+
+	//a ring of the last window latencies
+	if latencies := h.latencies[endpoint]; len(latencies) < window {
+		h.latencies[endpoint] = append(latencies, latency)
+	} else {
+		latencies[h.next[endpoint]%len(latencies)] = latency
+	}
+	h.next[endpoint]++
+}
+
+// hedgeResult is the outcome of one of the requests of a hedged call
+type hedgeResult struct {
+	resp    *http.Response
+	err     error
+	attempt int
+}
+
+// cancelBody cancels the context of the request which won once its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+/*
+	send is do hedged when the client hedges and req is a GET,
+	the first request answering without an error wins and the other is canceled
+*/
+func (c *Client) send(ctx context.Context, endpoint Endpoint, req *http.Request) (*http.Response, error) {
+
+	h := c.hedging
+	if h == nil || req.Method != http.MethodGet {
+		return c.do(ctx, req)
+	}
+
+	delay, ok := h.delay(endpoint)
+	started := time.Now()
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	launch := func() {
+		attempt := len(cancels)
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+
+		go func() {
+			//the hedge is a request like any other for the rate limit
+			if l := c.rateLimiter(); attempt > 0 && l != nil {
+				if err := l.Wait(attemptCtx); err != nil {
+					results <- hedgeResult{err: err, attempt: attempt}
+					return
+				}
+			}
+
+			resp, err := c.do(attemptCtx, req.WithContext(attemptCtx))
+			results <- hedgeResult{resp: resp, err: err, attempt: attempt}
+		}()
+	}
+
+	launch()
+	pending := 1
+
+	var hedgeTimer <-chan time.Time
+	if ok {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		hedgeTimer = timer.C
+	}
+
+	var firstErr error
+	for pending > 0 {
+		select {
+		case <-hedgeTimer:
+			hedgeTimer = nil
+			if !h.allow() {
+				continue
+			}
+			if c.budget != nil && c.budget.charge(endpoint) != nil {
+				continue
+			}
+			recordMetadata(ctx, func(meta *Metadata) { meta.Hedges++ })
+			launch()
+			pending++
+
+		case result := <-results:
+			pending--
+
+			if result.err != nil {
+				cancels[result.attempt]()
+				if firstErr == nil {
+					firstErr = result.err
+				}
+				continue
+			}
+
+			h.observe(endpoint, time.Since(started), result.attempt > 0)
+
+			//cancel the loser and close its response whenever it comes back
+			for attempt, cancel := range cancels {
+				if attempt != result.attempt {
+					cancel()
+				}
+			}
+			if pending > 0 {
+				go func() {
+					if loser := <-results; loser.resp != nil {
+						loser.resp.Body.Close()
+					}
+				}()
+			}
+
+			result.resp.Body = cancelBody{ReadCloser: result.resp.Body, cancel: cancels[result.attempt]}
+			return result.resp, nil
+		}
+	}
+
+	return nil, firstErr
+}
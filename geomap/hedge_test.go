@@ -0,0 +1,86 @@
+package geomap
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHedging(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		maxRatio float64
+		warmup   int
+		slow     time.Duration
+
+		sent   int
+		hedged int64
+		won    int64
+	}{
+		{name: "slow request hedged", maxRatio: 1, warmup: minHedgeSamples, slow: time.Second, sent: minHedgeSamples + 2, hedged: 1, won: 1},
+		{name: "fast request not hedged", maxRatio: 1, warmup: minHedgeSamples, sent: minHedgeSamples + 1},
+		{name: "too few latencies to hedge", maxRatio: 1, warmup: minHedgeSamples - 1, slow: 100 * time.Millisecond, sent: minHedgeSamples},
+		{name: "hedge over the ratio", maxRatio: 0.01, warmup: minHedgeSamples, slow: 100 * time.Millisecond, sent: minHedgeSamples + 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			h, err := NewHedging(0.9, test.maxRatio)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h.MinDelay = 20 * time.Millisecond
+
+			delays := make([]time.Duration, test.warmup+1)
+			delays[test.warmup] = test.slow
+			transport := &scriptedTransport{bodies: []string{fixtureGeocode()}, delays: delays}
+			c := transportClient(t, transport, WithHedging(h))
+
+			params := map[string]string{"address": "Jl. Sudirman 1", "key": "k"}
+			for i := 0; i < test.warmup; i++ {
+				if _, err := c.GetGeocode(context.Background(), params); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			started := time.Now()
+			resp, err := c.GetGeocode(context.Background(), params)
+			if err != nil || resp.Status != "OK" {
+				t.Fatalf("status %q, error %v", resp.Status, err)
+			}
+			if test.won > 0 && time.Since(started) >= test.slow {
+				t.Errorf("answered after %v, the hedge should have won", time.Since(started))
+			}
+
+			stats := h.Stats()
+			if sent := len(transport.sent()); sent != test.sent || stats.Hedged != test.hedged || stats.Won != test.won {
+				t.Errorf("%d sent, %d hedged, %d won, expected %d, %d, %d", sent, stats.Hedged, stats.Won, test.sent, test.hedged, test.won)
+			}
+		})
+	}
+}
+
+func TestHedgingPOSTNotHedged(t *testing.T) {
+
+	h, err := NewHedging(0.5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < minHedgeSamples; i++ {
+		h.observe(EndpointGeocode, time.Millisecond, false)
+	}
+
+	transport := &scriptedTransport{bodies: []string{fixtureGeocode()}, delays: []time.Duration{50 * time.Millisecond}}
+	c := transportClient(t, transport, WithHedging(h))
+
+	var resp GoogleGeocodeResponse
+	if err := c.call(context.Background(), EndpointGeocode, map[string]string{"key": "k"}, nil, []byte(`{}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := len(transport.sent()); sent != 1 {
+		t.Errorf("%d requests sent, a POST is never hedged", sent)
+	}
+}
@@ -0,0 +1,122 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const defaultHydrateConcurrency = 5
+
+/*
+	HydrateOptions configures HydrateDetails, Params are sent with every details request
+	and need at least the "key", Concurrency bounds the requests in flight (5 by default)
+*/
+type HydrateOptions struct {
+	Params      map[string]string
+	Concurrency int
+}
+
+// HydratedPlace is a search result merged with its details, Err is set when the details failed
+type HydratedPlace struct {
+	Place
+	Err error `json:"-"`
+}
+
+type HydrateResult struct {
+	Places []HydratedPlace `json:"places"`
+	Failed int             `json:"failed"`
+}
+
+/*
+	HydrateDetails fetches the details (only fields, e.g. "website", "opening_hours")
+	of every result of a nearby or text search and merges them into the result.
+	Requests go through the client rate limiter and cache, a failed place keeps its
+	search fields and reports the failure on Err, the other places are still hydrated
+*/
+func HydrateDetails(ctx context.Context, nearbyResp NearbySearchResponseV2, fields []string, opts HydrateOptions) (HydrateResult, error) {
+	return DefaultClient().HydrateDetails(ctx, nearbyResp, fields, opts)
+}
+
+// HydrateDetails is the package level HydrateDetails using c
+func (c *Client) HydrateDetails(ctx context.Context, nearbyResp NearbySearchResponseV2, fields []string, opts HydrateOptions) (HydrateResult, error) {
+
+	if len(fields) == 0 {
+		return HydrateResult{}, errors.New("fields must not be empty")
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultHydrateConcurrency
+	}
+
+	result := HydrateResult{Places: make([]HydratedPlace, len(nearbyResp.Results))}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+
+	for i, place := range nearbyResp.Results {
+
+		result.Places[i].Place = place
+		if place.PlaceID == "" {
+			result.Places[i].Err = errors.New("place has no place_id")
+			continue
+		}
+
+		wg.Add(1)
+		go func(hydrated *HydratedPlace) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				hydrated.Err = ctx.Err()
+				return
+			}
+
+			params := copyParams(opts.Params)
+			params["place_id"] = hydrated.PlaceID
+			params["fields"] = strings.Join(fields, ",")
+
+			details, err := c.PlaceDetailV2(ctx, params)
+			if err == nil && details.Status != "OK" {
+				err = errors.New(details.Status)
+			}
+			if err != nil {
+				hydrated.Err = err
+				return
+			}
+
+			mergePlace(&hydrated.Place, details.Result)
+		}(&result.Places[i])
+	}
+
+	wg.Wait()
+
+	for _, place := range result.Places {
+		if place.Err != nil {
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
+
+// mergePlace copies the fields set in details over place
+func mergePlace(place *Place, details Place) {
+
+	dst := reflect.ValueOf(place).Elem()
+	src := reflect.ValueOf(details)
+
+	for i := 0; i < src.NumField(); i++ {
+		field := src.Field(i)
+		if !reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()) {
+			dst.Field(i).Set(field)
+		}
+	}
+}
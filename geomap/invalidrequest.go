@@ -0,0 +1,147 @@
+package geomap
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+	InvalidRequestError is returned when google answers INVALID_REQUEST, the response
+	is still decoded. Hints tell the likely causes found in the params and the message,
+	google often answers without any message
+*/
+type InvalidRequestError struct {
+	Endpoint     Endpoint
+	ErrorMessage string
+	Hints        []string
+}
+
+func (e *InvalidRequestError) Error() string {
+
+	msg := fmt.Sprintf("%s: INVALID_REQUEST", e.Endpoint)
+	if e.ErrorMessage != "" {
+		msg += ": " + e.ErrorMessage
+	}
+	if len(e.Hints) > 0 {
+		msg += " (" + strings.Join(e.Hints, "; ") + ")"
+	}
+
+	return msg
+}
+
+// invalidRequestRule returns the hint of a common cause of INVALID_REQUEST, "" when it doesn't apply
+type invalidRequestRule func(endpoint Endpoint, params map[string]string, message string) string
+
+var invalidRequestRules = []invalidRequestRule{
+	missingParamsHint,
+	nearbyRadiusHint,
+	nearbyRankByDistanceHint,
+	malformedLocationHint,
+	fieldsHint,
+	pageTokenHint,
+}
+
+// newInvalidRequestError builds the error of an INVALID_REQUEST response to params
+func newInvalidRequestError(endpoint Endpoint, params map[string]string, message string) *InvalidRequestError {
+
+	err := &InvalidRequestError{Endpoint: endpoint, ErrorMessage: message}
+	for _, rule := range invalidRequestRules {
+		if hint := rule(endpoint, params, message); hint != "" {
+			err.Hints = append(err.Hints, hint)
+		}
+	}
+
+	return err
+}
+
+func missingParamsHint(endpoint Endpoint, params map[string]string, message string) string {
+
+	var missing []string
+	for _, anyOf := range requiredParams[endpoint] {
+		found := false
+		for _, param := range anyOf {
+			if params[param] != "" {
+				found = true
+			}
+		}
+		if !found {
+			missing = append(missing, strings.Join(anyOf, " or "))
+		}
+	}
+
+	if len(missing) == 0 {
+		return ""
+	}
+	return "missing " + strings.Join(missing, ", ")
+}
+
+func nearbyRadiusHint(endpoint Endpoint, params map[string]string, message string) string {
+
+	if endpoint != EndpointNearbySearch || params["pagetoken"] != "" {
+		return ""
+	}
+	if params["radius"] == "" && params["rankby"] != "distance" {
+		return "radius is required unless rankby=distance"
+	}
+
+	return ""
+}
+
+func nearbyRankByDistanceHint(endpoint Endpoint, params map[string]string, message string) string {
+
+	if endpoint != EndpointNearbySearch || params["rankby"] != "distance" {
+		return ""
+	}
+	if params["radius"] != "" {
+		return "radius must not be set with rankby=distance"
+	}
+	if params["keyword"] == "" && params["name"] == "" && params["type"] == "" {
+		return "rankby=distance needs keyword, name or type"
+	}
+
+	return ""
+}
+
+func malformedLocationHint(endpoint Endpoint, params map[string]string, message string) string {
+
+	for _, param := range []string{"location", "latlng"} {
+		if value := params[param]; value != "" {
+			if _, ok := parseLocation(value); !ok {
+				return fmt.Sprintf("%s %q must be \"lat,lng\" in degrees", param, value)
+			}
+		}
+	}
+
+	return ""
+}
+
+func fieldsHint(endpoint Endpoint, params map[string]string, message string) string {
+
+	fields := params["fields"]
+	if fields == "" {
+		if strings.Contains(strings.ToLower(message), "fields") {
+			return "fields is required, a comma separated list of field names"
+		}
+		return ""
+	}
+
+	for _, field := range strings.Split(fields, ",") {
+		if field == "" || strings.TrimSpace(field) != field || strings.ToLower(field) != field {
+			return fmt.Sprintf("fields %q must be lowercase names separated by commas without spaces", fields)
+		}
+	}
+	if strings.Contains(strings.ToLower(message), "field") {
+		return "a name of fields isn't valid for " + string(endpoint)
+	}
+
+	return ""
+}
+
+func pageTokenHint(endpoint Endpoint, params map[string]string, message string) string {
+
+	if params["pagetoken"] == "" {
+		return ""
+	}
+
+	return "pagetoken only becomes valid a few seconds after the previous page was returned, it may have expired or belong to another search"
+}
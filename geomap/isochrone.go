@@ -0,0 +1,106 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+/*
+	IsochroneOptions tunes the isochrone sampling,
+	zero values fall back to 16 bearings and 6 samples per bearing,
+	MaxSpeed (meters per second) bounds how far samples are placed and defaults per travel mode
+*/
+type IsochroneOptions struct {
+	Bearings int
+	Samples  int
+	MaxSpeed float64
+}
+
+// generous average speeds in meters per second, used to place the furthest samples
+var isochroneSpeeds = map[string]float64{
+	"driving":   22,
+	"bicycling": 6,
+	"walking":   1.6,
+	"transit":   14,
+}
+
+/*
+	Isochrone approximates the area reachable from origin within budget.
+	It is experimental: samples are placed on evenly spaced bearings,
+	travel times are requested with the distance matrix (mode is taken from params, driving by default)
+	and the furthest reachable sample of each bearing becomes a polygon vertex.
+	The result is a GeoJSON polygon feature with the budget in seconds as property.
+	Each call costs Bearings*Samples distance matrix elements
+*/
+func Isochrone(ctx context.Context, origin GoogleLocation, budget time.Duration, params map[string]string, opts IsochroneOptions) (GeoJSONFeature, error) {
+	return DefaultClient().Isochrone(ctx, origin, budget, params, opts)
+}
+
+// Isochrone is the package level Isochrone using c
+func (c *Client) Isochrone(ctx context.Context, origin GoogleLocation, budget time.Duration, params map[string]string, opts IsochroneOptions) (GeoJSONFeature, error) {
+
+	if budget <= 0 {
+		return GeoJSONFeature{}, errors.New("budget must be positive")
+	}
+
+	if opts.Bearings <= 0 {
+		opts.Bearings = 16
+	}
+	if opts.Samples <= 0 {
+		opts.Samples = 6
+	}
+	if opts.MaxSpeed <= 0 {
+		mode := params["mode"]
+		if mode == "" {
+			mode = "driving"
+		}
+		speed, ok := isochroneSpeeds[mode]
+		if !ok {
+			return GeoJSONFeature{}, errors.New("unknown travel mode " + mode)
+		}
+		opts.MaxSpeed = speed
+	}
+
+	maxDistance := opts.MaxSpeed * budget.Seconds()
+
+	samples := make([]GoogleLocation, 0, opts.Bearings*opts.Samples)
+	destinations := make([]string, 0, opts.Bearings*opts.Samples)
+	for b := 0; b < opts.Bearings; b++ {
+		bearing := float64(b) * 360 / float64(opts.Bearings)
+		for s := 1; s <= opts.Samples; s++ {
+			sample := Destination(origin, bearing, maxDistance*float64(s)/float64(opts.Samples))
+			samples = append(samples, sample)
+			destinations = append(destinations, formatLocation(sample))
+		}
+	}
+
+	matrix, err := c.ETAMatrix(ctx, []string{formatLocation(origin)}, destinations, params)
+	if err != nil {
+		return GeoJSONFeature{}, err
+	}
+
+	ring := make([]GoogleLocation, 0, opts.Bearings)
+	for b := 0; b < opts.Bearings; b++ {
+
+		//unreachable bearings collapse to the origin
+		vertex := origin
+		for s := 0; s < opts.Samples; s++ {
+			idx := b*opts.Samples + s
+			cell := matrix.Cells[0][idx]
+			if cell.Status == "OK" && cell.Duration.Duration <= budget {
+				vertex = samples[idx]
+			}
+		}
+
+		ring = append(ring, vertex)
+	}
+
+	properties := map[string]interface{}{
+		"budget_seconds": int(budget.Seconds()),
+		"bearings":       opts.Bearings,
+		"samples":        opts.Samples,
+	}
+
+	return NewGeoJSONPolygon(ring, properties), nil
+}
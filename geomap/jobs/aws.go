@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+/*
+	DynamoDBStateStore keeps the jobs in a DynamoDB table with a string hash key "id",
+	the job is the json attribute "job" and its version the number "version"
+*/
+type DynamoDBStateStore struct {
+	Client dynamodbiface.DynamoDBAPI
+	Table  string
+}
+
+func (d *DynamoDBStateStore) Load(ctx context.Context, id string) (*Job, error) {
+
+	out, err := d.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(d.Table),
+		Key:            map[string]*dynamodb.AttributeValue{"id": {S: aws.String(id)}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	value := out.Item["job"]
+	if value == nil {
+		return nil, ErrJobNotFound
+	}
+
+	var job Job
+	if err := json.Unmarshal(value.B, &job); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (d *DynamoDBStateStore) Save(ctx context.Context, job *Job) error {
+
+	saved := *job
+	saved.Version++
+
+	value, err := json.Marshal(&saved)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(d.Table),
+		Item: map[string]*dynamodb.AttributeValue{
+			"id":      {S: aws.String(job.ID)},
+			"version": {N: aws.String(strconv.FormatInt(saved.Version, 10))},
+			"status":  {S: aws.String(job.Status)},
+			"job":     {B: value},
+		},
+	}
+	if job.Version == 0 {
+		input.ConditionExpression = aws.String("attribute_not_exists(id)")
+	} else {
+		input.ConditionExpression = aws.String("version = :version")
+		input.ExpressionAttributeValues = map[string]*dynamodb.AttributeValue{
+			":version": {N: aws.String(strconv.FormatInt(job.Version, 10))},
+		}
+	}
+
+	_, err = d.Client.PutItemWithContext(ctx, input)
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	job.Version = saved.Version
+	return nil
+}
+
+// S3ObjectStore keeps the input and the results of the jobs in Bucket, under Prefix
+type S3ObjectStore struct {
+	Client s3iface.S3API
+	Bucket string
+	Prefix string
+}
+
+func (s *S3ObjectStore) Put(ctx context.Context, key string, body []byte) error {
+
+	_, err := s.Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.Bucket),
+		Key:         aws.String(s.Prefix + key),
+		Body:        bytes.NewReader(body),
+		ContentType: aws.String("application/x-ndjson"),
+	})
+	return err
+}
+
+func (s *S3ObjectStore) Get(ctx context.Context, key string) ([]byte, error) {
+
+	out, err := s.Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(s.Prefix + key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+
+	return ioutil.ReadAll(out.Body)
+}
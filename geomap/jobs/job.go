@@ -0,0 +1,389 @@
+/*
+	Package jobs runs batch geocodes too long for one lambda invocation as resumable jobs.
+	Start stores the input and the job, Resume geocodes it chunk by chunk, saving a
+	checkpoint after each chunk and returning before the invocation runs out of time,
+	so the next invocation (e.g. a Step Functions loop on Status) carries on where it
+	stopped. The job state lives in a StateStore (DynamoDB), the input and the results
+	in an ObjectStore (S3), see aws.go
+*/
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gomapservice/geomap"
+)
+
+const (
+	defaultChunkSize = 100
+
+	// how long a run lasts without a ctx deadline, under the 15 minutes of a lambda
+	defaultMaxRunTime = 14 * time.Minute
+
+	// time kept before the deadline to save the checkpoint
+	defaultSafetyMargin = 30 * time.Second
+
+	// failures kept on the job, the results have all of them
+	maxFailures = 1000
+)
+
+// job statuses
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusCanceled  = "canceled"
+)
+
+var (
+	// ErrJobNotFound is returned for an unknown job id
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrConflict is returned by StateStore.Save when another run saved the job since it was loaded
+	ErrConflict = errors.New("job was updated by another run")
+)
+
+// Failure is an input of a job which failed
+type Failure struct {
+	Index int    `json:"index"`
+	Input string `json:"input"`
+	Error string `json:"error"`
+}
+
+/*
+	Job is a batch geocode of Total addresses. Checkpoint is the index of the first address
+	not geocoded yet, Failures the first 1000 addresses which failed (Progress.Failed counts
+	all of them). Results are NDJSON objects of geomap.BatchGeocodeResult, one per chunk
+*/
+type Job struct {
+	ID         string               `json:"id"`
+	Status     string               `json:"status"`
+	Params     map[string]string    `json:"params"`
+	Total      int                  `json:"total"`
+	Checkpoint int                  `json:"checkpoint"`
+	Progress   geomap.BatchProgress `json:"progress"`
+	Failures   []Failure            `json:"failures,omitempty"`
+	Results    []string             `json:"results,omitempty"`
+	Runs       int                  `json:"runs"`
+	Version    int64                `json:"version"`
+	CreatedAt  time.Time            `json:"created_at"`
+	UpdatedAt  time.Time            `json:"updated_at"`
+}
+
+// Done reports whether the job has nothing left to run
+func (j *Job) Done() bool {
+	return j.Status == StatusCompleted || j.Status == StatusCanceled
+}
+
+/*
+	StateStore keeps the jobs. Save must fail with ErrConflict unless the stored version
+	is job.Version (or the job is new when it is 0), then store it with Version incremented
+*/
+type StateStore interface {
+	Load(ctx context.Context, id string) (*Job, error)
+	Save(ctx context.Context, job *Job) error
+}
+
+// ObjectStore keeps the input and the results of the jobs
+type ObjectStore interface {
+	Put(ctx context.Context, key string, body []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+/*
+	Runner starts and runs jobs with Client. Chunks of ChunkSize addresses (100 by default)
+	are geocoded with BatchGeocode and Options, whose Params are those of the job with
+	Credentials added. The credentials (e.g. the "key", unless Client has a key pool) are
+	only given at run time, they are never stored with the job. A run stops
+	SafetyMargin (30s) before the ctx deadline, or after MaxRunTime (14 minutes) without one,
+	when the next chunk may not fit in the time left
+*/
+type Runner struct {
+	Client  geomap.API
+	State   StateStore
+	Objects ObjectStore
+	Options geomap.BatchOptions
+
+	Credentials map[string]string
+
+	ChunkSize    int
+	MaxRunTime   time.Duration
+	SafetyMargin time.Duration
+}
+
+/*
+	Start stores addresses and creates their job, params are sent with every geocode.
+	The credentials of params are stripped before the job is stored, see Runner.Credentials
+*/
+func (r *Runner) Start(ctx context.Context, addresses []string, params map[string]string) (*Job, error) {
+
+	if len(addresses) == 0 {
+		return nil, errors.New("a job needs addresses")
+	}
+
+	id, err := newJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, address := range addresses {
+		if err := encoder.Encode(address); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.Objects.Put(ctx, inputKey(id), input.Bytes()); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:        id,
+		Status:    StatusPending,
+		Params:    geomap.WithoutCredentials(params),
+		Total:     len(addresses),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if err := r.State.Save(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// Status returns the job of id
+func (r *Runner) Status(ctx context.Context, id string) (*Job, error) {
+	return r.State.Load(ctx, id)
+}
+
+// Cancel stops a job, a run in progress stops after its chunk
+func (r *Runner) Cancel(ctx context.Context, id string) (*Job, error) {
+
+	job, err := r.State.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Done() {
+		return job, nil
+	}
+
+	job.Status = StatusCanceled
+	job.UpdatedAt = time.Now()
+	return job, r.State.Save(ctx, job)
+}
+
+/*
+	Resume runs the job of id from its checkpoint until it completes or the time of the
+	run is up, returning the job as saved by the last checkpoint. Call it again while the
+	job isn't Done. Two runs of a job don't overlap: the one saving second fails with
+	ErrConflict and stops, the chunk it geocoded is geocoded again by the next run
+*/
+func (r *Runner) Resume(ctx context.Context, id string) (*Job, error) {
+
+	started := time.Now()
+
+	job, err := r.State.Load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Done() {
+		return job, nil
+	}
+
+	addresses, err := r.input(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	job.Status = StatusRunning
+	job.Runs++
+	job.UpdatedAt = time.Now()
+	if err := r.State.Save(ctx, job); err != nil {
+		return nil, err
+	}
+
+	deadline := r.deadline(ctx, started)
+	var slowestChunk time.Duration
+
+	for job.Checkpoint < len(addresses) {
+
+		if time.Until(deadline) < slowestChunk {
+			return job, nil
+		}
+
+		chunkStarted := time.Now()
+		if err := r.runChunk(ctx, job, addresses); err != nil {
+			return job, err
+		}
+		if elapsed := time.Since(chunkStarted); elapsed > slowestChunk {
+			slowestChunk = elapsed
+		}
+
+		if canceled, err := r.save(ctx, job); err != nil {
+			return job, err
+		} else if canceled != nil {
+			return canceled, nil
+		}
+	}
+
+	job.Status = StatusCompleted
+	job.UpdatedAt = time.Now()
+	if canceled, err := r.save(ctx, job); err != nil {
+		return job, err
+	} else if canceled != nil {
+		return canceled, nil
+	}
+
+	return job, nil
+}
+
+// save checkpoints job, returning the job canceled meanwhile instead if Cancel saved it first
+func (r *Runner) save(ctx context.Context, job *Job) (*Job, error) {
+
+	err := r.State.Save(ctx, job)
+	if err != ErrConflict {
+		return nil, err
+	}
+
+	if current, loadErr := r.State.Load(ctx, job.ID); loadErr == nil && current.Status == StatusCanceled {
+		return current, nil
+	}
+
+	return nil, err
+}
+
+// runChunk geocodes the addresses of the chunk at the checkpoint of job, stores the results and moves the checkpoint
+func (r *Runner) runChunk(ctx context.Context, job *Job, addresses []string) error {
+
+	chunkSize := r.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+
+	start := job.Checkpoint
+	end := start + chunkSize
+	if end > len(addresses) {
+		end = len(addresses)
+	}
+
+	opts := r.Options
+	opts.Params = make(map[string]string, len(job.Params)+len(r.Credentials))
+	for key, val := range job.Params {
+		opts.Params[key] = val
+	}
+	for key, val := range r.Credentials {
+		opts.Params[key] = val
+	}
+
+	results, err := r.Client.BatchGeocode(ctx, addresses[start:end], opts)
+	if err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		//the results of a canceled chunk are errors, it runs again next time
+		return ctx.Err()
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for i := range results {
+		results[i].Index += start
+		if err := encoder.Encode(&results[i]); err != nil {
+			return err
+		}
+	}
+
+	//a chunk run again overwrites its results
+	key := resultsKey(job.ID, start)
+	if err := r.Objects.Put(ctx, key, body.Bytes()); err != nil {
+		return err
+	}
+	if len(job.Results) == 0 || job.Results[len(job.Results)-1] != key {
+		job.Results = append(job.Results, key)
+	}
+
+	for _, result := range results {
+		job.Progress.Done++
+		if result.Err == nil {
+			continue
+		}
+		job.Progress.Failed++
+		if len(job.Failures) < maxFailures {
+			job.Failures = append(job.Failures, Failure{Index: result.Index, Input: result.Address, Error: result.Error})
+		}
+	}
+
+	job.Checkpoint = end
+	job.UpdatedAt = time.Now()
+	return nil
+}
+
+// deadline returns when a run started at started must have saved its last checkpoint
+func (r *Runner) deadline(ctx context.Context, started time.Time) time.Time {
+
+	maxRunTime := r.MaxRunTime
+	if maxRunTime <= 0 {
+		maxRunTime = defaultMaxRunTime
+	}
+	margin := r.SafetyMargin
+	if margin <= 0 {
+		margin = defaultSafetyMargin
+	}
+
+	deadline := started.Add(maxRunTime)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	return deadline.Add(-margin)
+}
+
+// input reads the addresses of a job
+func (r *Runner) input(ctx context.Context, id string) ([]string, error) {
+
+	body, err := r.Objects.Get(ctx, inputKey(id))
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	for decoder.More() {
+		var address string
+		if err := decoder.Decode(&address); err != nil {
+			return nil, fmt.Errorf("job input: %v", err)
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+func inputKey(id string) string {
+	return "jobs/" + id + "/input.ndjson"
+}
+
+// resultsKey names the results of the chunk starting at start, sorting in input order
+func resultsKey(id string, start int) string {
+	return fmt.Sprintf("jobs/%s/results/%09d.ndjson", id, start)
+}
+
+func newJobID() (string, error) {
+
+	var id [12]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(id[:]), nil
+}
@@ -0,0 +1,271 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"gomapservice/geomap"
+	"gomapservice/geomap/geomaptest"
+)
+
+// memoryState is a StateStore in memory, jobs are stored as json so callers never share them
+type memoryState struct {
+	mu   sync.Mutex
+	jobs map[string][]byte
+}
+
+func (s *memoryState) Load(ctx context.Context, id string) (*Job, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	var job Job
+	return &job, json.Unmarshal(body, &job)
+}
+
+func (s *memoryState) Save(ctx context.Context, job *Job) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var version int64
+	if body, ok := s.jobs[job.ID]; ok {
+		var stored Job
+		if err := json.Unmarshal(body, &stored); err != nil {
+			return err
+		}
+		version = stored.Version
+	}
+	if version != job.Version {
+		return ErrConflict
+	}
+
+	job.Version++
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if s.jobs == nil {
+		s.jobs = map[string][]byte{}
+	}
+	s.jobs[job.ID] = body
+	return nil
+}
+
+// memoryObjects is an ObjectStore in memory
+type memoryObjects struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (o *memoryObjects) Put(ctx context.Context, key string, body []byte) error {
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.objects == nil {
+		o.objects = map[string][]byte{}
+	}
+	o.objects[key] = append([]byte(nil), body...)
+	return nil
+}
+
+func (o *memoryObjects) Get(ctx context.Context, key string) ([]byte, error) {
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.objects[key], nil
+}
+
+// geocodeStub answers the geocodes of "bad" with INVALID_REQUEST, every other address with OK after delay
+func geocodeStub(t *testing.T, delay time.Duration) *geomaptest.StubClient {
+
+	stub, err := geomaptest.NewStubClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub.OnFunc(geomap.EndpointGeocode, func(params map[string]string) (interface{}, error) {
+		time.Sleep(delay)
+		if params["address"] == "bad" {
+			return `{"results":[],"status":"INVALID_REQUEST"}`, nil
+		}
+		return `{"results":[],"status":"OK"}`, nil
+	})
+
+	return stub
+}
+
+func TestRunnerResume(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		addresses  []string
+		chunkSize  int
+		maxRunTime time.Duration
+		delay      time.Duration
+
+		status     string
+		checkpoint int
+		results    int
+		failures   []Failure
+	}{
+		{
+			name:      "completed in chunks",
+			addresses: []string{"a", "b", "c", "d", "e"},
+			chunkSize: 2,
+			status:    StatusCompleted, checkpoint: 5, results: 3,
+		},
+		{
+			name:      "failures kept with their index",
+			addresses: []string{"a", "b", "bad", "d"},
+			chunkSize: 2,
+			status:    StatusCompleted, checkpoint: 4, results: 2,
+			failures: []Failure{{Index: 2, Input: "bad"}},
+		},
+		{
+			name:       "stops when the next chunk doesn't fit",
+			addresses:  []string{"a", "b", "c", "d", "e"},
+			chunkSize:  2,
+			maxRunTime: 50 * time.Millisecond,
+			delay:      100 * time.Millisecond,
+			status:     StatusRunning, checkpoint: 2, results: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			state := &memoryState{}
+			r := &Runner{
+				Client:       geocodeStub(t, test.delay),
+				State:        state,
+				Objects:      &memoryObjects{},
+				ChunkSize:    test.chunkSize,
+				MaxRunTime:   test.maxRunTime,
+				SafetyMargin: time.Nanosecond,
+			}
+
+			job, err := r.Start(context.Background(), test.addresses, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job, err = r.Resume(context.Background(), job.ID); err != nil {
+				t.Fatal(err)
+			}
+
+			if job.Status != test.status || job.Checkpoint != test.checkpoint || len(job.Results) != test.results {
+				t.Errorf("%s at %d with %d results, expected %s at %d with %d", job.Status, job.Checkpoint, len(job.Results), test.status, test.checkpoint, test.results)
+			}
+			if job.Progress.Done != int64(test.checkpoint) || job.Progress.Failed != int64(len(test.failures)) {
+				t.Errorf("progress %+v, expected %d done, %d failed", job.Progress, test.checkpoint, len(test.failures))
+			}
+			for i, failure := range job.Failures {
+				if i >= len(test.failures) || failure.Index != test.failures[i].Index || failure.Input != test.failures[i].Input || failure.Error == "" {
+					t.Errorf("failure %+v not expected", failure)
+				}
+			}
+
+			//the returned job is the saved one
+			saved, err := state.Load(context.Background(), job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.Version != job.Version || saved.Checkpoint != job.Checkpoint {
+				t.Errorf("saved version %d at %d, returned version %d at %d", saved.Version, saved.Checkpoint, job.Version, job.Checkpoint)
+			}
+		})
+	}
+}
+
+func TestRunnerCredentials(t *testing.T) {
+
+	stub := geocodeStub(t, 0)
+	state := &memoryState{}
+	r := &Runner{Client: stub, State: state, Objects: &memoryObjects{}, Credentials: map[string]string{"key": "runtime-key"}}
+
+	job, err := r.Start(context.Background(), []string{"a"}, map[string]string{"key": "start-key", "region": "id"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := state.Load(context.Background(), job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, stored := saved.Params["key"]; stored || saved.Params["region"] != "id" {
+		t.Errorf("stored params %v, expected the region without the key", saved.Params)
+	}
+
+	if _, err := r.Resume(context.Background(), job.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, call := range stub.Calls() {
+		if call.Params["key"] != "runtime-key" || call.Params["region"] != "id" {
+			t.Errorf("geocoded with %v, expected the runtime key and the job params", call.Params)
+		}
+	}
+}
+
+func TestRunnerCancel(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		during   bool
+		geocodes int
+	}{
+		{name: "before the run", geocodes: 0},
+		{name: "during the first chunk", during: true, geocodes: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			stub, err := geomaptest.NewStubClient()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &Runner{Client: stub, State: &memoryState{}, Objects: &memoryObjects{}, ChunkSize: 2}
+
+			job, err := r.Start(context.Background(), []string{"a", "b", "c", "d"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var cancelOnce sync.Once
+			cancel := func() {
+				cancelOnce.Do(func() {
+					if _, err := r.Cancel(context.Background(), job.ID); err != nil {
+						t.Error(err)
+					}
+				})
+			}
+			stub.OnFunc(geomap.EndpointGeocode, func(map[string]string) (interface{}, error) {
+				cancel()
+				return `{"results":[],"status":"OK"}`, nil
+			})
+			if !test.during {
+				cancel()
+			}
+
+			if job, err = r.Resume(context.Background(), job.ID); err != nil {
+				t.Fatal(err)
+			}
+			if job.Status != StatusCanceled {
+				t.Errorf("job %s, expected canceled", job.Status)
+			}
+			if geocodes := len(stub.Calls()); geocodes != test.geocodes {
+				t.Errorf("%d geocodes, expected %d", geocodes, test.geocodes)
+			}
+		})
+	}
+}
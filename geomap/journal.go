@@ -0,0 +1,153 @@
+package geomap
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+/*
+	JournalEntry records one outbound request to google and a summary of its response,
+	credentials are redacted from Params and Request, which replays it (see RenderedRequest.Curl)
+*/
+type JournalEntry struct {
+	Time       time.Time         `json:"time"`
+	Endpoint   Endpoint          `json:"endpoint"`
+	Params     map[string]string `json:"params"`
+	HTTPStatus int               `json:"http_status,omitempty"`
+	Status     string            `json:"status,omitempty"`
+	Results    int               `json:"results"`
+	Duration   time.Duration     `json:"duration"`
+	Error      string            `json:"error,omitempty"`
+	Request    RenderedRequest   `json:"request"`
+}
+
+/*
+	JournalSink stores journal entries, e.g. to a file, S3 or CloudWatch Logs
+	(see the awsadapter package), Write is called synchronously on the request path
+	so sinks talking to remote services should buffer
+*/
+type JournalSink interface {
+	Write(ctx context.Context, entry JournalEntry) error
+}
+
+// params never written to the journal in clear
+var redactedParams = map[string]bool{
+	"key":       true,
+	"signature": true,
+	"client":    true,
+}
+
+/*
+	WithoutCredentials returns a copy of params without the credentials (the key, the
+	signature and the client id), for params stored outside of the client
+*/
+func WithoutCredentials(params map[string]string) map[string]string {
+
+	stripped := make(map[string]string, len(params))
+	for key, val := range params {
+		if !redactedParams[key] {
+			stripped[key] = val
+		}
+	}
+
+	return stripped
+}
+
+/*
+	WithJournal records the outbound requests to sink, for audit of what location data
+	was queried and when. sampleRate (0 to 1) is the share of successful requests recorded,
+	failed requests are always recorded
+*/
+func WithJournal(sink JournalSink, sampleRate float64) ClientOption {
+	return func(c *Client) error {
+		c.journal = sink
+		c.journalSampleRate = sampleRate
+		return nil
+	}
+}
+
+// writeJournal records a request when the client has a journal and the entry is sampled
+func (c *Client) writeJournal(ctx context.Context, entry JournalEntry) {
+
+	if c.journal == nil {
+		return
+	}
+
+	if entry.Error == "" && rand.Float64() >= c.journalSampleRate {
+		return
+	}
+
+	redacted := make(map[string]string, len(entry.Params))
+	for key, val := range entry.Params {
+		if redactedParams[key] {
+			val = "REDACTED"
+		}
+		redacted[key] = val
+	}
+	entry.Params = redacted
+
+	if err := c.journal.Write(ctx, entry); err != nil {
+		log.Printf("geomap: journal write failed: %v", err)
+	}
+}
+
+// responseSummary is the part of any google response used to summarize it
+type responseSummary struct {
+	Status       string            `json:"status"`
+	ErrorMessage string            `json:"error_message"`
+	Results      []json.RawMessage `json:"results"`
+	Candidates   []json.RawMessage `json:"candidates"`
+	Predictions  []json.RawMessage `json:"predictions"`
+	Routes       []json.RawMessage `json:"routes"`
+	Rows         []json.RawMessage `json:"rows"`
+	Result       json.RawMessage   `json:"result"`
+}
+
+func summarize(body []byte) responseSummary {
+
+	var summary responseSummary
+	json.Unmarshal(body, &summary)
+
+	return summary
+}
+
+// count returns how many results the response holds
+func (s responseSummary) count() int {
+
+	count := len(s.Results) + len(s.Candidates) + len(s.Predictions) + len(s.Routes) + len(s.Rows)
+	if len(s.Result) > 0 && string(s.Result) != "null" {
+		count++
+	}
+
+	return count
+}
+
+// writerJournal writes the entries as json lines
+type writerJournal struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterJournal returns a JournalSink writing one json line per entry to w, e.g. a file
+func NewWriterJournal(w io.Writer) JournalSink {
+	return &writerJournal{w: w}
+}
+
+func (j *writerJournal) Write(ctx context.Context, entry JournalEntry) error {
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_, err = j.w.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,194 @@
+package geomap
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoKeyAvailable is returned when every key of the pool is quarantined for the endpoint
+var ErrNoKeyAvailable = errors.New("no api key available, every key is quarantined")
+
+// statuses quarantining the key that received them
+var quarantineStatuses = map[string]bool{
+	"OVER_QUERY_LIMIT": true,
+	"REQUEST_DENIED":   true,
+}
+
+const defaultQuarantine = 5 * time.Minute
+
+type poolKey struct {
+	key     string
+	weight  int
+	current int
+
+	//by endpoint, a key denied one api may still be enabled for the others
+	quarantinedUntil map[Endpoint]time.Time
+}
+
+// usable reports whether the key isn't quarantined for endpoint at now
+func (k *poolKey) usable(endpoint Endpoint, now time.Time) bool {
+	return !now.Before(k.quarantinedUntil[endpoint])
+}
+
+/*
+	KeyPool spreads the requests over several api keys, in proportion of their weight.
+	A key answered with OVER_QUERY_LIMIT or REQUEST_DENIED is quarantined for the
+	endpoint of the request during Quarantine (5 minutes by default), so the pool survives
+	one key running out of quota or lacking an api. The last key usable for an endpoint
+	is never quarantined, a pool of one key never quarantines: failing every request
+	with ErrNoKeyAvailable would be worse than the refusals of google
+*/
+type KeyPool struct {
+	Quarantine time.Duration
+
+	mu   sync.Mutex
+	keys []*poolKey
+}
+
+// NewKeyPool returns a pool using keys in turn
+func NewKeyPool(keys ...string) *KeyPool {
+
+	weights := make(map[string]int, len(keys))
+	for _, key := range keys {
+		weights[key] = 1
+	}
+
+	return NewWeightedKeyPool(weights)
+}
+
+// NewWeightedKeyPool returns a pool using each key in proportion of its weight, keys weighing 0 or less are left out
+func NewWeightedKeyPool(weights map[string]int) *KeyPool {
+
+	p := &KeyPool{}
+	for key, weight := range weights {
+		if key != "" && weight > 0 {
+			p.keys = append(p.keys, &poolKey{key: key, weight: weight})
+		}
+	}
+
+	return p
+}
+
+/*
+	Pick returns the next key to use for endpoint, smooth weighted round robin
+	so heavier keys are picked more often without being picked in bursts
+*/
+func (p *KeyPool) Pick(endpoint Endpoint) (string, error) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	var (
+		best  *poolKey
+		total int
+	)
+	for _, k := range p.keys {
+		if !k.usable(endpoint, now) {
+			continue
+		}
+		k.current += k.weight
+		total += k.weight
+		if best == nil || k.current > best.current {
+			best = k
+		}
+	}
+
+	if best == nil {
+		return "", ErrNoKeyAvailable
+	}
+
+	best.current -= total
+	return best.key, nil
+}
+
+// Report records the status google answered to a request to endpoint made with key
+func (p *KeyPool) Report(key string, endpoint Endpoint, status string) {
+
+	if !quarantineStatuses[status] {
+		return
+	}
+
+	quarantine := p.Quarantine
+	if quarantine <= 0 {
+		quarantine = defaultQuarantine
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	var reported *poolKey
+	others := 0
+	for _, k := range p.keys {
+		switch {
+		case k.key == key:
+			reported = k
+		case k.usable(endpoint, now):
+			others++
+		}
+	}
+	if reported == nil || others == 0 {
+		return
+	}
+
+	if reported.quarantinedUntil == nil {
+		reported.quarantinedUntil = map[Endpoint]time.Time{}
+	}
+	reported.quarantinedUntil[endpoint] = now.Add(quarantine)
+	reported.current = 0
+}
+
+// WithAPIKey sends key with the requests whose params have no "key"
+func WithAPIKey(key string) ClientOption {
+	return WithKeyPool(NewKeyPool(key))
+}
+
+// WithKeyPool sends a key of pool with the requests whose params have no "key"
+func WithKeyPool(pool *KeyPool) ClientOption {
+	return func(c *Client) error {
+
+		if pool == nil || len(pool.keys) == 0 {
+			return errors.New("key pool has no key")
+		}
+
+		c.keyPool = pool
+		return nil
+	}
+}
+
+/*
+	withKey adds a key of the client pool for endpoint to params when they have none,
+	returning the params to send and the key picked, if any
+*/
+func (c *Client) withKey(endpoint Endpoint, params map[string]string) (map[string]string, string, error) {
+
+	if c.keyPool == nil || params["key"] != "" {
+		return params, "", nil
+	}
+
+	key, err := c.keyPool.Pick(endpoint)
+	if err != nil {
+		return nil, "", err
+	}
+
+	params = copyParams(params)
+	params["key"] = key
+	return params, key, nil
+}
+
+// reportKey feeds the status of a response to the pool the key was picked from
+func (c *Client) reportKey(endpoint Endpoint, key string, result *fetchResult, err error) {
+
+	if apiErr, ok := err.(*APIError); ok {
+		c.keyPool.Report(key, endpoint, apiErr.Status)
+		return
+	}
+
+	if err == nil {
+		c.keyPool.Report(key, endpoint, result.summarize().Status)
+	}
+}
@@ -0,0 +1,126 @@
+package geomap
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestKeyPoolPick(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		weights  map[string]int
+		reports  map[string]string
+		expected map[string]int
+	}{
+		{
+			name:     "keys in turn",
+			weights:  map[string]int{"a": 1, "b": 1},
+			expected: map[string]int{"a": 6, "b": 6},
+		},
+		{
+			name:     "keys in proportion of their weight",
+			weights:  map[string]int{"a": 3, "b": 1},
+			expected: map[string]int{"a": 9, "b": 3},
+		},
+		{
+			name:     "keys weighing nothing are left out",
+			weights:  map[string]int{"a": 1, "b": 0, "": 5},
+			expected: map[string]int{"a": 12},
+		},
+		{
+			name:     "quarantined keys are skipped",
+			weights:  map[string]int{"a": 1, "b": 1},
+			reports:  map[string]string{"a": "OVER_QUERY_LIMIT"},
+			expected: map[string]int{"b": 12},
+		},
+		{
+			name:     "other statuses don't quarantine",
+			weights:  map[string]int{"a": 1, "b": 1},
+			reports:  map[string]string{"a": "ZERO_RESULTS"},
+			expected: map[string]int{"a": 6, "b": 6},
+		},
+		{
+			name:     "the last usable key is never quarantined",
+			weights:  map[string]int{"a": 1},
+			reports:  map[string]string{"a": "REQUEST_DENIED"},
+			expected: map[string]int{"a": 12},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			pool := NewWeightedKeyPool(test.weights)
+			for key, status := range test.reports {
+				pool.Report(key, EndpointGeocode, status)
+			}
+
+			picked := map[string]int{}
+			for i := 0; i < 12; i++ {
+				key, err := pool.Pick(EndpointGeocode)
+				if err != nil {
+					t.Fatal(err)
+				}
+				picked[key]++
+			}
+
+			if !reflect.DeepEqual(picked, test.expected) {
+				t.Errorf("picked %v, expected %v", picked, test.expected)
+			}
+		})
+	}
+}
+
+func TestKeyPoolQuarantineByEndpoint(t *testing.T) {
+
+	pool := NewKeyPool("a", "b")
+	pool.Report("a", EndpointGeocode, "REQUEST_DENIED")
+
+	for i := 0; i < 4; i++ {
+		if key, _ := pool.Pick(EndpointGeocode); key != "b" {
+			t.Fatalf("geocode picked %q, a is quarantined", key)
+		}
+	}
+
+	picked := map[string]bool{}
+	for i := 0; i < 4; i++ {
+		key, _ := pool.Pick(EndpointPlaceDetails)
+		picked[key] = true
+	}
+	if !picked["a"] || !picked["b"] {
+		t.Errorf("place details picked %v, a is only quarantined for geocode", picked)
+	}
+}
+
+func TestClientKeyPool(t *testing.T) {
+
+	transport := &scriptedTransport{bodies: []string{`{"status":"OVER_QUERY_LIMIT","results":[]}`, fixtureGeocode()}}
+	c := transportClient(t, transport, WithKeyPool(NewKeyPool("a", "b")))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetGeocode(context.Background(), map[string]string{"address": "Jl. Sudirman 1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var keys []string
+	for _, req := range transport.sent() {
+		keys = append(keys, req.URL.Query().Get("key"))
+	}
+
+	//the key refused first is quarantined, the others go to the second one
+	if len(keys) != 3 || keys[1] != keys[2] || keys[0] == keys[1] {
+		t.Errorf("keys sent %v, expected the refused key then the other one", keys)
+	}
+
+	//a key given by the caller is sent as is
+	if _, err := c.GetGeocode(context.Background(), map[string]string{"address": "Jl. Sudirman 1", "key": "own"}); err != nil {
+		t.Fatal(err)
+	}
+	sent := transport.sent()
+	if key := sent[len(sent)-1].URL.Query().Get("key"); key != "own" {
+		t.Errorf("key sent %q, expected the one of the caller", key)
+	}
+}
@@ -0,0 +1,174 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEndpointDisabled is returned instead of sending a request to an endpoint or feature turned off by the kill switch
+var ErrEndpointDisabled = errors.New("endpoint disabled by policy")
+
+// features of an endpoint the kill switch turns off on their own, the endpoints are turned off by their name (e.g. "photo")
+const (
+	// details requests asking for the contact fields (opening hours, phone, website)
+	FeatureDetailsContact = "details-contact"
+
+	// details requests asking for the atmosphere fields (rating, reviews, price level...)
+	FeatureDetailsAtmosphere = "details-atmosphere"
+)
+
+// featureSKUs are the details fields turned off by each feature, by the SKU they are billed under
+var featureSKUs = map[string]FieldSKU{
+	FeatureDetailsContact:    SKUContact,
+	FeatureDetailsAtmosphere: SKUAtmosphere,
+}
+
+// FlagSource lists the endpoints and features currently disabled, e.g. from the environment or Parameter Store
+type FlagSource interface {
+	Disabled(ctx context.Context) ([]string, error)
+}
+
+// FlagSourceFunc adapts a function to a FlagSource
+type FlagSourceFunc func(ctx context.Context) ([]string, error)
+
+func (f FlagSourceFunc) Disabled(ctx context.Context) ([]string, error) {
+	return f(ctx)
+}
+
+// EnvFlags returns a FlagSource reading the comma separated list of the environment variable name, e.g. "photo,details-atmosphere"
+func EnvFlags(name string) FlagSource {
+	return FlagSourceFunc(func(ctx context.Context) ([]string, error) {
+		return ParseFlags(os.Getenv(name)), nil
+	})
+}
+
+// ParseFlags splits a comma or space separated list of endpoints and features
+func ParseFlags(raw string) []string {
+
+	return strings.FieldsFunc(strings.ToLower(raw), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+}
+
+/*
+	KillSwitch turns off endpoints (by Endpoint name, e.g. "photo") and features
+	(e.g. FeatureDetailsAtmosphere) at runtime, e.g. during a billing incident.
+	The disabled list is read from its source at most once every interval on the
+	request path, a failed read is logged and the last list kept. Cached responses
+	are still served since they aren't billed
+*/
+type KillSwitch struct {
+	source FlagSource
+	every  time.Duration
+
+	mu       sync.RWMutex
+	disabled map[string]bool
+	loaded   time.Time
+}
+
+// NewKillSwitch returns a KillSwitch reading source every interval, a nil source only disables what Set says
+func NewKillSwitch(source FlagSource, every time.Duration) *KillSwitch {
+	return &KillSwitch{source: source, every: every, disabled: map[string]bool{}}
+}
+
+// WithKillSwitch makes the client refuse the requests to the endpoints and features disabled by k
+func WithKillSwitch(k *KillSwitch) ClientOption {
+	return func(c *Client) error {
+
+		if k == nil {
+			return errors.New("kill switch must not be nil")
+		}
+
+		c.killSwitch = k
+		return nil
+	}
+}
+
+// Set replaces the disabled endpoints and features until the next read of the source
+func (k *KillSwitch) Set(disabled []string) {
+
+	set := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		set[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+
+	k.mu.Lock()
+	k.disabled = set
+	k.mu.Unlock()
+}
+
+// Disabled reports whether the endpoint or feature name is disabled, without reading the source
+func (k *KillSwitch) Disabled(name string) bool {
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	return k.disabled[strings.ToLower(name)]
+}
+
+// Refresh reads the source now
+func (k *KillSwitch) Refresh(ctx context.Context) error {
+
+	if k.source == nil {
+		return nil
+	}
+
+	disabled, err := k.source.Disabled(ctx)
+	if err != nil {
+		return err
+	}
+
+	k.Set(disabled)
+	return nil
+}
+
+// refreshIfStale reads the source when the last read is older than the interval
+func (k *KillSwitch) refreshIfStale(ctx context.Context) {
+
+	k.mu.Lock()
+	if k.source == nil || time.Since(k.loaded) < k.every {
+		k.mu.Unlock()
+		return
+	}
+	//the next callers keep the current list while this one reads the source
+	k.loaded = time.Now()
+	k.mu.Unlock()
+
+	if err := k.Refresh(ctx); err != nil {
+		log.Printf("geomap: kill switch refresh failed: %v", err)
+	}
+}
+
+// check refuses a request to a disabled endpoint, or asking for the fields of a disabled feature
+func (k *KillSwitch) check(ctx context.Context, endpoint Endpoint, params map[string]string) error {
+
+	k.refreshIfStale(ctx)
+
+	if k.Disabled(string(endpoint)) {
+		return ErrEndpointDisabled
+	}
+
+	if endpoint != EndpointPlaceDetails {
+		return nil
+	}
+
+	usage := ClassifyFields(strings.Split(params["fields"], ","))
+	requested := map[FieldSKU]bool{
+		SKUContact:    len(usage.Contact) > 0,
+		SKUAtmosphere: len(usage.Atmosphere) > 0,
+	}
+
+	for feature, sku := range featureSKUs {
+		//without fields google returns, and bills, every one of them
+		if k.Disabled(feature) && (params["fields"] == "" || requested[sku]) {
+			return ErrEndpointDisabled
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package geomap
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LatLng is a location in degrees, the same type as GoogleLocation
+type LatLng = GoogleLocation
+
+// defaultLatLngPrecision is the decimals of String, about 10cm
+const defaultLatLngPrecision = 6
+
+var (
+	decimalLatLng = regexp.MustCompile(`^([+-]?\d+(?:\.\d+)?)\s*(?:,|\s)\s*([+-]?\d+(?:\.\d+)?)$`)
+
+	//degrees, optional minutes and seconds, then the hemisphere, e.g. 47°36'36"N or 47.61N
+	dmsCoordinate = `(\d+(?:\.\d+)?)\s*°?\s*(?:(\d+(?:\.\d+)?)\s*['′]\s*)?(?:(\d+(?:\.\d+)?)\s*(?:"|″|'')\s*)?`
+	dmsLatLng     = regexp.MustCompile(`^` + dmsCoordinate + `([NSns])\s*,?\s*` + dmsCoordinate + `([EWew])$`)
+)
+
+/*
+	ParseLatLng reads a location written as decimal degrees separated by a comma or
+	spaces ("47.61,-122.33", "47.61 -122.33") or as degrees, minutes and seconds with
+	their hemispheres ("47°36'36\"N 122°19'48\"W", "47.61N, 122.33W"),
+	the latitude is first and must be within ±90, the longitude within ±180
+*/
+func ParseLatLng(s string) (LatLng, error) {
+
+	s = strings.TrimSpace(s)
+
+	if match := decimalLatLng.FindStringSubmatch(s); match != nil {
+		lat, _ := strconv.ParseFloat(match[1], 64)
+		lng, _ := strconv.ParseFloat(match[2], 64)
+		return validLatLng(lat, lng)
+	}
+
+	if match := dmsLatLng.FindStringSubmatch(s); match != nil {
+		lat, err := dmsDegrees(match[1], match[2], match[3], match[4])
+		if err != nil {
+			return LatLng{}, err
+		}
+		lng, err := dmsDegrees(match[5], match[6], match[7], match[8])
+		if err != nil {
+			return LatLng{}, err
+		}
+		return validLatLng(lat, lng)
+	}
+
+	return LatLng{}, fmt.Errorf("invalid location %q, expected \"lat,lng\"", s)
+}
+
+func validLatLng(lat, lng float64) (LatLng, error) {
+
+	location := LatLng{Lat: lat, Lng: lng}
+	if err := location.Validate(); err != nil {
+		return LatLng{}, err
+	}
+
+	return location, nil
+}
+
+// dmsDegrees returns the decimal degrees of a coordinate, negative in the southern and western hemispheres
+func dmsDegrees(degrees, minutes, seconds, hemisphere string) (float64, error) {
+
+	value, _ := strconv.ParseFloat(degrees, 64)
+
+	for i, part := range []string{minutes, seconds} {
+		if part == "" {
+			continue
+		}
+		//minutes and seconds can't come with decimal degrees
+		if strings.Contains(degrees, ".") {
+			return 0, fmt.Errorf("invalid coordinate %s°%s'%s\"", degrees, minutes, seconds)
+		}
+		sub, _ := strconv.ParseFloat(part, 64)
+		if sub >= 60 {
+			return 0, fmt.Errorf("invalid coordinate %s°%s'%s\", minutes and seconds must be below 60", degrees, minutes, seconds)
+		}
+		if i == 0 {
+			value += sub / 60
+		} else {
+			value += sub / 3600
+		}
+	}
+
+	if h := strings.ToUpper(hemisphere); h == "S" || h == "W" {
+		value = -value
+	}
+
+	return value, nil
+}
+
+// Validate fails when the latitude is outside ±90 or the longitude outside ±180
+func (l GoogleLocation) Validate() error {
+
+	if l.Lat < -90 || l.Lat > 90 {
+		return fmt.Errorf("latitude %v must be between -90 and 90", l.Lat)
+	}
+	if l.Lng < -180 || l.Lng > 180 {
+		return fmt.Errorf("longitude %v must be between -180 and 180", l.Lng)
+	}
+
+	return nil
+}
+
+// String renders the location as the "lat,lng" google expects, with 6 decimals
+func (l GoogleLocation) String() string {
+	return l.Format(defaultLatLngPrecision)
+}
+
+/*
+	Format renders the location as "lat,lng" with precision decimals,
+	e.g. 3 (about 100m) to log or cache a location without pinpointing it
+*/
+func (l GoogleLocation) Format(precision int) string {
+	return strconv.FormatFloat(l.Lat, 'f', precision, 64) + "," + strconv.FormatFloat(l.Lng, 'f', precision, 64)
+}
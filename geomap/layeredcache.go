@@ -0,0 +1,140 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+/*
+	CacheLayer is a level of a LayeredCache. TTL caps how long the layer keeps
+	an entry, 0 keeps it as long as the ttl the entry was set with
+*/
+type CacheLayer struct {
+	Name  string
+	Cache Cache
+	TTL   time.Duration
+}
+
+// CacheLayerStats counts the lookups of a layer since the cache was built or reset
+type CacheLayerStats struct {
+	Name   string
+	Hits   int64
+	Misses int64
+	Errors int64
+}
+
+/*
+	LayeredCache is a Cache made of layers looked up in order, typically a small
+	NewMemoryCache in front of a cache shared by every lambda (e.g. awsadapter.DynamoDBCache):
+	hot keys are served from memory while the shared layer survives containers being recycled.
+	A hit is written back to the layers above it (read-through) and Set writes every layer
+	(write-through). A failing layer counts as a miss, its error is only returned when no layer had the key
+*/
+type LayeredCache struct {
+	layers []CacheLayer
+
+	mu    sync.Mutex
+	stats []CacheLayerStats
+}
+
+// NewLayeredCache returns a LayeredCache looking layers up from the first (fastest) to the last
+func NewLayeredCache(layers ...CacheLayer) (*LayeredCache, error) {
+
+	if len(layers) == 0 {
+		return nil, errors.New("layered cache needs at least one layer")
+	}
+
+	stats := make([]CacheLayerStats, len(layers))
+	for i, layer := range layers {
+		if layer.Cache == nil {
+			return nil, errors.New("cache layer " + layer.Name + " has no cache")
+		}
+		stats[i].Name = layer.Name
+	}
+
+	return &LayeredCache{layers: layers, stats: stats}, nil
+}
+
+func (l *LayeredCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+
+	var firstErr error
+	for i, layer := range l.layers {
+
+		value, found, err := layer.Cache.Get(ctx, key)
+		l.count(i, found, err)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if !found {
+			continue
+		}
+
+		//the remaining ttl of the entry is unknown, only layers with their own ttl are filled
+		for _, upper := range l.layers[:i] {
+			if upper.TTL > 0 {
+				upper.Cache.Set(ctx, key, value, upper.TTL)
+			}
+		}
+
+		return value, true, nil
+	}
+
+	return nil, false, firstErr
+}
+
+func (l *LayeredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+
+	var firstErr error
+	for _, layer := range l.layers {
+
+		layerTTL := ttl
+		if layer.TTL > 0 && (layerTTL <= 0 || layer.TTL < layerTTL) {
+			layerTTL = layer.TTL
+		}
+
+		if err := layer.Cache.Set(ctx, key, value, layerTTL); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Stats returns the hits, misses and errors of every layer, in order
+func (l *LayeredCache) Stats() []CacheLayerStats {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]CacheLayerStats(nil), l.stats...)
+}
+
+// ResetStats clears the counts of every layer
+func (l *LayeredCache) ResetStats() {
+
+	l.mu.Lock()
+	for i := range l.stats {
+		l.stats[i] = CacheLayerStats{Name: l.stats[i].Name}
+	}
+	l.mu.Unlock()
+}
+
+func (l *LayeredCache) count(layer int, found bool, err error) {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch {
+	case err != nil:
+		l.stats[layer].Errors++
+	case found:
+		l.stats[layer].Hits++
+	default:
+		l.stats[layer].Misses++
+	}
+}
@@ -0,0 +1,235 @@
+package geomap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// the base maps of a tile session
+const (
+	MapTypeRoadmap   = "roadmap"
+	MapTypeSatellite = "satellite"
+	MapTypeTerrain   = "terrain"
+)
+
+// maxTileZoom is the deepest zoom level google serves 2D tiles for
+const maxTileZoom = 22
+
+/*
+	TileSessionRequest describes the tiles of a session, MapType, Language (IETF tag)
+	and Region (CLDR code) are required, the styles are the json of the maps styling
+	more references https://developers.google.com/maps/documentation/tile/session_tokens
+*/
+type TileSessionRequest struct {
+	MapType     string          `json:"mapType"`
+	Language    string          `json:"language"`
+	Region      string          `json:"region"`
+	ImageFormat string          `json:"imageFormat,omitempty"`
+	Scale       string          `json:"scale,omitempty"`
+	HighDPI     bool            `json:"highDpi,omitempty"`
+	LayerTypes  []string        `json:"layerTypes,omitempty"`
+	Styles      json.RawMessage `json:"styles,omitempty"`
+	Overlay     bool            `json:"overlay,omitempty"`
+	APIOptions  []string        `json:"apiOptions,omitempty"`
+}
+
+/*
+	TileSession is the session token the tiles are fetched with, it is valid until
+	Expires (about two weeks) and should be shared by every tile of the same style
+*/
+type TileSession struct {
+	Session     string `json:"session"`
+	Expiry      string `json:"expiry"`
+	TileWidth   int    `json:"tileWidth"`
+	TileHeight  int    `json:"tileHeight"`
+	ImageFormat string `json:"imageFormat"`
+}
+
+// Expires returns when the session stops being valid, zero when google didn't tell
+func (s TileSession) Expires() time.Time {
+
+	seconds, err := strconv.ParseInt(s.Expiry, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return time.Unix(seconds, 0)
+}
+
+// MapTile is a tile image, CacheControl is the caching google allows for it, to be passed on by a CDN
+type MapTile struct {
+	ContentType  string
+	CacheControl string
+	Data         []byte
+}
+
+// TileBounds is the viewport asked for its map information
+type TileBounds struct {
+	North float64
+	South float64
+	East  float64
+	West  float64
+}
+
+type MaxZoomRect struct {
+	MaxZoom int     `json:"maxZoom"`
+	North   float64 `json:"north"`
+	South   float64 `json:"south"`
+	East    float64 `json:"east"`
+	West    float64 `json:"west"`
+}
+
+/*
+	TileViewport is the map information of a viewport: the copyright to be displayed
+	along the tiles and the rectangles where the imagery stops before the requested zoom
+*/
+type TileViewport struct {
+	Copyright    string        `json:"copyright"`
+	MaxZoomRects []MaxZoomRect `json:"maxZoomRects"`
+}
+
+/*
+	CreateTileSession creates the session token the 2D tiles of req are fetched with.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/tile/session_tokens
+*/
+func CreateTileSession(ctx context.Context, req TileSessionRequest) (TileSession, error) {
+	return DefaultClient().CreateTileSession(ctx, req)
+}
+
+// CreateTileSession is the package level CreateTileSession using c
+func (c *Client) CreateTileSession(ctx context.Context, req TileSessionRequest) (TileSession, error) {
+
+	var session TileSession
+
+	if req.MapType == "" || req.Language == "" || req.Region == "" {
+		return session, errors.New("tile session needs a map type, a language and a region")
+	}
+
+	err := c.postJSON(ctx, EndpointTileSession, map[string]string{}, req, &session)
+	return session, err
+}
+
+/*
+	TileURL returns the url of the 2D tile x, y at zoom z of session, without the key,
+	for a backend or CDN origin fetching the tiles to add its own
+*/
+func TileURL(session string, z, x, y int) (string, error) {
+	return DefaultClient().TileURL(session, z, x, y)
+}
+
+// TileURL is the package level TileURL using c
+func (c *Client) TileURL(session string, z, x, y int) (string, error) {
+
+	if err := checkTile(session, z, x, y); err != nil {
+		return "", err
+	}
+
+	return c.endpointURL(EndpointTile) + tilePath(z, x, y) + "?" + url.Values{"session": {session}}.Encode(), nil
+}
+
+/*
+	Tile fetches the 2D tile x, y at zoom z of session.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/tile/2d-tiles-overview
+*/
+func Tile(ctx context.Context, session string, z, x, y int) (MapTile, error) {
+	return DefaultClient().Tile(ctx, session, z, x, y)
+}
+
+// Tile is the package level Tile using c
+func (c *Client) Tile(ctx context.Context, session string, z, x, y int) (MapTile, error) {
+
+	if err := checkTile(session, z, x, y); err != nil {
+		return MapTile{}, err
+	}
+
+	params, apiKey, err := c.withKey(EndpointTile, map[string]string{"session": session})
+	if err != nil {
+		return MapTile{}, err
+	}
+
+	query := url.Values{}
+	for key, val := range params {
+		query.Add(key, val)
+	}
+
+	result, err := c.fetch(withPathSuffix(ctx, tilePath(z, x, y)), EndpointTile, params, query, nil)
+	if apiKey != "" {
+		c.reportKey(EndpointTile, apiKey, result, err)
+	}
+	if err != nil {
+		return MapTile{}, err
+	}
+
+	return MapTile{
+		ContentType:  result.header.Get("Content-Type"),
+		CacheControl: result.header.Get("Cache-Control"),
+		Data:         result.body,
+	}, nil
+}
+
+/*
+	ViewportInfo returns the copyright and the max zoom rectangles of the viewport
+	bounds at zoom of session, the copyright must be displayed with the tiles.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/tile/viewport_information
+*/
+func ViewportInfo(ctx context.Context, session string, zoom int, bounds TileBounds) (TileViewport, error) {
+	return DefaultClient().ViewportInfo(ctx, session, zoom, bounds)
+}
+
+// ViewportInfo is the package level ViewportInfo using c
+func (c *Client) ViewportInfo(ctx context.Context, session string, zoom int, bounds TileBounds) (TileViewport, error) {
+
+	var viewport TileViewport
+
+	if session == "" {
+		return viewport, errors.New("tile session is required")
+	}
+	if zoom < 0 || zoom > maxTileZoom {
+		return viewport, fmt.Errorf("zoom must be between 0 and %d", maxTileZoom)
+	}
+	if bounds.North < bounds.South {
+		return viewport, errors.New("viewport north must not be below its south")
+	}
+
+	params := map[string]string{
+		"session": session,
+		"zoom":    strconv.Itoa(zoom),
+		"north":   strconv.FormatFloat(bounds.North, 'f', -1, 64),
+		"south":   strconv.FormatFloat(bounds.South, 'f', -1, 64),
+		"east":    strconv.FormatFloat(bounds.East, 'f', -1, 64),
+		"west":    strconv.FormatFloat(bounds.West, 'f', -1, 64),
+	}
+
+	err := c.getJSON(ctx, EndpointTileViewport, params, &viewport)
+	return viewport, err
+}
+
+// checkTile checks x and y are inside the 2^z by 2^z grid of zoom z
+func checkTile(session string, z, x, y int) error {
+
+	if session == "" {
+		return errors.New("tile session is required")
+	}
+	if z < 0 || z > maxTileZoom {
+		return fmt.Errorf("zoom must be between 0 and %d", maxTileZoom)
+	}
+
+	size := 1 << uint(z)
+	if x < 0 || x >= size || y < 0 || y >= size {
+		return fmt.Errorf("tile %d/%d is outside zoom %d", x, y, z)
+	}
+
+	return nil
+}
+
+func tilePath(z, x, y int) string {
+	return fmt.Sprintf("/%d/%d/%d", z, x, y)
+}
@@ -0,0 +1,44 @@
+package geomap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+	Metadata collects what happened behind the calls made with a context,
+	e.g. to report upstream retries in a handler response
+*/
+type Metadata struct {
+	mu sync.Mutex
+
+	Requests  int           `json:"requests"`
+	Retries   int           `json:"retries"`
+	RetryWait time.Duration `json:"retry_wait"`
+	Hedges    int           `json:"hedges,omitempty"`
+	Stale     bool          `json:"stale,omitempty"`
+
+	PageTokenRetries int           `json:"page_token_retries,omitempty"`
+	PageTokenWait    time.Duration `json:"page_token_wait,omitempty"`
+}
+
+type metadataKey struct{}
+
+// WithMetadata returns a context whose calls record their metadata into meta
+func WithMetadata(ctx context.Context, meta *Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, meta)
+}
+
+// recordMetadata updates the metadata of ctx, if any
+func recordMetadata(ctx context.Context, fn func(meta *Metadata)) {
+
+	meta, ok := ctx.Value(metadataKey{}).(*Metadata)
+	if !ok || meta == nil {
+		return
+	}
+
+	meta.mu.Lock()
+	fn(meta)
+	meta.mu.Unlock()
+}
@@ -0,0 +1,145 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// SearchVariant is one of the nearby searches merged by NearbyMulti, Label tags the places it found
+type SearchVariant struct {
+	Label   string
+	Options []ParamOption
+}
+
+// KeywordVariants returns a variant per keyword, labeled by the keyword
+func KeywordVariants(keywords ...string) []SearchVariant {
+
+	variants := make([]SearchVariant, len(keywords))
+	for i, keyword := range keywords {
+		variants[i] = SearchVariant{Label: keyword, Options: []ParamOption{Keyword(keyword)}}
+	}
+
+	return variants
+}
+
+// TypeVariants returns a variant per place type, labeled by the type
+func TypeVariants(placeTypes ...string) []SearchVariant {
+
+	variants := make([]SearchVariant, len(placeTypes))
+	for i, placeType := range placeTypes {
+		variants[i] = SearchVariant{Label: placeType, Options: []ParamOption{PlaceType(placeType)}}
+	}
+
+	return variants
+}
+
+// TaggedPlace is a merged place with the labels of the variants that found it
+type TaggedPlace struct {
+	Place
+	MatchedBy []string `json:"matched_by"`
+}
+
+/*
+	MultiSearchResult holds the places of every variant deduplicated by place_id,
+	Errors has the error of each failed variant by label
+*/
+type MultiSearchResult struct {
+	Results []TaggedPlace    `json:"results"`
+	Errors  map[string]error `json:"-"`
+}
+
+/*
+	NearbyMulti runs a nearby search per variant concurrently (e.g. "cafe", "coffee_shop"
+	and "bakery" around the same location) and merges the results deduplicated by place_id.
+	params are shared by every variant and need "location", "radius" and "key".
+	Places are ordered by their best rank in any variant, the variants that failed
+	are reported on Errors and the call only fails when every variant failed
+*/
+func NearbyMulti(ctx context.Context, params map[string]string, variants []SearchVariant) (MultiSearchResult, error) {
+	return DefaultClient().NearbyMulti(ctx, params, variants)
+}
+
+// NearbyMulti is the package level NearbyMulti using c
+func (c *Client) NearbyMulti(ctx context.Context, params map[string]string, variants []SearchVariant) (MultiSearchResult, error) {
+
+	if len(variants) == 0 {
+		return MultiSearchResult{}, errors.New("variants must not be empty")
+	}
+
+	responses := make([]NearbySearchResponseV2, len(variants))
+	errs := make([]error, len(variants))
+
+	var wg sync.WaitGroup
+	for i, variant := range variants {
+
+		variantParams, err := ApplyParams(copyParams(params), variant.Options...)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, variantParams map[string]string) {
+			defer wg.Done()
+
+			resp, err := c.PlaceNearbyV2(ctx, variantParams)
+			if err == nil && resp.Status != "OK" && resp.Status != "ZERO_RESULTS" {
+				err = errors.New(resp.Status)
+			}
+			responses[i], errs[i] = resp, err
+		}(i, variantParams)
+	}
+	wg.Wait()
+
+	result := MultiSearchResult{Errors: map[string]error{}}
+
+	//best rank of each place, ties keep the variant order
+	type ranked struct {
+		place   *TaggedPlace
+		rank    int
+		variant int
+	}
+	merged := map[string]*ranked{}
+	var order []*ranked
+
+	for i, resp := range responses {
+		if errs[i] != nil {
+			result.Errors[variants[i].Label] = errs[i]
+			continue
+		}
+
+		for rank, place := range resp.Results {
+			if existing, ok := merged[place.PlaceID]; ok {
+				existing.place.MatchedBy = append(existing.place.MatchedBy, variants[i].Label)
+				if rank < existing.rank {
+					existing.rank, existing.variant = rank, i
+				}
+				continue
+			}
+
+			r := &ranked{place: &TaggedPlace{Place: place, MatchedBy: []string{variants[i].Label}}, rank: rank, variant: i}
+			merged[place.PlaceID] = r
+			order = append(order, r)
+		}
+	}
+
+	if len(result.Errors) == len(variants) {
+		return result, errs[0]
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		if order[a].rank != order[b].rank {
+			return order[a].rank < order[b].rank
+		}
+		return order[a].variant < order[b].variant
+	})
+
+	result.Results = make([]TaggedPlace, len(order))
+	for i, r := range order {
+		result.Results[i] = *r.place
+	}
+
+	return result, nil
+}
@@ -0,0 +1,100 @@
+package geomap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Keyword sets the "keyword" param matched against every content google indexed for a place
+func Keyword(keyword string) ParamOption {
+	return func(params map[string]string) error {
+
+		if strings.TrimSpace(keyword) == "" {
+			return errors.New("keyword must not be empty")
+		}
+
+		params["keyword"] = keyword
+		return nil
+	}
+}
+
+/*
+	PlaceType restricts the results to one place type (e.g. "restaurant")
+	more references https://developers.google.com/places/web-service/supported_types
+*/
+func PlaceType(placeType string) ParamOption {
+	return func(params map[string]string) error {
+
+		if placeType == "" || strings.ContainsAny(placeType, " |,") {
+			return fmt.Errorf("invalid place type %q", placeType)
+		}
+
+		params["type"] = placeType
+		return nil
+	}
+}
+
+// MinPrice sets the "minprice" param, from 0 (most affordable) to 4 (most expensive)
+func MinPrice(level int) ParamOption {
+	return func(params map[string]string) error {
+		return setPriceLevel(params, "minprice", level)
+	}
+}
+
+// MaxPrice sets the "maxprice" param, from 0 (most affordable) to 4 (most expensive)
+func MaxPrice(level int) ParamOption {
+	return func(params map[string]string) error {
+		return setPriceLevel(params, "maxprice", level)
+	}
+}
+
+func setPriceLevel(params map[string]string, name string, level int) error {
+
+	if level < 0 || level > 4 {
+		return fmt.Errorf("%s must be between 0 and 4, got %d", name, level)
+	}
+
+	//whichever is set last checks the range against the other one
+	min, max := level, level
+	if name == "minprice" {
+		if other, err := strconv.Atoi(params["maxprice"]); err == nil {
+			max = other
+		}
+	} else {
+		if other, err := strconv.Atoi(params["minprice"]); err == nil {
+			min = other
+		}
+	}
+	if min > max {
+		return fmt.Errorf("minprice %d is greater than maxprice %d", min, max)
+	}
+
+	params[name] = strconv.Itoa(level)
+	return nil
+}
+
+// OpenNow only returns the places open at the time of the request
+func OpenNow() ParamOption {
+	return func(params map[string]string) error {
+		params["opennow"] = "true"
+		return nil
+	}
+}
+
+/*
+	PageToken requests the next page of a previous search with its next_page_token,
+	google ignores every other param but the key when a page token is set
+*/
+func PageToken(token string) ParamOption {
+	return func(params map[string]string) error {
+
+		if token == "" {
+			return errors.New("page token must not be empty")
+		}
+
+		params["pagetoken"] = token
+		return nil
+	}
+}
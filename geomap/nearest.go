@@ -0,0 +1,113 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+/*
+	NearestPlace is a nearby search result ranked by the actual
+	travel distance and duration from the search origin
+*/
+type NearestPlace struct {
+	Place    NearbyResult   `json:"place"`
+	Distance TextValue      `json:"distance"`
+	Duration GoogleDuration `json:"duration"`
+}
+
+/*
+	NearestByTravelTime searches places of placeType around origin ("lat,lng"),
+	then ranks the closest candidates by travel time with the distance matrix
+	and returns the n nearest for the travel mode (driving, walking, bicycling or transit).
+	params are sent with both requests and need at least the "key"
+*/
+func NearestByTravelTime(ctx context.Context, origin, placeType string, n int, mode string, params map[string]string) ([]NearestPlace, error) {
+	return DefaultClient().NearestByTravelTime(ctx, origin, placeType, n, mode, params)
+}
+
+// NearestByTravelTime is the package level NearestByTravelTime using c
+func (c *Client) NearestByTravelTime(ctx context.Context, origin, placeType string, n int, mode string, params map[string]string) ([]NearestPlace, error) {
+
+	if n <= 0 {
+		return nil, errors.New("n must be positive")
+	}
+
+	nearbyParams := copyParams(params)
+	//rankby distance can't be combined with radius
+	delete(nearbyParams, "radius")
+	nearbyParams["location"] = origin
+	nearbyParams["rankby"] = "distance"
+	nearbyParams["type"] = placeType
+
+	nearby, err := c.PlaceNearby(ctx, nearbyParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if nearby.Status == "ZERO_RESULTS" {
+		return []NearestPlace{}, nil
+	}
+	if nearby.Status != "OK" {
+		return nil, errors.New("nearby search status " + nearby.Status)
+	}
+
+	//straight line distance is a good enough pre-filter for the matrix
+	candidates := nearby.Results
+	if len(candidates) > maxMatrixDestinations {
+		candidates = candidates[:maxMatrixDestinations]
+	}
+
+	destinations := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		destinations[i] = "place_id:" + candidate.PlaceID
+	}
+
+	matrixParams, err := ApplyParams(copyParams(params), TravelMode(mode))
+	if err != nil {
+		return nil, err
+	}
+
+	matrix, err := c.ETAMatrix(ctx, []string{origin}, destinations, matrixParams)
+	if err != nil {
+		return nil, err
+	}
+
+	nearest := make([]NearestPlace, 0, len(candidates))
+	for i, cell := range matrix.Cells[0] {
+		if cell.Status != "OK" {
+			continue
+		}
+
+		duration := cell.Duration
+		if cell.DurationInTraffic != nil {
+			duration = *cell.DurationInTraffic
+		}
+
+		nearest = append(nearest, NearestPlace{
+			Place:    candidates[i],
+			Distance: cell.Distance,
+			Duration: duration,
+		})
+	}
+
+	sort.SliceStable(nearest, func(i, j int) bool {
+		return nearest[i].Duration.Duration < nearest[j].Duration.Duration
+	})
+
+	if len(nearest) > n {
+		nearest = nearest[:n]
+	}
+
+	return nearest, nil
+}
+
+func copyParams(params map[string]string) map[string]string {
+
+	copied := make(map[string]string, len(params))
+	for key, val := range params {
+		copied[key] = val
+	}
+
+	return copied
+}
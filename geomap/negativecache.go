@@ -0,0 +1,65 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// negativeStatuses are the answers that a query has nothing, worth caching as such
+var negativeStatuses = map[string]bool{
+	"ZERO_RESULTS": true,
+	"NOT_FOUND":    true,
+}
+
+/*
+	WithNegativeCache makes a client with a cache (see WithCache) also keep the
+	ZERO_RESULTS and NOT_FOUND responses for ttl, so the addresses and place ids
+	known to return nothing aren't billed again on every lookup. Keep ttl shorter
+	than the one of the positive responses, google data does change.
+	WithoutNegativeCache bypasses them for the calls of a context
+*/
+func WithNegativeCache(ttl time.Duration) ClientOption {
+	return func(c *Client) error {
+
+		if ttl <= 0 {
+			return errors.New("negative cache ttl must be positive")
+		}
+
+		c.negativeCacheTTL = ttl
+		return nil
+	}
+}
+
+type negativeBypassKey struct{}
+
+/*
+	WithoutNegativeCache returns a context whose calls ignore the cached negative
+	responses and ask google again, e.g. when a user insists an address exists
+*/
+func WithoutNegativeCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, negativeBypassKey{}, true)
+}
+
+// cacheTTLFor returns how long a response of status is cached, false when it isn't
+func (c *Client) cacheTTLFor(status string) (time.Duration, bool) {
+
+	if status == "OK" {
+		return c.cacheTTL, true
+	}
+	if negativeStatuses[status] && c.negativeCacheTTL > 0 {
+		return c.negativeCacheTTL, true
+	}
+
+	return 0, false
+}
+
+// bypassCached tells whether the cached body must be ignored by the calls of ctx
+func bypassCached(ctx context.Context, body []byte) bool {
+
+	if ctx.Value(negativeBypassKey{}) == nil {
+		return false
+	}
+
+	return negativeStatuses[summarize(body).Status]
+}
@@ -0,0 +1,85 @@
+package geomap
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNegativeCache(t *testing.T) {
+
+	const zeroResults = `{"results":[],"status":"ZERO_RESULTS"}`
+
+	tests := []struct {
+		name        string
+		bodies      []string
+		negativeTTL time.Duration
+		wait        time.Duration
+		bypass      bool
+
+		status string
+		sent   int
+	}{
+		{name: "not cached by default", bodies: []string{zeroResults, fixtureGeocode()}, status: "OK", sent: 2},
+		{name: "cached", bodies: []string{zeroResults, fixtureGeocode()}, negativeTTL: time.Minute, status: "ZERO_RESULTS", sent: 1},
+		{name: "expired", bodies: []string{zeroResults, fixtureGeocode()}, negativeTTL: time.Millisecond, wait: 5 * time.Millisecond, status: "OK", sent: 2},
+		{name: "bypassed", bodies: []string{zeroResults, fixtureGeocode()}, negativeTTL: time.Minute, bypass: true, status: "OK", sent: 2},
+		{name: "bypass keeps the positive responses", bodies: []string{fixtureGeocode(), zeroResults}, negativeTTL: time.Minute, bypass: true, status: "OK", sent: 1},
+		{name: "errors never cached", bodies: []string{`{"results":[],"status":"UNKNOWN_ERROR"}`, fixtureGeocode()}, negativeTTL: time.Minute, status: "OK", sent: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			opts := []ClientOption{WithCache(NewMemoryCache(0), time.Hour)}
+			if test.negativeTTL > 0 {
+				opts = append(opts, WithNegativeCache(test.negativeTTL))
+			}
+			transport := &scriptedTransport{bodies: test.bodies}
+			c := transportClient(t, transport, opts...)
+
+			params := map[string]string{"address": "Jl. Tidak Ada 99", "key": "k"}
+			if _, err := c.GetGeocode(context.Background(), params); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(test.wait)
+
+			ctx := context.Background()
+			if test.bypass {
+				ctx = WithoutNegativeCache(ctx)
+			}
+			resp, err := c.GetGeocode(ctx, params)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if sent := len(transport.sent()); resp.Status != test.status || sent != test.sent {
+				t.Errorf("status %q after %d requests, expected %q after %d", resp.Status, sent, test.status, test.sent)
+			}
+		})
+	}
+}
+
+func TestNegativeCacheBypassStoresTheAnswer(t *testing.T) {
+
+	transport := &scriptedTransport{bodies: []string{`{"results":[],"status":"ZERO_RESULTS"}`, fixtureGeocode()}}
+	c := transportClient(t, transport, WithCache(NewMemoryCache(0), time.Hour), WithNegativeCache(time.Minute))
+
+	params := map[string]string{"address": "Jl. Baru 1", "key": "k"}
+	for _, ctx := range []context.Context{context.Background(), WithoutNegativeCache(context.Background()), context.Background()} {
+		if _, err := c.GetGeocode(ctx, params); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//the address found by the bypass is served from the cache afterwards
+	if sent := len(transport.sent()); sent != 2 {
+		t.Errorf("%d requests, expected 2", sent)
+	}
+}
+
+func TestWithNegativeCacheTTL(t *testing.T) {
+	if _, err := NewClient(WithNegativeCache(0)); err == nil {
+		t.Error("a zero ttl was accepted")
+	}
+}
@@ -0,0 +1,289 @@
+package geomap
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+/*
+	AddressNormalizer reduces an address to a canonical form, addresses written
+	differently but meaning the same place should normalize to the same string
+*/
+type AddressNormalizer func(address string) string
+
+// latin letters with diacritics folded to their base letter
+var diacritics = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'ď': "d", 'đ': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'ŕ': "r", 'ř': "r",
+	'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss",
+	'ť': "t", 'ţ': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+/*
+	NormalizeAddress is the default AddressNormalizer: it folds case, full width
+	characters and latin diacritics, and turns punctuation and runs of spaces into
+	single spaces, so "Jl. Sudirman 1, jakarta" and "JL SUDIRMAN 1 JAKARTA" are equal
+*/
+func NormalizeAddress(address string) string {
+
+	var normalized strings.Builder
+	space := false
+
+	for _, r := range strings.ToLower(address) {
+
+		//full width forms used by CJK keyboards, e.g. "１２３" is "123"
+		if r >= '！' && r <= '～' {
+			r = unicode.ToLower(r - '！' + '!')
+		}
+
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			//combining marks left by decomposed input
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && normalized.Len() > 0 {
+				normalized.WriteByte(' ')
+			}
+			space = false
+			if folded, ok := diacritics[r]; ok {
+				normalized.WriteString(folded)
+			} else {
+				normalized.WriteRune(r)
+			}
+		default:
+			space = true
+		}
+	}
+
+	return normalized.String()
+}
+
+/*
+	ExpandAbbreviations returns a NormalizeAddress also replacing the words
+	of abbreviations (lower case, without punctuation) by their expansion
+*/
+func ExpandAbbreviations(abbreviations map[string]string) AddressNormalizer {
+	return func(address string) string {
+
+		words := strings.Fields(NormalizeAddress(address))
+		for i, word := range words {
+			if expanded, ok := abbreviations[word]; ok {
+				words[i] = expanded
+			}
+		}
+
+		return strings.Join(words, " ")
+	}
+}
+
+var (
+	abbreviationsMu sync.RWMutex
+	abbreviations   = map[string]map[string]string{
+		"ID": {
+			"jl": "jalan", "jln": "jalan", "gg": "gang", "no": "nomor", "jend": "jenderal",
+			"kel": "kelurahan", "kec": "kecamatan", "kab": "kabupaten", "prov": "provinsi", "prop": "provinsi",
+			"ds": "desa", "dsn": "dusun", "kp": "kampung", "kav": "kavling", "blk": "blok",
+			"komp": "kompleks", "perum": "perumahan",
+		},
+		"US": {
+			"st": "street", "ave": "avenue", "rd": "road", "blvd": "boulevard", "dr": "drive",
+			"ln": "lane", "ct": "court", "hwy": "highway", "ste": "suite", "apt": "apartment",
+			"n": "north", "s": "south", "e": "east", "w": "west",
+		},
+		"GB": {
+			"st": "street", "rd": "road", "ave": "avenue", "ln": "lane", "sq": "square", "cres": "crescent",
+		},
+	}
+
+	addressNormalizersMu sync.RWMutex
+	addressNormalizers   = map[string]AddressNormalizer{
+		"ID": normalizeIndonesianAddress,
+	}
+)
+
+/*
+	RegisterAbbreviations adds abbreviations (lower case, without punctuation) and their
+	expansion to the dictionary of region, used by its default normalizer and so by the
+	cache keys of its geocodes and MatchAddress. A region without a dictionary gets one
+*/
+func RegisterAbbreviations(region string, expansions map[string]string) {
+
+	abbreviationsMu.Lock()
+	defer abbreviationsMu.Unlock()
+
+	region = strings.ToUpper(region)
+	dictionary, ok := abbreviations[region]
+	if !ok {
+		dictionary = map[string]string{}
+		abbreviations[region] = dictionary
+	}
+
+	for abbreviation, expansion := range expansions {
+		dictionary[abbreviation] = expansion
+	}
+}
+
+// expandRegionAbbreviations is NormalizeAddress expanding the abbreviations of the dictionary of region
+func expandRegionAbbreviations(region, address string) string {
+
+	words := strings.Fields(NormalizeAddress(address))
+
+	abbreviationsMu.RLock()
+	dictionary := abbreviations[region]
+	for i, word := range words {
+		if expanded, ok := dictionary[word]; ok {
+			words[i] = expanded
+		}
+	}
+	abbreviationsMu.RUnlock()
+
+	return strings.Join(words, " ")
+}
+
+/*
+	normalizeIndonesianAddress expands the ID abbreviations and writes the neighbourhood
+	units the same way, "RT.003/RW.05", "rt 3 rw 5" and "RT/RW 03/005" are all "rt 3 rw 5"
+*/
+func normalizeIndonesianAddress(address string) string {
+
+	words := strings.Fields(expandRegionAbbreviations("ID", address))
+
+	var normalized []string
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+
+		//"rt rw 003 005"
+		if word == "rt" && i+3 < len(words) && words[i+1] == "rw" && isNumber(words[i+2]) && isNumber(words[i+3]) {
+			normalized = append(normalized, "rt", trimZeros(words[i+2]), "rw", trimZeros(words[i+3]))
+			i += 3
+			continue
+		}
+
+		//"rt 003" or "rt003"
+		if unit := unitPrefix(word); unit != "" {
+			if number := strings.TrimPrefix(word, unit); number != "" {
+				normalized = append(normalized, unit, trimZeros(number))
+				continue
+			}
+			if i+1 < len(words) && isNumber(words[i+1]) {
+				normalized = append(normalized, unit, trimZeros(words[i+1]))
+				i++
+				continue
+			}
+		}
+
+		normalized = append(normalized, word)
+	}
+
+	return strings.Join(normalized, " ")
+}
+
+// unitPrefix returns "rt" or "rw" when word is one of them, alone or followed by a number
+func unitPrefix(word string) string {
+
+	for _, unit := range []string{"rt", "rw"} {
+		if word == unit || (strings.HasPrefix(word, unit) && isNumber(word[len(unit):])) {
+			return unit
+		}
+	}
+
+	return ""
+}
+
+func isNumber(word string) bool {
+	return word != "" && strings.IndexFunc(word, func(r rune) bool { return r < '0' || r > '9' }) < 0
+}
+
+// trimZeros drops the leading zeros of a number, keeping a single 0
+func trimZeros(number string) string {
+
+	if trimmed := strings.TrimLeft(number, "0"); trimmed != "" {
+		return trimmed
+	}
+	return "0"
+}
+
+/*
+	RegisterAddressNormalizer sets the normalizer of the addresses of region, the
+	ccTLD style code of the "region" param or the country of the "components" param,
+	over its dictionary (see RegisterAbbreviations). The other regions use NormalizeAddress
+*/
+func RegisterAddressNormalizer(region string, normalizer AddressNormalizer) {
+	addressNormalizersMu.Lock()
+	addressNormalizers[strings.ToUpper(region)] = normalizer
+	addressNormalizersMu.Unlock()
+}
+
+// addressNormalizer returns the normalizer of region
+func addressNormalizer(region string) AddressNormalizer {
+
+	addressNormalizersMu.RLock()
+	normalizer, ok := addressNormalizers[region]
+	addressNormalizersMu.RUnlock()
+
+	if ok {
+		return normalizer
+	}
+
+	abbreviationsMu.RLock()
+	_, ok = abbreviations[region]
+	abbreviationsMu.RUnlock()
+
+	if ok {
+		return func(address string) string { return expandRegionAbbreviations(region, address) }
+	}
+
+	return NormalizeAddress
+}
+
+/*
+	normalizedQuery returns the query of a geocode with its address normalized,
+	so addresses written differently share their cache entry
+*/
+func normalizedQuery(endpoint Endpoint, query url.Values) url.Values {
+
+	address := query.Get("address")
+	if endpoint != EndpointGeocode || address == "" {
+		return query
+	}
+
+	normalized := url.Values{}
+	for key, values := range query {
+		normalized[key] = values
+	}
+	normalized.Set("address", addressNormalizer(addressRegion(query))(address))
+
+	return normalized
+}
+
+// addressRegion is the region biasing a geocode, from "region" or a single country of "components"
+func addressRegion(query url.Values) string {
+
+	if region := query.Get("region"); region != "" {
+		return strings.ToUpper(region)
+	}
+
+	region := ""
+	for _, component := range strings.Split(query.Get("components"), "|") {
+		if strings.HasPrefix(component, "country:") {
+			if region != "" {
+				return ""
+			}
+			region = strings.ToUpper(strings.TrimPrefix(component, "country:"))
+		}
+	}
+
+	return region
+}
@@ -0,0 +1,59 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	defaultPageTokenRetries = 3
+	defaultPageTokenDelay   = time.Second
+)
+
+/*
+	WithPageTokenRetry sets how a pagetoken google doesn't accept yet is retried. A
+	next_page_token only becomes valid a couple of seconds after its page was returned,
+	until then google answers INVALID_REQUEST, so the request is sent again up to
+	maxRetries times, delay apart. Defaults to 3 retries a second apart, which fits the
+	default lambda timeout, 0 retries fails right away with the InvalidRequestError
+*/
+func WithPageTokenRetry(maxRetries int, delay time.Duration) ClientOption {
+	return func(c *Client) error {
+
+		if maxRetries < 0 || delay < 0 {
+			return errors.New("page token retry limits must not be negative")
+		}
+
+		c.pageTokenRetries = maxRetries
+		c.pageTokenDelay = delay
+		return nil
+	}
+}
+
+/*
+	waitPageToken waits before the request of params, answered INVALID_REQUEST after attempt
+	retries, is sent again when its pagetoken may not be valid yet and retries are left,
+	telling whether to retry. The wait is recorded in the context metadata
+*/
+func (c *Client) waitPageToken(ctx context.Context, params map[string]string, attempt int) (bool, error) {
+
+	if params["pagetoken"] == "" || attempt >= c.pageTokenRetries {
+		return false, nil
+	}
+
+	timer := time.NewTimer(c.pageTokenDelay)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return false, ctx.Err()
+	case <-timer.C:
+	}
+
+	recordMetadata(ctx, func(meta *Metadata) {
+		meta.PageTokenRetries++
+		meta.PageTokenWait += c.pageTokenDelay
+	})
+
+	return true, nil
+}
@@ -0,0 +1,60 @@
+package geomap
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+	ParamOption sets one or more query parameters on a request params map.
+	Options validate their input and return an error instead of sending
+	a malformed request to google.
+*/
+type ParamOption func(params map[string]string) error
+
+/*
+	ApplyParams runs every option against params and returns the resulting map,
+	a new map is created when params is nil
+*/
+func ApplyParams(params map[string]string, opts ...ParamOption) (map[string]string, error) {
+
+	if params == nil {
+		params = map[string]string{}
+	}
+
+	for _, opt := range opts {
+		if err := opt(params); err != nil {
+			return params, err
+		}
+	}
+
+	return params, nil
+}
+
+/*
+	ExtraParams sends params the package doesn't model yet, e.g. a parameter google just
+	added, as they are. The credentials (key, signature, client) are refused, set them
+	through the client instead, and so is a param already set to another value
+	by the request or a previous option
+*/
+func ExtraParams(extra map[string]string) ParamOption {
+	return func(params map[string]string) error {
+
+		for name, value := range extra {
+			if name == "" || strings.TrimSpace(name) != name {
+				return fmt.Errorf("invalid extra param name %q", name)
+			}
+			if redactedParams[strings.ToLower(name)] {
+				return fmt.Errorf("extra param %q is reserved", name)
+			}
+			if current, ok := params[name]; ok && current != value {
+				return fmt.Errorf("extra param %q conflicts with its value %q", name, current)
+			}
+		}
+
+		for name, value := range extra {
+			params[name] = value
+		}
+		return nil
+	}
+}
@@ -0,0 +1,152 @@
+package geomap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+/*
+	PlacePhotoResponse is a place photo, either the raw image (Data and ContentType)
+	or, when the client has a PhotoStore, the URL where the stored photo can be fetched
+*/
+type PlacePhotoResponse struct {
+	ContentType string `json:"content_type,omitempty"`
+	Data        []byte `json:"-"`
+	URL         string `json:"url,omitempty"`
+}
+
+/*
+	PhotoStore keeps fetched photos keyed by photo reference and size,
+	returning a URL (e.g. pre-signed S3 or CloudFront, see the awsadapter package)
+	so responses stay small and repeated fetches of the same photo aren't billed again
+*/
+type PhotoStore interface {
+	Get(ctx context.Context, key string) (photoURL string, found bool, err error)
+	Put(ctx context.Context, key string, photo PlacePhotoResponse) (photoURL string, err error)
+}
+
+// WithPhotoStore makes PlacePhoto store the photos in store and return their URL instead of the bytes
+func WithPhotoStore(store PhotoStore) ClientOption {
+	return func(c *Client) error {
+		c.photoStore = store
+		return nil
+	}
+}
+
+/*
+	PlacePhoto will return PlacePhotoResponse on success
+	params need "photoreference", "key" and "maxwidth" and/or "maxheight"
+	more references https://developers.google.com/places/web-service/photos
+*/
+func PlacePhoto(ctx context.Context, params map[string]string) (PlacePhotoResponse, error) {
+	return DefaultClient().PlacePhoto(ctx, params)
+}
+
+// PlacePhoto is the package level PlacePhoto using c
+func (c *Client) PlacePhoto(ctx context.Context, params map[string]string) (PlacePhotoResponse, error) {
+
+	if params["photoreference"] == "" {
+		return PlacePhotoResponse{}, errors.New("photoreference is required")
+	}
+	if params["maxwidth"] == "" && params["maxheight"] == "" {
+		return PlacePhotoResponse{}, errors.New("maxwidth or maxheight is required")
+	}
+
+	key := photoKey(params)
+	if c.photoProcessing != nil {
+		key += "/" + c.photoProcessing.key()
+	}
+	if c.photoStore != nil {
+		photoURL, found, err := c.photoStore.Get(ctx, key)
+		if err != nil {
+			return PlacePhotoResponse{}, err
+		}
+		if found {
+			return PlacePhotoResponse{URL: photoURL}, nil
+		}
+	}
+
+	params, apiKey, err := c.withKey(EndpointPhoto, params)
+	if err != nil {
+		return PlacePhotoResponse{}, err
+	}
+
+	query := url.Values{}
+	for key, val := range params {
+		query.Add(key, val)
+	}
+
+	photo, err := c.fetchPhoto(ctx, params, query)
+	if apiErr, ok := err.(*APIError); ok && apiKey != "" {
+		c.keyPool.Report(apiKey, EndpointPhoto, apiErr.Status)
+	}
+	if err != nil {
+		return PlacePhotoResponse{}, err
+	}
+
+	if c.photoStore == nil {
+		return photo, nil
+	}
+
+	photoURL, err := c.photoStore.Put(ctx, key, photo)
+	if err != nil {
+		return PlacePhotoResponse{}, err
+	}
+
+	return PlacePhotoResponse{ContentType: photo.ContentType, URL: photoURL}, nil
+}
+
+// fetchPhoto downloads the photo, processing it on the fly when the client is set to
+func (c *Client) fetchPhoto(ctx context.Context, params map[string]string, query url.Values) (PlacePhotoResponse, error) {
+
+	//google redirects to the image, the http client follows it
+	if c.photoProcessing == nil {
+		result, err := c.fetch(ctx, EndpointPhoto, params, query, nil)
+		if err != nil {
+			return PlacePhotoResponse{}, err
+		}
+		return PlacePhotoResponse{ContentType: result.header.Get("Content-Type"), Data: result.body}, nil
+	}
+
+	var (
+		processed   bytes.Buffer
+		contentType string
+	)
+	err := c.stream(ctx, EndpointPhoto, params, query, nil, func(body io.Reader, header http.Header, entry *JournalEntry) error {
+
+		//the photo is decoded while received, it is never held whole to be measured
+		maxSize, guard := c.responseLimit(EndpointPhoto)
+		limited := &limitedReader{r: body, remaining: maxSize}
+
+		var err error
+		contentType, err = ProcessPhoto(limited, &processed, *c.photoProcessing)
+		if limited.remaining < 0 {
+			c.tripGuard(guard, EndpointPhoto, maxSize)
+			return ErrResponseTooLarge
+		}
+		return err
+	})
+	if err != nil {
+		return PlacePhotoResponse{}, err
+	}
+
+	return PlacePhotoResponse{ContentType: contentType, Data: processed.Bytes()}, nil
+}
+
+// photoKey identifies a photo by its reference and requested size
+func photoKey(params map[string]string) string {
+
+	width, height := params["maxwidth"], params["maxheight"]
+	if width == "" {
+		width = "0"
+	}
+	if height == "" {
+		height = "0"
+	}
+
+	return params["photoreference"] + "/" + width + "x" + height
+}
@@ -0,0 +1,230 @@
+package geomap
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"sync"
+
+	//registers the gif decoder, google serves some photos as gif
+	_ "image/gif"
+)
+
+const (
+	PhotoJPEG = "image/jpeg"
+	PhotoPNG  = "image/png"
+	PhotoWebP = "image/webp"
+
+	defaultPhotoQuality = 80
+
+	//40 megapixels, decoded as RGBA it is 160MB
+	defaultPhotoMaxPixels = 40 * 1000 * 1000
+
+	//enough for the headers of the formats google serves, exif included
+	photoHeaderSize = 64 * 1024
+)
+
+/*
+	PhotoEncoder writes img to w in its format, quality goes from 1 to 100
+	and is ignored by lossless formats
+*/
+type PhotoEncoder func(w io.Writer, img image.Image, quality int) error
+
+var (
+	photoEncodersMu sync.RWMutex
+	photoEncoders   = map[string]PhotoEncoder{
+		PhotoJPEG: func(w io.Writer, img image.Image, quality int) error {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+		},
+		PhotoPNG: func(w io.Writer, img image.Image, quality int) error {
+			return png.Encode(w, img)
+		},
+		PhotoWebP: encodeWebPLossless,
+	}
+)
+
+/*
+	RegisterPhotoEncoder makes contentType available as a PhotoProcessing format,
+	replacing the encoder already registered for it.
+	JPEG, PNG and WebP are built in, WebP is lossless unless built with -tags webp
+	which encodes it lossy with libwebp (cgo) at the Quality set.
+	AVIF has no encoder here, register one to use it
+*/
+func RegisterPhotoEncoder(contentType string, encoder PhotoEncoder) {
+	photoEncodersMu.Lock()
+	photoEncoders[contentType] = encoder
+	photoEncodersMu.Unlock()
+}
+
+func photoEncoder(contentType string) (PhotoEncoder, error) {
+
+	photoEncodersMu.RLock()
+	encoder, ok := photoEncoders[contentType]
+	photoEncodersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("no photo encoder registered for %s", contentType)
+	}
+
+	return encoder, nil
+}
+
+/*
+	PhotoProcessing resizes and transcodes the photos fetched by PlacePhoto.
+	Photos are scaled down to fit in MaxWidth x MaxHeight (0 is unbounded, never scaled up)
+	and encoded to Format (PhotoJPEG by default, PhotoPNG or PhotoWebP) with Quality
+	(80 by default, ignored by PNG and lossless WebP, see RegisterPhotoEncoder).
+	Photos are decoded while being downloaded and those over MaxPixels (40 megapixels
+	by default) are rejected from their header, so a single photo can't exhaust the
+	memory of a lambda
+*/
+type PhotoProcessing struct {
+	MaxWidth  int
+	MaxHeight int
+	Format    string
+	Quality   int
+	MaxPixels int
+}
+
+// WithPhotoProcessing makes PlacePhoto resize and transcode the photos as set by p
+func WithPhotoProcessing(p PhotoProcessing) ClientOption {
+	return func(c *Client) error {
+
+		if p.MaxWidth < 0 || p.MaxHeight < 0 || p.MaxPixels < 0 {
+			return errors.New("photo dimensions must not be negative")
+		}
+		if p.Quality < 0 || p.Quality > 100 {
+			return errors.New("photo quality must be between 1 and 100")
+		}
+
+		if p.Format == "" {
+			p.Format = PhotoJPEG
+		}
+		if p.Quality == 0 {
+			p.Quality = defaultPhotoQuality
+		}
+		if p.MaxPixels == 0 {
+			p.MaxPixels = defaultPhotoMaxPixels
+		}
+
+		if _, err := photoEncoder(p.Format); err != nil {
+			return err
+		}
+
+		c.photoProcessing = &p
+		return nil
+	}
+}
+
+// key identifies the processed variants of a photo in a PhotoStore
+func (p PhotoProcessing) key() string {
+	return strconv.Itoa(p.MaxWidth) + "x" + strconv.Itoa(p.MaxHeight) + "-q" + strconv.Itoa(p.Quality) + "." + p.Format
+}
+
+/*
+	ProcessPhoto decodes the photo read from r, resizes it and encodes it to w as set by p,
+	returning the content type written
+*/
+func ProcessPhoto(r io.Reader, w io.Writer, p PhotoProcessing) (string, error) {
+
+	if p.Format == "" {
+		p.Format = PhotoJPEG
+	}
+	if p.Quality == 0 {
+		p.Quality = defaultPhotoQuality
+	}
+	if p.MaxPixels == 0 {
+		p.MaxPixels = defaultPhotoMaxPixels
+	}
+
+	encoder, err := photoEncoder(p.Format)
+	if err != nil {
+		return "", err
+	}
+
+	//Peek doesn't consume, the decoder still reads the whole photo
+	br := bufio.NewReaderSize(r, photoHeaderSize)
+	header, _ := br.Peek(photoHeaderSize)
+	config, _, err := image.DecodeConfig(bytes.NewReader(header))
+	if err != nil {
+		return "", err
+	}
+	if config.Width*config.Height > p.MaxPixels {
+		return "", fmt.Errorf("photo of %dx%d is over %d pixels", config.Width, config.Height, p.MaxPixels)
+	}
+
+	img, _, err := image.Decode(br)
+	if err != nil {
+		return "", err
+	}
+
+	if err := encoder(w, resizeToFit(img, p.MaxWidth, p.MaxHeight), p.Quality); err != nil {
+		return "", err
+	}
+
+	return p.Format, nil
+}
+
+// resizeToFit scales img down to fit in maxWidth x maxHeight, keeping its aspect ratio
+func resizeToFit(img image.Image, maxWidth, maxHeight int) image.Image {
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight && float64(maxHeight)/float64(height) < scale {
+		scale = float64(maxHeight) / float64(height)
+	}
+	if scale == 1.0 {
+		return img
+	}
+
+	dstWidth, dstHeight := int(float64(width)*scale), int(float64(height)*scale)
+	if dstWidth < 1 {
+		dstWidth = 1
+	}
+	if dstHeight < 1 {
+		dstHeight = 1
+	}
+
+	//box filter, every destination pixel averages the source pixels it covers
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0 := bounds.Min.Y + y*height/dstHeight
+		y1 := bounds.Min.Y + (y+1)*height/dstHeight
+		if y1 == y0 {
+			y1++
+		}
+
+		for x := 0; x < dstWidth; x++ {
+			x0 := bounds.Min.X + x*width/dstWidth
+			x1 := bounds.Min.X + (x+1)*width/dstWidth
+			if x1 == x0 {
+				x1++
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA64(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,47 @@
+//go:build webp && cgo
+// +build webp,cgo
+
+package geomap
+
+/*
+	#cgo LDFLAGS: -lwebp
+	#include <stdlib.h>
+	#include <webp/encode.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"unsafe"
+)
+
+// init replaces the lossless WebP encoder by the lossy one of libwebp
+func init() {
+	RegisterPhotoEncoder(PhotoWebP, encodeWebPLossy)
+}
+
+// encodeWebPLossy writes img as a lossy WebP of quality with libwebp
+func encodeWebPLossy(w io.Writer, img image.Image, quality int) error {
+
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	//libwebp reads the pixels while encoding, they can't live in go memory moved by the gc
+	pixels := C.CBytes(nrgba.Pix)
+	defer C.free(pixels)
+
+	var output *C.uint8_t
+	size := C.WebPEncodeRGBA((*C.uint8_t)(pixels), C.int(nrgba.Rect.Dx()), C.int(nrgba.Rect.Dy()),
+		C.int(nrgba.Stride), C.float(quality), &output)
+	if size == 0 {
+		return errors.New("libwebp failed to encode the photo")
+	}
+	defer C.WebPFree(unsafe.Pointer(output))
+
+	_, err := w.Write(C.GoBytes(unsafe.Pointer(output), C.int(size)))
+	return err
+}
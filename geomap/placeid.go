@@ -0,0 +1,187 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrPlaceNotFound is returned when google no longer knows a place id
+var ErrPlaceNotFound = errors.New("place not found")
+
+/*
+	RefreshPlaceID returns the current id of a stored place id.
+	Place ids can go stale, google recommends refreshing them with a details
+	request only asking for the place_id field, which is free of charge.
+	ErrPlaceNotFound is returned when the id is gone and should be removed,
+	params need the "key". The answer always comes from google, never from the cache
+	(negative or stale), the fresh one is still stored
+	more references https://developers.google.com/places/place-id#save-id
+*/
+func RefreshPlaceID(ctx context.Context, oldID string, params map[string]string) (string, error) {
+	return DefaultClient().RefreshPlaceID(ctx, oldID, params)
+}
+
+// RefreshPlaceID is the package level RefreshPlaceID using c
+func (c *Client) RefreshPlaceID(ctx context.Context, oldID string, params map[string]string) (string, error) {
+
+	refreshParams := copyParams(params)
+	refreshParams["placeid"] = oldID
+	refreshParams["fields"] = "place_id"
+
+	//a cached or negatively cached answer would keep a stale id alive or drop a valid one
+	refreshCtx := WithoutNegativeCache(context.WithValue(ctx, cacheRefreshKey{}, true))
+
+	detail, err := c.PlaceDetailV2(refreshCtx, refreshParams)
+	if err != nil {
+		return "", err
+	}
+
+	switch detail.Status {
+	case "OK":
+	case "NOT_FOUND", "ZERO_RESULTS":
+		return "", ErrPlaceNotFound
+	default:
+		return "", errors.New("place detail status " + detail.Status)
+	}
+
+	if detail.Result.PlaceID == "" {
+		return oldID, nil
+	}
+
+	return detail.Result.PlaceID, nil
+}
+
+/*
+	PlaceIDStore is implemented by the caches or databases holding place ids,
+	so refreshed ids can be written back where they are stored
+*/
+type PlaceIDStore interface {
+	UpdatePlaceID(ctx context.Context, oldID, newID string) error
+	RemovePlaceID(ctx context.Context, id string) error
+}
+
+/*
+	RefreshStoredPlaceID refreshes oldID and keeps store in sync:
+	a changed id is updated and a gone id is removed (ErrPlaceNotFound is still returned)
+*/
+func RefreshStoredPlaceID(ctx context.Context, store PlaceIDStore, oldID string, params map[string]string) (string, error) {
+	return DefaultClient().RefreshStoredPlaceID(ctx, store, oldID, params)
+}
+
+// RefreshStoredPlaceID is the package level RefreshStoredPlaceID using c
+func (c *Client) RefreshStoredPlaceID(ctx context.Context, store PlaceIDStore, oldID string, params map[string]string) (string, error) {
+
+	newID, err := c.RefreshPlaceID(ctx, oldID, params)
+	if err == ErrPlaceNotFound {
+		if removeErr := store.RemovePlaceID(ctx, oldID); removeErr != nil {
+			return "", removeErr
+		}
+		return "", err
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if newID != oldID {
+		if err := store.UpdatePlaceID(ctx, oldID, newID); err != nil {
+			return newID, err
+		}
+	}
+
+	return newID, nil
+}
+
+// the outcomes of validating a stored place id
+const (
+	PlaceIDValid    = "valid"
+	PlaceIDRenamed  = "renamed"
+	PlaceIDNotFound = "not_found"
+	PlaceIDFailed   = "failed"
+)
+
+const defaultValidateConcurrency = 5
+
+/*
+	ValidateOptions configures ValidatePlaceIDs, Params are sent with every refresh
+	and need at least the "key", Concurrency bounds the requests in flight (5 by default)
+*/
+type ValidateOptions struct {
+	Params      map[string]string
+	Concurrency int
+}
+
+/*
+	PlaceIDValidation is the outcome of a stored place id: still valid, renamed to NewID,
+	not found (to be removed) or failed with Err, e.g. on a quota error, to be retried
+*/
+type PlaceIDValidation struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	NewID  string `json:"new_id,omitempty"`
+	Err    error  `json:"-"`
+}
+
+/*
+	ValidatePlaceIDs refreshes every stored place id with the free refresh call of
+	RefreshPlaceID and returns their outcomes in the order of ids, for database
+	hygiene jobs. Requests go through the client rate limiter, a failed id doesn't
+	stop the others
+*/
+func ValidatePlaceIDs(ctx context.Context, ids []string, opts ValidateOptions) ([]PlaceIDValidation, error) {
+	return DefaultClient().ValidatePlaceIDs(ctx, ids, opts)
+}
+
+// ValidatePlaceIDs is the package level ValidatePlaceIDs using c
+func (c *Client) ValidatePlaceIDs(ctx context.Context, ids []string, opts ValidateOptions) ([]PlaceIDValidation, error) {
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultValidateConcurrency
+	}
+
+	validations := make([]PlaceIDValidation, len(ids))
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+
+	for i, id := range ids {
+
+		validations[i].ID = id
+		if id == "" {
+			validations[i].Status, validations[i].Err = PlaceIDFailed, errors.New("empty place id")
+			continue
+		}
+
+		wg.Add(1)
+		go func(validation *PlaceIDValidation) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				validation.Status, validation.Err = PlaceIDFailed, ctx.Err()
+				return
+			}
+
+			newID, err := c.RefreshPlaceID(ctx, validation.ID, opts.Params)
+			switch {
+			case err == ErrPlaceNotFound:
+				validation.Status = PlaceIDNotFound
+			case err != nil:
+				validation.Status, validation.Err = PlaceIDFailed, err
+			case newID != validation.ID:
+				validation.Status, validation.NewID = PlaceIDRenamed, newID
+			default:
+				validation.Status = PlaceIDValid
+			}
+		}(&validations[i])
+	}
+
+	wg.Wait()
+
+	return validations, ctx.Err()
+}
@@ -0,0 +1,308 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// the widest photo google serves
+	maxPhotoWidth = 1600
+
+	// a next page token is only accepted a few seconds after google issued it
+	pageTokenRetries = 3
+	pageTokenWait    = 2 * time.Second
+)
+
+// params of a search carried over to the details and photos of its places
+var carriedParams = []string{"key", "language", "region"}
+
+/*
+	Places is a page of search results whose entries load their details and photos
+	on demand, so application code follows the places instead of shuttling their
+	place_id between calls. Next and Each walk the following pages
+*/
+type Places struct {
+	Entries       []*PlaceEntry
+	NextPageToken string
+
+	client   *Client
+	endpoint Endpoint
+	params   map[string]string
+}
+
+/*
+	PlaceEntry is a place of Places, Details and Photos call google the first time
+	they are asked for and then answer from what they loaded
+*/
+type PlaceEntry struct {
+	Place
+
+	client *Client
+	params map[string]string
+
+	mu      sync.Mutex
+	details map[string]Place
+	photos  []PlacePhotoResponse
+}
+
+/*
+	SearchNearby runs PlaceNearbyV2 and returns its results as Places
+	params need "location", "radius" (or "rankby") and "key"
+*/
+func SearchNearby(ctx context.Context, params map[string]string) (*Places, error) {
+	return DefaultClient().SearchNearby(ctx, params)
+}
+
+// SearchNearby is the package level SearchNearby using c
+func (c *Client) SearchNearby(ctx context.Context, params map[string]string) (*Places, error) {
+
+	resp, err := c.PlaceNearbyV2(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.searchPlaces(EndpointNearbySearch, params, resp)
+}
+
+/*
+	SearchText runs TextSearch and returns its results as Places
+	params need "query" and "key"
+*/
+func SearchText(ctx context.Context, params map[string]string) (*Places, error) {
+	return DefaultClient().SearchText(ctx, params)
+}
+
+// SearchText is the package level SearchText using c
+func (c *Client) SearchText(ctx context.Context, params map[string]string) (*Places, error) {
+
+	resp, err := c.TextSearch(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.searchPlaces(EndpointTextSearch, params, resp)
+}
+
+/*
+	NewPlaces wraps places found otherwise (find place candidates, stored results...)
+	as Places without following pages, params give the "key" of their details
+*/
+func (c *Client) NewPlaces(places []Place, params map[string]string) *Places {
+
+	p := &Places{client: c, params: params}
+	for _, place := range places {
+		p.Entries = append(p.Entries, c.newPlaceEntry(place, params))
+	}
+
+	return p
+}
+
+// searchPlaces wraps a search response
+func (c *Client) searchPlaces(endpoint Endpoint, params map[string]string, resp NearbySearchResponseV2) (*Places, error) {
+
+	if resp.Status != "OK" && resp.Status != "ZERO_RESULTS" {
+		return nil, errors.New(resp.Status)
+	}
+
+	p := c.NewPlaces(resp.Results, params)
+	p.endpoint = endpoint
+	p.NextPageToken = resp.NextPageToken
+
+	return p, nil
+}
+
+func (c *Client) newPlaceEntry(place Place, params map[string]string) *PlaceEntry {
+
+	carried := map[string]string{}
+	for _, param := range carriedParams {
+		if val := params[param]; val != "" {
+			carried[param] = val
+		}
+	}
+
+	return &PlaceEntry{Place: place, client: c, params: carried}
+}
+
+// Len returns the number of places of the page
+func (p *Places) Len() int {
+	return len(p.Entries)
+}
+
+// PlaceIDs returns the place_id of every place of the page
+func (p *Places) PlaceIDs() []string {
+
+	ids := make([]string, len(p.Entries))
+	for i, entry := range p.Entries {
+		ids[i] = entry.PlaceID
+	}
+
+	return ids
+}
+
+// HasNext reports whether google has a next page
+func (p *Places) HasNext() bool {
+	return p.NextPageToken != "" && p.endpoint != ""
+}
+
+/*
+	Next returns the next page, nil when there is none. The page token of google
+	only becomes valid a few seconds after it is issued, Next waits for it
+*/
+func (p *Places) Next(ctx context.Context) (*Places, error) {
+
+	if !p.HasNext() {
+		return nil, nil
+	}
+
+	//the search params are replaced by the token, only the credentials remain
+	params := map[string]string{"pagetoken": p.NextPageToken}
+	for _, param := range carriedParams {
+		if val := p.params[param]; val != "" {
+			params[param] = val
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+
+		var next *Places
+		var err error
+		if p.endpoint == EndpointTextSearch {
+			next, err = p.client.SearchText(ctx, params)
+		} else {
+			next, err = p.client.SearchNearby(ctx, params)
+		}
+
+		if _, notReady := err.(*InvalidRequestError); !notReady || attempt == pageTokenRetries {
+			return next, err
+		}
+
+		select {
+		case <-time.After(pageTokenWait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+/*
+	Each calls fn with every place of the page and of the following ones, up to maxPages
+	pages (google serves at most 3), until fn returns false
+*/
+func (p *Places) Each(ctx context.Context, maxPages int, fn func(entry *PlaceEntry) bool) error {
+
+	page := p
+	for pages := 0; page != nil && pages < maxPages; pages++ {
+
+		for _, entry := range page.Entries {
+			if !fn(entry) {
+				return nil
+			}
+		}
+
+		var err error
+		if page, err = page.Next(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+	Details returns the place with its details fields (e.g. "website", "opening_hours",
+	every field when empty) merged over its search fields. They are loaded once per
+	set of fields, through the client rate limiter and cache
+*/
+func (e *PlaceEntry) Details(ctx context.Context, fields ...string) (Place, error) {
+
+	if e.PlaceID == "" {
+		return Place{}, errors.New("place has no place_id")
+	}
+
+	sorted := append([]string(nil), fields...)
+	sort.Strings(sorted)
+	key := strings.Join(sorted, ",")
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if place, ok := e.details[key]; ok {
+		return place, nil
+	}
+
+	params := copyParams(e.params)
+	params["place_id"] = e.PlaceID
+	if key != "" {
+		params["fields"] = key
+	}
+
+	details, err := e.client.PlaceDetailV2(ctx, params)
+	if err == nil && details.Status != "OK" {
+		err = errors.New(details.Status)
+	}
+	if err != nil {
+		return Place{}, err
+	}
+
+	place := e.Place
+	mergePlace(&place, details.Result)
+
+	if e.details == nil {
+		e.details = map[string]Place{}
+	}
+	e.details[key] = place
+
+	return place, nil
+}
+
+/*
+	Photos fetches the photos of the place at their own size (up to the 1600px google serves),
+	the photo references come from the search result, else from the details of the place.
+	They are fetched once, a PhotoStore on the client makes them URLs instead of bytes
+*/
+func (e *PlaceEntry) Photos(ctx context.Context) ([]PlacePhotoResponse, error) {
+
+	photos := e.Place.Photos
+	if len(photos) == 0 {
+		place, err := e.Details(ctx, "photos")
+		if err != nil {
+			return nil, err
+		}
+		photos = place.Photos
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.photos != nil {
+		return e.photos, nil
+	}
+
+	fetched := make([]PlacePhotoResponse, 0, len(photos))
+	for _, photo := range photos {
+
+		width := photo.Width
+		if width <= 0 || width > maxPhotoWidth {
+			width = maxPhotoWidth
+		}
+
+		params := copyParams(e.params)
+		params["photoreference"] = photo.PhotoReference
+		params["maxwidth"] = strconv.Itoa(width)
+
+		resp, err := e.client.PlacePhoto(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		fetched = append(fetched, resp)
+	}
+
+	e.photos = fetched
+	return fetched, nil
+}
@@ -0,0 +1,92 @@
+/*
+	Package places serves nearby search, text search and place details through one
+	Backend, backed by the legacy Places API of geomap or the Places API (New) of
+	placesv1, so callers can migrate (and compare both) by changing their configuration.
+	Results are always the geomap.Place model and fields are named as in the legacy api
+*/
+package places
+
+import (
+	"context"
+	"fmt"
+
+	"gomapservice/geomap"
+)
+
+// backend modes of Select
+const (
+	ModeLegacy = "legacy"
+	ModeNew    = "new"
+	ModeShadow = "shadow"
+)
+
+// DefaultFields are the fields returned when a request names none
+var DefaultFields = []string{"place_id", "name", "formatted_address", "geometry", "types"}
+
+/*
+	NearbyRequest searches places of Type within Radius meters of Location,
+	Keyword is only supported by the legacy backend
+*/
+type NearbyRequest struct {
+	Location   geomap.GoogleLocation
+	Radius     float64
+	Type       string
+	Keyword    string
+	Language   string
+	MaxResults int
+	Fields     []string
+}
+
+// TextRequest searches places matching Query, biased toward Location when set
+type TextRequest struct {
+	Query      string
+	Location   *geomap.GoogleLocation
+	Radius     float64
+	Type       string
+	OpenNow    bool
+	Language   string
+	MaxResults int
+	Fields     []string
+}
+
+// DetailsRequest configures a details call, SessionToken ends an autocomplete session
+type DetailsRequest struct {
+	Fields       []string
+	Language     string
+	SessionToken string
+}
+
+// Backend is a Places API serving the searches and details
+type Backend interface {
+	Nearby(ctx context.Context, req NearbyRequest) ([]geomap.Place, error)
+	Text(ctx context.Context, req TextRequest) ([]geomap.Place, error)
+	Details(ctx context.Context, placeID string, req DetailsRequest) (geomap.Place, error)
+}
+
+/*
+	Select returns the backend of mode: legacy, new, or shadow which answers from legacy
+	while calling new alongside and reporting the parity of both (logged when report is nil)
+*/
+func Select(mode string, legacy, next Backend, report func(Parity)) (Backend, error) {
+
+	switch mode {
+	case ModeLegacy, "":
+		return legacy, nil
+	case ModeNew:
+		return next, nil
+	case ModeShadow:
+		return Shadow(legacy, next, report), nil
+	}
+
+	return nil, fmt.Errorf("unknown places backend mode %q", mode)
+}
+
+// truncate keeps the first max places, all of them when max is 0
+func truncate(places []geomap.Place, max int) []geomap.Place {
+
+	if max > 0 && len(places) > max {
+		return places[:max]
+	}
+
+	return places
+}
@@ -0,0 +1,125 @@
+package places
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gomapservice/geomap"
+)
+
+type legacyBackend struct {
+	api geomap.API
+	key string
+}
+
+/*
+	Legacy is the Backend of the legacy Places API called through api,
+	key is sent with every call unless empty, e.g. when the client has a key pool
+*/
+func Legacy(api geomap.API, key string) Backend {
+	return &legacyBackend{api: api, key: key}
+}
+
+func (b *legacyBackend) Nearby(ctx context.Context, req NearbyRequest) ([]geomap.Place, error) {
+
+	params := b.params(req.Language)
+	params["location"] = formatLocation(req.Location)
+	params["radius"] = strconv.FormatFloat(req.Radius, 'f', -1, 64)
+	if req.Type != "" {
+		params["type"] = req.Type
+	}
+	if req.Keyword != "" {
+		params["keyword"] = req.Keyword
+	}
+
+	resp, err := b.api.PlaceNearbyV2(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatus(resp.Status, resp.ErrorMessage); err != nil {
+		return nil, err
+	}
+
+	return truncate(resp.Results, req.MaxResults), nil
+}
+
+func (b *legacyBackend) Text(ctx context.Context, req TextRequest) ([]geomap.Place, error) {
+
+	params := b.params(req.Language)
+	params["query"] = req.Query
+	if req.Location != nil {
+		params["location"] = formatLocation(*req.Location)
+		params["radius"] = strconv.FormatFloat(req.Radius, 'f', -1, 64)
+	}
+	if req.Type != "" {
+		params["type"] = req.Type
+	}
+	if req.OpenNow {
+		params["opennow"] = "true"
+	}
+
+	resp, err := b.api.TextSearch(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatus(resp.Status, resp.ErrorMessage); err != nil {
+		return nil, err
+	}
+
+	return truncate(resp.Results, req.MaxResults), nil
+}
+
+func (b *legacyBackend) Details(ctx context.Context, placeID string, req DetailsRequest) (geomap.Place, error) {
+
+	fields := req.Fields
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+
+	params := b.params(req.Language)
+	params["placeid"] = placeID
+	params["fields"] = strings.Join(fields, ",")
+	if req.SessionToken != "" {
+		params["sessiontoken"] = req.SessionToken
+	}
+
+	resp, err := b.api.PlaceDetailV2(ctx, params)
+	if err != nil {
+		return geomap.Place{}, err
+	}
+	if err := checkStatus(resp.Status, resp.ErrorMessage); err != nil {
+		return geomap.Place{}, err
+	}
+
+	return resp.Result, nil
+}
+
+func (b *legacyBackend) params(language string) map[string]string {
+
+	params := map[string]string{}
+	if b.key != "" {
+		params["key"] = b.key
+	}
+	if language != "" {
+		params["language"] = language
+	}
+
+	return params
+}
+
+// checkStatus turns a status other than OK and ZERO_RESULTS into an error, as the new api does
+func checkStatus(status, message string) error {
+
+	if status == "OK" || status == "ZERO_RESULTS" {
+		return nil
+	}
+
+	//google answers these with http 200
+	return &geomap.APIError{HTTPStatus: http.StatusOK, Status: status, ErrorMessage: message}
+}
+
+func formatLocation(location geomap.GoogleLocation) string {
+	return strconv.FormatFloat(location.Lat, 'f', 6, 64) + "," + strconv.FormatFloat(location.Lng, 'f', 6, 64)
+}
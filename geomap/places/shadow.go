@@ -0,0 +1,148 @@
+package places
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"gomapservice/geomap"
+)
+
+/*
+	Parity compares the answers of the primary and shadow backends to one call by
+	their place ids: Missing are returned by the primary only, Extra by the shadow only
+*/
+type Parity struct {
+	Operation       string
+	Primary         []string
+	Shadow          []string
+	Missing         []string
+	Extra           []string
+	PrimaryErr      error
+	ShadowErr       error
+	PrimaryDuration time.Duration
+	ShadowDuration  time.Duration
+}
+
+// Match reports whether both backends succeeded and returned the same places, in any order
+func (p Parity) Match() bool {
+	return p.PrimaryErr == nil && p.ShadowErr == nil && len(p.Missing) == 0 && len(p.Extra) == 0
+}
+
+type shadowBackend struct {
+	primary Backend
+	shadow  Backend
+	report  func(Parity)
+}
+
+/*
+	Shadow answers from primary while sending every call to shadow concurrently,
+	report receives the parity of both once they answered (logged when nil).
+	It doubles the cost of the calls, enable it on a sample of the traffic
+*/
+func Shadow(primary, shadow Backend, report func(Parity)) Backend {
+
+	if report == nil {
+		report = logParity
+	}
+
+	return &shadowBackend{primary: primary, shadow: shadow, report: report}
+}
+
+func (b *shadowBackend) Nearby(ctx context.Context, req NearbyRequest) ([]geomap.Place, error) {
+	return b.compare("nearby", func(backend Backend) ([]geomap.Place, error) {
+		return backend.Nearby(ctx, req)
+	})
+}
+
+func (b *shadowBackend) Text(ctx context.Context, req TextRequest) ([]geomap.Place, error) {
+	return b.compare("text", func(backend Backend) ([]geomap.Place, error) {
+		return backend.Text(ctx, req)
+	})
+}
+
+func (b *shadowBackend) Details(ctx context.Context, placeID string, req DetailsRequest) (geomap.Place, error) {
+
+	places, err := b.compare("details", func(backend Backend) ([]geomap.Place, error) {
+		place, err := backend.Details(ctx, placeID, req)
+		if err != nil {
+			return nil, err
+		}
+		return []geomap.Place{place}, nil
+	})
+	if err != nil {
+		return geomap.Place{}, err
+	}
+
+	return places[0], nil
+}
+
+/*
+	compare calls both backends concurrently and waits for both, so no call is
+	left running once the handler returned, and returns the answer of the primary
+*/
+func (b *shadowBackend) compare(operation string, call func(backend Backend) ([]geomap.Place, error)) ([]geomap.Place, error) {
+
+	parity := Parity{Operation: operation}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		start := time.Now()
+		places, err := call(b.shadow)
+		parity.Shadow, parity.ShadowErr, parity.ShadowDuration = placeIDs(places), err, time.Since(start)
+	}()
+
+	start := time.Now()
+	places, err := call(b.primary)
+	parity.Primary, parity.PrimaryErr, parity.PrimaryDuration = placeIDs(places), err, time.Since(start)
+
+	wg.Wait()
+
+	parity.Missing = difference(parity.Primary, parity.Shadow)
+	parity.Extra = difference(parity.Shadow, parity.Primary)
+	b.report(parity)
+
+	return places, err
+}
+
+func placeIDs(places []geomap.Place) []string {
+
+	ids := make([]string, len(places))
+	for i, place := range places {
+		ids[i] = place.PlaceID
+	}
+
+	return ids
+}
+
+// difference returns the ids of a missing from b
+func difference(a, b []string) []string {
+
+	found := make(map[string]bool, len(b))
+	for _, id := range b {
+		found[id] = true
+	}
+
+	var missing []string
+	for _, id := range a {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing
+}
+
+func logParity(p Parity) {
+
+	if p.Match() {
+		return
+	}
+
+	log.Printf("places: %s parity mismatch: missing %v, extra %v, primary error %v, shadow error %v",
+		p.Operation, p.Missing, p.Extra, p.PrimaryErr, p.ShadowErr)
+}
@@ -0,0 +1,260 @@
+package places
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gomapservice/geomap"
+	"gomapservice/geomap/placesv1"
+)
+
+// v1Fields maps the legacy field names to the fields of the Places API (New)
+var v1Fields = map[string][]string{
+	"address_components":         {"addressComponents"},
+	"business_status":            {"businessStatus"},
+	"formatted_address":          {"formattedAddress"},
+	"formatted_phone_number":     {"nationalPhoneNumber"},
+	"geometry":                   {"location", "viewport"},
+	"international_phone_number": {"internationalPhoneNumber"},
+	"name":                       {"displayName"},
+	"opening_hours":              {"regularOpeningHours"},
+	"photos":                     {"photos"},
+	"place_id":                   {"id"},
+	"plus_code":                  {"plusCode"},
+	"price_level":                {"priceLevel"},
+	"rating":                     {"rating"},
+	"reviews":                    {"reviews"},
+	"types":                      {"types"},
+	"url":                        {"googleMapsUri"},
+	"user_ratings_total":         {"userRatingCount"},
+	"utc_offset":                 {"utcOffsetMinutes"},
+	"vicinity":                   {"shortFormattedAddress"},
+	"website":                    {"websiteUri"},
+}
+
+var priceLevels = map[string]int{
+	"PRICE_LEVEL_FREE":           0,
+	"PRICE_LEVEL_INEXPENSIVE":    1,
+	"PRICE_LEVEL_MODERATE":       2,
+	"PRICE_LEVEL_EXPENSIVE":      3,
+	"PRICE_LEVEL_VERY_EXPENSIVE": 4,
+}
+
+type v1Backend struct {
+	client *placesv1.Client
+}
+
+// New is the Backend of the Places API (New) called through client
+func New(client *placesv1.Client) Backend {
+	return &v1Backend{client: client}
+}
+
+func (b *v1Backend) Nearby(ctx context.Context, req NearbyRequest) ([]geomap.Place, error) {
+
+	if req.Keyword != "" {
+		return nil, errors.New("keyword isn't supported by the nearby search of the places api (new), use a text search")
+	}
+
+	fields, err := toV1Fields(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	search := placesv1.SearchNearbyRequest{
+		MaxResultCount: req.MaxResults,
+		LanguageCode:   req.Language,
+		LocationRestriction: placesv1.LocationArea{Circle: &placesv1.Circle{
+			Center: placesv1.LatLng{Latitude: req.Location.Lat, Longitude: req.Location.Lng},
+			Radius: req.Radius,
+		}},
+	}
+	if req.Type != "" {
+		search.IncludedTypes = []string{req.Type}
+	}
+
+	resp, err := b.client.SearchNearby(ctx, search, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromV1Places(resp.Places), nil
+}
+
+func (b *v1Backend) Text(ctx context.Context, req TextRequest) ([]geomap.Place, error) {
+
+	fields, err := toV1Fields(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	search := placesv1.SearchTextRequest{
+		TextQuery:    req.Query,
+		IncludedType: req.Type,
+		OpenNow:      req.OpenNow,
+		PageSize:     req.MaxResults,
+		LanguageCode: req.Language,
+	}
+	if req.Location != nil {
+		search.LocationBias = &placesv1.LocationArea{Circle: &placesv1.Circle{
+			Center: placesv1.LatLng{Latitude: req.Location.Lat, Longitude: req.Location.Lng},
+			Radius: req.Radius,
+		}}
+	}
+
+	resp, err := b.client.SearchText(ctx, search, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return truncate(fromV1Places(resp.Places), req.MaxResults), nil
+}
+
+func (b *v1Backend) Details(ctx context.Context, placeID string, req DetailsRequest) (geomap.Place, error) {
+
+	fields, err := toV1Fields(req.Fields)
+	if err != nil {
+		return geomap.Place{}, err
+	}
+
+	opts := placesv1.DetailsOptions{LanguageCode: req.Language, SessionToken: req.SessionToken}
+
+	place, err := b.client.GetPlace(ctx, placeID, opts, fields)
+	if err != nil {
+		return geomap.Place{}, err
+	}
+
+	return fromV1Place(place), nil
+}
+
+// toV1Fields translates legacy field names to a field mask of the new api
+func toV1Fields(fields []string) ([]string, error) {
+
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+
+	var translated []string
+	for _, field := range fields {
+		v1, ok := v1Fields[field]
+		if !ok {
+			return nil, fmt.Errorf("field %q has no equivalent in the places api (new)", field)
+		}
+		translated = append(translated, v1...)
+	}
+
+	return translated, nil
+}
+
+func fromV1Places(places []placesv1.Place) []geomap.Place {
+
+	converted := make([]geomap.Place, len(places))
+	for i, place := range places {
+		converted[i] = fromV1Place(place)
+	}
+
+	return converted
+}
+
+/*
+	fromV1Place converts a place of the new api to the geomap model,
+	photo references are the photo resource names of the new api
+*/
+func fromV1Place(place placesv1.Place) geomap.Place {
+
+	converted := geomap.Place{
+		PlaceID:                  place.ID,
+		FormattedAddress:         place.FormattedAddress,
+		Vicinity:                 place.ShortFormattedAddress,
+		Types:                    place.Types,
+		Rating:                   place.Rating,
+		UserRatingsTotal:         place.UserRatingCount,
+		PriceLevel:               priceLevels[place.PriceLevel],
+		BusinessStatus:           place.BusinessStatus,
+		FormattedPhoneNumber:     place.NationalPhoneNumber,
+		InternationalPhoneNumber: place.InternationalPhoneNumber,
+		Website:                  place.WebsiteURI,
+		URL:                      place.GoogleMapsURI,
+		PermanentlyClosed:        place.BusinessStatus == geomap.BusinessClosedPermanently,
+	}
+
+	if place.DisplayName != nil {
+		converted.Name = place.DisplayName.Text
+	}
+	if place.Location != nil {
+		converted.Geometry.Location = geomap.GoogleLocation{Lat: place.Location.Latitude, Lng: place.Location.Longitude}
+	}
+	if place.Viewport != nil {
+		converted.Geometry.Viewport = geomap.GoogleViewport{
+			Northeast: geomap.GoogleLocation{Lat: place.Viewport.High.Latitude, Lng: place.Viewport.High.Longitude},
+			SouthWest: geomap.GoogleLocation{Lat: place.Viewport.Low.Latitude, Lng: place.Viewport.Low.Longitude},
+		}
+	}
+	if place.PlusCode != nil {
+		converted.PlusCode = geomap.GooglePlusCode{GlobalCode: place.PlusCode.GlobalCode, CompoundCode: place.PlusCode.CompoundCode}
+	}
+	if place.UTCOffsetMinutes != nil {
+		converted.UtcOffset = *place.UTCOffsetMinutes
+	}
+
+	for _, component := range place.AddressComponents {
+		converted.AddressComponents = append(converted.AddressComponents, geomap.AddressComponent{
+			LongName:  component.LongText,
+			ShortName: component.ShortText,
+			Types:     component.Types,
+		})
+	}
+
+	if hours := place.RegularOpeningHours; hours != nil {
+		converted.OpeningHours = &geomap.OpeningHour{WeekdayText: hours.WeekdayDescriptions}
+		if hours.OpenNow != nil {
+			converted.OpeningHours.OpenNow = *hours.OpenNow
+		}
+		for _, period := range hours.Periods {
+			converted.OpeningHours.Periods = append(converted.OpeningHours.Periods, geomap.OpeningPeriod{
+				Open: geomap.OpeningTime{Day: period.Open.Day, Time: fmt.Sprintf("%02d%02d", period.Open.Hour, period.Open.Minute)},
+			})
+		}
+	}
+
+	for _, photo := range place.Photos {
+		var attributions []string
+		for _, author := range photo.AuthorAttributions {
+			attributions = append(attributions, author.DisplayName)
+		}
+		converted.Photos = append(converted.Photos, geomap.Photo{
+			PhotoReference:   photo.Name,
+			Width:            photo.WidthPx,
+			Height:           photo.HeightPx,
+			HTMLAttributions: attributions,
+		})
+	}
+
+	for _, review := range place.Reviews {
+		converted.Reviews = append(converted.Reviews, fromV1Review(review))
+	}
+
+	return converted
+}
+
+func fromV1Review(review placesv1.Review) geomap.GooglePlaceReview {
+
+	converted := geomap.GooglePlaceReview{
+		AuthorName:              review.AuthorAttribution.DisplayName,
+		AuthorURL:               review.AuthorAttribution.URI,
+		ProfilePhotoURL:         review.AuthorAttribution.PhotoURI,
+		Rating:                  int(review.Rating),
+		RelativeTimeDescription: review.RelativePublishTimeDescription,
+	}
+
+	if review.Text != nil {
+		converted.Text = review.Text.Text
+		converted.Language = review.Text.LanguageCode
+	}
+	if published, err := time.Parse(time.RFC3339, review.PublishTime); err == nil {
+		converted.Time = int(published.Unix())
+	}
+
+	return converted
+}
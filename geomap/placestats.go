@@ -0,0 +1,155 @@
+package geomap
+
+import (
+	"math"
+	"sort"
+)
+
+/*
+	PlaceStats aggregates a result set for market analysis. RatingHistogram counts the
+	places by star (4 for 4.0 to 4.9), PriceLevels by level. A price level of 0 can't be
+	told from a missing one in google responses so it is left out of the average.
+	OpenNowShare is the share of the places with opening hours that are open,
+	SpreadMeters the mean distance of the places to their Centroid
+*/
+type PlaceStats struct {
+	Count int `json:"count"`
+
+	Rated           int         `json:"rated"`
+	AverageRating   float64     `json:"average_rating"`
+	RatingHistogram map[int]int `json:"rating_histogram"`
+	TotalRatings    int         `json:"total_ratings"`
+
+	Priced            int         `json:"priced"`
+	AveragePriceLevel float64     `json:"average_price_level"`
+	PriceLevels       map[int]int `json:"price_levels"`
+
+	WithHours    int     `json:"with_hours"`
+	OpenNowShare float64 `json:"open_now_share"`
+
+	Types []TypeCount `json:"types"`
+
+	Located           int     `json:"located"`
+	Centroid          LatLng  `json:"centroid"`
+	SpreadMeters      float64 `json:"spread_meters"`
+	MaxDistanceMeters float64 `json:"max_distance_meters"`
+}
+
+// TypeCount is how many places have a type
+type TypeCount struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// AggregatePlaces computes the stats of places, e.g. the merged pages of nearby or text searches
+func AggregatePlaces(places []Place) PlaceStats {
+
+	stats := PlaceStats{
+		Count:           len(places),
+		RatingHistogram: map[int]int{},
+		PriceLevels:     map[int]int{},
+	}
+
+	var (
+		ratingSum, priceSum float64
+		openNow             int
+		types               = map[string]int{}
+		located             []LatLng
+	)
+
+	for _, place := range places {
+
+		if place.Rating > 0 {
+			stats.Rated++
+			ratingSum += place.Rating
+			stats.RatingHistogram[int(math.Min(math.Floor(place.Rating), 5))]++
+			stats.TotalRatings += place.UserRatingsTotal
+		}
+
+		if place.PriceLevel > 0 {
+			stats.Priced++
+			priceSum += float64(place.PriceLevel)
+			stats.PriceLevels[place.PriceLevel]++
+		}
+
+		if place.OpeningHours != nil {
+			stats.WithHours++
+			if place.OpeningHours.OpenNow {
+				openNow++
+			}
+		}
+
+		for _, placeType := range place.Types {
+			types[placeType]++
+		}
+
+		//a zero location is a place without geometry, e.g. details without the field
+		if location := place.Geometry.Location; location.Lat != 0 || location.Lng != 0 {
+			located = append(located, location)
+		}
+	}
+
+	if stats.Rated > 0 {
+		stats.AverageRating = ratingSum / float64(stats.Rated)
+	}
+	if stats.Priced > 0 {
+		stats.AveragePriceLevel = priceSum / float64(stats.Priced)
+	}
+	if stats.WithHours > 0 {
+		stats.OpenNowShare = float64(openNow) / float64(stats.WithHours)
+	}
+
+	for placeType, count := range types {
+		stats.Types = append(stats.Types, TypeCount{Type: placeType, Count: count})
+	}
+	sort.Slice(stats.Types, func(i, j int) bool {
+		if stats.Types[i].Count != stats.Types[j].Count {
+			return stats.Types[i].Count > stats.Types[j].Count
+		}
+		return stats.Types[i].Type < stats.Types[j].Type
+	})
+
+	stats.Located = len(located)
+	if len(located) > 0 {
+		stats.Centroid = centroid(located)
+		for _, location := range located {
+			distance := DistanceMeters(stats.Centroid, location)
+			stats.SpreadMeters += distance
+			stats.MaxDistanceMeters = math.Max(stats.MaxDistanceMeters, distance)
+		}
+		stats.SpreadMeters /= float64(len(located))
+	}
+
+	return stats
+}
+
+// Stats computes the stats of the places of the page
+func (p *Places) Stats() PlaceStats {
+
+	places := make([]Place, len(p.Entries))
+	for i, entry := range p.Entries {
+		places[i] = entry.Place
+	}
+
+	return AggregatePlaces(places)
+}
+
+// centroid averages the locations on the sphere, so places on both sides of the antimeridian stay together
+func centroid(locations []LatLng) LatLng {
+
+	var x, y, z float64
+	for _, location := range locations {
+		lat, lng := toRadians(location.Lat), toRadians(location.Lng)
+		x += math.Cos(lat) * math.Cos(lng)
+		y += math.Cos(lat) * math.Sin(lng)
+		z += math.Sin(lat)
+	}
+
+	n := float64(len(locations))
+	x, y, z = x/n, y/n, z/n
+
+	return LatLng{
+		Lat: toDegrees(math.Atan2(z, math.Sqrt(x*x+y*y))),
+		Lng: toDegrees(math.Atan2(y, x)),
+	}
+}
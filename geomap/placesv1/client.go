@@ -0,0 +1,178 @@
+/*
+	Package placesv1 calls the Places API (New) served from places.googleapis.com/v1.
+	Unlike the legacy endpoints of geomap the requests are POSTs of json bodies,
+	the key is sent in a header and every call names the fields it wants in a field mask,
+	which also decides its SKU
+*/
+package placesv1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gomapservice/geomap"
+)
+
+// DefaultBaseURL is where the api is served unless the client is given another base
+const DefaultBaseURL = "https://places.googleapis.com"
+
+// responses bigger than this are refused, a page of 20 places with every field is far smaller
+const maxResponseSize = 8 << 20
+
+// Client calls the Places API (New) with its key
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+	key        string
+	coarsen    geomap.Coarsener
+}
+
+// ClientOption configures a Client built by NewClient
+type ClientOption func(c *Client) error
+
+// NewClient returns a Client sending key with every request
+func NewClient(key string, opts ...ClientOption) (*Client, error) {
+
+	if key == "" {
+		return nil, errors.New("places api key must not be empty")
+	}
+
+	c := &Client{httpClient: &http.Client{}, baseURL: DefaultBaseURL, key: key}
+
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// WithHTTPClient makes the client send its requests with httpClient
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) error {
+		c.httpClient = httpClient
+		return nil
+	}
+}
+
+/*
+	WithCoarsener coarsens every location of the responses with coarsen, e.g.
+	geomap.CoordinatePrecision, as geomap.WithCoordinatePrecision does for the legacy apis
+*/
+func WithCoarsener(coarsen geomap.Coarsener) ClientOption {
+	return func(c *Client) error {
+
+		if coarsen == nil {
+			return errors.New("coarsener must not be nil")
+		}
+
+		c.coarsen = coarsen
+		return nil
+	}
+}
+
+// WithBaseURL sends the requests to base instead of DefaultBaseURL, e.g. a local mock
+func WithBaseURL(base string) ClientOption {
+	return func(c *Client) error {
+
+		parsed, err := url.Parse(base)
+		if err != nil {
+			return err
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("invalid base url %q", base)
+		}
+
+		c.baseURL = strings.TrimRight(base, "/")
+		return nil
+	}
+}
+
+/*
+	call sends payload (a GET without body when nil) to path with the field mask
+	and unmarshals the json response into v, a non 200 answer is a *geomap.APIError
+*/
+func (c *Client) call(ctx context.Context, method, path string, query url.Values, fieldMask string, payload interface{}, v interface{}) error {
+
+	var body io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.URL.RawQuery = query.Encode()
+
+	req.Header.Set("X-Goog-Api-Key", c.key)
+	if fieldMask != "" {
+		req.Header.Set("X-Goog-FieldMask", fieldMask)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return err
+	}
+	if len(respBody) > maxResponseSize {
+		return geomap.ErrResponseTooLarge
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp.StatusCode, respBody)
+	}
+
+	if err := json.Unmarshal(respBody, v); err != nil {
+		return err
+	}
+
+	if c.coarsen != nil {
+		geomap.CoarsenLocations(v, c.coarsen)
+	}
+
+	return nil
+}
+
+// errorBody is the json error of the google apis
+type errorBody struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newAPIError(httpStatus int, body []byte) *geomap.APIError {
+
+	apiErr := &geomap.APIError{HTTPStatus: httpStatus, Body: body}
+
+	var parsed errorBody
+	if json.Unmarshal(body, &parsed) == nil {
+		apiErr.Status = parsed.Error.Status
+		apiErr.ErrorMessage = parsed.Error.Message
+	}
+
+	return apiErr
+}
@@ -0,0 +1,115 @@
+package placesv1
+
+// LatLng is a location of the Places API (New)
+type LatLng struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type Viewport struct {
+	Low  LatLng `json:"low"`
+	High LatLng `json:"high"`
+}
+
+type LocalizedText struct {
+	Text         string `json:"text"`
+	LanguageCode string `json:"languageCode,omitempty"`
+}
+
+/*
+	Place is a place of the Places API (New),
+	only the fields named in the field mask of the request are filled
+*/
+type Place struct {
+	Name                     string             `json:"name,omitempty"`
+	ID                       string             `json:"id,omitempty"`
+	DisplayName              *LocalizedText     `json:"displayName,omitempty"`
+	Types                    []string           `json:"types,omitempty"`
+	PrimaryType              string             `json:"primaryType,omitempty"`
+	PrimaryTypeDisplayName   *LocalizedText     `json:"primaryTypeDisplayName,omitempty"`
+	FormattedAddress         string             `json:"formattedAddress,omitempty"`
+	ShortFormattedAddress    string             `json:"shortFormattedAddress,omitempty"`
+	AddressComponents        []AddressComponent `json:"addressComponents,omitempty"`
+	PlusCode                 *PlusCode          `json:"plusCode,omitempty"`
+	Location                 *LatLng            `json:"location,omitempty"`
+	Viewport                 *Viewport          `json:"viewport,omitempty"`
+	Rating                   float64            `json:"rating,omitempty"`
+	UserRatingCount          int                `json:"userRatingCount,omitempty"`
+	PriceLevel               string             `json:"priceLevel,omitempty"`
+	BusinessStatus           string             `json:"businessStatus,omitempty"`
+	NationalPhoneNumber      string             `json:"nationalPhoneNumber,omitempty"`
+	InternationalPhoneNumber string             `json:"internationalPhoneNumber,omitempty"`
+	WebsiteURI               string             `json:"websiteUri,omitempty"`
+	GoogleMapsURI            string             `json:"googleMapsUri,omitempty"`
+	UTCOffsetMinutes         *int               `json:"utcOffsetMinutes,omitempty"`
+	RegularOpeningHours      *OpeningHours      `json:"regularOpeningHours,omitempty"`
+	CurrentOpeningHours      *OpeningHours      `json:"currentOpeningHours,omitempty"`
+	Photos                   []Photo            `json:"photos,omitempty"`
+	Reviews                  []Review           `json:"reviews,omitempty"`
+}
+
+type AddressComponent struct {
+	LongText     string   `json:"longText"`
+	ShortText    string   `json:"shortText"`
+	Types        []string `json:"types"`
+	LanguageCode string   `json:"languageCode,omitempty"`
+}
+
+type PlusCode struct {
+	GlobalCode   string `json:"globalCode"`
+	CompoundCode string `json:"compoundCode,omitempty"`
+}
+
+type OpeningHours struct {
+	OpenNow             *bool    `json:"openNow,omitempty"`
+	Periods             []Period `json:"periods,omitempty"`
+	WeekdayDescriptions []string `json:"weekdayDescriptions,omitempty"`
+}
+
+type Period struct {
+	Open  Point  `json:"open"`
+	Close *Point `json:"close,omitempty"`
+}
+
+// Point is a time of the week, Day 0 is sunday
+type Point struct {
+	Day    int `json:"day"`
+	Hour   int `json:"hour"`
+	Minute int `json:"minute"`
+}
+
+// Photo is a photo of a place, Name is the resource to fetch its media from
+type Photo struct {
+	Name               string              `json:"name"`
+	WidthPx            int                 `json:"widthPx"`
+	HeightPx           int                 `json:"heightPx"`
+	AuthorAttributions []AuthorAttribution `json:"authorAttributions,omitempty"`
+}
+
+type AuthorAttribution struct {
+	DisplayName string `json:"displayName"`
+	URI         string `json:"uri,omitempty"`
+	PhotoURI    string `json:"photoUri,omitempty"`
+}
+
+type Review struct {
+	Name                           string            `json:"name"`
+	RelativePublishTimeDescription string            `json:"relativePublishTimeDescription,omitempty"`
+	Rating                         float64           `json:"rating"`
+	Text                           *LocalizedText    `json:"text,omitempty"`
+	OriginalText                   *LocalizedText    `json:"originalText,omitempty"`
+	AuthorAttribution              AuthorAttribution `json:"authorAttribution"`
+	PublishTime                    string            `json:"publishTime,omitempty"`
+}
+
+// Circle is a search area, Radius in meters (up to 50000)
+type Circle struct {
+	Center LatLng  `json:"center"`
+	Radius float64 `json:"radius"`
+}
+
+// LocationArea restricts or biases a search to a circle or a rectangle, set only one
+type LocationArea struct {
+	Circle    *Circle   `json:"circle,omitempty"`
+	Rectangle *Viewport `json:"rectangle,omitempty"`
+}
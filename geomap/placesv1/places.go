@@ -0,0 +1,214 @@
+package placesv1
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+/*
+	DefaultFields is the field mask used when a call names no field: the Essentials
+	fields of the place, request more (e.g. "rating", "regularOpeningHours") explicitly
+	as they move the call to a more expensive SKU
+*/
+var DefaultFields = []string{"id", "displayName", "formattedAddress", "location", "types"}
+
+/*
+	SearchNearbyRequest is the body of a nearby search,
+	LocationRestriction must be a circle
+*/
+type SearchNearbyRequest struct {
+	IncludedTypes        []string     `json:"includedTypes,omitempty"`
+	ExcludedTypes        []string     `json:"excludedTypes,omitempty"`
+	IncludedPrimaryTypes []string     `json:"includedPrimaryTypes,omitempty"`
+	ExcludedPrimaryTypes []string     `json:"excludedPrimaryTypes,omitempty"`
+	MaxResultCount       int          `json:"maxResultCount,omitempty"`
+	LocationRestriction  LocationArea `json:"locationRestriction"`
+	RankPreference       string       `json:"rankPreference,omitempty"`
+	LanguageCode         string       `json:"languageCode,omitempty"`
+	RegionCode           string       `json:"regionCode,omitempty"`
+}
+
+// SearchTextRequest is the body of a text search
+type SearchTextRequest struct {
+	TextQuery           string        `json:"textQuery"`
+	IncludedType        string        `json:"includedType,omitempty"`
+	StrictTypeFiltering bool          `json:"strictTypeFiltering,omitempty"`
+	OpenNow             bool          `json:"openNow,omitempty"`
+	MinRating           float64       `json:"minRating,omitempty"`
+	PriceLevels         []string      `json:"priceLevels,omitempty"`
+	PageSize            int           `json:"pageSize,omitempty"`
+	PageToken           string        `json:"pageToken,omitempty"`
+	LocationBias        *LocationArea `json:"locationBias,omitempty"`
+	LocationRestriction *LocationArea `json:"locationRestriction,omitempty"`
+	RankPreference      string        `json:"rankPreference,omitempty"`
+	LanguageCode        string        `json:"languageCode,omitempty"`
+	RegionCode          string        `json:"regionCode,omitempty"`
+}
+
+// SearchResponse is the answer of the searches, only text search pages with NextPageToken
+type SearchResponse struct {
+	Places        []Place `json:"places"`
+	NextPageToken string  `json:"nextPageToken,omitempty"`
+}
+
+/*
+	DetailsOptions configures GetPlace, SessionToken ends the autocomplete session
+	whose predictions the place was picked from
+*/
+type DetailsOptions struct {
+	LanguageCode string
+	RegionCode   string
+	SessionToken string
+}
+
+// AutocompleteRequest is the body of an autocomplete
+type AutocompleteRequest struct {
+	Input                   string        `json:"input"`
+	SessionToken            string        `json:"sessionToken,omitempty"`
+	IncludedPrimaryTypes    []string      `json:"includedPrimaryTypes,omitempty"`
+	IncludedRegionCodes     []string      `json:"includedRegionCodes,omitempty"`
+	LocationBias            *LocationArea `json:"locationBias,omitempty"`
+	LocationRestriction     *LocationArea `json:"locationRestriction,omitempty"`
+	Origin                  *LatLng       `json:"origin,omitempty"`
+	IncludeQueryPredictions bool          `json:"includeQueryPredictions,omitempty"`
+	LanguageCode            string        `json:"languageCode,omitempty"`
+	RegionCode              string        `json:"regionCode,omitempty"`
+}
+
+type AutocompleteResponse struct {
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+// Suggestion is either a place or a query prediction
+type Suggestion struct {
+	PlacePrediction *PlacePrediction `json:"placePrediction,omitempty"`
+	QueryPrediction *QueryPrediction `json:"queryPrediction,omitempty"`
+}
+
+type PlacePrediction struct {
+	Place            string            `json:"place"`
+	PlaceID          string            `json:"placeId"`
+	Text             FormattableText   `json:"text"`
+	StructuredFormat *StructuredFormat `json:"structuredFormat,omitempty"`
+	Types            []string          `json:"types,omitempty"`
+	DistanceMeters   int               `json:"distanceMeters,omitempty"`
+}
+
+type QueryPrediction struct {
+	Text             FormattableText   `json:"text"`
+	StructuredFormat *StructuredFormat `json:"structuredFormat,omitempty"`
+}
+
+// FormattableText is a text and the offsets of the parts matching the input
+type FormattableText struct {
+	Text    string        `json:"text"`
+	Matches []StringRange `json:"matches,omitempty"`
+}
+
+type StringRange struct {
+	StartOffset int `json:"startOffset,omitempty"`
+	EndOffset   int `json:"endOffset"`
+}
+
+type StructuredFormat struct {
+	MainText      FormattableText  `json:"mainText"`
+	SecondaryText *FormattableText `json:"secondaryText,omitempty"`
+}
+
+/*
+	SearchNearby returns the places of the types requested within the location restriction,
+	fields are the Place fields to return (DefaultFields when empty)
+	more references https://developers.google.com/maps/documentation/places/web-service/nearby-search
+*/
+func (c *Client) SearchNearby(ctx context.Context, req SearchNearbyRequest, fields []string) (SearchResponse, error) {
+
+	var searchResponse SearchResponse
+
+	if req.LocationRestriction.Circle == nil {
+		return searchResponse, errors.New("nearby search needs a circle location restriction")
+	}
+
+	err := c.call(ctx, "POST", "/v1/places:searchNearby", nil, fieldMask("places.", fields), req, &searchResponse)
+	return searchResponse, err
+}
+
+/*
+	SearchText returns the places matching the text query, fields are the Place
+	fields to return (DefaultFields when empty), pass NextPageToken as PageToken for the next page
+	more references https://developers.google.com/maps/documentation/places/web-service/text-search
+*/
+func (c *Client) SearchText(ctx context.Context, req SearchTextRequest, fields []string) (SearchResponse, error) {
+
+	var searchResponse SearchResponse
+
+	if req.TextQuery == "" {
+		return searchResponse, errors.New("text search needs a text query")
+	}
+
+	mask := fieldMask("places.", fields) + ",nextPageToken"
+
+	err := c.call(ctx, "POST", "/v1/places:searchText", nil, mask, req, &searchResponse)
+	return searchResponse, err
+}
+
+/*
+	GetPlace returns the details of the place, fields are the Place fields
+	to return (DefaultFields when empty)
+	more references https://developers.google.com/maps/documentation/places/web-service/place-details
+*/
+func (c *Client) GetPlace(ctx context.Context, placeID string, opts DetailsOptions, fields []string) (Place, error) {
+
+	var place Place
+
+	if placeID == "" {
+		return place, errors.New("place details need a place id")
+	}
+
+	query := url.Values{}
+	if opts.LanguageCode != "" {
+		query.Set("languageCode", opts.LanguageCode)
+	}
+	if opts.RegionCode != "" {
+		query.Set("regionCode", opts.RegionCode)
+	}
+	if opts.SessionToken != "" {
+		query.Set("sessionToken", opts.SessionToken)
+	}
+
+	err := c.call(ctx, "GET", "/v1/places/"+url.PathEscape(placeID), query, fieldMask("", fields), nil, &place)
+	return place, err
+}
+
+/*
+	Autocomplete returns the predictions for the input, every prediction field is returned.
+	Autocomplete calls sharing the SessionToken of the GetPlace call ending them are billed as a session
+	more references https://developers.google.com/maps/documentation/places/web-service/place-autocomplete
+*/
+func (c *Client) Autocomplete(ctx context.Context, req AutocompleteRequest) (AutocompleteResponse, error) {
+
+	var autocompleteResponse AutocompleteResponse
+
+	if req.Input == "" {
+		return autocompleteResponse, errors.New("autocomplete needs an input")
+	}
+
+	err := c.call(ctx, "POST", "/v1/places:autocomplete", nil, "", req, &autocompleteResponse)
+	return autocompleteResponse, err
+}
+
+// fieldMask joins fields, each prefixed with the path of the places in the response
+func fieldMask(prefix string, fields []string) string {
+
+	if len(fields) == 0 {
+		fields = DefaultFields
+	}
+
+	masked := make([]string, len(fields))
+	for i, field := range fields {
+		masked[i] = prefix + field
+	}
+
+	return strings.Join(masked, ",")
+}
@@ -0,0 +1,193 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// watchedFields are the details fetched for a place snapshot
+var watchedFields = []string{"place_id", "name", "business_status", "international_phone_number", "rating", "opening_hours"}
+
+// PlaceSnapshot is the state of the watched fields of a place when it was fetched
+type PlaceSnapshot struct {
+	PlaceID        string    `json:"place_id"`
+	Name           string    `json:"name,omitempty"`
+	BusinessStatus string    `json:"business_status,omitempty"`
+	Phone          string    `json:"phone,omitempty"`
+	Rating         float64   `json:"rating,omitempty"`
+	OpeningHours   []string  `json:"opening_hours,omitempty"`
+	FetchedAt      time.Time `json:"fetched_at"`
+}
+
+// NewPlaceSnapshot returns the snapshot of the details of place fetched at
+func NewPlaceSnapshot(place Place, at time.Time) PlaceSnapshot {
+
+	snapshot := PlaceSnapshot{
+		PlaceID:        place.PlaceID,
+		Name:           place.Name,
+		BusinessStatus: place.BusinessStatus,
+		Phone:          place.InternationalPhoneNumber,
+		Rating:         place.Rating,
+		FetchedAt:      at,
+	}
+	if place.OpeningHours != nil {
+		snapshot.OpeningHours = place.OpeningHours.WeekdayText
+	}
+
+	return snapshot
+}
+
+// PlaceChange is a watched field whose value changed
+type PlaceChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// PlaceChangeEvent is emitted when the watched fields of a place changed since its previous snapshot
+type PlaceChangeEvent struct {
+	PlaceID  string        `json:"place_id"`
+	Changes  []PlaceChange `json:"changes"`
+	Previous PlaceSnapshot `json:"previous"`
+	Current  PlaceSnapshot `json:"current"`
+}
+
+// DiffPlaces returns the watched fields that differ between prev and cur
+func DiffPlaces(prev, cur PlaceSnapshot) []PlaceChange {
+
+	var changes []PlaceChange
+	diff := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, PlaceChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	diff("name", prev.Name, cur.Name)
+	diff("business_status", prev.BusinessStatus, cur.BusinessStatus)
+	diff("phone", prev.Phone, cur.Phone)
+	diff("rating", strconv.FormatFloat(prev.Rating, 'f', -1, 64), strconv.FormatFloat(cur.Rating, 'f', -1, 64))
+	diff("opening_hours", strings.Join(prev.OpeningHours, "\n"), strings.Join(cur.OpeningHours, "\n"))
+
+	return changes
+}
+
+/*
+	SnapshotStore keeps the last snapshot of every watched place,
+	e.g. awsadapter.DynamoDBSnapshotStore
+*/
+type SnapshotStore interface {
+	Get(ctx context.Context, placeID string) (snapshot PlaceSnapshot, found bool, err error)
+	Put(ctx context.Context, snapshot PlaceSnapshot) error
+}
+
+// ChangeSink receives the change events of a PlaceWatcher, e.g. awsadapter.SNSChangeSink
+type ChangeSink interface {
+	Publish(ctx context.Context, event PlaceChangeEvent) error
+}
+
+/*
+	PlaceWatcher refetches the details of places and emits an event for every place
+	whose watched fields (name, business status, phone, rating and opening hours)
+	changed since the snapshot kept in Store. Params are sent with every details request
+	and need at least the "key", Limiter paces the watcher on top of the client rate limit
+	so a long list doesn't eat the quota of the live traffic
+*/
+type PlaceWatcher struct {
+	Client  *Client
+	Store   SnapshotStore
+	Sink    ChangeSink
+	Params  map[string]string
+	Limiter Limiter
+}
+
+// WatchResult counts the places checked by a run, Failed holds the error of every place that couldn't be
+type WatchResult struct {
+	Checked int
+	Changed int
+	Failed  map[string]error
+}
+
+/*
+	Check refetches placeIDs one after the other and publishes the changes.
+	A place seen for the first time is only stored, an event is published before
+	its snapshot is replaced so a failed publish is retried by the next run.
+	A failing place doesn't stop the others, only a done ctx does
+*/
+func (w *PlaceWatcher) Check(ctx context.Context, placeIDs []string) (WatchResult, error) {
+
+	if w.Store == nil || w.Sink == nil {
+		return WatchResult{}, errors.New("place watcher needs a store and a sink")
+	}
+
+	client := w.Client
+	if client == nil {
+		client = DefaultClient()
+	}
+
+	result := WatchResult{Failed: map[string]error{}}
+	for _, placeID := range placeIDs {
+
+		if w.Limiter != nil {
+			if err := w.Limiter.Wait(ctx); err != nil {
+				return result, err
+			}
+		}
+
+		changed, err := w.check(ctx, client, placeID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			result.Failed[placeID] = err
+			continue
+		}
+
+		result.Checked++
+		if changed {
+			result.Changed++
+		}
+	}
+
+	return result, nil
+}
+
+// check refetches placeID, telling whether it changed
+func (w *PlaceWatcher) check(ctx context.Context, client *Client, placeID string) (bool, error) {
+
+	params := copyParams(w.Params)
+	params["place_id"] = placeID
+	params["fields"] = strings.Join(watchedFields, ",")
+
+	//a cached response would hide the changes
+	details, err := client.PlaceDetailV2(context.WithValue(ctx, cacheRefreshKey{}, true), params)
+	if err == nil && details.Status != "OK" {
+		err = errors.New(details.Status)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	current := NewPlaceSnapshot(details.Result, time.Now())
+	current.PlaceID = placeID
+
+	previous, found, err := w.Store.Get(ctx, placeID)
+	if err != nil {
+		return false, err
+	}
+
+	var changes []PlaceChange
+	if found {
+		changes = DiffPlaces(previous, current)
+	}
+	if len(changes) > 0 {
+		event := PlaceChangeEvent{PlaceID: placeID, Changes: changes, Previous: previous, Current: current}
+		if err := w.Sink.Publish(ctx, event); err != nil {
+			return false, err
+		}
+	}
+
+	return len(changes) > 0, w.Store.Put(ctx, current)
+}
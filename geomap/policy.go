@@ -0,0 +1,208 @@
+package geomap
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrCountryNotAllowed is returned when a request or its result falls outside the country policy
+var ErrCountryNotAllowed = errors.New("country not allowed by policy")
+
+/*
+	CountryPolicy restricts the client to some markets, countries are ISO 3166 alpha-2 codes.
+	An empty Allow list allows every country not in Deny.
+	Requests restricted to a rejected country ("components=country:xx" or "region")
+	fail before reaching google. The results of geocodes, nearby and text searches and
+	find place (v1 and v2 models) located in a rejected country are filtered out when
+	FilterResults is set, otherwise the whole call fails, a place detail in a rejected
+	country always fails. The country of a result is its country address component, else
+	the end of its formatted address or plus code. Under an Allow list a country which
+	can't be resolved is rejected unless AllowUnknown is set, without one it is allowed
+*/
+type CountryPolicy struct {
+	Allow         []string
+	Deny          []string
+	FilterResults bool
+	AllowUnknown  bool
+}
+
+// WithCountryPolicy enforces p on every request and response of the client
+func WithCountryPolicy(p CountryPolicy) ClientOption {
+	return func(c *Client) error {
+		c.countryPolicy = &p
+		return nil
+	}
+}
+
+// Allowed reports whether the policy allows country
+func (p CountryPolicy) Allowed(country string) bool {
+
+	for _, denied := range p.Deny {
+		if strings.EqualFold(denied, country) {
+			return false
+		}
+	}
+
+	if len(p.Allow) == 0 {
+		return true
+	}
+
+	for _, allowed := range p.Allow {
+		if strings.EqualFold(allowed, country) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowedUnknown reports whether the policy allows a country it can't resolve
+func (p CountryPolicy) allowedUnknown() bool {
+	return len(p.Allow) == 0 || p.AllowUnknown
+}
+
+// allowedCountry checks a country given as a code or a name, e.g. a ccTLD region as "uk"
+func (p CountryPolicy) allowedCountry(s string) bool {
+
+	if country, ok := LookupCountry(s); ok {
+		return p.Allowed(country.Alpha2)
+	}
+
+	return p.allowedUnknown()
+}
+
+// checkRequest inspects the country restrictions of the request params
+func (p CountryPolicy) checkRequest(params map[string]string) error {
+
+	//region is a ccTLD, which the country table maps ("uk" is GB)
+	if region := params["region"]; region != "" && !p.allowedCountry(region) {
+		return ErrCountryNotAllowed
+	}
+
+	for _, component := range strings.Split(params["components"], "|") {
+		parts := strings.SplitN(component, ":", 2)
+		if len(parts) == 2 && parts[0] == "country" && !p.allowedCountry(parts[1]) {
+			return ErrCountryNotAllowed
+		}
+	}
+
+	return nil
+}
+
+/*
+	allowedPlace checks the country of a place, from its address components or else
+	the country name ending one of addresses
+*/
+func (p CountryPolicy) allowedPlace(components []AddressComponent, addresses ...string) bool {
+
+	if country, ok := CountryOf(components); ok {
+		return p.Allowed(country.Alpha2)
+	}
+
+	for _, address := range addresses {
+		parts := strings.Split(address, ",")
+		if country, ok := LookupCountry(parts[len(parts)-1]); ok {
+			return p.Allowed(country.Alpha2)
+		}
+	}
+
+	return p.allowedUnknown()
+}
+
+/*
+	allowedPlaces returns the places allowed by the policy in a new slice, false when
+	one is rejected and results aren't filtered
+*/
+func (p CountryPolicy) allowedPlaces(places []Place) ([]Place, bool) {
+
+	kept := make([]Place, 0, len(places))
+	for _, place := range places {
+		if p.allowedPlace(place.AddressComponents, place.FormattedAddress, place.PlusCode.CompoundCode) {
+			kept = append(kept, place)
+		} else if !p.FilterResults {
+			return nil, false
+		}
+	}
+
+	return kept, true
+}
+
+/*
+	checkResponse filters or rejects the results of the known responses, the results are
+	only replaced once all of them were checked so a rejected response is left as it was
+*/
+func (p CountryPolicy) checkResponse(v interface{}) error {
+
+	switch resp := v.(type) {
+	case *GoogleGeocodeResponse:
+		kept := make([]GeocodeResult, 0, len(resp.Results))
+		for _, result := range resp.Results {
+			if p.allowedPlace(result.AddressComponents, result.FormattedAddress, result.PlusCode.CompoundCode) {
+				kept = append(kept, result)
+			} else if !p.FilterResults {
+				return ErrCountryNotAllowed
+			}
+		}
+		resp.Results = kept
+
+	case *GeocodeResponseV2:
+		kept := make([]GeocodeResult, 0, len(resp.Results))
+		for _, result := range resp.Results {
+			if p.allowedPlace(result.AddressComponents, result.FormattedAddress, result.PlusCode.CompoundCode) {
+				kept = append(kept, result)
+			} else if !p.FilterResults {
+				return ErrCountryNotAllowed
+			}
+		}
+		resp.Results = kept
+
+	case *GoogleNearbySearchResponse:
+		kept := make([]NearbyResult, 0, len(resp.Results))
+		for _, result := range resp.Results {
+			if p.allowedPlace(nil, result.PlusCode.CompoundCode, result.Vicinity) {
+				kept = append(kept, result)
+			} else if !p.FilterResults {
+				return ErrCountryNotAllowed
+			}
+		}
+		resp.Results = kept
+
+	case *NearbySearchResponseV2:
+		kept, ok := p.allowedPlaces(resp.Results)
+		if !ok {
+			return ErrCountryNotAllowed
+		}
+		resp.Results = kept
+
+	case *GooglePlaceSearchResponse:
+		kept := make([]Candidate, 0, len(resp.Candidates))
+		for _, candidate := range resp.Candidates {
+			if p.allowedPlace(nil, candidate.FormattedAddress) {
+				kept = append(kept, candidate)
+			} else if !p.FilterResults {
+				return ErrCountryNotAllowed
+			}
+		}
+		resp.Candidates = kept
+
+	case *FindPlaceResponseV2:
+		kept, ok := p.allowedPlaces(resp.Candidates)
+		if !ok {
+			return ErrCountryNotAllowed
+		}
+		resp.Candidates = kept
+
+	//only a found place has a country, NOT_FOUND and the errors go through
+	case *GooglePlaceDetailResponse:
+		if resp.Status == "OK" && !p.allowedPlace(resp.Result.AddressComponents, resp.Result.FormattedAddress) {
+			return ErrCountryNotAllowed
+		}
+
+	case *PlaceDetailResponseV2:
+		if resp.Status == "OK" && !p.allowedPlace(resp.Result.AddressComponents, resp.Result.FormattedAddress, resp.Result.PlusCode.CompoundCode) {
+			return ErrCountryNotAllowed
+		}
+	}
+
+	return nil
+}
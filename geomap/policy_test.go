@@ -0,0 +1,77 @@
+package geomap
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountryPolicyCheckRequest(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		policy  CountryPolicy
+		params  map[string]string
+		allowed bool
+	}{
+		{"no restriction", CountryPolicy{Allow: []string{"ID"}}, map[string]string{"address": "x"}, true},
+		{"allowed region", CountryPolicy{Allow: []string{"ID"}}, map[string]string{"region": "id"}, true},
+		{"ccTLD region of an allowed country", CountryPolicy{Allow: []string{"GB"}}, map[string]string{"region": "uk"}, true},
+		{"ccTLD region of a denied country", CountryPolicy{Deny: []string{"GB"}}, map[string]string{"region": "uk"}, false},
+		{"region outside the allow list", CountryPolicy{Allow: []string{"ID"}}, map[string]string{"region": "sg"}, false},
+		{"denied country component", CountryPolicy{Deny: []string{"SG"}}, map[string]string{"components": "postal_code:1|country:SG"}, false},
+		{"unknown country under an allow list", CountryPolicy{Allow: []string{"ID"}}, map[string]string{"components": "country:zz"}, false},
+		{"unknown country allowed", CountryPolicy{Allow: []string{"ID"}, AllowUnknown: true}, map[string]string{"components": "country:zz"}, true},
+		{"unknown country without allow list", CountryPolicy{Deny: []string{"SG"}}, map[string]string{"components": "country:zz"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.policy.checkRequest(test.params); (err == nil) != test.allowed {
+				t.Errorf("error %v, expected allowed %v", err, test.allowed)
+			}
+		})
+	}
+}
+
+func TestCountryPolicyResponse(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		policy  CountryPolicy
+		params  map[string]string
+		err     error
+		results int
+		sent    int
+	}{
+		{"allowed result", CountryPolicy{Allow: []string{"ID"}}, nil, nil, 1, 1},
+		{"rejected result filtered", CountryPolicy{Allow: []string{"SG"}, FilterResults: true}, nil, nil, 0, 1},
+		{"rejected result fails the call", CountryPolicy{Allow: []string{"SG"}}, nil, ErrCountryNotAllowed, 1, 1},
+		{"denied result filtered", CountryPolicy{Deny: []string{"ID"}, FilterResults: true}, nil, nil, 0, 1},
+		{"rejected request never sent", CountryPolicy{Allow: []string{"ID"}}, map[string]string{"region": "sg"}, ErrCountryNotAllowed, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			transport := &scriptedTransport{bodies: []string{fixtureGeocode()}}
+			c := transportClient(t, transport, WithCountryPolicy(test.policy))
+
+			params := map[string]string{"address": "Jl. Sudirman 1", "key": "k"}
+			for key, val := range test.params {
+				params[key] = val
+			}
+
+			resp, err := c.GetGeocode(context.Background(), params)
+			if err != test.err {
+				t.Errorf("error %v, expected %v", err, test.err)
+			}
+			//a rejected response is left as google sent it
+			if len(resp.Results) != test.results {
+				t.Errorf("%d results, expected %d", len(resp.Results), test.results)
+			}
+			if sent := len(transport.sent()); sent != test.sent {
+				t.Errorf("%d requests sent, expected %d", sent, test.sent)
+			}
+		})
+	}
+}
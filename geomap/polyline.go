@@ -0,0 +1,83 @@
+package geomap
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// errInvalidPolyline is returned for points which aren't an encoded polyline
+var errInvalidPolyline = errors.New("invalid encoded polyline")
+
+/*
+	decodePolyline returns the locations of points, encoded with the polyline algorithm
+	of google (5 decimals, zigzag deltas in 5 bit chunks)
+	more references https://developers.google.com/maps/documentation/utilities/polylinealgorithm
+*/
+func decodePolyline(points string) ([]GoogleLocation, error) {
+
+	var locations []GoogleLocation
+	var lat, lng int64
+
+	for i := 0; i < len(points); {
+		var deltas [2]int64
+		for d := range deltas {
+			var result int64
+			var shift uint
+			for {
+				if i >= len(points) || shift > 60 {
+					return nil, errInvalidPolyline
+				}
+				b := int64(points[i]) - 63
+				i++
+				if b < 0 || b > 63 {
+					return nil, errInvalidPolyline
+				}
+				result |= (b & 0x1f) << shift
+				shift += 5
+				if b < 0x20 {
+					break
+				}
+			}
+			if result&1 != 0 {
+				deltas[d] = ^(result >> 1)
+			} else {
+				deltas[d] = result >> 1
+			}
+		}
+
+		lat += deltas[0]
+		lng += deltas[1]
+		locations = append(locations, GoogleLocation{Lat: float64(lat) / 1e5, Lng: float64(lng) / 1e5})
+	}
+
+	return locations, nil
+}
+
+// encodePolyline returns the encoded polyline of locations, see decodePolyline
+func encodePolyline(locations []GoogleLocation) string {
+
+	var sb strings.Builder
+	var lastLat, lastLng int64
+
+	for _, location := range locations {
+		lat := int64(math.Round(location.Lat * 1e5))
+		lng := int64(math.Round(location.Lng * 1e5))
+
+		for _, delta := range []int64{lat - lastLat, lng - lastLng} {
+			value := delta << 1
+			if delta < 0 {
+				value = ^value
+			}
+			for value >= 0x20 {
+				sb.WriteByte(byte((0x20 | (value & 0x1f)) + 63))
+				value >>= 5
+			}
+			sb.WriteByte(byte(value + 63))
+		}
+
+		lastLat, lastLng = lat, lng
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,168 @@
+package geomap
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+)
+
+/*
+	Coarsener lowers the precision of a location, see CoordinatePrecision and GeohashSnapping.
+	CoarsenLocations applies one to a whole response
+*/
+type Coarsener func(location GoogleLocation) GoogleLocation
+
+// CoordinatePrecision returns the Coarsener truncating the locations to decimals places
+func CoordinatePrecision(decimals int) (Coarsener, error) {
+
+	if decimals < 0 {
+		return nil, errors.New("coordinate precision must not be negative")
+	}
+
+	scale := math.Pow(10, float64(decimals))
+	return func(location GoogleLocation) GoogleLocation {
+		return GoogleLocation{
+			Lat: math.Trunc(location.Lat*scale) / scale,
+			Lng: math.Trunc(location.Lng*scale) / scale,
+		}
+	}, nil
+}
+
+// GeohashSnapping returns the Coarsener snapping the locations to the center of their geohash cell
+func GeohashSnapping(precision int) (Coarsener, error) {
+
+	if precision < 1 || precision > 12 {
+		return nil, errors.New("geohash precision must be between 1 and 12")
+	}
+
+	return func(location GoogleLocation) GoogleLocation {
+		return geohashCenter(location, precision)
+	}, nil
+}
+
+/*
+	WithCoordinatePrecision truncates every lat/lng returned by the client
+	to decimals places (2 is ~1km, 3 is ~100m), for products that only need
+	neighborhood level precision and should never handle exact coordinates.
+	See CoarsenLocations for what is coarsened
+*/
+func WithCoordinatePrecision(decimals int) ClientOption {
+	return func(c *Client) error {
+
+		coarsen, err := CoordinatePrecision(decimals)
+		if err != nil {
+			return err
+		}
+
+		c.coarsen = coarsen
+		return nil
+	}
+}
+
+/*
+	WithGeohashSnapping snaps every lat/lng returned by the client to the center
+	of its geohash cell of precision characters (5 is ~5km, 6 is ~1km)
+*/
+func WithGeohashSnapping(precision int) ClientOption {
+	return func(c *Client) error {
+
+		coarsen, err := GeohashSnapping(precision)
+		if err != nil {
+			return err
+		}
+
+		c.coarsen = coarsen
+		return nil
+	}
+}
+
+/*
+	CoarsenLocations applies coarsen to every coordinate of v, a pointer to a response:
+	the structs of a lat/lng or latitude/longitude pair of floats (GoogleLocation,
+	LatLngLiteral, the LatLng of placesv1...) and the points of the encoded polylines,
+	which are decoded, coarsened and encoded again without the repeated points
+*/
+func CoarsenLocations(v interface{}, coarsen Coarsener) {
+	walkLocations(reflect.ValueOf(v), coarsen)
+}
+
+var polylineType = reflect.TypeOf(Polyline{})
+
+// walkLocations coarsens every settable coordinate reachable from v
+func walkLocations(v reflect.Value, coarsen Coarsener) {
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walkLocations(v.Elem(), coarsen)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkLocations(v.Index(i), coarsen)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+
+		if t == polylineType {
+			if points := v.Field(0); points.CanSet() {
+				points.SetString(coarsenPolyline(points.String(), coarsen))
+			}
+			return
+		}
+
+		if lat, lng, ok := coordinateFields(t); ok && v.Field(lat).CanSet() {
+			location := coarsen(GoogleLocation{Lat: v.Field(lat).Float(), Lng: v.Field(lng).Float()})
+			v.Field(lat).SetFloat(location.Lat)
+			v.Field(lng).SetFloat(location.Lng)
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				walkLocations(v.Field(i), coarsen)
+			}
+		}
+	}
+}
+
+// coordinateFields returns the fields of the latitude and longitude of t, if it has both
+func coordinateFields(t reflect.Type) (int, int, bool) {
+
+	lat, lng := -1, -1
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Type.Kind() != reflect.Float64 {
+			continue
+		}
+
+		switch strings.Split(field.Tag.Get("json"), ",")[0] {
+		case "lat", "latitude":
+			lat = i
+		case "lng", "longitude":
+			lng = i
+		}
+	}
+
+	return lat, lng, lat >= 0 && lng >= 0
+}
+
+// coarsenPolyline returns points with every location coarsened, nothing when they can't be decoded
+func coarsenPolyline(points string, coarsen Coarsener) string {
+
+	locations, err := decodePolyline(points)
+	if err != nil {
+		return ""
+	}
+
+	coarsened := locations[:0]
+	for _, location := range locations {
+		location = coarsen(location)
+		if n := len(coarsened); n == 0 || coarsened[n-1] != location {
+			coarsened = append(coarsened, location)
+		}
+	}
+
+	return encodePolyline(coarsened)
+}
@@ -0,0 +1,125 @@
+package geomap
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+const (
+	defaultPrefetchConcurrency = 5
+
+	// wide enough for the thumbnails of a results page
+	defaultPrefetchWidth = 400
+)
+
+/*
+	PrefetchOptions configures PrefetchPhotos, Params are sent with every photo request
+	and need at least the "key", MaxWidth is the size of the photos (400px by default,
+	at most the 1600px google serves) and Concurrency bounds the requests in flight (5 by default)
+*/
+type PrefetchOptions struct {
+	Params      map[string]string
+	MaxWidth    int
+	Concurrency int
+}
+
+/*
+	PrefetchedPlace is a search result with its first photo, nil when the place has none.
+	Photo is a URL when the client has a PhotoStore (e.g. S3), the bytes otherwise,
+	Err is set when the photo failed
+*/
+type PrefetchedPlace struct {
+	Place
+	Photo *PlacePhotoResponse `json:"photo,omitempty"`
+	Err   error               `json:"-"`
+}
+
+type PrefetchResult struct {
+	Places []PrefetchedPlace `json:"places"`
+	Failed int               `json:"failed"`
+}
+
+/*
+	PrefetchPhotos fetches the first photo of every place concurrently, so a results page
+	is rendered without a photo call per place from the client. Photos go through the
+	client photo store, processing and size guard (see WithMaxPhotoSize) like PlacePhoto,
+	a failed photo is reported on Err and the other places are still prefetched
+*/
+func PrefetchPhotos(ctx context.Context, places []Place, opts PrefetchOptions) (PrefetchResult, error) {
+	return DefaultClient().PrefetchPhotos(ctx, places, opts)
+}
+
+// PrefetchPhotos is the package level PrefetchPhotos using c
+func (c *Client) PrefetchPhotos(ctx context.Context, places []Place, opts PrefetchOptions) (PrefetchResult, error) {
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultPrefetchConcurrency
+	}
+
+	maxWidth := opts.MaxWidth
+	if maxWidth <= 0 {
+		maxWidth = defaultPrefetchWidth
+	}
+	if maxWidth > maxPhotoWidth {
+		maxWidth = maxPhotoWidth
+	}
+
+	result := PrefetchResult{Places: make([]PrefetchedPlace, len(places))}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+
+	for i, place := range places {
+
+		result.Places[i].Place = place
+		if len(place.Photos) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(prefetched *PrefetchedPlace) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				prefetched.Err = ctx.Err()
+				return
+			}
+
+			photo := prefetched.Photos[0]
+
+			//a smaller photo isn't scaled up
+			width := maxWidth
+			if photo.Width > 0 && photo.Width < width {
+				width = photo.Width
+			}
+
+			params := copyParams(opts.Params)
+			params["photoreference"] = photo.PhotoReference
+			params["maxwidth"] = strconv.Itoa(width)
+
+			resp, err := c.PlacePhoto(ctx, params)
+			if err != nil {
+				prefetched.Err = err
+				return
+			}
+			prefetched.Photo = &resp
+		}(&result.Places[i])
+	}
+
+	wg.Wait()
+
+	for _, place := range result.Places {
+		if place.Err != nil {
+			result.Failed++
+		}
+	}
+
+	return result, nil
+}
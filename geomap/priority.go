@@ -0,0 +1,229 @@
+package geomap
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Priority orders the requests waiting for a PriorityLimiter, the highest first
+type Priority int
+
+const (
+	// batch jobs and other bulk traffic, the default of the batch operations
+	PriorityBulk Priority = iota
+
+	// requests without a priority
+	PriorityNormal
+
+	// requests a user is waiting for, e.g. autocomplete or a single geocode
+	PriorityInteractive
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context whose requests wait for a PriorityLimiter with priority p
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// withDefaultPriority sets p on ctx unless the caller already chose one
+func withDefaultPriority(ctx context.Context, p Priority) context.Context {
+
+	if _, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return ctx
+	}
+
+	return WithPriority(ctx, p)
+}
+
+func priorityOf(ctx context.Context) Priority {
+
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+
+	return PriorityNormal
+}
+
+// PriorityStats are the queue wait times of the requests of a priority since the last reset
+type PriorityStats struct {
+	Requests  int64         `json:"requests"`
+	TotalWait time.Duration `json:"total_wait"`
+	MaxWait   time.Duration `json:"max_wait"`
+}
+
+// AverageWait returns the mean time the requests waited in the queue
+func (s PriorityStats) AverageWait() time.Duration {
+
+	if s.Requests == 0 {
+		return 0
+	}
+
+	return s.TotalWait / time.Duration(s.Requests)
+}
+
+/*
+	PriorityLimiter queues the requests waiting for inner (e.g. NewRateLimiter) and hands
+	each request allowed by inner to the highest priority waiting (see WithPriority), so
+	interactive calls overtake batch traffic when the limit is saturated. Requests of the
+	same priority keep their order. Give it to the client with WithRateLimiter
+*/
+type PriorityLimiter struct {
+	inner Limiter
+
+	mu          sync.Mutex
+	queue       waiterQueue
+	seq         uint64
+	dispatching bool
+	stats       map[Priority]*PriorityStats
+}
+
+// NewPriorityLimiter returns a PriorityLimiter in front of inner
+func NewPriorityLimiter(inner Limiter) *PriorityLimiter {
+	return &PriorityLimiter{inner: inner, stats: map[Priority]*PriorityStats{}}
+}
+
+type waiter struct {
+	priority Priority
+	seq      uint64
+	queued   time.Time
+	granted  chan struct{}
+	err      error
+	index    int
+}
+
+func (l *PriorityLimiter) Wait(ctx context.Context) error {
+
+	w := &waiter{priority: priorityOf(ctx), queued: time.Now(), granted: make(chan struct{})}
+
+	l.mu.Lock()
+	l.seq++
+	w.seq = l.seq
+	heap.Push(&l.queue, w)
+	if !l.dispatching {
+		l.dispatching = true
+		go l.dispatch()
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-w.granted:
+		return w.err
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	select {
+	case <-w.granted:
+		//granted while giving up, the request may as well go
+		return w.err
+	default:
+	}
+
+	heap.Remove(&l.queue, w.index)
+	return ctx.Err()
+}
+
+/*
+	dispatch waits for inner and grants each allowed request to the head of the queue until
+	it is empty, when inner fails the head fails with its error, as it would without the queue
+*/
+func (l *PriorityLimiter) dispatch() {
+
+	for {
+		l.mu.Lock()
+		if l.queue.Len() == 0 {
+			l.dispatching = false
+			l.mu.Unlock()
+			return
+		}
+		l.mu.Unlock()
+
+		//the request allowed goes to whoever heads the queue once it is allowed
+		err := l.inner.Wait(context.Background())
+
+		l.mu.Lock()
+		if l.queue.Len() > 0 {
+			w := heap.Pop(&l.queue).(*waiter)
+			if err == nil {
+				l.record(w.priority, time.Since(w.queued))
+			}
+			w.err = err
+			close(w.granted)
+		}
+		l.mu.Unlock()
+	}
+}
+
+func (l *PriorityLimiter) record(p Priority, wait time.Duration) {
+
+	stats, ok := l.stats[p]
+	if !ok {
+		stats = &PriorityStats{}
+		l.stats[p] = stats
+	}
+
+	stats.Requests++
+	stats.TotalWait += wait
+	if wait > stats.MaxWait {
+		stats.MaxWait = wait
+	}
+}
+
+// Stats returns the queue wait times by priority
+func (l *PriorityLimiter) Stats() map[Priority]PriorityStats {
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[Priority]PriorityStats, len(l.stats))
+	for p, s := range l.stats {
+		stats[p] = *s
+	}
+
+	return stats
+}
+
+// ResetStats clears the wait times, e.g. after they were reported
+func (l *PriorityLimiter) ResetStats() {
+
+	l.mu.Lock()
+	l.stats = map[Priority]*PriorityStats{}
+	l.mu.Unlock()
+}
+
+// waiterQueue is a heap of the waiters, the highest priority and then the oldest first
+type waiterQueue []*waiter
+
+func (q waiterQueue) Len() int { return len(q) }
+
+func (q waiterQueue) Less(i, j int) bool {
+
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waiterQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waiterQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waiterQueue) Pop() interface{} {
+	old := *q
+	w := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,144 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// gateLimiter lets a request through for every value sent on it, failing it with the value when not nil
+type gateLimiter chan error
+
+func (g gateLimiter) Wait(ctx context.Context) error {
+	select {
+	case err := <-g:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// queued waits until l has n requests waiting
+func queued(t *testing.T, l *PriorityLimiter, n int) {
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		l.mu.Lock()
+		length := l.queue.Len()
+		l.mu.Unlock()
+		if length == n {
+			return
+		}
+	}
+
+	t.Fatalf("%d requests never queued", n)
+}
+
+func TestPriorityLimiter(t *testing.T) {
+
+	errInner := errors.New("limiter table unreachable")
+
+	tests := []struct {
+		name       string
+		priorities []Priority
+		gate       []error
+		order      []int
+		errs       []error
+	}{
+		{
+			name:       "highest priority first",
+			priorities: []Priority{PriorityBulk, PriorityNormal, PriorityInteractive},
+			gate:       []error{nil, nil, nil},
+			order:      []int{2, 1, 0},
+			errs:       []error{nil, nil, nil},
+		},
+		{
+			name:       "same priority in order",
+			priorities: []Priority{PriorityNormal, PriorityNormal, PriorityNormal},
+			gate:       []error{nil, nil, nil},
+			order:      []int{0, 1, 2},
+			errs:       []error{nil, nil, nil},
+		},
+		{
+			name:       "interactive overtakes bulk",
+			priorities: []Priority{PriorityBulk, PriorityInteractive, PriorityBulk, PriorityInteractive},
+			gate:       []error{nil, nil, nil, nil},
+			order:      []int{1, 3, 0, 2},
+			errs:       []error{nil, nil, nil, nil},
+		},
+		{
+			name:       "inner error fails the head only",
+			priorities: []Priority{PriorityBulk, PriorityInteractive},
+			gate:       []error{errInner, nil},
+			order:      []int{1, 0},
+			errs:       []error{nil, errInner},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			gate := make(gateLimiter)
+			l := NewPriorityLimiter(gate)
+
+			type grant struct {
+				index int
+				err   error
+			}
+			granted := make(chan grant, len(test.priorities))
+			for i, p := range test.priorities {
+				go func(i int, p Priority) {
+					granted <- grant{index: i, err: l.Wait(WithPriority(context.Background(), p))}
+				}(i, p)
+				queued(t, l, i+1)
+			}
+
+			var order []int
+			errs := make([]error, len(test.priorities))
+			for _, err := range test.gate {
+				gate <- err
+				g := <-granted
+				order = append(order, g.index)
+				errs[g.index] = g.err
+			}
+
+			if !reflect.DeepEqual(order, test.order) {
+				t.Errorf("granted %v, expected %v", order, test.order)
+			}
+			if !reflect.DeepEqual(errs, test.errs) {
+				t.Errorf("errors %v, expected %v", errs, test.errs)
+			}
+		})
+	}
+}
+
+func TestPriorityLimiterCanceled(t *testing.T) {
+
+	gate := make(gateLimiter)
+	l := NewPriorityLimiter(gate)
+
+	ctx, cancel := context.WithCancel(WithPriority(context.Background(), PriorityInteractive))
+	canceled := make(chan error)
+	go func() { canceled <- l.Wait(ctx) }()
+	queued(t, l, 1)
+
+	waited := make(chan error)
+	go func() { waited <- l.Wait(context.Background()) }()
+	queued(t, l, 2)
+
+	cancel()
+	if err := <-canceled; err != context.Canceled {
+		t.Fatalf("canceled request returned %v", err)
+	}
+	queued(t, l, 1)
+
+	gate <- nil
+	if err := <-waited; err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := l.Stats(); stats[PriorityNormal].Requests != 1 || stats[PriorityInteractive].Requests != 0 {
+		t.Errorf("stats %+v, expected the normal request only", stats)
+	}
+}
@@ -0,0 +1,107 @@
+package geomap
+
+import "sort"
+
+// RankInput is what a Ranker orders, Origin is nil when the search had no location
+type RankInput struct {
+	Query  string
+	Origin *GoogleLocation
+	Places []Place
+}
+
+// Ranker reorders search results, e.g. to tune relevance for a product
+type Ranker interface {
+	Rank(input RankInput) []Place
+}
+
+// RankerFunc adapts a function to a Ranker
+type RankerFunc func(input RankInput) []Place
+
+func (f RankerFunc) Rank(input RankInput) []Place {
+	return f(input)
+}
+
+// WithRanker makes nearby and text search (v2) return their results ordered by ranker
+func WithRanker(ranker Ranker) ClientOption {
+	return func(c *Client) error {
+		c.ranker = ranker
+		return nil
+	}
+}
+
+// ScoreRanker orders the places by descending score, ties keep google order
+func ScoreRanker(score func(input RankInput, place Place) float64) Ranker {
+	return RankerFunc(func(input RankInput) []Place {
+
+		scores := make([]float64, len(input.Places))
+		for i, place := range input.Places {
+			scores[i] = score(input, place)
+		}
+
+		ranked := make([]int, len(input.Places))
+		for i := range ranked {
+			ranked[i] = i
+		}
+		sort.SliceStable(ranked, func(a, b int) bool {
+			return scores[ranked[a]] > scores[ranked[b]]
+		})
+
+		places := make([]Place, len(ranked))
+		for i, index := range ranked {
+			places[i] = input.Places[index]
+		}
+
+		return places
+	})
+}
+
+/*
+	DistanceWeightedRating scores a place by its rating weighted by its distance to the
+	search origin, the weight halves at halfDistance meters. Without origin it is the rating
+*/
+func DistanceWeightedRating(halfDistance float64) Ranker {
+	return ScoreRanker(func(input RankInput, place Place) float64 {
+
+		if input.Origin == nil || halfDistance <= 0 {
+			return place.Rating
+		}
+
+		distance := DistanceMeters(*input.Origin, place.Geometry.Location)
+		return place.Rating * halfDistance / (halfDistance + distance)
+	})
+}
+
+/*
+	BayesianRating scores a place by its rating pulled toward priorMean as if it had
+	priorCount more ratings of priorMean, so a 5.0 from 2 users doesn't beat a 4.6 from 2000
+*/
+func BayesianRating(priorMean float64, priorCount int) Ranker {
+	return ScoreRanker(func(input RankInput, place Place) float64 {
+
+		count := float64(place.UserRatingsTotal)
+		prior := float64(priorCount)
+		if count+prior == 0 {
+			return priorMean
+		}
+
+		return (prior*priorMean + count*place.Rating) / (prior + count)
+	})
+}
+
+// rank orders places with the client ranker, if any
+func (c *Client) rank(params map[string]string, places []Place) []Place {
+
+	if c.ranker == nil || len(places) == 0 {
+		return places
+	}
+
+	input := RankInput{Query: params["query"], Places: places}
+	if input.Query == "" {
+		input.Query = params["keyword"]
+	}
+	if origin, ok := parseLocation(params["location"]); ok {
+		input.Origin = &origin
+	}
+
+	return c.ranker.Rank(input)
+}
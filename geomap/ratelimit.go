@@ -0,0 +1,88 @@
+package geomap
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/*
+	Limiter paces the outbound requests to google,
+	Wait blocks until a request is allowed or the context is done
+*/
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+/*
+	tokenBucket allows perSecond requests on average
+	with bursts of up to burst requests
+*/
+type tokenBucket struct {
+	mu        sync.Mutex
+	perSecond float64
+	burst     float64
+	tokens    float64
+	last      time.Time
+}
+
+/*
+	NewRateLimiter returns a token bucket Limiter allowing perSecond requests
+	on average with bursts of up to burst requests
+*/
+func NewRateLimiter(perSecond float64, burst int) Limiter {
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		perSecond: perSecond,
+		burst:     float64(burst),
+		tokens:    float64(burst),
+		last:      time.Now(),
+	}
+}
+
+func (b *tokenBucket) Wait(ctx context.Context) error {
+
+	for {
+		wait := b.reserve()
+		if wait <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes a token when available, otherwise returns how long until the next one
+func (b *tokenBucket) reserve() time.Duration {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.perSecond
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+
+	if b.perSecond <= 0 {
+		return time.Second
+	}
+
+	return time.Duration((1 - b.tokens) / b.perSecond * float64(time.Second))
+}
@@ -0,0 +1,91 @@
+package geomap
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+/*
+	RenderedRequest is a request to google as sent by the client, with the credentials
+	redacted, to be shared in a bug report or support ticket and replayed once the key is put back
+*/
+type RenderedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+func (r RenderedRequest) String() string {
+	return r.Method + " " + r.URL
+}
+
+// Curl returns the request as a curl command
+func (r RenderedRequest) Curl() string {
+
+	command := "curl"
+	if r.Method != "" && r.Method != "GET" {
+		command += " -X " + r.Method
+	}
+	command += " " + shellQuote(r.URL)
+	if r.Body != "" {
+		command += " -H 'Content-Type: application/json' --data " + shellQuote(r.Body)
+	}
+
+	return command
+}
+
+/*
+	RenderRequest builds the GET request to endpoint with params the way the client
+	would send it (default params, key of the key pool...) without sending it
+*/
+func RenderRequest(endpoint Endpoint, params map[string]string) (RenderedRequest, error) {
+	return DefaultClient().RenderRequest(endpoint, params)
+}
+
+// RenderRequest is the package level RenderRequest using c
+func (c *Client) RenderRequest(endpoint Endpoint, params map[string]string) (RenderedRequest, error) {
+
+	if _, ok := endpointPaths[endpoint]; !ok {
+		return RenderedRequest{}, fmt.Errorf("unknown endpoint %q", endpoint)
+	}
+
+	params, _, err := c.withKey(endpoint, c.withDefaults(endpoint, params))
+	if err != nil {
+		return RenderedRequest{}, err
+	}
+
+	query := url.Values{}
+	for key, val := range params {
+		query.Add(key, val)
+	}
+
+	req, err := http.NewRequest("GET", c.endpointURL(endpoint), nil)
+	if err != nil {
+		return RenderedRequest{}, err
+	}
+	req.URL.RawQuery = query.Encode()
+
+	return renderRequest(req, nil), nil
+}
+
+// renderRequest renders req and its payload, redacting the credentials of its query
+func renderRequest(req *http.Request, payload []byte) RenderedRequest {
+
+	redacted := *req.URL
+	query := redacted.Query()
+	for key := range query {
+		if redactedParams[key] {
+			query.Set(key, "REDACTED")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+
+	return RenderedRequest{Method: req.Method, URL: redacted.String(), Body: string(payload)}
+}
+
+// shellQuote quotes s for a posix shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
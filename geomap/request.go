@@ -0,0 +1,365 @@
+package geomap
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+	getJSON sends a GET request to endpoint with params as the query string
+	and unmarshals the json response into v
+*/
+func (c *Client) getJSON(ctx context.Context, endpoint Endpoint, params map[string]string, v interface{}) error {
+	return c.call(ctx, endpoint, params, nil, nil, v)
+}
+
+// getJSONRepeated is getJSON also sending the params of repeated, which appear several times in the query
+func (c *Client) getJSONRepeated(ctx context.Context, endpoint Endpoint, params map[string]string, repeated url.Values, v interface{}) error {
+	return c.call(ctx, endpoint, params, repeated, nil, v)
+}
+
+/*
+	postJSON sends payload as the json body of a POST request to endpoint, the way
+	the newer google apis are called, and unmarshals the json response into v
+*/
+func (c *Client) postJSON(ctx context.Context, endpoint Endpoint, params map[string]string, payload interface{}, v interface{}) error {
+
+	body, err := c.jsonCodec().Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return c.call(ctx, endpoint, params, nil, body, v)
+}
+
+// call sends the request to endpoint, a POST of payload when it isn't nil
+func (c *Client) call(ctx context.Context, endpoint Endpoint, params map[string]string, repeated url.Values, payload []byte, v interface{}) error {
+
+	params = c.withDefaults(endpoint, params)
+
+	if c.countryPolicy != nil {
+		if err := c.countryPolicy.checkRequest(params); err != nil {
+			return err
+		}
+	}
+
+	query := url.Values{}
+	for key, val := range params {
+		query.Add(key, val)
+	}
+	for key, values := range repeated {
+		query[key] = append(query[key], values...)
+	}
+
+	//the newer apis answer without a status to tell what is worth caching
+	var key string
+	if c.cache != nil && payload == nil {
+		key = cacheKey(endpoint, normalizedQuery(endpoint, query))
+		if body, found := c.cached(ctx, key); found && ctx.Value(cacheRefreshKey{}) == nil && !c.dryRun && !bypassCached(ctx, body) {
+			if err := c.decode(endpoint, body, v); err != nil {
+				return err
+			}
+			return c.transform(v)
+		}
+	}
+
+	//the pool key is picked by the request actually sent, identical calls share it,
+	//and kept by its pagetoken retries
+	var sent map[string]string
+	var apiKey string
+	fetch := func() (*fetchResult, error) {
+
+		if sent == nil {
+			var err error
+			if sent, apiKey, err = c.withKey(endpoint, params); err != nil {
+				return nil, err
+			}
+		}
+
+		sentQuery := query
+		if apiKey != "" {
+			sentQuery = url.Values{}
+			for key, values := range query {
+				sentQuery[key] = values
+			}
+			sentQuery.Set("key", apiKey)
+		}
+
+		result, err := c.fetch(ctx, endpoint, sent, sentQuery, payload)
+		if apiKey != "" {
+			c.reportKey(endpoint, apiKey, result, err)
+		}
+		c.deferExhausted(ctx, endpoint, sent, repeated, payload, result, err)
+		return result, err
+	}
+
+	var result *fetchResult
+	var err error
+	for attempt := 0; ; attempt++ {
+		if c.dedup[endpoint] {
+			//Encode sorts the keys so identical requests share the same key, the pool key isn't in it yet
+			result, err = c.flight.do(string(endpoint)+"?"+query.Encode()+string(payload), fetch)
+		} else {
+			result, err = fetch()
+		}
+		if err != nil || !invalidRequest(result) {
+			break
+		}
+
+		retry, waitErr := c.waitPageToken(ctx, params, attempt)
+		if !retry && waitErr == nil {
+			break
+		}
+		if !c.dedup[endpoint] {
+			result.release()
+		}
+		if waitErr != nil {
+			return waitErr
+		}
+	}
+	if err != nil {
+		if key != "" && c.serveStale(ctx, endpoint, key, err, v) {
+			return c.transform(v)
+		}
+		return err
+	}
+
+	//a shared result may still be read by the other callers
+	if !c.dedup[endpoint] {
+		defer result.release()
+	}
+
+	//Unmarshal the contents
+	if err := c.decode(endpoint, result.body, v); err != nil {
+		return err
+	}
+
+	if key != "" {
+		if outageStatuses[result.summarize().Status] && c.serveStale(ctx, endpoint, key, nil, v) {
+			return c.transform(v)
+		}
+		c.storeCached(ctx, endpoint, key, result)
+	}
+
+	if invalidRequest(result) {
+		return newInvalidRequestError(endpoint, params, result.summarize().ErrorMessage)
+	}
+
+	return c.transform(v)
+}
+
+// invalidRequest reports whether google answered INVALID_REQUEST, most responses are never summarized
+func invalidRequest(result *fetchResult) bool {
+	return bytes.Contains(result.body, []byte("INVALID_REQUEST")) && result.summarize().Status == "INVALID_REQUEST"
+}
+
+// resetTarget zeroes the value v points to, before a response is decoded into it again
+func resetTarget(v interface{}) {
+
+	if target := reflect.ValueOf(v); target.Kind() == reflect.Ptr && !target.IsNil() {
+		target.Elem().Set(reflect.Zero(target.Elem().Type()))
+	}
+}
+
+// transform applies the client response policies to a decoded response
+func (c *Client) transform(v interface{}) error {
+
+	//policy first, so filtering isn't fooled by scrubbed or coarsened values
+	if c.countryPolicy != nil {
+		if err := c.countryPolicy.checkResponse(v); err != nil {
+			return err
+		}
+	}
+
+	if c.scrubber != nil {
+		c.scrubber.Scrub(v)
+	}
+
+	if c.coarsen != nil {
+		walkLocations(reflect.ValueOf(v), c.coarsen)
+	}
+
+	return nil
+}
+
+// fetchResult is a successful response, shared between deduplicated calls
+type fetchResult struct {
+	body   []byte
+	header http.Header
+
+	//buf holds body until released to bodyPool
+	buf *bytes.Buffer
+
+	summaryOnce sync.Once
+	summary     responseSummary
+}
+
+// summarize decodes the summary of the body once, however many features need it
+func (r *fetchResult) summarize() responseSummary {
+
+	r.summaryOnce.Do(func() {
+		r.summary = summarize(r.body)
+	})
+
+	return r.summary
+}
+
+// bodies bigger than this aren't pooled, so one huge response isn't kept alive
+const maxPooledBody = 1 << 20
+
+// bodyPool reuses the buffers responses are read into
+var bodyPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// release returns the body buffer to the pool, body must not be used afterwards
+func (r *fetchResult) release() {
+
+	if r.buf == nil {
+		return
+	}
+
+	if r.buf.Cap() <= maxPooledBody {
+		bodyPool.Put(r.buf)
+	}
+	r.buf, r.body = nil, nil
+}
+
+// fetch sends the request and returns the response body
+func (c *Client) fetch(ctx context.Context, endpoint Endpoint, params map[string]string, query url.Values, payload []byte) (*fetchResult, error) {
+
+	var result *fetchResult
+	err := c.stream(ctx, endpoint, params, query, payload, func(body io.Reader, header http.Header, entry *JournalEntry) error {
+
+		maxSize, guard := c.responseLimit(endpoint)
+
+		buf := bodyPool.Get().(*bytes.Buffer)
+		buf.Reset()
+
+		//one byte over the limit tells a body of exactly maxSize from a bigger one
+		if _, err := buf.ReadFrom(io.LimitReader(body, maxSize+1)); err != nil {
+			bodyPool.Put(buf)
+			return err
+		}
+		if int64(buf.Len()) > maxSize {
+			bodyPool.Put(buf)
+			c.tripGuard(guard, endpoint, maxSize)
+			return ErrResponseTooLarge
+		}
+
+		result = &fetchResult{body: buf.Bytes(), header: header, buf: buf}
+
+		if (c.journal != nil || c.alerter != nil) && strings.Contains(header.Get("Content-Type"), "json") {
+			summary := result.summarize()
+			entry.Status = summary.Status
+			entry.Results = summary.count()
+
+			if c.alerter != nil {
+				c.observeStatus(ctx, endpoint, summary)
+			}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+/*
+	stream sends the request and hands the body of a successful response to read
+	while it is still being received, for responses too big to be held in memory
+*/
+func (c *Client) stream(ctx context.Context, endpoint Endpoint, params map[string]string, query url.Values, payload []byte, read func(body io.Reader, header http.Header, entry *JournalEntry) error) error {
+
+	method, body := "GET", io.Reader(nil)
+	if payload != nil {
+		//a bytes.Reader body can be rewound by do when retrying
+		method, body = "POST", bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, c.requestURL(ctx, endpoint), body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	//Insert the query mapping into the request
+	req.URL.RawQuery = query.Encode()
+
+	if err := c.drain.begin(); err != nil {
+		return err
+	}
+	defer c.drain.end()
+
+	if c.killSwitch != nil {
+		if err := c.killSwitch.check(ctx, endpoint, params); err != nil {
+			return err
+		}
+	}
+
+	if c.fieldsCheck != nil && endpoint == EndpointPlaceDetails {
+		if err := c.fieldsCheck.check(ctx, params); err != nil {
+			return err
+		}
+	}
+
+	if c.dryRun {
+		return dryRun(endpoint, renderRequest(req, payload), params)
+	}
+
+	if c.budget != nil {
+		if err := c.budget.charge(endpoint); err != nil {
+			return err
+		}
+	}
+
+	if l := c.rateLimiter(); l != nil {
+		if err := l.Wait(ctx); err != nil {
+			return err
+		}
+	}
+
+	entry := JournalEntry{Time: time.Now(), Endpoint: endpoint, Params: params}
+	if c.journal != nil {
+		entry.Request = renderRequest(req, payload)
+	}
+	defer func() {
+		entry.Duration = time.Since(entry.Time)
+		c.writeJournal(ctx, entry)
+	}()
+
+	resp, err := c.send(ctx, endpoint, req)
+	if err != nil {
+		entry.Error = err.Error()
+		return err
+	}
+	defer resp.Body.Close()
+
+	entry.HTTPStatus = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		//do already buffered the error body
+		body, _ := ioutil.ReadAll(resp.Body)
+		apiErr := newAPIError(resp.StatusCode, body)
+		entry.Status = apiErr.Status
+		entry.Error = apiErr.Error()
+		return apiErr
+	}
+
+	if err := read(resp.Body, resp.Header, &entry); err != nil {
+		entry.Error = err.Error()
+		return err
+	}
+
+	return nil
+}
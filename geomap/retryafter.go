@@ -0,0 +1,144 @@
+package geomap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultThrottleRetries = 3
+	defaultThrottleMaxWait = 10 * time.Second
+
+	//error bodies are small, anything bigger isn't worth holding
+	maxErrorBodySize = 64 * 1024
+)
+
+/*
+	WithThrottleRetry sets how throttled requests (HTTP 429, or 403 with a rate limit message)
+	are retried: up to maxRetries times, waiting as long as Retry-After asks
+	(exponential backoff from one second without it) while the total wait stays under maxWait.
+	Defaults to 3 retries within 10 seconds, 0 retries fails right away
+*/
+func WithThrottleRetry(maxRetries int, maxWait time.Duration) ClientOption {
+	return func(c *Client) error {
+
+		if maxRetries < 0 || maxWait < 0 {
+			return errors.New("throttle retry limits must not be negative")
+		}
+
+		c.throttleRetries = maxRetries
+		c.throttleMaxWait = maxWait
+		return nil
+	}
+}
+
+/*
+	do sends req, waiting and retrying while google throttles it,
+	the time spent waiting is recorded in the context metadata
+*/
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+
+		recordMetadata(ctx, func(meta *Metadata) { meta.Requests++ })
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		//keep the error body readable for the caller
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		if attempt >= c.throttleRetries || !throttled(resp.StatusCode, body) {
+			return resp, nil
+		}
+
+		wait := retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		if wait <= 0 {
+			wait = time.Second << uint(attempt)
+		}
+		if waited+wait > c.throttleMaxWait {
+			return resp, nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+
+		waited += wait
+		recordMetadata(ctx, func(meta *Metadata) {
+			meta.Retries++
+			meta.RetryWait += wait
+		})
+
+		if l := c.rateLimiter(); l != nil {
+			if err := l.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		//the body of a POST was consumed by the previous attempt
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// throttled reports whether a response is google asking to slow down
+func throttled(statusCode int, body []byte) bool {
+
+	if statusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if statusCode == http.StatusForbidden {
+		lower := strings.ToLower(string(body))
+		return strings.Contains(lower, "ratelimitexceeded") || strings.Contains(lower, "rate limit exceeded")
+	}
+
+	return false
+}
+
+// retryAfter parses a Retry-After header, in seconds or as an http date, 0 when absent or invalid
+func retryAfter(header string, now time.Time) time.Duration {
+
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header)); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+
+	return 0
+}
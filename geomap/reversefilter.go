@@ -0,0 +1,110 @@
+package geomap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ResultType is an address type filtering the results of a reverse geocoding
+type ResultType string
+
+const (
+	ResultStreetAddress            ResultType = "street_address"
+	ResultRoute                    ResultType = "route"
+	ResultIntersection             ResultType = "intersection"
+	ResultPolitical                ResultType = "political"
+	ResultCountry                  ResultType = "country"
+	ResultAdministrativeAreaLevel1 ResultType = "administrative_area_level_1"
+	ResultAdministrativeAreaLevel2 ResultType = "administrative_area_level_2"
+	ResultAdministrativeAreaLevel3 ResultType = "administrative_area_level_3"
+	ResultAdministrativeAreaLevel4 ResultType = "administrative_area_level_4"
+	ResultAdministrativeAreaLevel5 ResultType = "administrative_area_level_5"
+	ResultAdministrativeAreaLevel6 ResultType = "administrative_area_level_6"
+	ResultAdministrativeAreaLevel7 ResultType = "administrative_area_level_7"
+	ResultColloquialArea           ResultType = "colloquial_area"
+	ResultLocality                 ResultType = "locality"
+	ResultSublocality              ResultType = "sublocality"
+	ResultNeighborhood             ResultType = "neighborhood"
+	ResultPremise                  ResultType = "premise"
+	ResultSubpremise               ResultType = "subpremise"
+	ResultPlusCode                 ResultType = "plus_code"
+	ResultPostalCode               ResultType = "postal_code"
+	ResultNaturalFeature           ResultType = "natural_feature"
+	ResultAirport                  ResultType = "airport"
+	ResultPark                     ResultType = "park"
+	ResultPointOfInterest          ResultType = "point_of_interest"
+)
+
+// LocationType is the precision of a reverse geocoding result, see GoogleGeometry.LocationType
+type LocationType string
+
+const (
+	LocationRooftop           LocationType = "ROOFTOP"
+	LocationRangeInterpolated LocationType = "RANGE_INTERPOLATED"
+	LocationGeometricCenter   LocationType = "GEOMETRIC_CENTER"
+	LocationApproximate       LocationType = "APPROXIMATE"
+)
+
+var resultTypes = map[ResultType]bool{
+	ResultStreetAddress: true, ResultRoute: true, ResultIntersection: true, ResultPolitical: true,
+	ResultCountry: true, ResultAdministrativeAreaLevel1: true, ResultAdministrativeAreaLevel2: true,
+	ResultAdministrativeAreaLevel3: true, ResultAdministrativeAreaLevel4: true, ResultAdministrativeAreaLevel5: true,
+	ResultAdministrativeAreaLevel6: true, ResultAdministrativeAreaLevel7: true, ResultColloquialArea: true,
+	ResultLocality: true, ResultSublocality: true, ResultNeighborhood: true, ResultPremise: true,
+	ResultSubpremise: true, ResultPlusCode: true, ResultPostalCode: true, ResultNaturalFeature: true,
+	ResultAirport: true, ResultPark: true, ResultPointOfInterest: true,
+}
+
+var locationTypes = map[LocationType]bool{
+	LocationRooftop: true, LocationRangeInterpolated: true, LocationGeometricCenter: true, LocationApproximate: true,
+}
+
+/*
+	ResultTypes sets the "result_type" param of a reverse geocoding (with "latlng") to the
+	results of any of types, an unknown type is refused instead of failing at google
+	more references https://developers.google.com/maps/documentation/geocoding/requests-reverse-geocoding#optional-parameters
+*/
+func ResultTypes(types ...ResultType) ParamOption {
+	return func(params map[string]string) error {
+
+		if len(types) == 0 {
+			return errors.New("result types must not be empty")
+		}
+
+		names := make([]string, len(types))
+		for i, resultType := range types {
+			if !resultTypes[resultType] {
+				return fmt.Errorf("invalid result type %q", resultType)
+			}
+			names[i] = string(resultType)
+		}
+
+		params["result_type"] = strings.Join(names, "|")
+		return nil
+	}
+}
+
+/*
+	LocationTypes sets the "location_type" param of a reverse geocoding (with "latlng")
+	to the results of any of the precisions of types, e.g. only LocationRooftop
+*/
+func LocationTypes(types ...LocationType) ParamOption {
+	return func(params map[string]string) error {
+
+		if len(types) == 0 {
+			return errors.New("location types must not be empty")
+		}
+
+		names := make([]string, len(types))
+		for i, locationType := range types {
+			if !locationTypes[locationType] {
+				return fmt.Errorf("invalid location type %q", locationType)
+			}
+			names[i] = string(locationType)
+		}
+
+		params["location_type"] = strings.Join(names, "|")
+		return nil
+	}
+}
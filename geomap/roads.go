@@ -0,0 +1,164 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// google snaps up to 100 points per request
+const maxSnapPoints = 100
+
+type SnapToRoadsResponse struct {
+	SnappedPoints  []SnappedPoint `json:"snappedPoints"`
+	WarningMessage string         `json:"warningMessage,omitempty"`
+}
+
+/*
+	SnappedPoint is a point of the path on the road, OriginalIndex is the index
+	of the point of the request it was snapped from, nil for interpolated points
+*/
+type SnappedPoint struct {
+	Location      LatLngLiteral `json:"location"`
+	OriginalIndex *int          `json:"originalIndex,omitempty"`
+	PlaceID       string        `json:"placeId"`
+}
+
+/*
+	SnapToRoads will return SnapToRoadsResponse on success
+	params need "path" (up to 100 "lat,lng" separated by "|"), "interpolate"
+	adds the points following the road geometry between them
+	more references https://developers.google.com/maps/documentation/roads/snap
+*/
+func SnapToRoads(ctx context.Context, params map[string]string) (SnapToRoadsResponse, error) {
+	return DefaultClient().SnapToRoads(ctx, params)
+}
+
+// SnapToRoads is the package level SnapToRoads using c
+func (c *Client) SnapToRoads(ctx context.Context, params map[string]string) (SnapToRoadsResponse, error) {
+
+	var snapToRoadsResponse SnapToRoadsResponse
+
+	err := c.getJSON(ctx, EndpointSnapToRoads, params, &snapToRoadsResponse)
+	return snapToRoadsResponse, err
+}
+
+// TracePoint is a GPS fix of a trip, Time is optional
+type TracePoint struct {
+	Location GoogleLocation
+	Time     time.Time
+}
+
+/*
+	TripSegment is the road travelled between the trace points From and To,
+	Duration is only known when both points have a time
+*/
+type TripSegment struct {
+	From           int
+	To             int
+	DistanceMeters float64
+	Duration       time.Duration
+}
+
+// Trip is the road distance of a GPS trace, Path is the trace snapped to the roads
+type Trip struct {
+	DistanceMeters float64
+	Duration       time.Duration
+	Segments       []TripSegment
+	Path           []GoogleLocation
+}
+
+/*
+	TripDistance snaps trace to the roads, with the points between the fixes interpolated
+	along the road, and measures the road distance of the trip and of each segment between
+	two fixes, for mileage and fleet reports. Traces over 100 points are snapped in
+	overlapping chunks. Fixes google can't snap (e.g. GPS noise) are merged into the
+	segment spanning them. The key is taken from the client, see WithAPIKey
+*/
+func TripDistance(ctx context.Context, trace []TracePoint) (Trip, error) {
+	return DefaultClient().TripDistance(ctx, trace)
+}
+
+// TripDistance is the package level TripDistance using c
+func (c *Client) TripDistance(ctx context.Context, trace []TracePoint) (Trip, error) {
+
+	if len(trace) < 2 {
+		return Trip{}, errors.New("trip needs at least 2 trace points")
+	}
+
+	//consecutive chunks share their boundary point so no road is missed between them
+	var snapped []SnappedPoint
+	for start := 0; start < len(trace)-1; start += maxSnapPoints - 1 {
+
+		end := start + maxSnapPoints
+		if end > len(trace) {
+			end = len(trace)
+		}
+
+		path := make([]string, 0, end-start)
+		for _, point := range trace[start:end] {
+			path = append(path, formatLocation(point.Location))
+		}
+
+		resp, err := c.SnapToRoads(ctx, map[string]string{"path": strings.Join(path, "|"), "interpolate": "true"})
+		if err != nil {
+			return Trip{}, err
+		}
+
+		for _, point := range resp.SnappedPoints {
+			if point.OriginalIndex == nil {
+				snapped = append(snapped, point)
+				continue
+			}
+			//the boundary point was snapped by the previous chunk
+			if *point.OriginalIndex == 0 && start > 0 {
+				continue
+			}
+			index := *point.OriginalIndex + start
+			point.OriginalIndex = &index
+			snapped = append(snapped, point)
+		}
+	}
+
+	return measureTrip(trace, snapped), nil
+}
+
+// measureTrip sums the distances along the snapped path, cut into segments at the snapped fixes
+func measureTrip(trace []TracePoint, snapped []SnappedPoint) Trip {
+
+	var (
+		trip     Trip
+		previous = -1
+		distance float64
+	)
+
+	for i, point := range snapped {
+
+		location := GoogleLocation{Lat: point.Location.Latitude, Lng: point.Location.Longitude}
+		if i > 0 {
+			distance += DistanceMeters(trip.Path[i-1], location)
+		}
+		trip.Path = append(trip.Path, location)
+
+		if point.OriginalIndex == nil {
+			continue
+		}
+
+		index := *point.OriginalIndex
+		if previous >= 0 {
+			segment := TripSegment{From: previous, To: index, DistanceMeters: distance}
+			if from, to := trace[previous].Time, trace[index].Time; !from.IsZero() && !to.IsZero() {
+				segment.Duration = to.Sub(from)
+			}
+			trip.Segments = append(trip.Segments, segment)
+			trip.DistanceMeters += segment.DistanceMeters
+			trip.Duration += segment.Duration
+		}
+
+		previous = index
+		distance = 0
+	}
+
+	return trip
+}
@@ -0,0 +1,211 @@
+package geomap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// the element fields returned unless RouteMatrixRequest.Fields says otherwise
+var defaultRouteMatrixFields = []string{"originIndex", "destinationIndex", "status", "condition", "distanceMeters", "duration"}
+
+// RouteWaypoint is an origin or destination of the Routes API, set one of Location, PlaceID or Address
+type RouteWaypoint struct {
+	Location *RouteWaypointLocation `json:"location,omitempty"`
+	PlaceID  string                 `json:"placeId,omitempty"`
+	Address  string                 `json:"address,omitempty"`
+}
+
+type RouteWaypointLocation struct {
+	LatLng LatLngLiteral `json:"latLng"`
+}
+
+// RouteWaypointAt returns the waypoint at lat, lng
+func RouteWaypointAt(lat, lng float64) RouteWaypoint {
+	return RouteWaypoint{Location: &RouteWaypointLocation{LatLng: LatLngLiteral{Latitude: lat, Longitude: lng}}}
+}
+
+/*
+	RouteMatrixRequest is a computeRouteMatrix request, TravelMode defaults to DRIVE
+	and Fields (the element fields to return) to the indexes, status, condition,
+	distance and duration, DepartureTime is only sent when set
+*/
+type RouteMatrixRequest struct {
+	Origins           []RouteWaypoint
+	Destinations      []RouteWaypoint
+	TravelMode        string
+	RoutingPreference string
+	DepartureTime     time.Time
+	LanguageCode      string
+	Units             string
+	Fields            []string
+}
+
+/*
+	RouteMatrixElement is the route from an origin to a destination, the indexes
+	are the positions in the request. Status is set when the element failed
+*/
+type RouteMatrixElement struct {
+	OriginIndex      int        `json:"originIndex"`
+	DestinationIndex int        `json:"destinationIndex"`
+	Status           *RPCStatus `json:"status,omitempty"`
+	Condition        string     `json:"condition,omitempty"`
+	DistanceMeters   int        `json:"distanceMeters,omitempty"`
+	Duration         string     `json:"duration,omitempty"`
+	StaticDuration   string     `json:"staticDuration,omitempty"`
+}
+
+// RPCStatus is the error of a google api, Code 0 is OK
+type RPCStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+/*
+	Err returns the failure of the element: its status, or a condition
+	other than ROUTE_EXISTS when no route links the origin and the destination
+*/
+func (e RouteMatrixElement) Err() error {
+
+	if e.Status != nil && e.Status.Code != 0 {
+		return fmt.Errorf("route matrix element %d,%d: code %d %s", e.OriginIndex, e.DestinationIndex, e.Status.Code, e.Status.Message)
+	}
+	if e.Condition != "" && e.Condition != "ROUTE_EXISTS" {
+		return fmt.Errorf("route matrix element %d,%d: %s", e.OriginIndex, e.DestinationIndex, e.Condition)
+	}
+
+	return nil
+}
+
+// DurationValue parses Duration, google sends it as seconds like "1234s"
+func (e RouteMatrixElement) DurationValue() (time.Duration, error) {
+	return time.ParseDuration(e.Duration)
+}
+
+// routeMatrixBody is the json body of computeRouteMatrix
+type routeMatrixBody struct {
+	Origins           []routeMatrixWaypoint `json:"origins"`
+	Destinations      []routeMatrixWaypoint `json:"destinations"`
+	TravelMode        string                `json:"travelMode,omitempty"`
+	RoutingPreference string                `json:"routingPreference,omitempty"`
+	DepartureTime     string                `json:"departureTime,omitempty"`
+	LanguageCode      string                `json:"languageCode,omitempty"`
+	Units             string                `json:"units,omitempty"`
+}
+
+type routeMatrixWaypoint struct {
+	Waypoint RouteWaypoint `json:"waypoint"`
+}
+
+/*
+	RouteMatrix computes the routes from every origin to every destination with the
+	Routes API. Google streams the elements as they are computed, in no particular order:
+	each is handed to fn as soon as it is decoded instead of buffering the whole matrix,
+	an error returned by fn stops the call and is returned.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/routes/compute_route_matrix
+*/
+func RouteMatrix(ctx context.Context, req RouteMatrixRequest, fn func(RouteMatrixElement) error) error {
+	return DefaultClient().RouteMatrix(ctx, req, fn)
+}
+
+// RouteMatrix is the package level RouteMatrix using c
+func (c *Client) RouteMatrix(ctx context.Context, req RouteMatrixRequest, fn func(RouteMatrixElement) error) error {
+
+	if len(req.Origins) == 0 || len(req.Destinations) == 0 {
+		return errors.New("route matrix needs origins and destinations")
+	}
+
+	body := routeMatrixBody{
+		TravelMode:        req.TravelMode,
+		RoutingPreference: req.RoutingPreference,
+		LanguageCode:      req.LanguageCode,
+		Units:             req.Units,
+	}
+	for _, origin := range req.Origins {
+		body.Origins = append(body.Origins, routeMatrixWaypoint{Waypoint: origin})
+	}
+	for _, destination := range req.Destinations {
+		body.Destinations = append(body.Destinations, routeMatrixWaypoint{Waypoint: destination})
+	}
+	if !req.DepartureTime.IsZero() {
+		body.DepartureTime = req.DepartureTime.UTC().Format(time.RFC3339)
+	}
+
+	payload, err := c.jsonCodec().Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	fields := req.Fields
+	if len(fields) == 0 {
+		fields = defaultRouteMatrixFields
+	}
+
+	//the field mask is required, google also takes it as the $fields param
+	params, apiKey, err := c.withKey(EndpointRouteMatrix, map[string]string{"$fields": strings.Join(fields, ",")})
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	for key, val := range params {
+		query.Add(key, val)
+	}
+
+	err = c.stream(ctx, EndpointRouteMatrix, params, query, payload, func(r io.Reader, header http.Header, entry *JournalEntry) error {
+		count, err := decodeRouteMatrix(r, c.elementLimit(), fn)
+		entry.Results = count
+		return err
+	})
+	if apiErr, ok := err.(*APIError); ok && apiKey != "" {
+		c.keyPool.Report(apiKey, EndpointRouteMatrix, apiErr.Status)
+	}
+
+	return err
+}
+
+// decodeRouteMatrix decodes the elements of the json array one by one, up to maxElements
+func decodeRouteMatrix(r io.Reader, maxElements int, fn func(RouteMatrixElement) error) (int, error) {
+
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return 0, fmt.Errorf("decoding routematrix response: %v", err)
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return 0, fmt.Errorf("decoding routematrix response: expected an array, got %v", token)
+	}
+
+	count := 0
+	for decoder.More() {
+
+		if count == maxElements {
+			return count, ErrTooManyElements
+		}
+
+		var element RouteMatrixElement
+		if err := decoder.Decode(&element); err != nil {
+			return count, fmt.Errorf("decoding routematrix response: %v", err)
+		}
+		count++
+
+		if err := fn(element); err != nil {
+			return count, err
+		}
+	}
+
+	//the closing bracket tells a complete matrix from a connection cut short
+	if _, err := decoder.Token(); err != nil {
+		return count, fmt.Errorf("decoding routematrix response: %v", err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,122 @@
+package geomap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strings"
+)
+
+// ScrubMode is how the scrubber handles a sensitive field
+type ScrubMode int
+
+const (
+	// ScrubRemove empties the sensitive fields
+	ScrubRemove ScrubMode = iota
+	// ScrubHash replaces the sensitive fields by a salted hash, still usable for joins
+	ScrubHash
+)
+
+// DefaultScrubFields are the json fields treated as sensitive when Scrubber.Fields is empty
+var DefaultScrubFields = []string{
+	"formatted_address",
+	"adr_address",
+	"vicinity",
+	"start_address",
+	"end_address",
+	"compound_code",
+	"global_code",
+	"photo_reference",
+}
+
+/*
+	Scrubber removes or hashes potentially sensitive fields (exact addresses,
+	plus codes, photo references) from responses, Fields are json field names
+*/
+type Scrubber struct {
+	Mode   ScrubMode
+	Fields []string
+	Salt   string
+}
+
+/*
+	WithScrubber scrubs every response decoded by the client before it is returned,
+	for deployments with strict location privacy policies
+*/
+func WithScrubber(s Scrubber) ClientOption {
+	return func(c *Client) error {
+		c.scrubber = &s
+		return nil
+	}
+}
+
+// Scrub scrubs v in place, v must be a pointer to a response or model
+func (s Scrubber) Scrub(v interface{}) {
+
+	fields := s.Fields
+	if len(fields) == 0 {
+		fields = DefaultScrubFields
+	}
+
+	sensitive := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		sensitive[field] = true
+	}
+
+	walkStrings(reflect.ValueOf(v), func(name string, value reflect.Value) {
+		if !sensitive[name] || value.String() == "" {
+			return
+		}
+
+		if s.Mode == ScrubHash {
+			sum := sha256.Sum256([]byte(s.Salt + value.String()))
+			value.SetString(hex.EncodeToString(sum[:16]))
+			return
+		}
+
+		value.SetString("")
+	})
+}
+
+// walkStrings calls fn with the json name of every settable string field reachable from v
+func walkStrings(v reflect.Value, fn func(name string, value reflect.Value)) {
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walkStrings(v.Elem(), fn)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkStrings(v.Index(i), fn)
+		}
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			value := v.Field(i)
+			if value.Kind() == reflect.String && value.CanSet() {
+				fn(jsonName(field), value)
+				continue
+			}
+
+			walkStrings(value, fn)
+		}
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
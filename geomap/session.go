@@ -0,0 +1,183 @@
+package geomap
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrSessionCompleted is returned when a session is used again after its details call
+	ErrSessionCompleted = errors.New("autocomplete session already completed")
+
+	// ErrSessionExpired is returned when a session is used after its ttl
+	ErrSessionExpired = errors.New("autocomplete session expired")
+)
+
+/*
+	SessionToken identifies one autocomplete session: the autocomplete calls
+	made while the user types and the details call once a prediction is picked
+	are billed together when they share the same token
+*/
+type SessionToken struct {
+	ID      string
+	Created time.Time
+}
+
+// NewSessionToken returns a random (UUID v4) session token
+func NewSessionToken() (SessionToken, error) {
+
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return SessionToken{}, err
+	}
+
+	//version 4 and RFC 4122 variant bits
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	id := fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16])
+
+	return SessionToken{ID: id, Created: time.Now()}, nil
+}
+
+// Session is an autocomplete session started by a SessionManager
+type Session struct {
+	mu        sync.Mutex
+	token     SessionToken
+	lastUsed  time.Time
+	completed bool
+}
+
+// Token returns the current token of the session
+func (s *Session) Token() SessionToken {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.token
+}
+
+/*
+	SessionManager ties autocomplete calls and the terminating details call together.
+	A session is completed by its details call, reusing it afterwards (or after TTL
+	of inactivity) would be billed as individual requests: with Strict the call fails
+	with ErrSessionCompleted or ErrSessionExpired, otherwise Warn is called and the
+	session continues with a fresh token.
+	The zero value is ready to use with the default client, a 3 minutes TTL and log.Printf warnings
+*/
+type SessionManager struct {
+	Client API
+	TTL    time.Duration
+	Strict bool
+	Warn   func(format string, args ...interface{})
+}
+
+// Start begins a new autocomplete session
+func (m *SessionManager) Start() (*Session, error) {
+
+	token, err := NewSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{token: token, lastUsed: token.Created}, nil
+}
+
+/*
+	Autocomplete calls PlaceAutocomplete with the session token of s,
+	params need "input" and "key"
+*/
+func (m *SessionManager) Autocomplete(ctx context.Context, s *Session, params map[string]string) (GoogleAutocompleteResponse, error) {
+
+	token, err := m.use(s, false)
+	if err != nil {
+		return GoogleAutocompleteResponse{}, err
+	}
+
+	autocompleteParams := copyParams(params)
+	autocompleteParams["sessiontoken"] = token
+
+	return m.client().PlaceAutocomplete(ctx, autocompleteParams)
+}
+
+/*
+	Details calls PlaceDetailV2 with the session token of s and completes the session,
+	params need "placeid" and "key", only request the "fields" you need as they drive the session cost
+*/
+func (m *SessionManager) Details(ctx context.Context, s *Session, params map[string]string) (PlaceDetailResponseV2, error) {
+
+	token, err := m.use(s, true)
+	if err != nil {
+		return PlaceDetailResponseV2{}, err
+	}
+
+	detailParams := copyParams(params)
+	detailParams["sessiontoken"] = token
+
+	return m.client().PlaceDetailV2(ctx, detailParams)
+}
+
+// use validates the session state and returns the token to send
+func (m *SessionManager) use(s *Session, complete bool) (string, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ttl := m.TTL
+	if ttl <= 0 {
+		ttl = 3 * time.Minute
+	}
+
+	var problem error
+	switch {
+	case s.completed:
+		problem = ErrSessionCompleted
+	case time.Since(s.lastUsed) > ttl:
+		problem = ErrSessionExpired
+	}
+
+	if problem != nil {
+		if m.Strict {
+			return "", problem
+		}
+
+		m.warn("geomap: %v, continuing with a new session token", problem)
+		token, err := NewSessionToken()
+		if err != nil {
+			return "", err
+		}
+		s.token = token
+		s.completed = false
+	}
+
+	s.lastUsed = time.Now()
+	if complete {
+		s.completed = true
+	}
+
+	return s.token.ID, nil
+}
+
+func (m *SessionManager) warn(format string, args ...interface{}) {
+
+	if m.Warn != nil {
+		m.Warn(format, args...)
+		return
+	}
+
+	log.Printf(format, args...)
+}
+
+func (m *SessionManager) client() API {
+
+	if m.Client != nil {
+		return m.Client
+	}
+
+	return DefaultClient()
+}
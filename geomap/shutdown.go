@@ -0,0 +1,137 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClientClosed is returned by the calls made after Shutdown
+var ErrClientClosed = errors.New("client is shut down")
+
+/*
+	Flusher holds buffered work to send before the process exits, e.g. the buffering
+	journal sinks of the awsadapter package or CacheSnapshots
+*/
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+/*
+	ShutdownReport is what Shutdown did: Drained calls completed while it waited,
+	Dropped calls were still in flight at the deadline and may never complete,
+	Rejected calls were refused since Shutdown started. FlushErrors has a message
+	per flusher which failed, what it buffered is lost
+*/
+type ShutdownReport struct {
+	Drained     int      `json:"drained"`
+	Dropped     int      `json:"dropped"`
+	Rejected    int      `json:"rejected"`
+	FlushErrors []string `json:"flush_errors,omitempty"`
+}
+
+// drainState counts the calls in flight of a client and refuses new ones once closed
+type drainState struct {
+	mu       sync.Mutex
+	closed   bool
+	inFlight int
+	rejected int
+	idle     chan struct{}
+	flushers []Flusher
+}
+
+// WithShutdownFlush flushes flushers when the client is shut down, after its calls drained
+func WithShutdownFlush(flushers ...Flusher) ClientOption {
+	return func(c *Client) error {
+		c.drain.flushers = append(c.drain.flushers, flushers...)
+		return nil
+	}
+}
+
+// begin counts a call in flight, it fails once the client is shut down
+func (d *drainState) begin() error {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		d.rejected++
+		return ErrClientClosed
+	}
+
+	d.inFlight++
+	return nil
+}
+
+// end uncounts a call started by begin
+func (d *drainState) end() {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.inFlight--
+	if d.inFlight == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+/*
+	Shutdown stops the client accepting calls, new ones fail with ErrClientClosed, waits
+	for the calls in flight until ctx is done and then flushes the flushers of
+	WithShutdownFlush and the journal with what is left of ctx, without a deadline when
+	the drain used it up. Call it on SIGTERM in servers and long
+	running consumers, see the handler package for the lambdas. The error is the first
+	flush failure, the report has all of them and the calls dropped
+*/
+func (c *Client) Shutdown(ctx context.Context) (ShutdownReport, error) {
+
+	d := &c.drain
+
+	d.mu.Lock()
+	d.closed = true
+	started := d.inFlight
+	var idle chan struct{}
+	if d.inFlight > 0 {
+		if d.idle == nil {
+			d.idle = make(chan struct{})
+		}
+		idle = d.idle
+	}
+	d.mu.Unlock()
+
+	if idle != nil {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+		}
+	}
+
+	d.mu.Lock()
+	report := ShutdownReport{Drained: started - d.inFlight, Dropped: d.inFlight, Rejected: d.rejected}
+	flushers := append([]Flusher(nil), d.flushers...)
+	d.mu.Unlock()
+
+	//the journal goes last so the entries of the drained calls are in it
+	if flusher, ok := c.journal.(Flusher); ok {
+		flushers = append(flushers, flusher)
+	}
+
+	//the flushes run whatever the drain left of ctx, a dropped call must not lose the rest
+	flushCtx := ctx
+	if ctx.Err() != nil {
+		flushCtx = context.Background()
+	}
+
+	var first error
+	for _, flusher := range flushers {
+		if err := flusher.Flush(flushCtx); err != nil {
+			report.FlushErrors = append(report.FlushErrors, err.Error())
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	return report, first
+}
@@ -0,0 +1,47 @@
+package geomap
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+/*
+	ResultSink receives results as they are produced, e.g. by BatchGeocode,
+	to stream them to a file or a Firehose delivery stream (see the awsadapter package).
+	Put may buffer, Flush sends what is buffered
+*/
+type ResultSink interface {
+	Put(ctx context.Context, result interface{}) error
+	Flush(ctx context.Context) error
+}
+
+// writerSink writes the results as json lines
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a ResultSink writing one json line per result to w
+func NewWriterSink(w io.Writer) ResultSink {
+	return &writerSink{w: w}
+}
+
+func (s *writerSink) Put(ctx context.Context, result interface{}) error {
+
+	line, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+func (s *writerSink) Flush(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,181 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// the imagery quality a solar request accepts, google answers 404 when only lower quality imagery exists
+const (
+	SolarQualityHigh   = "HIGH"
+	SolarQualityMedium = "MEDIUM"
+	SolarQualityLow    = "LOW"
+)
+
+type LatLngBox struct {
+	SW LatLngLiteral `json:"sw"`
+	NE LatLngLiteral `json:"ne"`
+}
+
+type BuildingInsightsResponse struct {
+	Name                 string         `json:"name"`
+	Center               LatLngLiteral  `json:"center"`
+	BoundingBox          LatLngBox      `json:"boundingBox"`
+	ImageryDate          Date           `json:"imageryDate"`
+	ImageryProcessedDate Date           `json:"imageryProcessedDate"`
+	ImageryQuality       string         `json:"imageryQuality"`
+	PostalCode           string         `json:"postalCode,omitempty"`
+	AdministrativeArea   string         `json:"administrativeArea,omitempty"`
+	StatisticalArea      string         `json:"statisticalArea,omitempty"`
+	RegionCode           string         `json:"regionCode,omitempty"`
+	SolarPotential       SolarPotential `json:"solarPotential"`
+}
+
+// SolarPotential is how much of the roof can hold panels and what they would produce
+type SolarPotential struct {
+	MaxArrayPanelsCount        int                `json:"maxArrayPanelsCount"`
+	MaxArrayAreaMeters2        float64            `json:"maxArrayAreaMeters2"`
+	MaxSunshineHoursPerYear    float64            `json:"maxSunshineHoursPerYear"`
+	CarbonOffsetFactorKgPerMwh float64            `json:"carbonOffsetFactorKgPerMwh"`
+	WholeRoofStats             SizeAndSunshine    `json:"wholeRoofStats"`
+	BuildingStats              SizeAndSunshine    `json:"buildingStats"`
+	RoofSegmentStats           []RoofSegment      `json:"roofSegmentStats"`
+	SolarPanelConfigs          []SolarPanelConfig `json:"solarPanelConfigs"`
+	SolarPanels                []SolarPanel       `json:"solarPanels"`
+	PanelCapacityWatts         float64            `json:"panelCapacityWatts"`
+	PanelHeightMeters          float64            `json:"panelHeightMeters"`
+	PanelWidthMeters           float64            `json:"panelWidthMeters"`
+	PanelLifetimeYears         int                `json:"panelLifetimeYears"`
+}
+
+// SizeAndSunshine is the area of a roof (segment) and the quantiles of the sunshine it receives in hours per year
+type SizeAndSunshine struct {
+	AreaMeters2       float64   `json:"areaMeters2"`
+	SunshineQuantiles []float64 `json:"sunshineQuantiles"`
+	GroundAreaMeters2 float64   `json:"groundAreaMeters2"`
+}
+
+type RoofSegment struct {
+	PitchDegrees              float64         `json:"pitchDegrees"`
+	AzimuthDegrees            float64         `json:"azimuthDegrees"`
+	Stats                     SizeAndSunshine `json:"stats"`
+	Center                    LatLngLiteral   `json:"center"`
+	BoundingBox               LatLngBox       `json:"boundingBox"`
+	PlaneHeightAtCenterMeters float64         `json:"planeHeightAtCenterMeters"`
+}
+
+// SolarPanelConfig is a layout of PanelsCount panels, the best segments being filled first
+type SolarPanelConfig struct {
+	PanelsCount          int                  `json:"panelsCount"`
+	YearlyEnergyDcKwh    float64              `json:"yearlyEnergyDcKwh"`
+	RoofSegmentSummaries []RoofSegmentSummary `json:"roofSegmentSummaries"`
+}
+
+type RoofSegmentSummary struct {
+	PitchDegrees      float64 `json:"pitchDegrees"`
+	AzimuthDegrees    float64 `json:"azimuthDegrees"`
+	PanelsCount       int     `json:"panelsCount"`
+	YearlyEnergyDcKwh float64 `json:"yearlyEnergyDcKwh"`
+	SegmentIndex      int     `json:"segmentIndex"`
+}
+
+type SolarPanel struct {
+	Center            LatLngLiteral `json:"center"`
+	Orientation       string        `json:"orientation"`
+	YearlyEnergyDcKwh float64       `json:"yearlyEnergyDcKwh"`
+	SegmentIndex      int           `json:"segmentIndex"`
+}
+
+/*
+	DataLayersOptions configures SolarDataLayers: the RadiusMeters around the location to cover,
+	the View (e.g. "FULL_LAYERS", "IMAGERY_LAYERS"), the minimum RequiredQuality and the
+	PixelSizeMeters of the rasters (0.1 by default), zero values are left to google
+*/
+type DataLayersOptions struct {
+	RadiusMeters    float64
+	View            string
+	RequiredQuality string
+	PixelSizeMeters float64
+}
+
+/*
+	DataLayersResponse holds the urls of the GeoTIFF rasters around a location,
+	they are fetched with the api key appended as the "key" query param
+*/
+type DataLayersResponse struct {
+	ImageryDate          Date     `json:"imageryDate"`
+	ImageryProcessedDate Date     `json:"imageryProcessedDate"`
+	ImageryQuality       string   `json:"imageryQuality"`
+	DSMURL               string   `json:"dsmUrl"`
+	RGBURL               string   `json:"rgbUrl"`
+	MaskURL              string   `json:"maskUrl"`
+	AnnualFluxURL        string   `json:"annualFluxUrl,omitempty"`
+	MonthlyFluxURL       string   `json:"monthlyFluxUrl,omitempty"`
+	HourlyShadeURLs      []string `json:"hourlyShadeUrls,omitempty"`
+}
+
+/*
+	BuildingInsights returns the roof segments and solar potential of the building closest
+	to lat, lng, imagery under requiredQuality (SolarQualityHigh when empty) is refused.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/solar/building-insights
+*/
+func BuildingInsights(ctx context.Context, lat, lng float64, requiredQuality string) (BuildingInsightsResponse, error) {
+	return DefaultClient().BuildingInsights(ctx, lat, lng, requiredQuality)
+}
+
+// BuildingInsights is the package level BuildingInsights using c
+func (c *Client) BuildingInsights(ctx context.Context, lat, lng float64, requiredQuality string) (BuildingInsightsResponse, error) {
+
+	var buildingInsightsResponse BuildingInsightsResponse
+
+	params := map[string]string{
+		"location.latitude":  strconv.FormatFloat(lat, 'f', -1, 64),
+		"location.longitude": strconv.FormatFloat(lng, 'f', -1, 64),
+	}
+	if requiredQuality != "" {
+		params["requiredQuality"] = requiredQuality
+	}
+
+	err := c.getJSON(ctx, EndpointBuildingInsights, params, &buildingInsightsResponse)
+	return buildingInsightsResponse, err
+}
+
+/*
+	SolarDataLayers returns the urls of the solar rasters (elevation, imagery, roof mask,
+	flux and shade) covering lat, lng.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/solar/data-layers
+*/
+func SolarDataLayers(ctx context.Context, lat, lng float64, opts DataLayersOptions) (DataLayersResponse, error) {
+	return DefaultClient().SolarDataLayers(ctx, lat, lng, opts)
+}
+
+// SolarDataLayers is the package level SolarDataLayers using c
+func (c *Client) SolarDataLayers(ctx context.Context, lat, lng float64, opts DataLayersOptions) (DataLayersResponse, error) {
+
+	var dataLayersResponse DataLayersResponse
+
+	if opts.RadiusMeters <= 0 {
+		return dataLayersResponse, errors.New("data layers radius must be positive")
+	}
+
+	params := map[string]string{
+		"location.latitude":  strconv.FormatFloat(lat, 'f', -1, 64),
+		"location.longitude": strconv.FormatFloat(lng, 'f', -1, 64),
+		"radiusMeters":       strconv.FormatFloat(opts.RadiusMeters, 'f', -1, 64),
+	}
+	if opts.View != "" {
+		params["view"] = opts.View
+	}
+	if opts.RequiredQuality != "" {
+		params["requiredQuality"] = opts.RequiredQuality
+	}
+	if opts.PixelSizeMeters > 0 {
+		params["pixelSizeMeters"] = strconv.FormatFloat(opts.PixelSizeMeters, 'f', -1, 64)
+	}
+
+	err := c.getJSON(ctx, EndpointDataLayers, params, &dataLayersResponse)
+	return dataLayersResponse, err
+}
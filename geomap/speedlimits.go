@@ -0,0 +1,158 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// speed limit units
+const (
+	SpeedKPH = "KPH"
+	SpeedMPH = "MPH"
+)
+
+/*
+	ErrPremiumRequired is returned by SpeedLimits when the client wasn't opted in with
+	WithSpeedLimits or when google refused the project access to the premium endpoint
+*/
+var ErrPremiumRequired = errors.New("speed limits need roads api premium access (asset tracking license)")
+
+/*
+	WithSpeedLimits enables SpeedLimits, the Roads API speed limits are only served
+	to projects with an asset tracking license and billed as premium requests,
+	so they must be opted in explicitly
+*/
+func WithSpeedLimits() ClientOption {
+	return func(c *Client) error {
+		c.speedLimits = true
+		return nil
+	}
+}
+
+// SpeedLimit is the speed limit of a road segment identified by its place id
+type SpeedLimit struct {
+	PlaceID    string  `json:"placeId"`
+	SpeedLimit float64 `json:"speedLimit"`
+	Units      string  `json:"units"`
+}
+
+type SpeedLimitsResponse struct {
+	SpeedLimits    []SpeedLimit   `json:"speedLimits"`
+	SnappedPoints  []SnappedPoint `json:"snappedPoints,omitempty"`
+	WarningMessage string         `json:"warningMessage,omitempty"`
+}
+
+/*
+	SpeedLimitsRequest asks the speed limits along Path (snapped to the roads first) or of
+	the road segments PlaceIDs, up to 100 of either, Units is SpeedKPH (default) or SpeedMPH
+*/
+type SpeedLimitsRequest struct {
+	Path     []GoogleLocation
+	PlaceIDs []string
+	Units    string
+}
+
+// InputSpeedLimit is the speed limit joined onto a point of Path or an entry of PlaceIDs, nil when unknown
+type InputSpeedLimit struct {
+	Index      int
+	PlaceID    string
+	SpeedLimit *SpeedLimit
+}
+
+/*
+	SpeedLimitsResult is the response of google and its speed limits joined back onto
+	the inputs of the request, in their order
+*/
+type SpeedLimitsResult struct {
+	Response SpeedLimitsResponse
+	Inputs   []InputSpeedLimit
+}
+
+/*
+	SpeedLimits returns the posted speed limits along a path or of road segments.
+	This is a premium endpoint: the client must be opted in with WithSpeedLimits
+	and the project have an asset tracking license, else ErrPremiumRequired is returned.
+	The key is taken from the client, see WithAPIKey
+	more references https://developers.google.com/maps/documentation/roads/speed-limits
+*/
+func SpeedLimits(ctx context.Context, req SpeedLimitsRequest) (SpeedLimitsResult, error) {
+	return DefaultClient().SpeedLimits(ctx, req)
+}
+
+// SpeedLimits is the package level SpeedLimits using c
+func (c *Client) SpeedLimits(ctx context.Context, req SpeedLimitsRequest) (SpeedLimitsResult, error) {
+
+	var result SpeedLimitsResult
+
+	if !c.speedLimits {
+		return result, ErrPremiumRequired
+	}
+
+	if (len(req.Path) == 0) == (len(req.PlaceIDs) == 0) {
+		return result, errors.New("speed limits need either a path or place ids")
+	}
+	if len(req.Path) > maxSnapPoints || len(req.PlaceIDs) > maxSnapPoints {
+		return result, errors.New("speed limits take up to 100 points or place ids")
+	}
+
+	params := map[string]string{}
+	if req.Units != "" {
+		params["units"] = req.Units
+	}
+
+	var repeated url.Values
+	if len(req.Path) > 0 {
+		path := make([]string, len(req.Path))
+		for i, location := range req.Path {
+			path[i] = formatLocation(location)
+		}
+		params["path"] = strings.Join(path, "|")
+	} else {
+		repeated = url.Values{"placeId": req.PlaceIDs}
+	}
+
+	err := c.getJSONRepeated(ctx, EndpointSpeedLimits, params, repeated, &result.Response)
+	if apiErr, ok := err.(*APIError); ok && apiErr.HTTPStatus == 403 {
+		return result, ErrPremiumRequired
+	}
+	if err != nil {
+		return result, err
+	}
+
+	result.Inputs = joinSpeedLimits(req, result.Response)
+	return result, nil
+}
+
+// joinSpeedLimits matches the speed limits to the inputs by the place id they were snapped to
+func joinSpeedLimits(req SpeedLimitsRequest, resp SpeedLimitsResponse) []InputSpeedLimit {
+
+	limits := make(map[string]*SpeedLimit, len(resp.SpeedLimits))
+	for i := range resp.SpeedLimits {
+		limits[resp.SpeedLimits[i].PlaceID] = &resp.SpeedLimits[i]
+	}
+
+	if len(req.PlaceIDs) > 0 {
+		inputs := make([]InputSpeedLimit, len(req.PlaceIDs))
+		for i, placeID := range req.PlaceIDs {
+			inputs[i] = InputSpeedLimit{Index: i, PlaceID: placeID, SpeedLimit: limits[placeID]}
+		}
+		return inputs
+	}
+
+	inputs := make([]InputSpeedLimit, len(req.Path))
+	for i := range inputs {
+		inputs[i].Index = i
+	}
+	for _, point := range resp.SnappedPoints {
+		if point.OriginalIndex == nil || *point.OriginalIndex < 0 || *point.OriginalIndex >= len(inputs) {
+			continue
+		}
+		input := &inputs[*point.OriginalIndex]
+		input.PlaceID = point.PlaceID
+		input.SpeedLimit = limits[point.PlaceID]
+	}
+
+	return inputs
+}
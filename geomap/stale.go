@@ -0,0 +1,102 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+)
+
+// outageStatuses are the statuses of the responses google sends with a 200 when it can't answer
+var outageStatuses = map[string]bool{
+	"OVER_QUERY_LIMIT": true,
+	"UNKNOWN_ERROR":    true,
+}
+
+// staleKeyPrefix keys the copies of the responses kept past their ttl
+const staleKeyPrefix = "stale/"
+
+/*
+	WithStaleIfError makes a client with a cache (see WithCache) answer the calls to endpoints
+	with their last cached response when google fails (network errors, 5xx, quota or budget
+	exhausted, OVER_QUERY_LIMIT and UNKNOWN_ERROR statuses), provided it expired less than maxStale ago. The calls of a context with
+	Metadata are flagged Stale. A second copy of every response of the endpoints is kept for
+	the cache ttl plus maxStale, call it again for other endpoints with another window
+*/
+func WithStaleIfError(maxStale time.Duration, endpoints ...Endpoint) ClientOption {
+	return func(c *Client) error {
+
+		if maxStale <= 0 {
+			return errors.New("max staleness must be positive")
+		}
+
+		if c.staleIfError == nil {
+			c.staleIfError = map[Endpoint]time.Duration{}
+		}
+		for _, endpoint := range endpoints {
+			c.staleIfError[endpoint] = maxStale
+		}
+		return nil
+	}
+}
+
+// storeStale keeps a copy of body past the cache ttl, when endpoint may be served stale
+func (c *Client) storeStale(ctx context.Context, endpoint Endpoint, key string, body []byte) {
+
+	window := c.staleIfError[endpoint]
+	//responses cached forever never go stale
+	if window <= 0 || c.cacheTTL <= 0 {
+		return
+	}
+
+	if err := c.cache.Set(ctx, staleKeyPrefix+key, body, c.cacheTTL+window); err != nil {
+		log.Printf("geomap: cache set failed: %v", err)
+	}
+}
+
+/*
+	serveStale decodes the stale copy of key into v when the call failed with err
+	in a way worth hiding, telling whether it did
+*/
+func (c *Client) serveStale(ctx context.Context, endpoint Endpoint, key string, err error, v interface{}) bool {
+
+	//the caller gave up, nobody is waiting for the stale response,
+	//or refreshes the cache and wants google's answer or none
+	if c.staleIfError[endpoint] <= 0 || ctx.Err() != nil || ctx.Value(cacheRefreshKey{}) != nil || !staleable(err) {
+		return false
+	}
+
+	body, found := c.cached(ctx, staleKeyPrefix+key)
+	if !found {
+		return false
+	}
+
+	//v may already hold the failed response, whose fields the stale one may not overwrite
+	resetTarget(v)
+	if c.decode(endpoint, body, v) != nil {
+		return false
+	}
+
+	recordMetadata(ctx, func(meta *Metadata) {
+		meta.Stale = true
+	})
+	return true
+}
+
+// staleable tells whether a failed call may be answered with a stale response
+func staleable(err error) bool {
+
+	switch e := err.(type) {
+	case *DryRunResult:
+		return false
+	case *APIError:
+		//a request google refuses as such would be refused again, only hide its outages
+		return e.HTTPStatus >= 500 || e.HTTPStatus == 429
+	case *url.Error:
+		//the http client wraps the error of the context
+		err = e.Err
+	}
+
+	return err != context.Canceled
+}
@@ -0,0 +1,134 @@
+package geomap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// statusTransport answers every request with its http status and an empty json body
+type statusTransport int
+
+func (t statusTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: int(t),
+		Header:     http.Header{"Content-Type": {"application/json; charset=UTF-8"}},
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{}`))),
+		Request:    req,
+	}, nil
+}
+
+// errTransport fails every request with the network error it holds
+type errTransport struct{ err error }
+
+func (t errTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// outageTransport answers from google until down is set, then from down
+type outageTransport struct {
+	mu   sync.Mutex
+	down http.RoundTripper
+}
+
+func (t *outageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+
+	t.mu.Lock()
+	down := t.down
+	t.mu.Unlock()
+
+	if down != nil {
+		return down.RoundTrip(req)
+	}
+	return fixtureTransport(fixtureGeocode()).RoundTrip(req)
+}
+
+func TestStaleIfError(t *testing.T) {
+
+	const ttl = 10 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		outage   http.RoundTripper
+		maxStale time.Duration
+		ctx      func(ctx context.Context) context.Context
+		stale    bool
+	}{
+		{name: "5xx", outage: statusTransport(http.StatusServiceUnavailable), maxStale: time.Minute, stale: true},
+		{name: "429", outage: statusTransport(http.StatusTooManyRequests), maxStale: time.Minute, stale: true},
+		{name: "network error", outage: errTransport{errors.New("connection reset")}, maxStale: time.Minute, stale: true},
+		{name: "OVER_QUERY_LIMIT", outage: fixtureTransport(`{"results":[],"status":"OVER_QUERY_LIMIT"}`), maxStale: time.Minute, stale: true},
+		{name: "UNKNOWN_ERROR", outage: fixtureTransport(`{"results":[],"status":"UNKNOWN_ERROR"}`), maxStale: time.Minute, stale: true},
+		{name: "4xx not hidden", outage: statusTransport(http.StatusForbidden), maxStale: time.Minute},
+		{name: "expired past max staleness", outage: statusTransport(http.StatusServiceUnavailable), maxStale: time.Millisecond},
+		{
+			name: "refresh not answered stale", outage: statusTransport(http.StatusServiceUnavailable), maxStale: time.Minute,
+			ctx: func(ctx context.Context) context.Context { return context.WithValue(ctx, cacheRefreshKey{}, true) },
+		},
+		{
+			name: "canceled call not answered", outage: statusTransport(http.StatusServiceUnavailable), maxStale: time.Minute,
+			ctx: func(ctx context.Context) context.Context {
+				ctx, cancel := context.WithCancel(ctx)
+				cancel()
+				return ctx
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			transport := &outageTransport{}
+			//a throttled request fails right away instead of being retried
+			c := transportClient(t, transport, WithCache(NewMemoryCache(0), ttl), WithStaleIfError(test.maxStale, EndpointGeocode), WithThrottleRetry(0, 0))
+
+			params := map[string]string{"address": "Jl. Sudirman 1", "key": "k"}
+			if _, err := c.GetGeocode(context.Background(), params); err != nil {
+				t.Fatal(err)
+			}
+
+			//the fresh response expires, google goes down
+			time.Sleep(2 * ttl)
+			transport.mu.Lock()
+			transport.down = test.outage
+			transport.mu.Unlock()
+
+			var meta Metadata
+			ctx := WithMetadata(context.Background(), &meta)
+			if test.ctx != nil {
+				ctx = test.ctx(ctx)
+			}
+
+			resp, err := c.GetGeocode(ctx, params)
+			stale := err == nil && resp.Status == "OK" && len(resp.Results) == 1
+			if stale != test.stale || meta.Stale != test.stale {
+				t.Errorf("status %q, error %v, flagged stale %v, expected stale %v", resp.Status, err, meta.Stale, test.stale)
+			}
+		})
+	}
+}
+
+func TestStaleIfErrorOtherEndpoints(t *testing.T) {
+
+	transport := &outageTransport{}
+	c := transportClient(t, transport, WithCache(NewMemoryCache(0), time.Millisecond), WithStaleIfError(time.Minute, EndpointPlaceDetails))
+
+	params := map[string]string{"address": "Jl. Sudirman 1", "key": "k"}
+	if _, err := c.GetGeocode(context.Background(), params); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	transport.mu.Lock()
+	transport.down = statusTransport(http.StatusServiceUnavailable)
+	transport.mu.Unlock()
+
+	if _, err := c.GetGeocode(context.Background(), params); err == nil {
+		t.Error("geocode answered stale, only place details may be")
+	}
+}
@@ -0,0 +1,239 @@
+/*
+	Package store persists what the geomap client geocodes, a self hosted geocode database
+	built from the addresses and places seen. Give a DynamoDBStore to geomap.WithGeocodeStore
+	so the client reads through it: an address geocoded once isn't sent to google again
+	until its results expire, google only allows keeping them for 30 days
+	more references https://cloud.google.com/maps-platform/terms/maps-service-terms
+*/
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gomapservice/geomap"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+)
+
+// kinds of the items of a DynamoDBStore
+const (
+	KindAddress = "address"
+	KindPlace   = "place"
+)
+
+// MaxTTL is the longest google allows geocode results to be stored, and the default TTL
+const MaxTTL = 30 * 24 * time.Hour
+
+/*
+	DynamoDBStore keeps the geocoded addresses and places in a DynamoDB table with a string
+	hash key "key": an address item ("address#" and the sha256 of the normalized address
+	with the params changing its results) holds the results of its geocode, a place item
+	("place#" and the place_id) the latest result naming the place. The keys of a tenant
+	are prefixed by its id and "/", see geomap.GeocodeKey. Each save increments the
+	"version" of the item. Every item expires after TTL (MaxTTL when 0 or longer), enable
+	the table TTL on "expires_at" to remove them, they are ignored until removed
+*/
+type DynamoDBStore struct {
+	Client dynamodbiface.DynamoDBAPI
+	Table  string
+	TTL    time.Duration
+}
+
+// Entity is an item of a DynamoDBStore
+type Entity struct {
+	Key               string                 `json:"key"`
+	Kind              string                 `json:"kind"`
+	NormalizedAddress string                 `json:"normalized_address,omitempty"`
+	PlaceID           string                 `json:"place_id,omitempty"`
+	Results           []geomap.GeocodeResult `json:"results"`
+	Version           int64                  `json:"version"`
+	UpdatedAt         time.Time              `json:"updated_at"`
+}
+
+// AddressKey returns the item key of the results of key
+func AddressKey(key geomap.GeocodeKey) string {
+
+	sum := sha256.Sum256([]byte(key.String()))
+	return tenantPrefix(key.Tenant) + KindAddress + "#" + hex.EncodeToString(sum[:])
+}
+
+// PlaceKey returns the item key of a place, see TenantPlaceKey for the places of a tenant
+func PlaceKey(placeID string) string {
+	return KindPlace + "#" + placeID
+}
+
+// TenantPlaceKey returns the item key of a place saved for tenant
+func TenantPlaceKey(tenant, placeID string) string {
+	return tenantPrefix(tenant) + PlaceKey(placeID)
+}
+
+func tenantPrefix(tenant string) string {
+
+	if tenant == "" {
+		return ""
+	}
+	return tenant + "/"
+}
+
+// ttl returns how long the items are kept, never longer than google allows
+func (s *DynamoDBStore) ttl() time.Duration {
+
+	if s.TTL <= 0 || s.TTL > MaxTTL {
+		return MaxTTL
+	}
+	return s.TTL
+}
+
+// LoadGeocode returns the stored results of key
+func (s *DynamoDBStore) LoadGeocode(ctx context.Context, key geomap.GeocodeKey) ([]geomap.GeocodeResult, bool, error) {
+
+	entity, found, err := s.Get(ctx, AddressKey(key))
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	return entity.Results, true, nil
+}
+
+// SaveGeocode stores the results of key and the places they name
+func (s *DynamoDBStore) SaveGeocode(ctx context.Context, key geomap.GeocodeKey, results []geomap.GeocodeResult) error {
+
+	if err := s.put(ctx, AddressKey(key), KindAddress, key.Address, "", results); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.PlaceID == "" {
+			continue
+		}
+		if err := s.put(ctx, TenantPlaceKey(key.Tenant, result.PlaceID), KindPlace, "", result.PlaceID, []geomap.GeocodeResult{result}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Place returns the latest stored result of a place saved without tenant, see TenantPlaceKey
+func (s *DynamoDBStore) Place(ctx context.Context, placeID string) (geomap.GeocodeResult, bool, error) {
+
+	entity, found, err := s.Get(ctx, PlaceKey(placeID))
+	if err != nil || !found || len(entity.Results) == 0 {
+		return geomap.GeocodeResult{}, false, err
+	}
+
+	return entity.Results[0], true, nil
+}
+
+// Get returns the entity of an item key, see AddressKey and PlaceKey
+func (s *DynamoDBStore) Get(ctx context.Context, key string) (Entity, bool, error) {
+
+	out, err := s.Client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.Table),
+		Key:       map[string]*dynamodb.AttributeValue{"key": {S: aws.String(key)}},
+	})
+	if err != nil {
+		return Entity{}, false, err
+	}
+
+	item := out.Item
+	if item["results"] == nil {
+		return Entity{}, false, nil
+	}
+
+	//items saved without expiry expire from their last update
+	expiresAt := numberAttribute(item["updated_at"]) + int64(s.ttl()/time.Second)
+	if expires := item["expires_at"]; expires != nil {
+		expiresAt = numberAttribute(expires)
+	}
+	if time.Now().Unix() >= expiresAt {
+		return Entity{}, false, nil
+	}
+
+	entity := Entity{
+		Key:               key,
+		Kind:              stringAttribute(item["kind"]),
+		NormalizedAddress: stringAttribute(item["normalized_address"]),
+		PlaceID:           stringAttribute(item["place_id"]),
+		Version:           numberAttribute(item["version"]),
+		UpdatedAt:         time.Unix(numberAttribute(item["updated_at"]), 0),
+	}
+	if err := json.Unmarshal(item["results"].B, &entity.Results); err != nil {
+		return Entity{}, false, errors.New("stored results are not valid: " + err.Error())
+	}
+
+	return entity, true, nil
+}
+
+// put writes an item, incrementing its version
+func (s *DynamoDBStore) put(ctx context.Context, key, kind, address, placeID string, results []geomap.GeocodeResult) error {
+
+	encoded, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	set := []string{"#kind = :kind", "#results = :results", "#updated = :updated"}
+	names := map[string]*string{
+		"#kind":    aws.String("kind"),
+		"#results": aws.String("results"),
+		"#updated": aws.String("updated_at"),
+		"#version": aws.String("version"),
+	}
+	values := map[string]*dynamodb.AttributeValue{
+		":kind":    {S: aws.String(kind)},
+		":results": {B: encoded},
+		":updated": {N: aws.String(strconv.FormatInt(now.Unix(), 10))},
+		":one":     {N: aws.String("1")},
+	}
+
+	if address != "" {
+		set = append(set, "#address = :address")
+		names["#address"] = aws.String("normalized_address")
+		values[":address"] = &dynamodb.AttributeValue{S: aws.String(address)}
+	}
+	if placeID != "" {
+		set = append(set, "#place = :place")
+		names["#place"] = aws.String("place_id")
+		values[":place"] = &dynamodb.AttributeValue{S: aws.String(placeID)}
+	}
+	set = append(set, "#expires = :expires")
+	names["#expires"] = aws.String("expires_at")
+	values[":expires"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(now.Add(s.ttl()).Unix(), 10))}
+
+	_, err = s.Client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.Table),
+		Key:                       map[string]*dynamodb.AttributeValue{"key": {S: aws.String(key)}},
+		UpdateExpression:          aws.String("SET " + strings.Join(set, ", ") + " ADD #version :one"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+func stringAttribute(value *dynamodb.AttributeValue) string {
+
+	if value == nil {
+		return ""
+	}
+	return aws.StringValue(value.S)
+}
+
+func numberAttribute(value *dynamodb.AttributeValue) int64 {
+
+	if value == nil {
+		return 0
+	}
+	n, _ := strconv.ParseInt(aws.StringValue(value.N), 10, 64)
+	return n
+}
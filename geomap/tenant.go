@@ -0,0 +1,233 @@
+package geomap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+/*
+	TenantConfig is the setup of the client of one tenant: its API keys (or a KeyPool),
+	its rate limit (requests per second, unlimited when 0), its budget (see Budget)
+	and Options added after the options shared by every tenant
+*/
+type TenantConfig struct {
+	APIKeys   []string
+	KeyPool   *KeyPool
+	RateLimit float64
+	Burst     int
+	BudgetUSD float64
+	Options   []ClientOption
+}
+
+// TenantLoader returns the configuration of a tenant, e.g. from a database
+type TenantLoader func(ctx context.Context, tenantID string) (TenantConfig, error)
+
+/*
+	ClientPool builds and keeps one Client per tenant of a multi-tenant service, so each tenant
+	calls google with its own keys, is paced by its own rate limit and accounted in its own budget.
+	When set before the first call, Cache and GeocodeStore are shared by the tenants and
+	partitioned by tenant so a tenant never reads responses fetched with the keys of another
+*/
+type ClientPool struct {
+	Cache        Cache
+	CacheTTL     time.Duration
+	GeocodeStore GeocodeStore
+
+	load   TenantLoader
+	shared []ClientOption
+
+	mu      sync.Mutex
+	tenants map[string]*tenantEntry
+}
+
+type tenantEntry struct {
+	ready  chan struct{}
+	client *Client
+	budget *Budget
+	err    error
+}
+
+/*
+	NewClientPool returns a pool loading the configuration of tenants with load,
+	shared options apply to every tenant client before the tenant options
+*/
+func NewClientPool(load TenantLoader, shared ...ClientOption) *ClientPool {
+	return &ClientPool{load: load, shared: shared, tenants: map[string]*tenantEntry{}}
+}
+
+/*
+	Client returns the client of tenantID, built from its configuration on first use.
+	A tenant failing to load isn't kept, the next call loads it again
+*/
+func (p *ClientPool) Client(ctx context.Context, tenantID string) (*Client, error) {
+
+	if tenantID == "" {
+		return nil, errors.New("tenant id must not be empty")
+	}
+
+	p.mu.Lock()
+	entry, ok := p.tenants[tenantID]
+	if !ok {
+		entry = &tenantEntry{ready: make(chan struct{})}
+		p.tenants[tenantID] = entry
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		entry.client, entry.budget, entry.err = p.build(ctx, tenantID)
+		if entry.err != nil {
+			p.mu.Lock()
+			delete(p.tenants, tenantID)
+			p.mu.Unlock()
+		}
+		close(entry.ready)
+	}
+
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return entry.client, entry.err
+}
+
+/*
+	Usage returns what the tenant spent since its budget was last reset,
+	found is false for a tenant without client yet
+*/
+func (p *ClientPool) Usage(tenantID string) (usage BudgetUsage, found bool) {
+
+	entry := p.loaded(tenantID)
+	if entry == nil {
+		return BudgetUsage{}, false
+	}
+
+	return entry.budget.Usage(), true
+}
+
+// ResetUsage resets the budget of every tenant, e.g. at the start of a billing period
+func (p *ClientPool) ResetUsage() {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, entry := range p.tenants {
+		select {
+		case <-entry.ready:
+			if entry.budget != nil {
+				entry.budget.Reset()
+			}
+		default:
+		}
+	}
+}
+
+/*
+	Evict drops the client of tenantID, its configuration is loaded again on next use,
+	e.g. after its keys or limits changed. Its usage is dropped with it
+*/
+func (p *ClientPool) Evict(tenantID string) {
+
+	p.mu.Lock()
+	delete(p.tenants, tenantID)
+	p.mu.Unlock()
+}
+
+// loaded returns the entry of a tenant whose client was built successfully
+func (p *ClientPool) loaded(tenantID string) *tenantEntry {
+
+	p.mu.Lock()
+	entry, ok := p.tenants[tenantID]
+	p.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	select {
+	case <-entry.ready:
+		if entry.err != nil {
+			return nil
+		}
+		return entry
+	default:
+		return nil
+	}
+}
+
+func (p *ClientPool) build(ctx context.Context, tenantID string) (*Client, *Budget, error) {
+
+	config, err := p.load(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	budget := &Budget{LimitUSD: config.BudgetUSD}
+
+	opts := append([]ClientOption{}, p.shared...)
+	opts = append(opts, WithBudget(budget))
+
+	switch {
+	case config.KeyPool != nil:
+		opts = append(opts, WithKeyPool(config.KeyPool))
+	case len(config.APIKeys) > 0:
+		opts = append(opts, WithKeyPool(NewKeyPool(config.APIKeys...)))
+	}
+
+	if config.RateLimit > 0 {
+		burst := config.Burst
+		if burst <= 0 {
+			burst = 1
+		}
+		opts = append(opts, WithRateLimiter(NewRateLimiter(config.RateLimit, burst)))
+	}
+
+	if p.Cache != nil {
+		opts = append(opts, WithCache(prefixedCache{cache: p.Cache, prefix: tenantID + "/"}, p.CacheTTL))
+	}
+	if p.GeocodeStore != nil {
+		opts = append(opts, WithGeocodeStore(tenantGeocodeStore{store: p.GeocodeStore, tenant: tenantID}))
+	}
+
+	opts = append(opts, config.Options...)
+
+	client, err := NewClient(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return client, budget, nil
+}
+
+// prefixedCache is a partition of a cache, its keys are prefixed
+type prefixedCache struct {
+	cache  Cache
+	prefix string
+}
+
+func (c prefixedCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return c.cache.Get(ctx, c.prefix+key)
+}
+
+func (c prefixedCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.cache.Set(ctx, c.prefix+key, value, ttl)
+}
+
+// tenantGeocodeStore is the partition of a geocode store of a tenant, its keys have the tenant set
+type tenantGeocodeStore struct {
+	store  GeocodeStore
+	tenant string
+}
+
+func (s tenantGeocodeStore) LoadGeocode(ctx context.Context, key GeocodeKey) ([]GeocodeResult, bool, error) {
+	key.Tenant = s.tenant
+	return s.store.LoadGeocode(ctx, key)
+}
+
+func (s tenantGeocodeStore) SaveGeocode(ctx context.Context, key GeocodeKey, results []GeocodeResult) error {
+	key.Tenant = s.tenant
+	return s.store.SaveGeocode(ctx, key, results)
+}
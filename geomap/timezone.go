@@ -0,0 +1,30 @@
+package geomap
+
+import "context"
+
+type GoogleTimezoneResponse struct {
+	DstOffset    int    `json:"dstOffset"`
+	RawOffset    int    `json:"rawOffset"`
+	Status       string `json:"status"`
+	TimeZoneID   string `json:"timeZoneId"`
+	TimeZoneName string `json:"timeZoneName"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+/*
+	GetTimezone will return GoogleTimezoneResponse on success
+	params need "location", "timestamp" (unix seconds) and "key"
+	more references https://developers.google.com/maps/documentation/timezone/intro
+*/
+func GetTimezone(ctx context.Context, params map[string]string) (GoogleTimezoneResponse, error) {
+	return DefaultClient().GetTimezone(ctx, params)
+}
+
+// GetTimezone is the package level GetTimezone using c
+func (c *Client) GetTimezone(ctx context.Context, params map[string]string) (GoogleTimezoneResponse, error) {
+
+	var googleTimezoneResponse GoogleTimezoneResponse
+
+	err := c.getJSON(ctx, EndpointTimezone, params, &googleTimezoneResponse)
+	return googleTimezoneResponse, err
+}
@@ -0,0 +1,105 @@
+package geomap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+/*
+	GoogleDuration is a duration as returned by google ({"text": "1 hour 5 mins", "value": 3900}),
+	Duration holds the parsed value so callers don't have to convert seconds themselves
+*/
+type GoogleDuration struct {
+	Text     string        `json:"text"`
+	Value    int           `json:"value"`
+	Duration time.Duration `json:"-"`
+}
+
+func (d *GoogleDuration) UnmarshalJSON(data []byte) error {
+
+	//alias drops the method set so the default decoding can be reused
+	type googleDuration GoogleDuration
+
+	var decoded googleDuration
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*d = GoogleDuration(decoded)
+	d.Duration = time.Duration(d.Value) * time.Second
+
+	return nil
+}
+
+/*
+	DepartureTime sets the "departure_time" param used by directions and distance matrix
+	to compute duration_in_traffic, see DepartNow to depart at the time of the request.
+	It can't be combined with ArrivalTime
+*/
+func DepartureTime(t time.Time) ParamOption {
+	return func(params map[string]string) error {
+
+		if t.IsZero() {
+			return errors.New("departure_time must be set")
+		}
+
+		return setDepartureTime(params, strconv.FormatInt(t.Unix(), 10))
+	}
+}
+
+// DepartNow sets the "departure_time" param to the time of the request, see DepartureTime
+func DepartNow() ParamOption {
+	return func(params map[string]string) error {
+		return setDepartureTime(params, "now")
+	}
+}
+
+func setDepartureTime(params map[string]string, departure string) error {
+
+	if _, ok := params["arrival_time"]; ok {
+		return errors.New("departure_time can't be combined with arrival_time")
+	}
+
+	params["departure_time"] = departure
+	return nil
+}
+
+/*
+	ArrivalTime sets the "arrival_time" param, google only honors it for transit requests.
+	It can't be combined with DepartureTime
+*/
+func ArrivalTime(t time.Time) ParamOption {
+	return func(params map[string]string) error {
+
+		if _, ok := params["departure_time"]; ok {
+			return errors.New("arrival_time can't be combined with departure_time")
+		}
+
+		if t.IsZero() {
+			return errors.New("arrival_time must be set")
+		}
+
+		params["arrival_time"] = strconv.FormatInt(t.Unix(), 10)
+		return nil
+	}
+}
+
+/*
+	TrafficModel sets the "traffic_model" param (best_guess, pessimistic or optimistic),
+	google only uses it for driving requests that also set a departure time
+*/
+func TrafficModel(model string) ParamOption {
+	return func(params map[string]string) error {
+
+		switch model {
+		case "best_guess", "pessimistic", "optimistic":
+			params["traffic_model"] = model
+			return nil
+		}
+
+		return fmt.Errorf("unknown traffic model %q", model)
+	}
+}
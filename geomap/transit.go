@@ -0,0 +1,193 @@
+package geomap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TransitDetails struct {
+	ArrivalStop   TransitStop `json:"arrival_stop"`
+	ArrivalTime   GoogleTime  `json:"arrival_time"`
+	DepartureStop TransitStop `json:"departure_stop"`
+	DepartureTime GoogleTime  `json:"departure_time"`
+	Headsign      string      `json:"headsign"`
+	Headway       int         `json:"headway,omitempty"`
+	Line          TransitLine `json:"line"`
+	NumStops      int         `json:"num_stops"`
+}
+
+type TransitStop struct {
+	Location GoogleLocation `json:"location"`
+	Name     string         `json:"name"`
+}
+
+type TransitLine struct {
+	Agencies  []TransitAgency `json:"agencies"`
+	Color     string          `json:"color,omitempty"`
+	Icon      string          `json:"icon,omitempty"`
+	Name      string          `json:"name"`
+	ShortName string          `json:"short_name"`
+	TextColor string          `json:"text_color,omitempty"`
+	URL       string          `json:"url,omitempty"`
+	Vehicle   TransitVehicle  `json:"vehicle"`
+}
+
+type TransitAgency struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone,omitempty"`
+	URL   string `json:"url"`
+}
+
+type TransitVehicle struct {
+	Icon      string `json:"icon"`
+	LocalIcon string `json:"local_icon,omitempty"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+}
+
+type TransitFare struct {
+	Currency string  `json:"currency"`
+	Text     string  `json:"text"`
+	Value    float64 `json:"value"`
+}
+
+/*
+	GoogleTime is a point in time as returned by google for transit ({"text", "time_zone", "value"}),
+	Time holds the parsed value in the stop's time zone (UTC when the zone is unknown)
+*/
+type GoogleTime struct {
+	Text     string    `json:"text"`
+	TimeZone string    `json:"time_zone"`
+	Value    int64     `json:"value"`
+	Time     time.Time `json:"-"`
+}
+
+func (t *GoogleTime) UnmarshalJSON(data []byte) error {
+
+	//alias drops the method set so the default decoding can be reused
+	type googleTime GoogleTime
+
+	var decoded googleTime
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	*t = GoogleTime(decoded)
+	t.Time = time.Unix(t.Value, 0).UTC()
+	if location := loadLocation(t.TimeZone); location != nil {
+		t.Time = t.Time.In(location)
+	}
+
+	return nil
+}
+
+// locations caches the time zones by name, LoadLocation reads the zone database each time
+var locations sync.Map
+
+// loadLocation returns the time zone name, nil when unknown
+func loadLocation(name string) *time.Location {
+
+	if name == "" {
+		return nil
+	}
+
+	if location, ok := locations.Load(name); ok {
+		return location.(*time.Location)
+	}
+
+	//unknown names aren't cached, a malformed response could fill the cache with them
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	locations.Store(name, location)
+
+	return location
+}
+
+/*
+	TravelMode sets the "mode" param for directions and distance matrix,
+	one of driving, walking, bicycling or transit
+*/
+func TravelMode(mode string) ParamOption {
+	return func(params map[string]string) error {
+
+		switch mode {
+		case "driving", "walking", "bicycling", "transit":
+			params["mode"] = mode
+			return nil
+		}
+
+		return fmt.Errorf("unknown travel mode %q", mode)
+	}
+}
+
+/*
+	TransitMode sets the "transit_mode" param with the preferred vehicles
+	(bus, subway, train, tram or rail), mode is set to transit when it is still empty
+*/
+func TransitMode(modes ...string) ParamOption {
+	return func(params map[string]string) error {
+
+		if len(modes) == 0 {
+			return errors.New("transit modes must not be empty")
+		}
+
+		for _, mode := range modes {
+			switch mode {
+			case "bus", "subway", "train", "tram", "rail":
+			default:
+				return fmt.Errorf("unknown transit mode %q", mode)
+			}
+		}
+
+		if err := requireTransit(params); err != nil {
+			return err
+		}
+
+		params["transit_mode"] = strings.Join(modes, "|")
+		return nil
+	}
+}
+
+/*
+	TransitRoutingPreference sets the "transit_routing_preference" param,
+	one of less_walking or fewer_transfers
+*/
+func TransitRoutingPreference(preference string) ParamOption {
+	return func(params map[string]string) error {
+
+		switch preference {
+		case "less_walking", "fewer_transfers":
+		default:
+			return fmt.Errorf("unknown transit routing preference %q", preference)
+		}
+
+		if err := requireTransit(params); err != nil {
+			return err
+		}
+
+		params["transit_routing_preference"] = preference
+		return nil
+	}
+}
+
+// transit options are ignored by google unless mode is transit
+func requireTransit(params map[string]string) error {
+
+	mode, ok := params["mode"]
+	if !ok {
+		params["mode"] = "transit"
+		return nil
+	}
+
+	if mode != "transit" {
+		return fmt.Errorf("transit options need mode transit, got %q", mode)
+	}
+
+	return nil
+}
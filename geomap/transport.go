@@ -0,0 +1,133 @@
+package geomap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+/*
+	WithProxy sends the requests through the http(s) proxy at proxyURL instead of the
+	proxy from the environment. Like the other transport options it configures the
+	transport of the http client, so set WithHTTPClient before it
+*/
+func WithProxy(proxyURL string) ClientOption {
+	return func(c *Client) error {
+
+		parsed, err := url.Parse(proxyURL)
+		if err != nil {
+			return err
+		}
+		if parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid proxy url %q", proxyURL)
+		}
+
+		transport, err := c.transport()
+		if err != nil {
+			return err
+		}
+
+		transport.Proxy = http.ProxyURL(parsed)
+		return nil
+	}
+}
+
+/*
+	WithCABundle trusts the pem encoded certificates of bundle in addition to the system roots,
+	for egress gateways intercepting TLS with their own certificate authority
+*/
+func WithCABundle(bundle []byte) ClientOption {
+	return func(c *Client) error {
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return errors.New("no certificate found in CA bundle")
+		}
+
+		config, err := c.tlsConfig()
+		if err != nil {
+			return err
+		}
+
+		config.RootCAs = pool
+		return nil
+	}
+}
+
+// WithClientCertificate presents the pem encoded certificate and key to servers requiring mutual TLS
+func WithClientCertificate(certPEM, keyPEM []byte) ClientOption {
+	return func(c *Client) error {
+
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return err
+		}
+
+		config, err := c.tlsConfig()
+		if err != nil {
+			return err
+		}
+
+		config.Certificates = append(config.Certificates, cert)
+		return nil
+	}
+}
+
+// WithTLSMinVersion refuses connections below version, e.g. tls.VersionTLS12
+func WithTLSMinVersion(version uint16) ClientOption {
+	return func(c *Client) error {
+
+		if version < tls.VersionTLS10 || version > tls.VersionTLS13 {
+			return fmt.Errorf("unsupported TLS version %#x", version)
+		}
+
+		config, err := c.tlsConfig()
+		if err != nil {
+			return err
+		}
+
+		config.MinVersion = version
+		return nil
+	}
+}
+
+/*
+	transport returns the transport of the client http client to be configured,
+	the http client is copied first so a client passed to WithHTTPClient isn't changed
+*/
+func (c *Client) transport() (*http.Transport, error) {
+
+	copied := *c.httpClient
+	c.httpClient = &copied
+
+	switch transport := c.httpClient.Transport.(type) {
+	case nil:
+		c.httpClient.Transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		c.httpClient.Transport = transport.Clone()
+	default:
+		return nil, fmt.Errorf("cannot configure transport of type %T", transport)
+	}
+
+	return c.httpClient.Transport.(*http.Transport), nil
+}
+
+func (c *Client) tlsConfig() (*tls.Config, error) {
+
+	transport, err := c.transport()
+	if err != nil {
+		return nil, err
+	}
+
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+
+	return transport.TLSClientConfig, nil
+}
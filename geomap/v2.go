@@ -0,0 +1,313 @@
+package geomap
+
+import "context"
+
+/*
+	v2 models
+
+	The v1 response structs drop fields google returns (business_status, geometry
+	and place_id on candidates...) and Candidate.Rating is an int while google
+	returns decimals, which fails the whole decoding. The v2 models share one
+	complete Place struct across find place, nearby, text search and details,
+	and use named types only so results can be passed around and converted.
+*/
+
+/*
+	Place is the complete place model shared by every v2 places response,
+	which fields are filled depends on the endpoint and the requested "fields"
+*/
+type Place struct {
+	AddressComponents        []AddressComponent  `json:"address_components,omitempty"`
+	AdrAddress               string              `json:"adr_address,omitempty"`
+	BusinessStatus           string              `json:"business_status,omitempty"`
+	FormattedAddress         string              `json:"formatted_address,omitempty"`
+	FormattedPhoneNumber     string              `json:"formatted_phone_number,omitempty"`
+	Geometry                 GoogleGeometry      `json:"geometry"`
+	Icon                     string              `json:"icon,omitempty"`
+	ID                       string              `json:"id,omitempty"`
+	InternationalPhoneNumber string              `json:"international_phone_number,omitempty"`
+	Name                     string              `json:"name,omitempty"`
+	OpeningHours             *OpeningHour        `json:"opening_hours,omitempty"`
+	PermanentlyClosed        bool                `json:"permanently_closed,omitempty"`
+	Photos                   []Photo             `json:"photos,omitempty"`
+	PlaceID                  string              `json:"place_id,omitempty"`
+	PlusCode                 GooglePlusCode      `json:"plus_code"`
+	PriceLevel               int                 `json:"price_level,omitempty"`
+	Rating                   float64             `json:"rating,omitempty"`
+	Reference                string              `json:"reference,omitempty"`
+	Reviews                  []GooglePlaceReview `json:"reviews,omitempty"`
+	Scope                    string              `json:"scope,omitempty"`
+	Types                    []string            `json:"types,omitempty"`
+	URL                      string              `json:"url,omitempty"`
+	UserRatingsTotal         int                 `json:"user_ratings_total,omitempty"`
+	UtcOffset                int                 `json:"utc_offset,omitempty"`
+	Vicinity                 string              `json:"vicinity,omitempty"`
+	Website                  string              `json:"website,omitempty"`
+}
+
+type GeocodeResult struct {
+	AddressComponents []AddressComponent `json:"address_components"`
+	FormattedAddress  string             `json:"formatted_address"`
+	Geometry          GoogleGeometry     `json:"geometry"`
+	PartialMatch      bool               `json:"partial_match,omitempty"`
+	PlaceID           string             `json:"place_id"`
+	PlusCode          GooglePlusCode     `json:"plus_code"`
+	Types             []string           `json:"types"`
+}
+
+type FindPlaceResponseV2 struct {
+	Candidates   []Place `json:"candidates"`
+	Status       string  `json:"status"`
+	ErrorMessage string  `json:"error_message,omitempty"`
+}
+
+type NearbySearchResponseV2 struct {
+	HTMLAttributions []string `json:"html_attributions"`
+	NextPageToken    string   `json:"next_page_token,omitempty"`
+	Results          []Place  `json:"results"`
+	Status           string   `json:"status"`
+	ErrorMessage     string   `json:"error_message,omitempty"`
+}
+
+type PlaceDetailResponseV2 struct {
+	HTMLAttributions []string `json:"html_attributions"`
+	Result           Place    `json:"result"`
+	Status           string   `json:"status"`
+	ErrorMessage     string   `json:"error_message,omitempty"`
+}
+
+type GeocodeResponseV2 struct {
+	Results      []GeocodeResult `json:"results"`
+	Status       string          `json:"status"`
+	ErrorMessage string          `json:"error_message,omitempty"`
+
+	// AddressDescriptor is experimental, only set with WithExperimental(ExperimentalAddressDescriptors)
+	AddressDescriptor *AddressDescriptor `json:"address_descriptor,omitempty"`
+}
+
+/*
+	GetGeocodeV2 is GetGeocode returning the v2 model
+	more references https://developers.google.com/maps/documentation/geocoding/intro#Geocoding
+*/
+func GetGeocodeV2(ctx context.Context, params map[string]string) (GeocodeResponseV2, error) {
+	return DefaultClient().GetGeocodeV2(ctx, params)
+}
+
+// GetGeocodeV2 is the package level GetGeocodeV2 using c
+func (c *Client) GetGeocodeV2(ctx context.Context, params map[string]string) (GeocodeResponseV2, error) {
+
+	//the store keeps the results, not the address descriptors
+	descriptors := c.experimental[ExperimentalAddressDescriptors]
+	if !descriptors {
+		results, found, err := c.storedGeocode(ctx, params)
+		if err != nil {
+			return GeocodeResponseV2{}, err
+		}
+		if found {
+			stored := GeocodeResponseV2{Status: "OK", Results: results}
+			if err := c.transform(&stored); err != nil {
+				return GeocodeResponseV2{}, err
+			}
+			stored.Results = c.dedupGeocode(params, stored.Results)
+			return stored, nil
+		}
+	}
+
+	var geocodeResponse GeocodeResponseV2
+
+	err := c.getJSON(ctx, EndpointGeocode, c.withExperimental(params), &geocodeResponse)
+	if !descriptors {
+		//google may send it unrequested in some regions, it stays opt-in
+		geocodeResponse.AddressDescriptor = nil
+	}
+	if err == nil {
+		c.storeGeocode(ctx, params, geocodeResponse.Status, geocodeResponse.Results)
+		geocodeResponse.Results = c.dedupGeocode(params, geocodeResponse.Results)
+	}
+
+	return geocodeResponse, err
+}
+
+/*
+	FindPlaceV2 is FindPlace returning the v2 model,
+	request the candidate fields with the "fields" param
+	more references https://developers.google.com/places/web-service/search
+*/
+func FindPlaceV2(ctx context.Context, params map[string]string) (FindPlaceResponseV2, error) {
+	return DefaultClient().FindPlaceV2(ctx, params)
+}
+
+// FindPlaceV2 is the package level FindPlaceV2 using c
+func (c *Client) FindPlaceV2(ctx context.Context, params map[string]string) (FindPlaceResponseV2, error) {
+
+	var findPlaceResponse FindPlaceResponseV2
+
+	err := c.getJSON(ctx, EndpointFindPlace, params, &findPlaceResponse)
+	return findPlaceResponse, err
+}
+
+/*
+	PlaceNearbyV2 is PlaceNearby returning the v2 model
+	more references https://developers.google.com/places/web-service/search
+*/
+func PlaceNearbyV2(ctx context.Context, params map[string]string) (NearbySearchResponseV2, error) {
+	return DefaultClient().PlaceNearbyV2(ctx, params)
+}
+
+// PlaceNearbyV2 is the package level PlaceNearbyV2 using c
+func (c *Client) PlaceNearbyV2(ctx context.Context, params map[string]string) (NearbySearchResponseV2, error) {
+
+	var nearbySearchResponse NearbySearchResponseV2
+
+	err := c.getJSON(ctx, EndpointNearbySearch, params, &nearbySearchResponse)
+	if err == nil && c.excludeClosedBusinesses() {
+		nearbySearchResponse.Results = ExcludeClosed(nearbySearchResponse.Results)
+	}
+	if err == nil {
+		nearbySearchResponse.Results = c.rank(params, nearbySearchResponse.Results)
+	}
+
+	return nearbySearchResponse, err
+}
+
+/*
+	PlaceDetailV2 is PlaceDetail returning the v2 model
+	more references https://developers.google.com/places/web-service/details
+*/
+func PlaceDetailV2(ctx context.Context, params map[string]string) (PlaceDetailResponseV2, error) {
+	return DefaultClient().PlaceDetailV2(ctx, params)
+}
+
+// PlaceDetailV2 is the package level PlaceDetailV2 using c
+func (c *Client) PlaceDetailV2(ctx context.Context, params map[string]string) (PlaceDetailResponseV2, error) {
+
+	var placeDetailResponse PlaceDetailResponseV2
+
+	err := c.getJSON(ctx, EndpointPlaceDetails, params, &placeDetailResponse)
+	return placeDetailResponse, err
+}
+
+// V2 converts a v1 geocode response to the v2 model
+func (r GoogleGeocodeResponse) V2() GeocodeResponseV2 {
+
+	//both models share GeocodeResult, the results are only copied
+	return GeocodeResponseV2{Status: r.Status, Results: append([]GeocodeResult(nil), r.Results...)}
+}
+
+/*
+	V2 converts a v1 find place response to the v2 model,
+	the fields missing from v1 candidates stay empty
+*/
+func (r GooglePlaceSearchResponse) V2() FindPlaceResponseV2 {
+
+	converted := FindPlaceResponseV2{Status: r.Status, Candidates: make([]Place, len(r.Candidates))}
+	for i, candidate := range r.Candidates {
+		converted.Candidates[i] = candidate.V2()
+	}
+
+	return converted
+}
+
+// V2 converts a v1 candidate to the v2 place model
+func (c Candidate) V2() Place {
+	return Place{
+		FormattedAddress: c.FormattedAddress,
+		Name:             c.Name,
+		Photos:           c.Photos,
+		Rating:           float64(c.Rating),
+	}
+}
+
+// V2 converts a v1 nearby search response to the v2 model
+func (r GoogleNearbySearchResponse) V2() NearbySearchResponseV2 {
+
+	converted := NearbySearchResponseV2{
+		HTMLAttributions: toStrings(r.HTMLAttributions),
+		NextPageToken:    r.NextPageToken,
+		Status:           r.Status,
+		Results:          make([]Place, len(r.Results)),
+	}
+	for i, result := range r.Results {
+		converted.Results[i] = result.V2()
+	}
+
+	return converted
+}
+
+// V2 converts a v1 nearby result to the v2 place model
+func (r NearbyResult) V2() Place {
+
+	openingHours := r.OpeningHours
+	return Place{
+		BusinessStatus:    r.BusinessStatus,
+		Geometry:          r.Geometry,
+		Icon:              r.Icon,
+		ID:                r.ID,
+		Name:              r.Name,
+		OpeningHours:      &openingHours,
+		PermanentlyClosed: r.PermanentlyClosed,
+		Photos:            r.Photos,
+		PlaceID:           r.PlaceID,
+		PlusCode:          r.PlusCode,
+		PriceLevel:        r.PriceLevel,
+		Rating:            r.Rating,
+		Reference:         r.Reference,
+		Scope:             r.Scope,
+		Types:             r.Types,
+		UserRatingsTotal:  r.UserRatingsTotal,
+		Vicinity:          r.Vicinity,
+	}
+}
+
+// V2 converts a v1 place detail response to the v2 model
+func (r GooglePlaceDetailResponse) V2() PlaceDetailResponseV2 {
+
+	result := r.Result
+	openingHours := result.OpeningHours
+
+	return PlaceDetailResponseV2{
+		HTMLAttributions: toStrings(r.HTMLAttributions),
+		Status:           r.Status,
+		Result: Place{
+			AddressComponents:        result.AddressComponents,
+			AdrAddress:               result.AdrAddress,
+			BusinessStatus:           result.BusinessStatus,
+			FormattedAddress:         result.FormattedAddress,
+			FormattedPhoneNumber:     result.FormattedPhoneNumber,
+			Geometry:                 result.Geometry,
+			Icon:                     result.Icon,
+			ID:                       result.ID,
+			InternationalPhoneNumber: result.InternationalPhoneNumber,
+			Name:                     result.Name,
+			OpeningHours:             &openingHours,
+			PermanentlyClosed:        result.PermanentlyClosed,
+			Photos:                   result.Photos,
+			PlaceID:                  result.PlaceID,
+			PlusCode:                 result.PlusCode,
+			PriceLevel:               result.PriceLevel,
+			Rating:                   result.Rating,
+			Reference:                result.Reference,
+			Reviews:                  result.Reviews,
+			Scope:                    result.Scope,
+			Types:                    result.Types,
+			URL:                      result.URL,
+			UserRatingsTotal:         result.UserRatingsTotal,
+			UtcOffset:                result.UtcOffset,
+			Vicinity:                 result.Vicinity,
+			Website:                  result.Website,
+		},
+	}
+}
+
+// v1 keeps html attributions as interface{}, google only sends strings
+func toStrings(values []interface{}) []string {
+
+	converted := make([]string, 0, len(values))
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			converted = append(converted, s)
+		}
+	}
+
+	return converted
+}
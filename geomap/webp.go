@@ -0,0 +1,428 @@
+package geomap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"sort"
+)
+
+/*
+	encodeWebPLossless writes img as a lossless WebP (VP8L), quality is ignored.
+	The pixels go through the subtract green and predictor transforms then are prefix
+	coded without backward references, which keeps the encoder small at the cost of
+	larger files than libwebp, see photowebp_libwebp.go for the lossy encoder
+	more references https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification
+*/
+func encodeWebPLossless(w io.Writer, img image.Image, quality int) error {
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > webpMaxDimension || height > webpMaxDimension {
+		return fmt.Errorf("webp photos are 1 to %d pixels wide and high, not %dx%d", webpMaxDimension, width, height)
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	//argb pixels with the green subtracted from red and blue, the first transform
+	pixels := make([]uint32, width*height)
+	alphaUsed := uint32(0)
+	for i := range pixels {
+		r, g, b, a := nrgba.Pix[i*4], nrgba.Pix[i*4+1], nrgba.Pix[i*4+2], nrgba.Pix[i*4+3]
+		if a != 0xff {
+			alphaUsed = 1
+		}
+		pixels[i] = uint32(a)<<24 | uint32(r-g)<<16 | uint32(g)<<8 | uint32(b-g)
+	}
+
+	bw := &bitWriter{}
+	bw.write(webpLosslessSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	bw.write(alphaUsed, 1)
+	bw.write(0, 3)
+
+	//subtract green
+	bw.write(1, 1)
+	bw.write(webpSubtractGreen, 2)
+
+	//predictor, a single mode for the whole photo
+	bw.write(1, 1)
+	bw.write(webpPredictor, 2)
+	bw.write(webpPredictorBits-2, 3)
+	blocks := make([]uint32, subSampleSize(width)*subSampleSize(height))
+	for i := range blocks {
+		blocks[i] = webpPredictorMode << 8
+	}
+	bw.writeImage(blocks, false)
+
+	bw.write(0, 1)
+
+	bw.writeImage(predictResiduals(pixels, width, height), true)
+
+	data := bw.bytes()
+	padding := len(data) % 2
+
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+len(data)+padding))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+
+	return nil
+}
+
+const (
+	webpMaxDimension      = 1 << 14
+	webpLosslessSignature = 0x2f
+
+	// transform types
+	webpPredictor     = 0
+	webpSubtractGreen = 2
+
+	// blocks of 512x512 pixels, all of them use ClampAddSubtractFull(L, T, TL)
+	webpPredictorBits = 9
+	webpPredictorMode = 12
+
+	// the green alphabet also has the 24 length prefixes, unused without backward references
+	webpGreenAlphabet    = 256 + 24
+	webpDistanceAlphabet = 40
+
+	webpMaxCodeLength       = 15
+	webpMaxCodeLengthLength = 7
+)
+
+// webpCodeLengthOrder is the order the lengths of the code length code are written in
+var webpCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+func subSampleSize(size int) int {
+	return (size + 1<<webpPredictorBits - 1) >> webpPredictorBits
+}
+
+// predictResiduals returns the residuals of the predictor transform of pixels
+func predictResiduals(pixels []uint32, width, height int) []uint32 {
+
+	residuals := make([]uint32, len(pixels))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+
+			var predicted uint32
+			switch {
+			case x == 0 && y == 0:
+				predicted = 0xff000000
+			case y == 0:
+				predicted = pixels[i-1]
+			case x == 0:
+				predicted = pixels[i-width]
+			default:
+				predicted = clampAddSubtractFull(pixels[i-1], pixels[i-width], pixels[i-width-1])
+			}
+
+			residuals[i] = subtractPixels(pixels[i], predicted)
+		}
+	}
+
+	return residuals
+}
+
+// clampAddSubtractFull predicts a + b - c by channel, clamped to 0-255
+func clampAddSubtractFull(a, b, c uint32) uint32 {
+
+	var predicted uint32
+	for shift := uint(0); shift < 32; shift += 8 {
+		value := int(a>>shift&0xff) + int(b>>shift&0xff) - int(c>>shift&0xff)
+		if value < 0 {
+			value = 0
+		} else if value > 0xff {
+			value = 0xff
+		}
+		predicted |= uint32(value) << shift
+	}
+
+	return predicted
+}
+
+// subtractPixels subtracts b from a by channel, modulo 256
+func subtractPixels(a, b uint32) uint32 {
+
+	var difference uint32
+	for shift := uint(0); shift < 32; shift += 8 {
+		difference |= (a>>shift - b>>shift) & 0xff << shift
+	}
+
+	return difference
+}
+
+// bitWriter packs bits least significant first, as VP8L reads them
+type bitWriter struct {
+	buf  []byte
+	acc  uint64
+	bits uint
+}
+
+func (w *bitWriter) write(value uint32, bits int) {
+
+	w.acc |= uint64(value) << w.bits
+	w.bits += uint(bits)
+	for w.bits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.bits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+
+	if w.bits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.bits = 0, 0
+	}
+
+	return w.buf
+}
+
+/*
+	writeImage writes argb pixels as an entropy coded image without color cache, with a
+	single group of prefix codes (the meta prefix codes bit is only in the main image)
+*/
+func (w *bitWriter) writeImage(pixels []uint32, main bool) {
+
+	w.write(0, 1)
+	if main {
+		w.write(0, 1)
+	}
+
+	green := make([]int, webpGreenAlphabet)
+	red := make([]int, 256)
+	blue := make([]int, 256)
+	alpha := make([]int, 256)
+	for _, pixel := range pixels {
+		green[pixel>>8&0xff]++
+		red[pixel>>16&0xff]++
+		blue[pixel&0xff]++
+		alpha[pixel>>24]++
+	}
+
+	greenCode := w.writePrefixCode(green)
+	redCode := w.writePrefixCode(red)
+	blueCode := w.writePrefixCode(blue)
+	alphaCode := w.writePrefixCode(alpha)
+	w.writePrefixCode(make([]int, webpDistanceAlphabet))
+
+	for _, pixel := range pixels {
+		greenCode.write(w, int(pixel>>8&0xff))
+		redCode.write(w, int(pixel>>16&0xff))
+		blueCode.write(w, int(pixel&0xff))
+		alphaCode.write(w, int(pixel>>24))
+	}
+}
+
+// prefixCode is the codes of an alphabet, bit reversed to be written least significant first
+type prefixCode struct {
+	codes   []uint32
+	lengths []int
+}
+
+func (c prefixCode) write(w *bitWriter, symbol int) {
+	if c.lengths[symbol] > 0 {
+		w.write(c.codes[symbol], c.lengths[symbol])
+	}
+}
+
+// writePrefixCode writes the prefix code of the symbol counts and returns it
+func (w *bitWriter) writePrefixCode(counts []int) prefixCode {
+
+	var used []int
+	for symbol, count := range counts {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	//a simple code of one or two symbols, a single symbol takes no bits
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+
+		w.write(1, 1)
+		w.write(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			w.write(0, 1)
+			w.write(uint32(used[0]), 1)
+		} else {
+			w.write(1, 1)
+			w.write(uint32(used[0]), 8)
+		}
+
+		lengths := make([]int, len(counts))
+		if len(used) == 2 {
+			w.write(uint32(used[1]), 8)
+			lengths[used[0]], lengths[used[1]] = 1, 1
+		}
+		return prefixCode{codes: canonicalCodes(lengths), lengths: lengths}
+	}
+
+	lengths := huffmanLengths(counts, webpMaxCodeLength)
+
+	lengthCounts := make([]int, len(webpCodeLengthOrder))
+	for _, length := range lengths {
+		lengthCounts[length]++
+	}
+	lengthLengths := huffmanLengths(lengthCounts, webpMaxCodeLengthLength)
+
+	//a code of one symbol is read without bits, give the lengths code a second symbol never written
+	single := -1
+	for symbol, length := range lengthLengths {
+		if length > 0 {
+			if single != -1 {
+				single = -1
+				break
+			}
+			single = symbol
+		}
+	}
+	if single != -1 {
+		lengthLengths[(single+1)%len(lengthLengths)] = 1
+	}
+
+	w.write(0, 1)
+	w.write(uint32(len(webpCodeLengthOrder)-4), 4)
+	for _, symbol := range webpCodeLengthOrder {
+		w.write(uint32(lengthLengths[symbol]), 3)
+	}
+
+	//every symbol of the alphabet has its length written
+	w.write(0, 1)
+	lengthCode := prefixCode{codes: canonicalCodes(lengthLengths), lengths: lengthLengths}
+	for _, length := range lengths {
+		lengthCode.write(w, length)
+	}
+
+	return prefixCode{codes: canonicalCodes(lengths), lengths: lengths}
+}
+
+/*
+	huffmanLengths returns the code lengths of a huffman code of counts, no longer than
+	maxLength: the smallest counts are raised until the tree is shallow enough
+*/
+func huffmanLengths(counts []int, maxLength int) []int {
+
+	type node struct {
+		count       int
+		symbol      int
+		left, right int
+	}
+
+	var leaves []node
+	for symbol, count := range counts {
+		if count > 0 {
+			leaves = append(leaves, node{count: count, symbol: symbol, left: -1, right: -1})
+		}
+	}
+
+	lengths := make([]int, len(counts))
+	if len(leaves) == 1 {
+		lengths[leaves[0].symbol] = 1
+	}
+	if len(leaves) <= 1 {
+		return lengths
+	}
+
+	for minCount := 1; ; minCount *= 2 {
+
+		nodes := make([]node, len(leaves), 2*len(leaves)-1)
+		for i, leaf := range leaves {
+			if leaf.count < minCount {
+				leaf.count = minCount
+			}
+			nodes[i] = leaf
+		}
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].count < nodes[j].count })
+
+		//two queues: the sorted leaves and the merged nodes, which are created in order
+		nextLeaf, nextMerged := 0, len(leaves)
+		smallest := func() int {
+			if nextLeaf < len(leaves) && (nextMerged >= len(nodes) || nodes[nextLeaf].count <= nodes[nextMerged].count) {
+				nextLeaf++
+				return nextLeaf - 1
+			}
+			nextMerged++
+			return nextMerged - 1
+		}
+		for len(nodes) < cap(nodes) {
+			left := smallest()
+			right := smallest()
+			nodes = append(nodes, node{count: nodes[left].count + nodes[right].count, left: left, right: right})
+		}
+
+		deepest := 0
+		depths := make([]int, len(nodes))
+		for i := len(nodes) - 1; i >= 0; i-- {
+			if nodes[i].left < 0 {
+				lengths[nodes[i].symbol] = depths[i]
+				if depths[i] > deepest {
+					deepest = depths[i]
+				}
+				continue
+			}
+			depths[nodes[i].left] = depths[i] + 1
+			depths[nodes[i].right] = depths[i] + 1
+		}
+
+		if deepest <= maxLength {
+			return lengths
+		}
+	}
+}
+
+// canonicalCodes returns the canonical codes of lengths, bit reversed
+func canonicalCodes(lengths []int) []uint32 {
+
+	var lengthCounts [webpMaxCodeLength + 1]uint32
+	for _, length := range lengths {
+		if length > 0 {
+			lengthCounts[length]++
+		}
+	}
+
+	var nextCode [webpMaxCodeLength + 1]uint32
+	code := uint32(0)
+	for length := 1; length <= webpMaxCodeLength; length++ {
+		code = (code + lengthCounts[length-1]) << 1
+		nextCode[length] = code
+	}
+
+	codes := make([]uint32, len(lengths))
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+
+		code := nextCode[length]
+		nextCode[length]++
+
+		var reversed uint32
+		for i := 0; i < length; i++ {
+			reversed = reversed<<1 | code>>uint(i)&1
+		}
+		codes[symbol] = reversed
+	}
+
+	return codes
+}
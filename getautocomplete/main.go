@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"gomapservice/geomap"
+	"gomapservice/handler"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+const (
+	// inputs shorter than this are answered without calling google, they rarely match what the user types
+	minInputLength = 3
+
+	// google returns up to 5 predictions
+	maxPredictions = 5
+)
+
+// Prediction is the trimmed prediction returned to web frontends
+type Prediction struct {
+	PlaceID       string `json:"place_id"`
+	Description   string `json:"description"`
+	MainText      string `json:"main_text"`
+	SecondaryText string `json:"secondary_text"`
+}
+
+// AutocompleteResponse is the body of the autocomplete endpoint, Session is echoed for clients not using cookies
+type AutocompleteResponse struct {
+	Session     string       `json:"session"`
+	Predictions []Prediction `json:"predictions"`
+}
+
+// Handler is our lambda handler invoked by the `lambda.Start` function call
+// Handler function Using AWS Lambda Proxy Request
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	ctx := context.Background()
+
+	//required query
+	input := strings.TrimSpace(request.QueryStringParameters["input"])
+
+	limit := maxPredictions
+	if raw := request.QueryStringParameters["limit"]; raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			return events.APIGatewayProxyResponse{Body: "Invalid limit", StatusCode: 400}, nil
+		}
+		if parsed < limit {
+			limit = parsed
+		}
+	}
+
+	//the session lives on the client, a new one starts after the select call ended the previous
+	token := handler.SessionToken(request)
+	if token == "" {
+		session, err := geomap.NewSessionToken()
+		if err != nil {
+			return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 500}, err
+		}
+		token = session.ID
+	}
+
+	body := AutocompleteResponse{Session: token, Predictions: []Prediction{}}
+
+	//short inputs are answered empty without being billed, the session stays open
+	if utf8.RuneCountInString(input) >= minInputLength {
+
+		//Replace with api key
+		key := request.StageVariables["GOOGLE_API_KEY"]
+
+		geoParams := map[string]string{
+			"input":        input,
+			"key":          key,
+			"sessiontoken": token,
+		}
+		if types := os.Getenv("AUTOCOMPLETE_TYPES"); types != "" {
+			geoParams["types"] = types
+		}
+
+		//restricts the predictions to the countries of AUTOCOMPLETE_COUNTRIES, e.g. "id,sg"
+		if countries := os.Getenv("AUTOCOMPLETE_COUNTRIES"); countries != "" {
+			filter := geomap.ComponentFilter{Countries: strings.Split(countries, ",")}
+			if _, err := geomap.ApplyParams(geoParams, geomap.Components(filter)); err != nil {
+				return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 500}, err
+			}
+		}
+
+		googleResp, err := geomap.PlaceAutocomplete(ctx, geoParams)
+		if err != nil {
+			return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 400}, err
+		}
+
+		for i, prediction := range googleResp.Predictions {
+			if i == limit {
+				break
+			}
+			body.Predictions = append(body.Predictions, Prediction{
+				PlaceID:       prediction.PlaceID,
+				Description:   prediction.Description,
+				MainText:      prediction.StructuredFormatting.MainText,
+				SecondaryText: prediction.StructuredFormatting.SecondaryText,
+			})
+		}
+	}
+
+	//Returning response with AWS Lambda Proxy Response, the trimmed predictions have a single version
+	response, err := handler.Respond(request, 200, handler.Encoders{
+		handler.ResponseV1: func() (interface{}, error) { return body, nil },
+	})
+	if err != nil || response.StatusCode != 200 {
+		return response, err
+	}
+	handler.SetSessionToken(&response, token)
+
+	return response, nil
+}
+
+func init() {
+	handler.Prewarm()
+}
+
+func main() {
+	//drains the calls in flight and flushes the journal before lambda kills the container
+	go func() {
+		handler.ShutdownOnSignal(handler.ShutdownTimeout, syscall.SIGTERM, syscall.SIGINT)
+		os.Exit(0)
+	}()
+
+	lambda.Start(handler.Chain(Handler, handler.Default()...))
+}
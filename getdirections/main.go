@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"gomapservice/geomap"
+	"gomapservice/handler"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Response is of type APIGatewayProxyResponse since we're leveraging the
+// AWS Lambda Proxy Request functionality (default behavior)
+//
+// https://serverless.com/framework/docs/providers/aws/events/apigateway/#lambda-proxy-integration
+type Response events.APIGatewayProxyResponse
+
+// Handler is our lambda handler invoked by the `lambda.Start` function call
+// Handler function Using AWS Lambda Proxy Request
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	ctx := context.Background()
+
+	//required query
+	origin := request.QueryStringParameters["origin"]
+	destination := request.QueryStringParameters["destination"]
+
+	//Replace with api key
+	key := request.StageVariables["GOOGLE_API_KEY"]
+
+	geoParams := map[string]string{
+		"origin":      origin,
+		"destination": destination,
+		"key":         key,
+	}
+
+	//optional query, waypoints are separated by '|' as google takes them
+	opts := []geomap.ParamOption{}
+	if mode := request.QueryStringParameters["mode"]; mode != "" {
+		opts = append(opts, geomap.TravelMode(mode))
+	}
+	if raw := request.QueryStringParameters["waypoints"]; raw != "" {
+		var waypoints []geomap.Waypoint
+		for _, location := range strings.Split(raw, "|") {
+			waypoints = append(waypoints, geomap.Waypoint{Location: location})
+		}
+		opts = append(opts, geomap.Waypoints(request.QueryStringParameters["optimize"] == "true", waypoints...))
+	}
+
+	if _, err := geomap.ApplyParams(geoParams, opts...); err != nil {
+		return events.APIGatewayProxyResponse{Body: err.Error(), StatusCode: 400}, nil
+	}
+
+	//obtains directions response to be processed
+	googleResp, err := geomap.GetDirections(ctx, geoParams)
+	if err != nil {
+		return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 400}, err
+	}
+
+	//Returning response with AWS Lambda Proxy Response, the distances and durations
+	//are localized by handler.Localize
+	return handler.Respond(request, 200, handler.Encoders{
+		handler.ResponseV1: func() (interface{}, error) { return googleResp, nil },
+	})
+}
+
+func init() {
+	handler.Prewarm()
+}
+
+func main() {
+	//drains the calls in flight and flushes the journal before lambda kills the container
+	go func() {
+		handler.ShutdownOnSignal(handler.ShutdownTimeout, syscall.SIGTERM, syscall.SIGINT)
+		os.Exit(0)
+	}()
+
+	lambda.Start(handler.Chain(Handler, handler.Default()...))
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"gomapservice/geomap"
+	"gomapservice/handler"
+	"os"
+	"syscall"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -24,6 +26,9 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	//required query
 	address := request.QueryStringParameters["address"]
 
+	//optional query, strict address validation rejects missing and partial matches
+	strict := request.QueryStringParameters["strict"] == "true"
+
 	//Replace with api key
 	key := request.StageVariables["GOOGLE_API_KEY"]
 
@@ -38,12 +43,32 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 400}, err
 	}
 
-	jsonString, _ := json.Marshal(googleResp)
+	if strict {
+		switch googleResp.Check(true) {
+		case geomap.ErrZeroResults:
+			return events.APIGatewayProxyResponse{Body: "Not Found", StatusCode: 404}, nil
+		case geomap.ErrPartialMatch:
+			return events.APIGatewayProxyResponse{Body: "Partial Match", StatusCode: 422}, nil
+		}
+	}
 
-	//Returning response with AWS Lambda Proxy Response
-	return events.APIGatewayProxyResponse{Body: string(jsonString), StatusCode: 200}, nil
+	//Returning response with AWS Lambda Proxy Response, in the version asked by the client
+	return handler.Respond(request, 200, handler.Encoders{
+		handler.ResponseV1: func() (interface{}, error) { return googleResp, nil },
+		handler.ResponseV2: func() (interface{}, error) { return googleResp.V2(), nil },
+	})
+}
+
+func init() {
+	handler.Prewarm()
 }
 
 func main() {
-	lambda.Start(Handler)
+	//drains the calls in flight and flushes the journal before lambda kills the container
+	go func() {
+		handler.ShutdownOnSignal(handler.ShutdownTimeout, syscall.SIGTERM, syscall.SIGINT)
+		os.Exit(0)
+	}()
+
+	lambda.Start(handler.Chain(Handler, handler.Default()...))
 }
@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"gomapservice/geomap"
+	"gomapservice/handler"
+	"log"
+	"os"
+	"strconv"
+	"syscall"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
@@ -15,6 +19,27 @@ import (
 // https://serverless.com/framework/docs/providers/aws/events/apigateway/#lambda-proxy-integration
 type Response events.APIGatewayProxyResponse
 
+/*
+	NearbyResponse is the google response with the cursor of the next page, passed back
+	as the cursor param, and the URL of the first photo of each place by place_id
+	when requested with photos=true. ApproximateLocation is where the client was
+	located without the location param
+*/
+type NearbyResponse struct {
+	geomap.GoogleNearbySearchResponse
+	NextCursor          string                       `json:"next_cursor,omitempty"`
+	PhotoURLs           map[string]string            `json:"photo_urls,omitempty"`
+	ApproximateLocation *handler.ApproximateLocation `json:"approximate_location,omitempty"`
+}
+
+// NearbyResponseV2 is NearbyResponse with the google response in the v2 models
+type NearbyResponseV2 struct {
+	geomap.NearbySearchResponseV2
+	NextCursor          string                       `json:"next_cursor,omitempty"`
+	PhotoURLs           map[string]string            `json:"photo_urls,omitempty"`
+	ApproximateLocation *handler.ApproximateLocation `json:"approximate_location,omitempty"`
+}
+
 // Handler is our lambda handler invoked by the `lambda.Start` function call
 // Handler function Using AWS Lambda Proxy Request
 func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -26,32 +51,146 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	radius := request.QueryStringParameters["radius"]
 	name := request.QueryStringParameters["name"]
 
+	//"near me" without GPS, the location is approximated from the request headers
+	var approximate *handler.ApproximateLocation
+	if location == "" {
+		viewer, err := handler.ViewerLocation(ctx, request, nil)
+		if err != nil {
+			return events.APIGatewayProxyResponse{Body: "Location required", StatusCode: 400}, nil
+		}
+		approximate = &viewer
+		location = viewer.Location.String()
+	}
+
+	latLng, err := geomap.ParseLatLng(location)
+	if err != nil {
+		return events.APIGatewayProxyResponse{Body: err.Error(), StatusCode: 400}, nil
+	}
+
 	//Replace with api key
 	key := request.StageVariables["GOOGLE_API_KEY"]
 
 	geoParams := map[string]string{
-		"location": location,
+		"location": latLng.String(),
 		"radius":   radius,
 		"key":      key,
 	}
 
-	//optional query param
+	//optional query params, validated by the typed options
+	opts := []geomap.ParamOption{}
 	if name != "" {
 		geoParams["name"] = name
 	}
+	if keyword := request.QueryStringParameters["keyword"]; keyword != "" {
+		opts = append(opts, geomap.Keyword(keyword))
+	}
+	if placeType := request.QueryStringParameters["type"]; placeType != "" {
+		opts = append(opts, geomap.PlaceType(placeType))
+	}
+	for param, option := range map[string]func(int) geomap.ParamOption{"minprice": geomap.MinPrice, "maxprice": geomap.MaxPrice} {
+		if value := request.QueryStringParameters[param]; value != "" {
+			level, err := strconv.Atoi(value)
+			if err != nil {
+				return events.APIGatewayProxyResponse{Body: "Invalid " + param, StatusCode: 400}, nil
+			}
+			opts = append(opts, option(level))
+		}
+	}
+	if request.QueryStringParameters["opennow"] == "true" {
+		opts = append(opts, geomap.OpenNow())
+	}
+	cursors, err := handler.EnvCursorCodec()
+	if err != nil {
+		return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 500}, err
+	}
+
+	pageToken := request.QueryStringParameters["pagetoken"]
+	if cursor := request.QueryStringParameters[handler.CursorParam]; cursor != "" {
+		if pageToken, err = cursors.Decode(cursor, request.QueryStringParameters); err != nil {
+			return events.APIGatewayProxyResponse{Body: "Invalid cursor", StatusCode: 400}, nil
+		}
+	}
+	if pageToken != "" {
+		opts = append(opts, geomap.PageToken(pageToken))
+	}
+
+	if _, err := geomap.ApplyParams(geoParams, opts...); err != nil {
+		return events.APIGatewayProxyResponse{Body: err.Error(), StatusCode: 400}, nil
+	}
 
 	//obtains place nearby response to be processed
 	googleResp, err := geomap.PlaceNearby(ctx, geoParams)
+	if _, ok := err.(*geomap.InvalidRequestError); ok && pageToken != "" {
+		//google only accepts a page token a few seconds after issuing it
+		return events.APIGatewayProxyResponse{
+			Body:       "Next page not ready yet",
+			StatusCode: 503,
+			Headers:    map[string]string{"Retry-After": "2"},
+		}, nil
+	}
 	if err != nil {
 		return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 400}, err
 	}
 
-	jsonString, _ := json.Marshal(googleResp)
+	body := NearbyResponse{GoogleNearbySearchResponse: googleResp, ApproximateLocation: approximate}
+	if body.NextCursor, err = cursors.Encode(googleResp.NextPageToken, request.QueryStringParameters); err != nil {
+		return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 500}, err
+	}
 
-	//Returning response with AWS Lambda Proxy Response
-	return events.APIGatewayProxyResponse{Body: string(jsonString), StatusCode: 200}, nil
+	if request.QueryStringParameters["photos"] == "true" {
+		body.PhotoURLs = photoURLs(ctx, googleResp.Results, key)
+	}
+
+	//Returning response with AWS Lambda Proxy Response, in the version asked by the client
+	return handler.Respond(request, 200, handler.Encoders{
+		handler.ResponseV1: func() (interface{}, error) { return body, nil },
+		handler.ResponseV2: func() (interface{}, error) {
+			return NearbyResponseV2{
+				NearbySearchResponseV2: googleResp.V2(),
+				NextCursor:             body.NextCursor,
+				PhotoURLs:              body.PhotoURLs,
+				ApproximateLocation:    body.ApproximateLocation,
+			}, nil
+		},
+	})
+}
+
+// photoURLs prefetches the first photo of the results, only URLs are returned: the lambda needs a PHOTO_BUCKET
+func photoURLs(ctx context.Context, results []geomap.NearbyResult, key string) map[string]string {
+
+	places := make([]geomap.Place, len(results))
+	for i, result := range results {
+		places[i] = geomap.Place{PlaceID: result.PlaceID, Photos: result.Photos}
+	}
+
+	prefetched, _ := geomap.PrefetchPhotos(ctx, places, geomap.PrefetchOptions{
+		Params: map[string]string{"key": key},
+	})
+
+	urls := map[string]string{}
+	for _, place := range prefetched.Places {
+		if place.Err != nil {
+			log.Printf("photo of %s: %v", place.PlaceID, place.Err)
+			continue
+		}
+		if place.Photo != nil && place.Photo.URL != "" {
+			urls[place.PlaceID] = place.Photo.URL
+		}
+	}
+
+	return urls
+}
+
+func init() {
+	handler.Prewarm()
 }
 
 func main() {
-	lambda.Start(Handler)
+	//drains the calls in flight and flushes the journal before lambda kills the container
+	go func() {
+		handler.ShutdownOnSignal(handler.ShutdownTimeout, syscall.SIGTERM, syscall.SIGINT)
+		os.Exit(0)
+	}()
+
+	lambda.Start(handler.Chain(Handler, handler.Default()...))
 }
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// DefaultCompressMinSize is the smallest body compressed by Default, smaller ones gain less than the gzip overhead
+const DefaultCompressMinSize = 1024
+
+/*
+	Compress gzips the bodies of at least minSize bytes for the clients accepting it
+	(Accept-Encoding), they are returned base64 encoded with isBase64Encoded set as API
+	Gateway expects: the API needs binary media types covering the responses (see
+	serverless.yml) for the client to receive the compressed bytes. Multi-page nearby responses shrink
+	several times over mobile networks. Responses already encoded are left as they are
+*/
+func Compress(minSize int) Middleware {
+	return func(next Func) Func {
+		return func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+			response, err := next(request)
+			if err != nil || response.IsBase64Encoded || len(response.Body) < minSize {
+				return response, err
+			}
+
+			if responseHeader(response, "Content-Encoding") != "" || !acceptsGzip(header(request, "Accept-Encoding")) {
+				return response, nil
+			}
+
+			var buf bytes.Buffer
+			writer := gzip.NewWriter(&buf)
+			if _, err := writer.Write([]byte(response.Body)); err != nil {
+				return response, nil
+			}
+			if err := writer.Close(); err != nil {
+				return response, nil
+			}
+
+			response = addVary(response, "Accept-Encoding")
+			response.Headers["Content-Encoding"] = "gzip"
+
+			response.Body = base64.StdEncoding.EncodeToString(buf.Bytes())
+			response.IsBase64Encoded = true
+
+			return response, nil
+		}
+	}
+}
+
+// acceptsGzip tells whether an Accept-Encoding header allows gzip, explicitly or by "*"
+func acceptsGzip(acceptEncoding string) bool {
+
+	accepted := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+
+		//an explicit gzip refusal wins over "*"
+		if coding == "gzip" {
+			return quality > 0
+		}
+		accepted = quality > 0
+	}
+
+	return accepted
+}
+
+// responseHeader returns the response header name whatever its case
+func responseHeader(response events.APIGatewayProxyResponse, name string) string {
+
+	for key, val := range response.Headers {
+		if strings.EqualFold(key, name) {
+			return val
+		}
+	}
+
+	return ""
+}
+
+// addVary adds name to the Vary header of response, keeping the headers it already varies with
+func addVary(response events.APIGatewayProxyResponse, name string) events.APIGatewayProxyResponse {
+
+	if response.Headers == nil {
+		response.Headers = map[string]string{}
+	}
+
+	for key, val := range response.Headers {
+		if !strings.EqualFold(key, "Vary") {
+			continue
+		}
+		for _, varied := range strings.Split(val, ",") {
+			if strings.EqualFold(strings.TrimSpace(varied), name) {
+				return response
+			}
+		}
+		response.Headers[key] = val + ", " + name
+		return response
+	}
+
+	response.Headers["Vary"] = name
+	return response
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// CursorParam is the query param carrying the cursor of the next page, returned as next_cursor
+const CursorParam = "cursor"
+
+// ErrInvalidCursor is returned for a cursor that wasn't issued by the codec, or issued for another query
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// params left out of the query a cursor is bound to: the cursor itself, the raw google token and the response version
+var cursorUnboundParams = map[string]bool{CursorParam: true, "pagetoken": true, VersionParam: true}
+
+/*
+	CursorCodec turns the next_page_token of google into an opaque cursor and back, so
+	API consumers page with next_cursor without depending on google tokens.
+	With a key the token is sealed with AES-GCM and bound to the query it was issued
+	for: a cursor replayed with other params (another location, radius...) is refused
+*/
+type CursorCodec struct {
+	aead cipher.AEAD
+}
+
+/*
+	NewCursorCodec returns a codec sealing the cursors with key (16, 24 or 32 bytes),
+	a nil key only encodes them
+*/
+func NewCursorCodec(key []byte) (*CursorCodec, error) {
+
+	if key == nil {
+		return &CursorCodec{}, nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CursorCodec{aead: aead}, nil
+}
+
+var (
+	envCursorCodec     *CursorCodec
+	envCursorCodecErr  error
+	envCursorCodecOnce sync.Once
+)
+
+// EnvCursorCodec returns the codec sealing the cursors with the base64 CURSOR_KEY, only encoding them without it
+func EnvCursorCodec() (*CursorCodec, error) {
+
+	envCursorCodecOnce.Do(func() {
+		var key []byte
+		if raw := os.Getenv("CURSOR_KEY"); raw != "" {
+			if key, envCursorCodecErr = base64.StdEncoding.DecodeString(raw); envCursorCodecErr != nil {
+				return
+			}
+		}
+		envCursorCodec, envCursorCodecErr = NewCursorCodec(key)
+	})
+
+	return envCursorCodec, envCursorCodecErr
+}
+
+// Encode returns the cursor of token for the request query params, empty when there's no next page
+func (c *CursorCodec) Encode(token string, query map[string]string) (string, error) {
+
+	if token == "" {
+		return "", nil
+	}
+
+	if c.aead == nil {
+		return base64.RawURLEncoding.EncodeToString([]byte(token)), nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(token)+c.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(token), cursorScope(query))
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decode returns the google token of cursor, checking it was issued for the same query params
+func (c *CursorCodec) Decode(cursor string, query map[string]string) (string, error) {
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(raw) == 0 {
+		return "", ErrInvalidCursor
+	}
+
+	if c.aead == nil {
+		return string(raw), nil
+	}
+
+	if len(raw) < c.aead.NonceSize() {
+		return "", ErrInvalidCursor
+	}
+
+	nonce, sealed := raw[:c.aead.NonceSize()], raw[c.aead.NonceSize():]
+	token, err := c.aead.Open(nil, nonce, sealed, cursorScope(query))
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+
+	return string(token), nil
+}
+
+// cursorScope is the canonical form of the query params a cursor is bound to
+func cursorScope(query map[string]string) []byte {
+
+	values := url.Values{}
+	for key, val := range query {
+		if !cursorUnboundParams[key] {
+			values.Set(key, val)
+		}
+	}
+
+	//Encode sorts by key
+	return []byte(values.Encode())
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCursorCodec(t *testing.T) {
+
+	issued := map[string]string{"location": "-6.2,106.8", "radius": "500", "v": "2"}
+
+	tests := []struct {
+		name    string
+		key     []byte
+		cursor  func(cursor string) string
+		query   map[string]string
+		decoded bool
+	}{
+		{"sealed", []byte("0123456789abcdef"), nil, issued, true},
+		{"sealed, the cursor and version aren't bound", []byte("0123456789abcdef"), nil, map[string]string{"location": "-6.2,106.8", "radius": "500", "v": "1", "cursor": "x"}, true},
+		{"sealed, replayed with other params", []byte("0123456789abcdef"), nil, map[string]string{"location": "-6.2,106.8", "radius": "5000"}, false},
+		{"sealed, tampered", []byte("0123456789abcdef"), func(cursor string) string { return flipLast(cursor) }, issued, false},
+		{"sealed, truncated", []byte("0123456789abcdef"), func(cursor string) string { return cursor[:8] }, issued, false},
+		{"sealed, not base64", []byte("0123456789abcdef"), func(cursor string) string { return "!" + cursor }, issued, false},
+		{"encoded only", nil, nil, issued, true},
+		{"encoded only, empty", nil, func(string) string { return "" }, issued, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			codec, err := NewCursorCodec(test.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cursor, err := codec.Encode("google-page-token", issued)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.key != nil && strings.Contains(cursor, "google") {
+				t.Errorf("sealed cursor %q shows the token", cursor)
+			}
+			if test.cursor != nil {
+				cursor = test.cursor(cursor)
+			}
+
+			token, err := codec.Decode(cursor, test.query)
+			switch {
+			case test.decoded && (err != nil || token != "google-page-token"):
+				t.Errorf("decoded %q, %v, expected the token", token, err)
+			case !test.decoded && err != ErrInvalidCursor:
+				t.Errorf("decoded %q, %v, expected ErrInvalidCursor", token, err)
+			}
+		})
+	}
+}
+
+func TestCursorCodecLastPage(t *testing.T) {
+
+	codec, err := NewCursorCodec([]byte("0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cursor, err := codec.Encode("", nil); cursor != "" || err != nil {
+		t.Errorf("cursor %q, %v, expected none without next page", cursor, err)
+	}
+}
+
+func TestNewCursorCodecKeySize(t *testing.T) {
+	if _, err := NewCursorCodec([]byte("short")); err == nil {
+		t.Error("a 5 bytes key was accepted")
+	}
+}
+
+// flipLast changes the last character of a base64 cursor
+func flipLast(cursor string) string {
+
+	last := "A"
+	if strings.HasSuffix(cursor, "A") {
+		last = "B"
+	}
+
+	return cursor[:len(cursor)-1] + last
+}
@@ -0,0 +1,380 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"gomapservice/awsadapter"
+	"gomapservice/geomap"
+	"gomapservice/geomap/store"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	// how long a response is replayed for its idempotency key
+	idempotencyTTL = 24 * time.Hour
+
+	// how long a running request holds its idempotency key, API Gateway gives up after 29s
+	idempotencyLease = time.Minute
+
+	// how long google responses are cached unless CACHE_TTL says otherwise
+	defaultCacheTTL = 24 * time.Hour
+
+	// how long the container memory keeps a response unless CACHE_MEMORY_TTL says otherwise
+	defaultMemoryCacheTTL = 5 * time.Minute
+
+	// how often the disabled endpoints are read from Parameter Store
+	killSwitchRefresh = time.Minute
+
+	// how often the memory cache is snapshotted unless CACHE_SNAPSHOT_INTERVAL says otherwise
+	defaultSnapshotInterval = 10 * time.Minute
+
+	// the object of the memory cache snapshot unless CACHE_SNAPSHOT_KEY says otherwise
+	defaultSnapshotKey = "geomap/cache-snapshot.json.gz"
+)
+
+// cacheSnapshots snapshots the memory cache when Setup configured it, see CACHE_SNAPSHOT_BUCKET
+var cacheSnapshots *geomap.CacheSnapshots
+
+// deferredQueue keeps the requests refused for quota when Setup configured it, see DEFERRED_QUEUE_URL
+var deferredQueue *awsadapter.SQSDeferredQueue
+
+/*
+	Setup configures the default geomap client from the environment of the lambda:
+	CACHE_TABLE names the DynamoDB table of the cache shared by every lambda,
+	CACHE_TTL (e.g. "6h") how long responses are cached,
+	CACHE_MEMORY_ENTRIES how many of them are also kept in the container memory
+	for CACHE_MEMORY_TTL (5 minutes by default) in front of the table.
+	CACHE_SNAPSHOT_BUCKET names an S3 bucket the memory cache is snapshotted to
+	(as CACHE_SNAPSHOT_KEY) every CACHE_SNAPSHOT_INTERVAL (10 minutes by default),
+	new containers load the latest snapshot and start warm.
+	GEOCODE_STORE_TABLE names the DynamoDB table the geocoded addresses are kept in for
+	GEOCODE_STORE_TTL (e.g. "168h", 30 days when empty, the most google allows),
+	see the store package.
+	DISABLED_ENDPOINTS turns off endpoints and features (e.g. "photo,details-atmosphere"),
+	DISABLED_ENDPOINTS_PARAMETER names a Parameter Store parameter holding the same list,
+	read every minute so it applies without a redeploy.
+	RATE_LIMIT_PER_SECOND paces the requests to google, with bursts of RATE_LIMIT_BURST,
+	across every container through the DynamoDB table RATE_LIMIT_TABLE (synced every
+	RATE_LIMIT_SYNC_INTERVAL while waiting) or per container without one.
+	DEFERRED_QUEUE_URL names the SQS queue the requests refused for an exhausted daily quota
+	are kept in until the quota resets at QUOTA_RESET_HOUR o'clock (0 by default) in
+	QUOTA_RESET_TIMEZONE (America/Los_Angeles by default), see DeferredQueue.
+	PHOTO_BUCKET names the S3 bucket the place photos are stored in, served from
+	PHOTO_CLOUDFRONT_DOMAIN when set, pre-signed S3 URLs otherwise
+*/
+func Setup() error {
+
+	var opts []geomap.ClientOption
+
+	if table := os.Getenv("CACHE_TABLE"); table != "" {
+		ttl := defaultCacheTTL
+		if raw := os.Getenv("CACHE_TTL"); raw != "" {
+			var err error
+			if ttl, err = time.ParseDuration(raw); err != nil {
+				return err
+			}
+		}
+
+		var cache geomap.Cache = &awsadapter.DynamoDBCache{
+			Client: dynamodb.New(session.Must(session.NewSession())),
+			Table:  table,
+		}
+
+		if raw := os.Getenv("CACHE_MEMORY_ENTRIES"); raw != "" {
+			layered, memory, err := memoryCacheLayer(raw, cache)
+			if err != nil {
+				return err
+			}
+			cache = layered
+
+			if bucket := os.Getenv("CACHE_SNAPSHOT_BUCKET"); bucket != "" {
+				if cacheSnapshots, err = memorySnapshots(bucket, memory); err != nil {
+					return err
+				}
+				opts = append(opts, geomap.WithShutdownFlush(cacheSnapshots))
+			}
+		}
+
+		opts = append(opts, geomap.WithCache(cache, ttl))
+	}
+
+	if table := os.Getenv("GEOCODE_STORE_TABLE"); table != "" {
+		geocodeStore := &store.DynamoDBStore{
+			Client: dynamodb.New(session.Must(session.NewSession())),
+			Table:  table,
+		}
+		if raw := os.Getenv("GEOCODE_STORE_TTL"); raw != "" {
+			var err error
+			if geocodeStore.TTL, err = time.ParseDuration(raw); err != nil {
+				return err
+			}
+			if geocodeStore.TTL <= 0 || geocodeStore.TTL > store.MaxTTL {
+				return fmt.Errorf("GEOCODE_STORE_TTL must be positive and at most %v", store.MaxTTL)
+			}
+		}
+
+		opts = append(opts, geomap.WithGeocodeStore(geocodeStore))
+	}
+
+	if bucket := os.Getenv("PHOTO_BUCKET"); bucket != "" {
+		opts = append(opts, geomap.WithPhotoStore(&awsadapter.S3PhotoStore{
+			Client:           s3.New(session.Must(session.NewSession())),
+			Bucket:           bucket,
+			CloudFrontDomain: os.Getenv("PHOTO_CLOUDFRONT_DOMAIN"),
+		}))
+	}
+
+	if raw := os.Getenv("RATE_LIMIT_PER_SECOND"); raw != "" {
+		limiter, err := rateLimiterFromEnv(raw)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, geomap.WithRateLimiter(limiter))
+	}
+
+	if queueURL := os.Getenv("DEFERRED_QUEUE_URL"); queueURL != "" {
+		reset, err := quotaResetFromEnv()
+		if err != nil {
+			return err
+		}
+
+		deferredQueue = &awsadapter.SQSDeferredQueue{
+			Client:   sqs.New(session.Must(session.NewSession())),
+			QueueURL: queueURL,
+			Reset:    reset,
+		}
+		opts = append(opts, geomap.WithDeferredQueue(deferredQueue, reset))
+	}
+
+	if killSwitch := killSwitchFromEnv(); killSwitch != nil {
+		opts = append(opts, geomap.WithKillSwitch(killSwitch))
+	}
+
+	if len(opts) == 0 {
+		return nil
+	}
+
+	client, err := geomap.NewClient(opts...)
+	if err != nil {
+		return err
+	}
+
+	geomap.SetDefaultClient(client)
+	return nil
+}
+
+// memoryCacheLayer puts a memory cache of entries (e.g. "1000") in front of shared, returning both
+func memoryCacheLayer(entries string, shared geomap.Cache) (geomap.Cache, geomap.Cache, error) {
+
+	maxEntries, err := strconv.Atoi(entries)
+	if err != nil || maxEntries <= 0 {
+		return nil, nil, fmt.Errorf("invalid CACHE_MEMORY_ENTRIES %q", entries)
+	}
+
+	ttl := defaultMemoryCacheTTL
+	if raw := os.Getenv("CACHE_MEMORY_TTL"); raw != "" {
+		if ttl, err = time.ParseDuration(raw); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	memory := geomap.NewMemoryCache(maxEntries)
+	layered, err := geomap.NewLayeredCache(
+		geomap.CacheLayer{Name: "memory", Cache: memory, TTL: ttl},
+		geomap.CacheLayer{Name: "dynamodb", Cache: shared},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return layered, memory, nil
+}
+
+// memorySnapshots loads the latest snapshot of bucket into memory and returns the snapshots saving the next ones
+func memorySnapshots(bucket string, memory geomap.Cache) (*geomap.CacheSnapshots, error) {
+
+	key := defaultSnapshotKey
+	if raw := os.Getenv("CACHE_SNAPSHOT_KEY"); raw != "" {
+		key = raw
+	}
+
+	interval := defaultSnapshotInterval
+	if raw := os.Getenv("CACHE_SNAPSHOT_INTERVAL"); raw != "" {
+		var err error
+		if interval, err = time.ParseDuration(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	snapshots, err := geomap.NewCacheSnapshots(memory, &awsadapter.S3CacheSnapshotStore{
+		Client: s3.New(session.Must(session.NewSession())),
+		Bucket: bucket,
+		Key:    key,
+	}, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	//a missing or broken snapshot only means a cold start
+	if restored, err := snapshots.Load(context.Background()); err != nil {
+		log.Printf("handler: cache snapshot load failed: %v", err)
+	} else {
+		log.Printf("handler: %d cache entries restored from s3://%s/%s", restored, bucket, key)
+	}
+
+	return snapshots, nil
+}
+
+// snapshotCache saves the memory cache snapshot once due, after the request is handled
+func snapshotCache(next Func) Func {
+	return func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+		response, err := next(request)
+		if cacheSnapshots != nil {
+			cacheSnapshots.SaveIfDue(context.Background())
+		}
+
+		return response, err
+	}
+}
+
+/*
+	rateLimiterFromEnv returns the limiter of perSecond requests, shared by the containers
+	through RATE_LIMIT_TABLE when set and local to the container otherwise
+*/
+func rateLimiterFromEnv(perSecond string) (geomap.Limiter, error) {
+
+	rate, err := strconv.ParseFloat(perSecond, 64)
+	if err != nil || rate <= 0 {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_PER_SECOND %q", perSecond)
+	}
+
+	burst := int(math.Max(rate, 1))
+	if raw := os.Getenv("RATE_LIMIT_BURST"); raw != "" {
+		if burst, err = strconv.Atoi(raw); err != nil {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_BURST %q", raw)
+		}
+	}
+
+	table := os.Getenv("RATE_LIMIT_TABLE")
+	if table == "" {
+		return geomap.NewRateLimiter(rate, burst), nil
+	}
+
+	limiter := awsadapter.NewDynamoDBRateLimiter(dynamodb.New(session.Must(session.NewSession())), table, "google", rate, burst)
+	if raw := os.Getenv("RATE_LIMIT_SYNC_INTERVAL"); raw != "" {
+		if limiter.SyncInterval, err = time.ParseDuration(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return limiter, nil
+}
+
+// DeferredQueue returns the queue of the requests deferred for quota, nil unless Setup configured it
+func DeferredQueue() *awsadapter.SQSDeferredQueue {
+	return deferredQueue
+}
+
+// quotaResetFromEnv returns the quota reset of QUOTA_RESET_TIMEZONE and QUOTA_RESET_HOUR
+func quotaResetFromEnv() (geomap.QuotaReset, error) {
+
+	reset := geomap.DefaultQuotaReset()
+
+	if raw := os.Getenv("QUOTA_RESET_TIMEZONE"); raw != "" {
+		location, err := time.LoadLocation(raw)
+		if err != nil {
+			return reset, fmt.Errorf("invalid QUOTA_RESET_TIMEZONE %q", raw)
+		}
+		reset.Location = location
+	}
+
+	if raw := os.Getenv("QUOTA_RESET_HOUR"); raw != "" {
+		hour, err := strconv.Atoi(raw)
+		if err != nil || hour < 0 || hour > 23 {
+			return reset, fmt.Errorf("invalid QUOTA_RESET_HOUR %q", raw)
+		}
+		reset.Hour = hour
+	}
+
+	return reset, nil
+}
+
+// killSwitchFromEnv returns the kill switch configured by the environment, nil when none is
+func killSwitchFromEnv() *geomap.KillSwitch {
+
+	if name := os.Getenv("DISABLED_ENDPOINTS_PARAMETER"); name != "" {
+		return geomap.NewKillSwitch(&awsadapter.SSMFlagSource{
+			Client: ssm.New(session.Must(session.NewSession())),
+			Name:   name,
+		}, killSwitchRefresh)
+	}
+
+	if disabled := os.Getenv("DISABLED_ENDPOINTS"); disabled != "" {
+		killSwitch := geomap.NewKillSwitch(nil, 0)
+		killSwitch.Set(geomap.ParseFlags(disabled))
+		return killSwitch
+	}
+
+	return nil
+}
+
+/*
+	Default returns the middlewares enabled by the environment of the lambda,
+	Setup runs once before the first request (see Prewarm),
+	IDEMPOTENCY_TABLE names the DynamoDB table backing Idempotency,
+	COMPRESS_MIN_SIZE the smallest body gzipped by Compress (DefaultCompressMinSize
+	by default, "off" disables it), JSON_CASE set to "camel" writes the responses
+	in camelCase (see CamelCase). Requests for an unknown response version are
+	refused, see Versioned. Distances and durations are written for the locale of
+	the request, see Localize. The default client isn't drained here, see Shutdown
+*/
+func Default() []Middleware {
+
+	var middlewares []Middleware
+
+	//outermost so the idempotency store keeps the plain responses, whatever the retry accepts
+	switch raw := os.Getenv("COMPRESS_MIN_SIZE"); raw {
+	case "off":
+	case "":
+		middlewares = append(middlewares, Compress(DefaultCompressMinSize))
+	default:
+		minSize, err := strconv.Atoi(raw)
+		if err != nil {
+			log.Printf("handler: invalid COMPRESS_MIN_SIZE %q, using %d", raw, DefaultCompressMinSize)
+			minSize = DefaultCompressMinSize
+		}
+		middlewares = append(middlewares, Compress(minSize))
+	}
+
+	if os.Getenv("JSON_CASE") == "camel" {
+		middlewares = append(middlewares, CamelCase())
+	}
+
+	//inside CamelCase so the google field names are still snake_case
+	middlewares = append(middlewares, Versioned(), Localize())
+
+	middlewares = append(middlewares, Initialized(setup), snapshotCache)
+
+	if table := os.Getenv("IDEMPOTENCY_TABLE"); table != "" {
+		store := &awsadapter.DynamoDBIdempotencyStore{
+			Client: dynamodb.New(session.Must(session.NewSession())),
+			Table:  table,
+		}
+		middlewares = append(middlewares, Idempotency(store, idempotencyLease, idempotencyTTL))
+	}
+
+	return middlewares
+}
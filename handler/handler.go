@@ -0,0 +1,36 @@
+// Package handler holds the middlewares shared by the lambda handlers
+package handler
+
+import (
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Func is a lambda handler using the AWS Lambda Proxy Request
+type Func func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Middleware wraps a Func with a behavior shared by several handlers
+type Middleware func(next Func) Func
+
+// Chain wraps h with middlewares, the first one being the outermost
+func Chain(h Func, middlewares ...Middleware) Func {
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+
+	return h
+}
+
+// header returns the request header name, API Gateway keeps the case sent by the client
+func header(request events.APIGatewayProxyRequest, name string) string {
+
+	for key, val := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return val
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// IdempotencyHeader is the request header carrying the client idempotency key
+const IdempotencyHeader = "Idempotency-Key"
+
+/*
+	IdempotencyStore keeps the responses of the requests made with an idempotency key,
+	e.g. DynamoDB (see the awsadapter package).
+	Claim reserves key for lease, while the request runs: claimed is true when the caller
+	should handle the request, otherwise storedFingerprint is the fingerprint of the params
+	of the first request and stored its response, or nil while it is still running.
+	Complete keeps the response for ttl
+*/
+type IdempotencyStore interface {
+	Claim(ctx context.Context, key, fingerprint string, lease time.Duration) (storedFingerprint string, stored []byte, claimed bool, err error)
+	Complete(ctx context.Context, key, fingerprint string, response []byte, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+/*
+	Idempotency replays the stored response of a request retried with the same Idempotency-Key
+	header (mobile networks, API Gateway retries) instead of calling google again.
+	Keys are scoped to the caller (see requestPrincipal), a key reused with other params
+	gets a 422. A retry arriving while the first request is still running gets a 409, until
+	lease has passed in case the first one died. Failed requests and 5xx responses aren't
+	stored so they can be retried, the others are replayed for ttl
+*/
+func Idempotency(store IdempotencyStore, lease, ttl time.Duration) Middleware {
+	return func(next Func) Func {
+		return func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+			idempotencyKey := header(request, IdempotencyHeader)
+			if idempotencyKey == "" {
+				return next(request)
+			}
+
+			ctx := context.Background()
+
+			//the same key sent by two callers, to two routes or for two response versions are
+			//two different requests, Versioned already refused the unknown versions
+			version, _ := RequestVersion(request)
+			key := strings.Join([]string{requestPrincipal(request), request.HTTPMethod, request.Resource, "v" + strconv.Itoa(version), idempotencyKey}, " ")
+			fingerprint := requestFingerprint(request)
+
+			storedFingerprint, stored, claimed, err := store.Claim(ctx, key, fingerprint, lease)
+			if err != nil {
+				//the store being down shouldn't take the api down
+				log.Printf("handler: idempotency claim failed: %v", err)
+				return next(request)
+			}
+
+			if !claimed {
+				if storedFingerprint != fingerprint {
+					return events.APIGatewayProxyResponse{Body: "Idempotency-Key Reused With Other Params", StatusCode: 422}, nil
+				}
+
+				var response events.APIGatewayProxyResponse
+				if stored == nil || json.Unmarshal(stored, &response) != nil {
+					return events.APIGatewayProxyResponse{Body: "Request In Progress", StatusCode: 409}, nil
+				}
+				return response, nil
+			}
+
+			response, err := next(request)
+			if err != nil || response.StatusCode >= 500 {
+				if releaseErr := store.Release(ctx, key); releaseErr != nil {
+					log.Printf("handler: idempotency release failed: %v", releaseErr)
+				}
+				return response, err
+			}
+
+			encoded, err := json.Marshal(response)
+			if err == nil {
+				err = store.Complete(ctx, key, fingerprint, encoded, ttl)
+			}
+			if err != nil {
+				log.Printf("handler: idempotency complete failed: %v", err)
+			}
+
+			return response, nil
+		}
+	}
+}
+
+/*
+	requestPrincipal returns who sent the request: the principal of the authorizer
+	(a custom authorizer principalId or the sub of the cognito claims), else the IAM or
+	cognito identity, else the API key, else the source ip for an anonymous api
+*/
+func requestPrincipal(request events.APIGatewayProxyRequest) string {
+
+	authorizer := request.RequestContext.Authorizer
+	if principal, ok := authorizer["principalId"].(string); ok && principal != "" {
+		return "principal:" + principal
+	}
+	if claims, ok := authorizer["claims"].(map[string]interface{}); ok {
+		if sub, ok := claims["sub"].(string); ok && sub != "" {
+			return "sub:" + sub
+		}
+	}
+
+	identity := request.RequestContext.Identity
+	switch {
+	case identity.CognitoIdentityID != "":
+		return "cognito:" + identity.CognitoIdentityID
+	case identity.UserArn != "":
+		return "iam:" + identity.UserArn
+	case identity.APIKey != "":
+		return "apikey:" + identity.APIKey
+	}
+
+	return "ip:" + identity.SourceIP
+}
+
+// requestFingerprint hashes the query params and the body of the request, in a stable order
+func requestFingerprint(request events.APIGatewayProxyRequest) string {
+
+	query := url.Values{}
+	for key, val := range request.QueryStringParameters {
+		query.Set(key, val)
+	}
+	for key, values := range request.MultiValueQueryStringParameters {
+		query[key] = values
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(query.Encode()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(request.Body))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
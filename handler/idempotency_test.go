@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// memoryIdempotencyStore is an IdempotencyStore in memory, expiring the claims after their lease
+type memoryIdempotencyStore struct {
+	mu    sync.Mutex
+	items map[string]memoryIdempotencyItem
+}
+
+type memoryIdempotencyItem struct {
+	fingerprint string
+	response    []byte
+	expires     time.Time
+}
+
+func (s *memoryIdempotencyStore) Claim(ctx context.Context, key, fingerprint string, lease time.Duration) (string, []byte, bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if item, ok := s.items[key]; ok && time.Now().Before(item.expires) {
+		return item.fingerprint, item.response, false, nil
+	}
+
+	if s.items == nil {
+		s.items = map[string]memoryIdempotencyItem{}
+	}
+	s.items[key] = memoryIdempotencyItem{fingerprint: fingerprint, expires: time.Now().Add(lease)}
+	return "", nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key, fingerprint string, response []byte, ttl time.Duration) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items[key] = memoryIdempotencyItem{fingerprint: fingerprint, response: response, expires: time.Now().Add(ttl)}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.items, key)
+	return nil
+}
+
+func idempotentRequest(key, sourceIP, address string) events.APIGatewayProxyRequest {
+
+	request := events.APIGatewayProxyRequest{
+		HTTPMethod:            "GET",
+		Resource:              "/geocode",
+		Headers:               map[string]string{},
+		QueryStringParameters: map[string]string{"address": address},
+	}
+	request.RequestContext.Identity.SourceIP = sourceIP
+	if key != "" {
+		request.Headers[IdempotencyHeader] = key
+	}
+
+	return request
+}
+
+func TestIdempotency(t *testing.T) {
+
+	type step struct {
+		request events.APIGatewayProxyRequest
+		status  int
+
+		expectedStatus int
+		expectedBody   string
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "retry replayed",
+			steps: []step{
+				{idempotentRequest("k1", "10.0.0.1", "a"), 200, 200, "call 1"},
+				{idempotentRequest("k1", "10.0.0.1", "a"), 200, 200, "call 1"},
+			},
+		},
+		{
+			name: "requests without key always handled",
+			steps: []step{
+				{idempotentRequest("", "10.0.0.1", "a"), 200, 200, "call 1"},
+				{idempotentRequest("", "10.0.0.1", "a"), 200, 200, "call 2"},
+			},
+		},
+		{
+			name: "key reused with other params",
+			steps: []step{
+				{idempotentRequest("k1", "10.0.0.1", "a"), 200, 200, "call 1"},
+				{idempotentRequest("k1", "10.0.0.1", "b"), 200, 422, "Idempotency-Key Reused With Other Params"},
+			},
+		},
+		{
+			name: "same key of another caller",
+			steps: []step{
+				{idempotentRequest("k1", "10.0.0.1", "a"), 200, 200, "call 1"},
+				{idempotentRequest("k1", "10.0.0.2", "a"), 200, 200, "call 2"},
+			},
+		},
+		{
+			name: "server errors retried",
+			steps: []step{
+				{idempotentRequest("k1", "10.0.0.1", "a"), 502, 502, "call 1"},
+				{idempotentRequest("k1", "10.0.0.1", "a"), 200, 200, "call 2"},
+				{idempotentRequest("k1", "10.0.0.1", "a"), 200, 200, "call 2"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			calls := 0
+			var status int
+			h := Idempotency(&memoryIdempotencyStore{}, time.Minute, time.Hour)(func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				calls++
+				return events.APIGatewayProxyResponse{Body: "call " + strconv.Itoa(calls), StatusCode: status}, nil
+			})
+
+			for i, step := range test.steps {
+				status = step.status
+				response, err := h(step.request)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if response.StatusCode != step.expectedStatus || response.Body != step.expectedBody {
+					t.Errorf("step %d: %d %q, expected %d %q", i, response.StatusCode, response.Body, step.expectedStatus, step.expectedBody)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+
+	tests := []struct {
+		name           string
+		lease          time.Duration
+		expectedStatus int
+	}{
+		{"retry during the lease", time.Minute, 409},
+		{"retry after the lease", time.Millisecond, 200},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			request := idempotentRequest("k1", "10.0.0.1", "a")
+
+			var h Func
+			var retried events.APIGatewayProxyResponse
+			depth := 0
+			h = Idempotency(&memoryIdempotencyStore{}, test.lease, time.Hour)(func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+				//the retry arrives while the first request is still running
+				depth++
+				if depth == 1 {
+					time.Sleep(5 * time.Millisecond)
+					retried, _ = h(request)
+				}
+				return events.APIGatewayProxyResponse{Body: "OK", StatusCode: 200}, nil
+			})
+
+			if _, err := h(request); err != nil {
+				t.Fatal(err)
+			}
+			if retried.StatusCode != test.expectedStatus {
+				t.Errorf("retry answered %d, expected %d", retried.StatusCode, test.expectedStatus)
+			}
+		})
+	}
+}
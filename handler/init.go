@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	"gomapservice/lambdainit"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// setup runs Setup once per container, see Prewarm
+var setup = lambdainit.New(func(ctx context.Context) error {
+	return Setup()
+})
+
+/*
+	Prewarm starts Setup in the background, call it from the init() of the lambdas
+	whose handler is chained with Default, which waits for it before the first request
+*/
+func Prewarm() {
+	setup.Prewarm()
+}
+
+/*
+	Initialized runs once before the requests reach next, the request fails
+	with a 500 while the initialization fails and is retried by the next request
+*/
+func Initialized(once *lambdainit.Once) Middleware {
+	return func(next Func) Func {
+		return func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+			if err := once.Do(context.Background()); err != nil {
+				log.Printf("handler: initialization failed: %v", err)
+				return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 500}, nil
+			}
+
+			return next(request)
+		}
+	}
+}
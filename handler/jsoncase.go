@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+/*
+	CamelCase rewrites the keys of the json responses from the snake_case of google
+	to camelCase (e.g. "formatted_address" to "formattedAddress"), the convention of
+	our frontends. Only keys made of lowercase letters, digits and underscores are
+	field names, other keys (place ids...) are data and kept as they are
+*/
+func CamelCase() Middleware {
+	return func(next Func) Func {
+		return func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+			response, err := next(request)
+			if err != nil {
+				return response, err
+			}
+
+			return rewriteJSON(response, camelCaseKeys), nil
+		}
+	}
+}
+
+// camelCaseKeys renames the snake_case keys of the objects found in v, telling whether any was
+func camelCaseKeys(v interface{}) bool {
+
+	renamed := false
+
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for key, child := range node {
+			if camelCaseKeys(child) {
+				renamed = true
+			}
+			if camel := camelCaseKey(key); camel != key {
+				delete(node, key)
+				node[camel] = child
+				renamed = true
+			}
+		}
+
+	case []interface{}:
+		for _, child := range node {
+			if camelCaseKeys(child) {
+				renamed = true
+			}
+		}
+	}
+
+	return renamed
+}
+
+// camelCaseKey returns the camelCase form of a snake_case field name, key itself when it isn't one
+func camelCaseKey(key string) string {
+
+	if key == "" || key[0] < 'a' || key[0] > 'z' || !strings.Contains(key, "_") {
+		return key
+	}
+
+	var b strings.Builder
+	upper := false
+	for _, r := range key {
+		switch {
+		case r == '_':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			if upper {
+				r -= 'a' - 'A'
+			}
+			b.WriteRune(r)
+			upper = false
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+			upper = false
+		default:
+			return key
+		}
+	}
+
+	return b.String()
+}
+
+/*
+	rewriteJSON applies transform to the decoded json body of response, re-encoding it when
+	transform changed it. Bodies that aren't json, or already encoded (see Compress), are kept
+*/
+func rewriteJSON(response events.APIGatewayProxyResponse, transform func(body interface{}) bool) events.APIGatewayProxyResponse {
+
+	if response.IsBase64Encoded {
+		return response
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(response.Body))
+	decoder.UseNumber()
+
+	var body interface{}
+	if decoder.Decode(&body) != nil {
+		return response
+	}
+
+	if !transform(body) {
+		return response
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if encoder.Encode(body) != nil {
+		return response
+	}
+
+	response.Body = strings.TrimSuffix(buf.String(), "\n")
+	return response
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Locale is how distances and durations are written for a client
+type Locale struct {
+	Language string
+	Imperial bool
+}
+
+// unitWords are the unit names of a language
+type unitWords struct {
+	minute, hour, day, days string
+	comma                   bool
+}
+
+// localeWords are the languages distances and durations are localized to, english otherwise
+var localeWords = map[string]unitWords{
+	"en": {"min", "h", "day", "days", false},
+	"id": {"mnt", "jam", "hari", "hari", true},
+	"ms": {"min", "jam", "hari", "hari", false},
+	"es": {"min", "h", "día", "días", true},
+	"pt": {"min", "h", "dia", "dias", true},
+	"fr": {"min", "h", "jour", "jours", true},
+	"de": {"Min.", "Std.", "Tag", "Tage", true},
+	"nl": {"min", "u", "dag", "dagen", true},
+	"it": {"min", "h", "giorno", "giorni", true},
+}
+
+// imperialRegions are the regions measuring road distances in miles
+var imperialRegions = map[string]bool{"US": true, "GB": true, "LR": true, "MM": true}
+
+/*
+	RequestLocale returns the locale of the request: the units query param ("metric" or
+	"imperial") and else the region of the preferred Accept-Language tag pick the units,
+	its language picks the words
+*/
+func RequestLocale(request events.APIGatewayProxyRequest) Locale {
+
+	locale := Locale{Language: "en"}
+
+	tag := preferredLanguage(header(request, "Accept-Language"))
+	if tag != "" {
+		parts := strings.Split(strings.Replace(tag, "_", "-", -1), "-")
+		locale.Language = strings.ToLower(parts[0])
+		if len(parts) > 1 {
+			locale.Imperial = imperialRegions[strings.ToUpper(parts[len(parts)-1])]
+		}
+	}
+
+	switch request.QueryStringParameters["units"] {
+	case "imperial":
+		locale.Imperial = true
+	case "metric":
+		locale.Imperial = false
+	}
+
+	return locale
+}
+
+// preferredLanguage returns the language tag of the highest quality in an Accept-Language header
+func preferredLanguage(acceptLanguage string) string {
+
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if q := strings.TrimSpace(param); strings.HasPrefix(q, "q=") {
+				if parsed, err := strconv.ParseFloat(q[2:], 64); err == nil {
+					quality = parsed
+				}
+			}
+		}
+		tags = append(tags, weighted{tag, quality})
+	}
+
+	if len(tags) == 0 {
+		return ""
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].quality > tags[j].quality })
+	return tags[0].tag
+}
+
+func (l Locale) words() unitWords {
+
+	if words, ok := localeWords[l.Language]; ok {
+		return words
+	}
+
+	return localeWords["en"]
+}
+
+/*
+	FormatDistance writes meters for a reader of l: meters (rounded to 10) under a kilometer,
+	then kilometers with a decimal under 10, or feet under a tenth of a mile then miles
+*/
+func FormatDistance(meters float64, l Locale) string {
+
+	words := l.words()
+
+	if l.Imperial {
+		miles := meters / 1609.344
+		if miles < 0.1 {
+			return strconv.FormatFloat(math.Round(meters*3.28084/10)*10, 'f', 0, 64) + " ft"
+		}
+		return formatDecimal(miles, words.comma) + " mi"
+	}
+
+	if meters < 1000 {
+		return strconv.FormatFloat(math.Round(meters/10)*10, 'f', 0, 64) + " m"
+	}
+	return formatDecimal(meters/1000, words.comma) + " km"
+}
+
+// formatDecimal writes value with one decimal under 10, none above
+func formatDecimal(value float64, comma bool) string {
+
+	if value >= 10 {
+		return strconv.FormatFloat(math.Round(value), 'f', 0, 64)
+	}
+
+	s := strconv.FormatFloat(value, 'f', 1, 64)
+	if comma {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+
+	return s
+}
+
+// FormatDuration writes seconds for a reader of l, e.g. "25 min", "1 h 5 min" or "2 days 3 h"
+func FormatDuration(seconds float64, l Locale) string {
+
+	words := l.words()
+
+	minutes := int(math.Ceil(seconds / 60))
+	if minutes < 1 {
+		minutes = 1
+	}
+	if minutes < 60 {
+		return strconv.Itoa(minutes) + " " + words.minute
+	}
+
+	hours := minutes / 60
+	if hours < 24 {
+		s := strconv.Itoa(hours) + " " + words.hour
+		if minutes%60 != 0 {
+			s += " " + strconv.Itoa(minutes%60) + " " + words.minute
+		}
+		return s
+	}
+
+	days := hours / 24
+	dayWord := words.days
+	if days == 1 {
+		dayWord = words.day
+	}
+	s := strconv.Itoa(days) + " " + dayWord
+	if hours%24 != 0 {
+		s += " " + strconv.Itoa(hours%24) + " " + words.hour
+	}
+	return s
+}
+
+// the fields of google responses holding a distance or a duration as {"text", "value"}
+var (
+	distanceFields = map[string]bool{"distance": true}
+	durationFields = map[string]bool{"duration": true, "duration_in_traffic": true}
+)
+
+/*
+	Localize adds a "localized" string next to the value of every distance and duration
+	of the json responses (e.g. "distance": {"text", "value", "localized"}), written for the
+	locale of the request (see RequestLocale), so thin clients can display them as they are
+*/
+func Localize() Middleware {
+	return func(next Func) Func {
+		return func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+			response, err := next(request)
+			if err != nil || response.StatusCode != 200 {
+				return response, err
+			}
+
+			//most responses have neither, don't decode them for nothing
+			if !strings.Contains(response.Body, `"distance"`) && !strings.Contains(response.Body, `"duration`) {
+				return response, nil
+			}
+
+			locale := RequestLocale(request)
+			return rewriteJSON(response, func(body interface{}) bool {
+				return localize(body, locale)
+			}), nil
+		}
+	}
+}
+
+// localize adds the localized strings to the distances and durations found in v, telling whether any was
+func localize(v interface{}, locale Locale) bool {
+
+	found := false
+
+	switch node := v.(type) {
+	case map[string]interface{}:
+		for key, child := range node {
+			if value, ok := measuredValue(child); ok && (distanceFields[key] || durationFields[key]) {
+				if distanceFields[key] {
+					child.(map[string]interface{})["localized"] = FormatDistance(value, locale)
+				} else {
+					child.(map[string]interface{})["localized"] = FormatDuration(value, locale)
+				}
+				found = true
+				continue
+			}
+			if localize(child, locale) {
+				found = true
+			}
+		}
+
+	case []interface{}:
+		for _, child := range node {
+			if localize(child, locale) {
+				found = true
+			}
+		}
+	}
+
+	return found
+}
+
+// measuredValue returns the numeric value of a {"text", "value"} object
+func measuredValue(v interface{}) (float64, bool) {
+
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+
+	number, ok := object["value"].(json.Number)
+	if !ok {
+		return 0, false
+	}
+
+	value, err := number.Float64()
+	return value, err == nil
+}
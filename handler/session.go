@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	// SessionCookie holds the autocomplete session token of browsers
+	SessionCookie = "geomap_session"
+
+	// SessionHeader carries the autocomplete session token of clients not using cookies, it is echoed in responses
+	SessionHeader = "X-Session-Token"
+
+	// google ends a session after a few minutes of inactivity
+	sessionMaxAge = 3 * time.Minute
+)
+
+/*
+	SessionToken returns the autocomplete session token sent by the client in the
+	SessionHeader or the SessionCookie, empty when the client starts a new session
+*/
+func SessionToken(request events.APIGatewayProxyRequest) string {
+
+	if token := header(request, SessionHeader); token != "" {
+		return token
+	}
+
+	//API Gateway v1 events only have the raw header, parse it with net/http
+	cookies := (&http.Request{Header: http.Header{"Cookie": {header(request, "Cookie")}}}).Cookies()
+	for _, cookie := range cookies {
+		if cookie.Name == SessionCookie {
+			return cookie.Value
+		}
+	}
+
+	return ""
+}
+
+/*
+	SetSessionToken keeps the session going on the client: the token is echoed in the
+	SessionHeader and set in the SessionCookie, renewed on every autocomplete call
+*/
+func SetSessionToken(response *events.APIGatewayProxyResponse, token string) {
+	setSessionHeaders(response, token, int(sessionMaxAge/time.Second))
+}
+
+// EndSessionToken expires the session cookie once the session is completed by its details call
+func EndSessionToken(response *events.APIGatewayProxyResponse) {
+	setSessionHeaders(response, "", -1)
+}
+
+func setSessionHeaders(response *events.APIGatewayProxyResponse, token string, maxAge int) {
+
+	if response.Headers == nil {
+		response.Headers = map[string]string{}
+	}
+
+	//SameSite None so web frontends served from another origin send the cookie back
+	cookie := &http.Cookie{
+		Name:     SessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteNoneMode,
+	}
+
+	response.Headers["Set-Cookie"] = cookie.String()
+	response.Headers[SessionHeader] = token
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"gomapservice/geomap"
+)
+
+/*
+	ShutdownTimeout is how long to drain the calls in flight on SIGTERM, lambda kills the
+	container 500ms after the signal (300ms with external extensions), which lambda only
+	sends to containers with a registered extension
+*/
+const ShutdownTimeout = 250 * time.Millisecond
+
+/*
+	Shutdown drains the default client (see geomap.Client.Shutdown), flushing the journal
+	and the cache snapshot, and logs what was dropped. It doesn't exit the process,
+	call it from the lifecycle of the lambda, e.g. with ShutdownOnSignal
+*/
+func Shutdown(ctx context.Context) (geomap.ShutdownReport, error) {
+
+	report, err := geomap.DefaultClient().Shutdown(ctx)
+	if err != nil {
+		log.Printf("handler: flush on shutdown failed: %v", err)
+	}
+
+	log.Printf("handler: shut down, %d calls drained, %d dropped, %d rejected",
+		report.Drained, report.Dropped, report.Rejected)
+
+	return report, err
+}
+
+/*
+	ShutdownOnSignal waits for one of signals then calls Shutdown with timeout and returns
+	its report, the caller decides whether to exit, e.g. from main:
+
+		go func() {
+			handler.ShutdownOnSignal(handler.ShutdownTimeout, syscall.SIGTERM)
+			os.Exit(0)
+		}()
+*/
+func ShutdownOnSignal(timeout time.Duration, signals ...os.Signal) (geomap.ShutdownReport, error) {
+
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	defer signal.Stop(received)
+
+	sig := <-received
+	log.Printf("handler: received %v", sig)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return Shutdown(ctx)
+}
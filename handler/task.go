@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"gomapservice/geomap"
+)
+
+/*
+	RetriableError fails a Step Functions task with the error "RetriableError",
+	match it in the Retry of the state, e.g. throttling or a google outage
+*/
+type RetriableError struct {
+	Message string
+}
+
+func (e *RetriableError) Error() string {
+	return e.Message
+}
+
+/*
+	NonRetriableError fails a Step Functions task with the error "NonRetriableError",
+	match it in the Catch of the state, retrying won't help e.g. a denied key or an invalid request
+*/
+type NonRetriableError struct {
+	Message string
+}
+
+func (e *NonRetriableError) Error() string {
+	return e.Message
+}
+
+// google statuses worth retrying, the others fail for good
+var retriableStatuses = map[string]bool{
+	"OVER_QUERY_LIMIT": true,
+	"UNKNOWN_ERROR":    true,
+}
+
+/*
+	TaskError classifies the error of a call to google for Step Functions,
+	timeouts, network errors, throttling and 5xx are retriable
+*/
+func TaskError(err error) error {
+
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *RetriableError, *NonRetriableError:
+		return err
+	case *geomap.APIError:
+		if e.HTTPStatus >= 500 || e.HTTPStatus == http.StatusTooManyRequests || retriableStatuses[e.Status] {
+			return &RetriableError{Message: err.Error()}
+		}
+		return &NonRetriableError{Message: err.Error()}
+	case net.Error:
+		return &RetriableError{Message: err.Error()}
+	}
+
+	if err == context.DeadlineExceeded {
+		return &RetriableError{Message: err.Error()}
+	}
+
+	return &NonRetriableError{Message: err.Error()}
+}
+
+/*
+	StatusError classifies a google response status for Step Functions,
+	OK, ZERO_RESULTS and NOT_FOUND aren't errors and are left to the workflow
+*/
+func StatusError(status, errorMessage string) error {
+
+	switch {
+	case status == "OK" || status == "ZERO_RESULTS" || status == "NOT_FOUND":
+		return nil
+	case retriableStatuses[status]:
+		return &RetriableError{Message: status + ": " + errorMessage}
+	}
+
+	return &NonRetriableError{Message: status + ": " + errorMessage}
+}
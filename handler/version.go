@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// response schema versions
+const (
+	// ResponseV1 is the response of google passed through, what the mobile clients were built on
+	ResponseV1 = 1
+
+	// ResponseV2 is the response in the normalized v2 models of geomap (see geomap.Place)
+	ResponseV2 = 2
+
+	LatestResponseVersion = ResponseV2
+)
+
+// VersionParam is the query param picking the response version, it wins over the Accept header
+const VersionParam = "v"
+
+// ErrUnsupportedVersion is returned for a response version the service doesn't know
+var ErrUnsupportedVersion = errors.New("unsupported response version")
+
+// versionMediaType is the vendor media type of a response version in the Accept header
+var versionMediaType = regexp.MustCompile(`^application/vnd\.geomap\.v(\d+)\+json$`)
+
+/*
+	RequestVersion returns the response version asked by the request: the v query param
+	("2" or "v2"), else the Accept header as "application/vnd.geomap.v2+json" or
+	"application/json; version=2", else ResponseV1 so the clients asking for nothing
+	keep the shape they were built on
+*/
+func RequestVersion(request events.APIGatewayProxyRequest) (int, error) {
+
+	if raw := request.QueryStringParameters[VersionParam]; raw != "" {
+		return parseVersion(strings.TrimPrefix(strings.ToLower(raw), "v"))
+	}
+
+	for _, mediaRange := range strings.Split(header(request, "Accept"), ",") {
+		fields := strings.Split(mediaRange, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		if match := versionMediaType.FindStringSubmatch(mediaType); match != nil {
+			return parseVersion(match[1])
+		}
+
+		if mediaType != "application/json" {
+			continue
+		}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(strings.ToLower(param), "version=") {
+				return parseVersion(param[len("version="):])
+			}
+		}
+	}
+
+	return ResponseV1, nil
+}
+
+func parseVersion(raw string) (int, error) {
+
+	version, err := strconv.Atoi(raw)
+	if err != nil || version < ResponseV1 || version > LatestResponseVersion {
+		return 0, ErrUnsupportedVersion
+	}
+
+	return version, nil
+}
+
+// Encoder returns the body of a response in one version, encoded as json
+type Encoder func() (interface{}, error)
+
+/*
+	Encoders are the encoders of a response by version. A version without an encoder gets
+	the one of the highest version below it, so a handler only adds an encoder when the
+	shape of its response changes
+*/
+type Encoders map[int]Encoder
+
+/*
+	Respond answers request with the body encoded for the version it asked (see
+	RequestVersion), telling the version in the X-Response-Version header. A version
+	unknown or older than every encoder is answered 406
+*/
+func Respond(request events.APIGatewayProxyRequest, statusCode int, encoders Encoders) (events.APIGatewayProxyResponse, error) {
+
+	version, err := RequestVersion(request)
+	if err != nil {
+		return notAcceptable(), nil
+	}
+
+	for version >= ResponseV1 && encoders[version] == nil {
+		version--
+	}
+	if version < ResponseV1 {
+		return notAcceptable(), nil
+	}
+
+	body, err := encoders[version]()
+	if err != nil {
+		return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 500}, err
+	}
+
+	jsonString, err := json.Marshal(body)
+	if err != nil {
+		return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 500}, err
+	}
+
+	return events.APIGatewayProxyResponse{
+		Body:       string(jsonString),
+		StatusCode: statusCode,
+		Headers: map[string]string{
+			"Content-Type":       "application/json",
+			"X-Response-Version": strconv.Itoa(version),
+			"Vary":               "Accept",
+		},
+	}, nil
+}
+
+/*
+	Versioned refuses the requests for an unknown response version with a 406 before the
+	handler runs, and marks every response as varying with the Accept header for the caches
+*/
+func Versioned() Middleware {
+	return func(next Func) Func {
+		return func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+			if _, err := RequestVersion(request); err != nil {
+				return notAcceptable(), nil
+			}
+
+			response, err := next(request)
+			if err != nil {
+				return response, err
+			}
+
+			return addVary(response, "Accept"), nil
+		}
+	}
+}
+
+func notAcceptable() events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		Body:       "Unsupported response version, supported: " + strconv.Itoa(ResponseV1) + " to " + strconv.Itoa(LatestResponseVersion),
+		StatusCode: 406,
+		Headers:    map[string]string{"Vary": "Accept"},
+	}
+}
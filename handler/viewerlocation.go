@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+
+	"gomapservice/geomap"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// how far from the CloudFront viewer location, the one of its city, the client may be
+const cityRadiusMeters = 25000
+
+// where an approximate location came from
+const (
+	LocationSourceViewerHeaders = "cloudfront-viewer"
+	LocationSourceIP            = "ip"
+	LocationSourceCountry       = "country"
+)
+
+// ErrNoViewerLocation is returned when the request carries nothing to locate the client with
+var ErrNoViewerLocation = errors.New("viewer location unknown")
+
+/*
+	IPLocator returns the approximate location of an ip address, e.g. an adapter of a
+	MaxMind GeoIP2/GeoLite2 city database reader, with the radius the client is within
+*/
+type IPLocator interface {
+	Locate(ctx context.Context, ip net.IP) (location geomap.LatLng, radiusMeters float64, err error)
+}
+
+// ApproximateLocation is where the client likely is, within RadiusMeters of Location
+type ApproximateLocation struct {
+	Location     geomap.LatLng `json:"location"`
+	RadiusMeters float64       `json:"radius_meters"`
+	Source       string        `json:"source"`
+}
+
+/*
+	ViewerLocation approximates the location of a client which didn't send its own, for
+	"near me" requests without GPS. It tries, from the most precise, the CloudFront-Viewer-Latitude
+	and -Longitude headers, locator (may be nil) with the client ip (X-Forwarded-For, else
+	the API Gateway source ip) and the CloudFront-Viewer-Country header, whose country is
+	geocoded with the default client (cached like any geocode)
+*/
+func ViewerLocation(ctx context.Context, request events.APIGatewayProxyRequest, locator IPLocator) (ApproximateLocation, error) {
+
+	lat, latErr := strconv.ParseFloat(header(request, "CloudFront-Viewer-Latitude"), 64)
+	lng, lngErr := strconv.ParseFloat(header(request, "CloudFront-Viewer-Longitude"), 64)
+	if latErr == nil && lngErr == nil {
+		location := geomap.LatLng{Lat: lat, Lng: lng}
+		if location.Validate() == nil {
+			return ApproximateLocation{Location: location, RadiusMeters: cityRadiusMeters, Source: LocationSourceViewerHeaders}, nil
+		}
+	}
+
+	if ip := clientIP(request); locator != nil && ip != nil {
+		location, radius, err := locator.Locate(ctx, ip)
+		if err == nil {
+			return ApproximateLocation{Location: location, RadiusMeters: radius, Source: LocationSourceIP}, nil
+		}
+	}
+
+	if country := header(request, "CloudFront-Viewer-Country"); country != "" {
+		return countryLocation(ctx, request, country)
+	}
+
+	return ApproximateLocation{}, ErrNoViewerLocation
+}
+
+// clientIP returns the ip of the client, the first X-Forwarded-For hop being the client itself
+func clientIP(request events.APIGatewayProxyRequest) net.IP {
+
+	if forwarded := header(request, "X-Forwarded-For"); forwarded != "" {
+		if ip := net.ParseIP(strings.TrimSpace(strings.Split(forwarded, ",")[0])); ip != nil {
+			return ip
+		}
+	}
+
+	return net.ParseIP(request.RequestContext.Identity.SourceIP)
+}
+
+// countryLocation geocodes country to the circle holding its viewport
+func countryLocation(ctx context.Context, request events.APIGatewayProxyRequest, country string) (ApproximateLocation, error) {
+
+	if _, ok := geomap.LookupCountry(country); !ok {
+		return ApproximateLocation{}, ErrNoViewerLocation
+	}
+
+	params, err := geomap.ApplyParams(map[string]string{
+		"key": request.StageVariables["GOOGLE_API_KEY"],
+	}, geomap.Components(geomap.ComponentFilter{Countries: []string{country}}))
+	if err != nil {
+		return ApproximateLocation{}, err
+	}
+
+	resp, err := geomap.GetGeocodeV2(ctx, params)
+	if err != nil {
+		return ApproximateLocation{}, err
+	}
+	if resp.Status != "OK" || len(resp.Results) == 0 {
+		return ApproximateLocation{}, ErrNoViewerLocation
+	}
+
+	_, radius := geomap.BoundsCircle(resp.Results[0].Geometry.Viewport)
+	return ApproximateLocation{Location: resp.Results[0].Geometry.Location, RadiusMeters: radius, Source: LocationSourceCountry}, nil
+}
@@ -0,0 +1,122 @@
+/*
+	Package lambdainit initializes what the handlers of a lambda share (the geomap client,
+	secrets, caches...) exactly once per container, however many goroutines need it.
+	Unlike sync.Once a failed initialization is retried by the next caller instead of
+	leaving the container broken until it is recycled
+*/
+package lambdainit
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Once runs an initialization until it succeeds once, see New
+type Once struct {
+	fn func(ctx context.Context) error
+
+	done uint32
+	mu   sync.Mutex
+
+	version     func(ctx context.Context) (string, error)
+	every       time.Duration
+	current     string
+	lastVersion time.Time
+	versionMu   sync.Mutex
+}
+
+// New returns a Once running fn
+func New(fn func(ctx context.Context) error) *Once {
+	return &Once{fn: fn}
+}
+
+/*
+	Do runs the initialization unless it already succeeded, concurrent callers wait for
+	the running one and share its outcome. A failure is returned to the callers waiting
+	and the initialization is tried again on the next call
+*/
+func (o *Once) Do(ctx context.Context) error {
+
+	o.checkVersion(ctx)
+
+	if atomic.LoadUint32(&o.done) == 1 {
+		return nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.done == 1 {
+		return nil
+	}
+
+	if err := o.fn(ctx); err != nil {
+		return err
+	}
+
+	atomic.StoreUint32(&o.done, 1)
+	return nil
+}
+
+/*
+	Prewarm starts the initialization in the background, call it from init() so it
+	overlaps the lambda init phase instead of delaying the first invocation.
+	A failure is logged, the first invocation tries again
+*/
+func (o *Once) Prewarm() {
+	go func() {
+		if err := o.Do(context.Background()); err != nil {
+			log.Printf("lambdainit: prewarm failed: %v", err)
+		}
+	}()
+}
+
+// Invalidate makes the next Do initialize again, e.g. when a secret was rotated
+func (o *Once) Invalidate() {
+
+	o.mu.Lock()
+	atomic.StoreUint32(&o.done, 0)
+	o.mu.Unlock()
+}
+
+/*
+	Watch initializes again when the configuration version changes, e.g. the version
+	of an AppConfig profile or a Parameter Store parameter. version is called by Do
+	at most once every interval, its failures are logged and the current setup kept
+*/
+func (o *Once) Watch(version func(ctx context.Context) (string, error), every time.Duration) {
+
+	o.versionMu.Lock()
+	o.version = version
+	o.every = every
+	o.versionMu.Unlock()
+}
+
+// checkVersion invalidates the initialization when the watched version changed
+func (o *Once) checkVersion(ctx context.Context) {
+
+	o.versionMu.Lock()
+	defer o.versionMu.Unlock()
+
+	if o.version == nil || time.Since(o.lastVersion) < o.every {
+		return
+	}
+	o.lastVersion = time.Now()
+
+	version, err := o.version(ctx)
+	if err != nil {
+		log.Printf("lambdainit: configuration version check failed: %v", err)
+		return
+	}
+
+	if version != o.current {
+		//the first version seen is the one the setup is done with
+		if o.current != "" {
+			o.Invalidate()
+		}
+		o.current = version
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"gomapservice/awsadapter"
+	"gomapservice/geomap"
+	"gomapservice/handler"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/sns"
+)
+
+// Input is the constant input of the scheduled EventBridge rule
+type Input struct {
+	PlaceIDs []string `json:"place_ids"`
+}
+
+type Output struct {
+	Checked int      `json:"checked"`
+	Changed int      `json:"changed"`
+	Failed  []string `json:"failed,omitempty"`
+}
+
+// details requests per second unless PLACEWATCH_RATE says otherwise
+const defaultRate = 2
+
+var watcher *geomap.PlaceWatcher
+
+/*
+	Handler refetches the watched places and publishes their changes,
+	scheduled as often as the local POI database needs to be in sync
+*/
+func Handler(ctx context.Context, input Input) (Output, error) {
+
+	var output Output
+
+	result, err := watcher.Check(ctx, input.PlaceIDs)
+	if err != nil {
+		return output, err
+	}
+
+	for placeID, err := range result.Failed {
+		log.Printf("placewatcher: %s failed: %v", placeID, err)
+		output.Failed = append(output.Failed, placeID)
+	}
+	output.Checked, output.Changed = result.Checked, result.Changed
+
+	return output, nil
+}
+
+/*
+	newWatcher builds the watcher from the environment: PLACEWATCH_TABLE names the
+	DynamoDB table of the snapshots (hash key "place_id"), the changes are published to the
+	SNS topic PLACEWATCH_TOPIC or else to the EventBridge bus PLACEWATCH_EVENT_BUS ("default")
+*/
+func newWatcher() (*geomap.PlaceWatcher, error) {
+
+	table := os.Getenv("PLACEWATCH_TABLE")
+	if table == "" {
+		return nil, errors.New("PLACEWATCH_TABLE is required")
+	}
+
+	rate := float64(defaultRate)
+	if raw := os.Getenv("PLACEWATCH_RATE"); raw != "" {
+		var err error
+		if rate, err = strconv.ParseFloat(raw, 64); err != nil || rate <= 0 {
+			return nil, errors.New("invalid PLACEWATCH_RATE " + raw)
+		}
+	}
+
+	sess := session.Must(session.NewSession())
+
+	var sink geomap.ChangeSink
+	if topic := os.Getenv("PLACEWATCH_TOPIC"); topic != "" {
+		sink = &awsadapter.SNSChangeSink{Client: sns.New(sess), TopicARN: topic}
+	} else {
+		sink = &awsadapter.EventBridgeChangeSink{Client: eventbridge.New(sess), EventBusName: os.Getenv("PLACEWATCH_EVENT_BUS")}
+	}
+
+	return &geomap.PlaceWatcher{
+		Store:   &awsadapter.DynamoDBSnapshotStore{Client: dynamodb.New(sess), Table: table},
+		Sink:    sink,
+		Params:  map[string]string{"key": os.Getenv("GOOGLE_API_KEY")},
+		Limiter: geomap.NewRateLimiter(rate, 1),
+	}, nil
+}
+
+func main() {
+	if err := handler.Setup(); err != nil {
+		log.Fatal(err)
+	}
+
+	var err error
+	if watcher, err = newWatcher(); err != nil {
+		log.Fatal(err)
+	}
+
+	lambda.Start(Handler)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"gomapservice/awsadapter"
+	"gomapservice/geomap"
+	"gomapservice/handler"
+	"log"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+/*
+	Handler replays the requests deferred for quota (see DEFERRED_QUEUE_URL) whose quota
+	has reset, scheduled every hour so a backlog is worked through the day after it built up
+*/
+func Handler(ctx context.Context) (awsadapter.ReplayReport, error) {
+
+	queue := handler.DeferredQueue()
+	if queue == nil {
+		return awsadapter.ReplayReport{}, errors.New("DEFERRED_QUEUE_URL is required")
+	}
+
+	report, err := queue.Replay(ctx, geomap.DefaultClient(), map[string]string{"key": os.Getenv("GOOGLE_API_KEY")})
+	log.Printf("replaydeferred: replayed %d, waiting %d, failed %d, dropped %d", report.Replayed, report.Waiting, report.Failed, report.Dropped)
+
+	return report, err
+}
+
+func main() {
+	if err := handler.Setup(); err != nil {
+		log.Fatal(err)
+	}
+
+	lambda.Start(Handler)
+}
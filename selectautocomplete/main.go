@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"gomapservice/geomap"
+	"gomapservice/handler"
+	"os"
+	"syscall"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// the fields requested by default, they drive the cost of the details call ending the session
+const defaultFields = "address_component,formatted_address,geometry,name,place_id"
+
+// Handler is our lambda handler invoked by the `lambda.Start` function call
+// Handler function Using AWS Lambda Proxy Request
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+
+	ctx := context.Background()
+
+	//required query
+	placeid := request.QueryStringParameters["placeid"]
+	if placeid == "" {
+		return events.APIGatewayProxyResponse{Body: "Missing placeid", StatusCode: 400}, nil
+	}
+
+	//Replace with api key
+	key := request.StageVariables["GOOGLE_API_KEY"]
+
+	fields := os.Getenv("AUTOCOMPLETE_FIELDS")
+	if fields == "" {
+		fields = defaultFields
+	}
+
+	geoParams := map[string]string{
+		"placeid": placeid,
+		"fields":  fields,
+		"key":     key,
+	}
+
+	//without a session the details call is billed on its own
+	if token := handler.SessionToken(request); token != "" {
+		geoParams["sessiontoken"] = token
+	}
+
+	//obtains place detail response to be processed
+	googleResp, err := geomap.PlaceDetailV2(ctx, geoParams)
+	if err != nil {
+		return events.APIGatewayProxyResponse{Body: "Error", StatusCode: 400}, err
+	}
+
+	//Returning response with AWS Lambda Proxy Response, the session is completed.
+	//The details were always answered in the v2 model, both versions get it
+	encodeDetail := func() (interface{}, error) { return googleResp, nil }
+	response, err := handler.Respond(request, 200, handler.Encoders{
+		handler.ResponseV1: encodeDetail,
+		handler.ResponseV2: encodeDetail,
+	})
+	if err != nil || response.StatusCode != 200 {
+		return response, err
+	}
+	handler.EndSessionToken(&response)
+
+	return response, nil
+}
+
+func init() {
+	handler.Prewarm()
+}
+
+func main() {
+	//drains the calls in flight and flushes the journal before lambda kills the container
+	go func() {
+		handler.ShutdownOnSignal(handler.ShutdownTimeout, syscall.SIGTERM, syscall.SIGINT)
+		os.Exit(0)
+	}()
+
+	lambda.Start(handler.Chain(Handler, handler.Default()...))
+}
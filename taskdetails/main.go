@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"gomapservice/geomap"
+	"gomapservice/handler"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Input is the state input of the place details task
+type Input struct {
+	PlaceID  string   `json:"place_id"`
+	Fields   []string `json:"fields,omitempty"`
+	Language string   `json:"language,omitempty"`
+}
+
+// Handler is a Step Functions task, plain json in and out without the API Gateway envelope
+func Handler(ctx context.Context, input Input) (geomap.PlaceDetailResponseV2, error) {
+
+	if input.PlaceID == "" {
+		return geomap.PlaceDetailResponseV2{}, &handler.NonRetriableError{Message: "place_id is required"}
+	}
+
+	geoParams := map[string]string{
+		"place_id": input.PlaceID,
+		"key":      os.Getenv("GOOGLE_API_KEY"),
+	}
+	if len(input.Fields) > 0 {
+		geoParams["fields"] = strings.Join(input.Fields, ",")
+	}
+	if input.Language != "" {
+		geoParams["language"] = input.Language
+	}
+
+	googleResp, err := geomap.PlaceDetailV2(ctx, geoParams)
+	if err != nil {
+		return googleResp, handler.TaskError(err)
+	}
+
+	return googleResp, handler.StatusError(googleResp.Status, googleResp.ErrorMessage)
+}
+
+func main() {
+	if err := handler.Setup(); err != nil {
+		log.Fatal(err)
+	}
+
+	lambda.Start(Handler)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"gomapservice/geomap"
+	"gomapservice/handler"
+	"log"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Input is the state input of the directions task
+type Input struct {
+	Origin      string   `json:"origin"`
+	Destination string   `json:"destination"`
+	Mode        string   `json:"mode,omitempty"`
+	Waypoints   []string `json:"waypoints,omitempty"`
+	Optimize    bool     `json:"optimize,omitempty"`
+}
+
+// Handler is a Step Functions task, plain json in and out without the API Gateway envelope
+func Handler(ctx context.Context, input Input) (geomap.GoogleDirectionsResponse, error) {
+
+	if input.Origin == "" || input.Destination == "" {
+		return geomap.GoogleDirectionsResponse{}, &handler.NonRetriableError{Message: "origin and destination are required"}
+	}
+
+	geoParams := map[string]string{
+		"origin":      input.Origin,
+		"destination": input.Destination,
+		"key":         os.Getenv("GOOGLE_API_KEY"),
+	}
+
+	opts := []geomap.ParamOption{}
+	if input.Mode != "" {
+		opts = append(opts, geomap.TravelMode(input.Mode))
+	}
+	if len(input.Waypoints) > 0 {
+		waypoints := make([]geomap.Waypoint, len(input.Waypoints))
+		for i, location := range input.Waypoints {
+			waypoints[i] = geomap.Waypoint{Location: location}
+		}
+		opts = append(opts, geomap.Waypoints(input.Optimize, waypoints...))
+	}
+
+	if _, err := geomap.ApplyParams(geoParams, opts...); err != nil {
+		return geomap.GoogleDirectionsResponse{}, &handler.NonRetriableError{Message: err.Error()}
+	}
+
+	googleResp, err := geomap.GetDirections(ctx, geoParams)
+	if err != nil {
+		return googleResp, handler.TaskError(err)
+	}
+
+	return googleResp, handler.StatusError(googleResp.Status, googleResp.ErrorMessage)
+}
+
+func main() {
+	if err := handler.Setup(); err != nil {
+		log.Fatal(err)
+	}
+
+	lambda.Start(Handler)
+}
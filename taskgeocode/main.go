@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"gomapservice/geomap"
+	"gomapservice/handler"
+	"log"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Input is the state input of the geocode task
+type Input struct {
+	Address    string `json:"address"`
+	Components string `json:"components,omitempty"`
+	Language   string `json:"language,omitempty"`
+	Region     string `json:"region,omitempty"`
+}
+
+// Handler is a Step Functions task, plain json in and out without the API Gateway envelope
+func Handler(ctx context.Context, input Input) (geomap.GeocodeResponseV2, error) {
+
+	if input.Address == "" && input.Components == "" {
+		return geomap.GeocodeResponseV2{}, &handler.NonRetriableError{Message: "address or components is required"}
+	}
+
+	geoParams := map[string]string{
+		"key": os.Getenv("GOOGLE_API_KEY"),
+	}
+	for param, value := range map[string]string{"address": input.Address, "components": input.Components, "language": input.Language, "region": input.Region} {
+		if value != "" {
+			geoParams[param] = value
+		}
+	}
+
+	googleResp, err := geomap.GetGeocodeV2(ctx, geoParams)
+	if err != nil {
+		return googleResp, handler.TaskError(err)
+	}
+
+	return googleResp, handler.StatusError(googleResp.Status, googleResp.ErrorMessage)
+}
+
+func main() {
+	if err := handler.Setup(); err != nil {
+		log.Fatal(err)
+	}
+
+	lambda.Start(Handler)
+}